@@ -0,0 +1,44 @@
+package adkg
+
+import (
+	vss "BingoVSS/Bingo"
+	"BingoVSS/Internal/Agreement"
+	"BingoVSS/Internal/Proofs"
+
+	"github.com/drand/kyber"
+)
+
+// The ADKG messages travel in vss.Envelopes next to the BingoShare messages, with types after theirs.
+const (
+	MsgDealing     vss.MessageType = iota + 64 // a BingoShare message of the dealing of Dealer
+	MsgAgreement                               // a step of the agreement on the dealing of Dealer
+	MsgPublicShare                             // the public key share of the sender
+)
+
+// DealingMessage carries a message of the BingoShare instance in which Dealer is the dealer.
+type DealingMessage struct {
+	Dealer int
+	Msg    vss.Message
+}
+
+// AgreementMessage carries a message of the agreement on whether the dealing of Dealer is used.
+type AgreementMessage struct {
+	Dealer int
+	Msg    agreement.Message
+}
+
+/*
+PublicShareMessage publishes X = x_i·g for the secret key share x_i of the sender i. V is the
+commitment x_i·g + r_i·gUp to the values of the aggregated row of i at X = 0, Opening proves V
+against the aggregated row commitment and Proof shows that X and V contain the same x_i.
+*/
+type PublicShareMessage struct {
+	X       kyber.Point
+	V       kyber.Point
+	Opening kyber.Point
+	Proof   proofs.ShareProof
+}
+
+func (m *DealingMessage) Type() vss.MessageType     { return MsgDealing }
+func (m *AgreementMessage) Type() vss.MessageType   { return MsgAgreement }
+func (m *PublicShareMessage) Type() vss.MessageType { return MsgPublicShare }
@@ -0,0 +1,467 @@
+package adkg
+
+import (
+	vss "BingoVSS/Bingo"
+	"BingoVSS/Internal/Agreement"
+	kzg "BingoVSS/Internal/Biv_KZG"
+	"BingoVSS/Internal/Polynomial"
+	"BingoVSS/Internal/Proofs"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/drand/kyber"
+)
+
+// proofTag separates the proofs of public key shares from other uses of proofs.ShareProof.
+const proofTag = "adkg/share"
+
+// State is the progress of a party in the ADKG.
+type State int
+
+const (
+	StateDealing State = iota // the dealings and the agreements on them are running
+	StateAgreed               // the set of dealings is agreed on, waiting for their rows
+	StateShared               // holds its key share, waiting for d_2+1 valid public shares
+	StateDone                 // holds its key share and the group public key
+)
+
+func (s State) String() string {
+	switch s {
+	case StateDealing:
+		return "dealing"
+	case StateAgreed:
+		return "agreed"
+	case StateShared:
+		return "shared"
+	case StateDone:
+		return "done"
+	}
+	return "unknown"
+}
+
+/*
+Party is one participant of an asynchronous distributed key generation among n >= 3f+1 parties
+that all act as Bingo dealers. Like vss.Node it only talks through Handle, so it can run in its
+own process. For party i:
+
+ 1. deal a random secret s_i = φ_i(0, 0) with BingoShare, and take part in the BingoShare of
+    every other dealer d with a vss.Node
+ 2. common subset: agree on every dealing d with a binary agreement. Input 1 once the BingoShare
+    of d terminated, and input 0 to all remaining agreements once n-f of them decided 1. The
+    dealings S whose agreement decided 1 are the same for everyone and at least n-f of them
+ 3. once it holds its row φ_d(X, i) of every dealing d in S, which BingoShare guarantees for
+    dealings some honest party saw terminate, the key share of i is x_i = Σ_{d∈S} φ_d(0, i), a
+    share of degree d_2 of the secret key x = Σ_{d∈S} s_d
+ 4. publish X_i = x_i·g with a proof that it matches the commitments of the dealings in S, and
+    interpolate the public key x·g and every public share from d_2+1 valid X_j
+
+No party ever learns x, and the public key depends on the dealings of at least n-f-f ≥ f+1
+honest parties.
+*/
+type Party struct {
+	id     int
+	params vss.Params
+	suite  *vss.Suite
+	setup  *kzg.KzgSetup
+	sh     *kzg.KzgShareSetup
+
+	started  bool
+	dealings []*vss.Node       // dealings[d] is our part in the BingoShare of dealer d
+	subset   *agreement.Subset // decides which dealings are used
+	accepted []bool            // accepted[d] is set once the dealing of d was passed to subset
+
+	set    []int        // the agreed dealings, nil before all agreements decided
+	share  kyber.Scalar // x_i
+	hiding kyber.Scalar // r_i = Σ_{d∈S} φ'_d(0, i)
+
+	pending      map[int]*PublicShareMessage // the first public share of each party, waiting for the key share of the party
+	publicFrom   map[int]kyber.Point
+	faulty       []bool
+	publicShares []kyber.Point // x·g at 0 and the public shares X_j at 1..n, nil before d_2+1 were verified
+}
+
+/*
+This function constructs the party with index id in 1..n. The agreements toss their coins with the
+coin key of the party, which must not serve another key generation.
+*/
+func NewParty(id int, params vss.Params, suite *vss.Suite, setup *kzg.KzgSetup, coin *agreement.CoinKey) (*Party, error) {
+	if err := params.Validate(); err != nil {
+		return nil, err
+	}
+	if id < 1 || id > params.N {
+		return nil, fmt.Errorf("adkg: party index %d outside 1..%d", id, params.N)
+	}
+	if coin == nil || coin.ReturnSelf() != id {
+		return nil, fmt.Errorf("adkg: no coin key for party %d", id)
+	}
+
+	parties := make([]int, params.N)
+	for j := range parties {
+		parties[j] = j + 1
+	}
+
+	p := &Party{
+		id:         id,
+		params:     params,
+		suite:      suite,
+		setup:      setup,
+		sh:         kzg.NewShareSetup(setup.ReturnT_1(), setup.ReturnT_2(), setup.ReturnT_u(), suite.ReturnSuite(), setup.ReturnG_u(), setup.ReturnG_1()),
+		dealings:   make([]*vss.Node, params.N+1),
+		accepted:   make([]bool, params.N+1),
+		pending:    make(map[int]*PublicShareMessage),
+		publicFrom: make(map[int]kyber.Point),
+		faulty:     make([]bool, params.N+1),
+	}
+	for d := 1; d <= params.N; d++ {
+		nd, err := vss.NewNode(id, params, suite, setup)
+		if err != nil {
+			return nil, err
+		}
+		p.dealings[d] = nd
+	}
+	subset, err := agreement.NewSubset(id, parties, params.F, func(d int) agreement.Coin {
+		return coin.Coin(fmt.Sprintf("adkg/%d", d))
+	})
+	if err != nil {
+		return nil, err
+	}
+	p.subset = subset
+	return p, nil
+}
+
+func (p *Party) ReturnID() int {
+	return p.id
+}
+
+func (p *Party) ReturnState() State {
+	switch {
+	case p.publicShares != nil:
+		return StateDone
+	case p.share != nil:
+		return StateShared
+	case p.set != nil:
+		return StateAgreed
+	}
+	return StateDealing
+}
+
+// ReturnSet returns the dealers whose dealings make up the key, or nil before they are agreed on.
+func (p *Party) ReturnSet() []int {
+	return p.set
+}
+
+// ReturnShare returns the secret key share x_i, or nil before the party holds it.
+func (p *Party) ReturnShare() kyber.Scalar {
+	return p.share
+}
+
+// ReturnPublicKey returns the group public key x·g, or nil before the party is done.
+func (p *Party) ReturnPublicKey() kyber.Point {
+	if p.publicShares == nil {
+		return nil
+	}
+	return p.publicShares[0]
+}
+
+// ReturnPublicShares returns the public key at index 0 and the public key share x_j·g of every
+// party j at index j, or nil before the party is done.
+func (p *Party) ReturnPublicShares() []kyber.Point {
+	return p.publicShares
+}
+
+// ReturnDealing returns the BingoShare participant of the party in the dealing of d.
+func (p *Party) ReturnDealing(d int) *vss.Node {
+	return p.dealings[d]
+}
+
+// Start deals the secret of the party and returns the messages to send.
+func (p *Party) Start() ([]vss.Envelope, error) {
+	if p.started {
+		return nil, fmt.Errorf("adkg: party %d already started", p.id)
+	}
+	p.started = true
+
+	secret := []vss.Secret{*vss.NewSecret(0, *p.suite)}
+	msgs, err := vss.NewDealerWithSuite(p.suite).DealMessages(secret, p.params, p.setup)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]vss.Envelope, 0, len(msgs))
+	for _, env := range msgs {
+		out = append(out, vss.Envelope{From: p.id, To: env.To, Msg: &DealingMessage{Dealer: p.id, Msg: env.Msg}})
+	}
+	return p.route(out, nil)
+}
+
+// Handle processes one inbound message and returns the messages the party sends in response. As
+// with vss.Node, an error means that something in the message was invalid and has been dropped,
+// and it may come together with messages to send.
+func (p *Party) Handle(env vss.Envelope) ([]vss.Envelope, error) {
+	if env.To != p.id {
+		return nil, fmt.Errorf("adkg: message for %d delivered to %d", env.To, p.id)
+	}
+	if env.Msg == nil || reflect.ValueOf(env.Msg).IsNil() {
+		return nil, fmt.Errorf("adkg: empty message from %d", env.From)
+	}
+	if env.From < 1 || env.From > p.params.N {
+		return nil, fmt.Errorf("adkg: unknown party %d", env.From)
+	}
+
+	out, err := p.handle(env)
+	return p.route(out, err)
+}
+
+// route handles the messages of the party to itself right away and returns the others.
+func (p *Party) route(msgs []vss.Envelope, err error) ([]vss.Envelope, error) {
+	var out []vss.Envelope
+	for len(msgs) > 0 {
+		env := msgs[0]
+		msgs = msgs[1:]
+		if env.To != p.id {
+			out = append(out, env)
+			continue
+		}
+		next, _ := p.handle(env)
+		msgs = append(msgs, next...)
+	}
+	return out, err
+}
+
+func (p *Party) handle(env vss.Envelope) ([]vss.Envelope, error) {
+	var out []vss.Envelope
+	var err error
+
+	switch m := env.Msg.(type) {
+	case *DealingMessage:
+		if m.Dealer < 1 || m.Dealer > p.params.N || m.Msg == nil || reflect.ValueOf(m.Msg).IsNil() {
+			return nil, fmt.Errorf("adkg: invalid dealing message from %d", env.From)
+		}
+		// Only the dealer sends the commitment and the rows of its own dealing
+		from := env.From
+		if from == m.Dealer && (m.Msg.Type() == vss.MsgCommitment || m.Msg.Type() == vss.MsgPolynomial) {
+			from = vss.DealerID
+		}
+		var next []vss.Envelope
+		next, err = p.dealings[m.Dealer].Handle(vss.Envelope{From: from, To: p.id, Msg: m.Msg})
+		for _, n := range next {
+			out = append(out, vss.Envelope{From: p.id, To: n.To, Msg: &DealingMessage{Dealer: m.Dealer, Msg: n.Msg}})
+		}
+
+	case *AgreementMessage:
+		var next []agreement.SubsetEnvelope
+		next, err = p.subset.Handle(env.From, m.Dealer, m.Msg)
+		out = p.agreementMessages(next)
+
+	case *PublicShareMessage:
+		// A party only publishes one share, so later ones are dropped and cannot pile up
+		if _, ok := p.publicFrom[env.From]; !ok && p.pending[env.From] == nil && !p.faulty[env.From] {
+			p.pending[env.From] = m
+		}
+
+	default:
+		return nil, fmt.Errorf("adkg: unexpected %s message from %d", env.Msg.Type(), env.From)
+	}
+
+	next, progressErr := p.progress()
+	if err == nil {
+		err = progressErr
+	}
+	return append(out, next...), err
+}
+
+// progress moves the party on as far as the state of the dealings and agreements allows.
+func (p *Party) progress() ([]vss.Envelope, error) {
+	var out []vss.Envelope
+
+	// Step 2: vote for the dealings that terminated
+	for d := 1; d <= p.params.N; d++ {
+		if p.accepted[d] || p.dealings[d].ReturnState() != vss.StateTerminated {
+			continue
+		}
+		p.accepted[d] = true
+		next, err := p.subset.Accept(d)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, p.agreementMessages(next)...)
+	}
+	if p.set = p.subset.Set(); p.set == nil {
+		return out, nil
+	}
+
+	// Step 3: the key share, once all the rows of the agreed dealings are there
+	if p.share == nil {
+		for _, d := range p.set {
+			if p.dealings[d].ReturnRow() == nil {
+				return out, nil
+			}
+		}
+		msgs, err := p.publishShare()
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, msgs...)
+	}
+
+	// Step 4: the public shares and the public key
+	err := p.checkPublicShares()
+	if p.publicShares == nil && len(p.publicFrom) >= p.params.D_2+1 {
+		if interpolateErr := p.interpolatePublicShares(); interpolateErr != nil {
+			return out, interpolateErr
+		}
+	}
+	return out, err
+}
+
+// publishShare computes x_i and sends X_i with its proofs to every other party.
+func (p *Party) publishShare() ([]vss.Envelope, error) {
+	g := p.suite.ReturnSuite()
+	row, hiding := p.aggregateRow()
+
+	opening, x, r, err := kzg.KZGEval(p.sh, row, hiding, g.G1().Scalar().Zero())
+	if err != nil {
+		return nil, err
+	}
+	p.share, p.hiding = x, r
+
+	X := g.G1().Point().Mul(x, nil)
+	V := kzg.ValueCommitment(p.sh, x, r)
+	msg := &PublicShareMessage{X: X, V: V, Opening: opening, Proof: proofs.ProveShare(g, p.sh.ReturnG_u(), g.G1().Point().Base(), proofTag, p.id, x, r, X, V)}
+	p.publicFrom[p.id] = X
+
+	var out []vss.Envelope
+	for j := 1; j <= p.params.N; j++ {
+		if j != p.id {
+			out = append(out, vss.Envelope{From: p.id, To: j, Msg: msg})
+		}
+	}
+	return out, nil
+}
+
+// aggregateRow returns Σ_{d∈S} φ_d(X, i) and Σ_{d∈S} φ'_d(X, i).
+func (p *Party) aggregateRow() ([]kyber.Scalar, []kyber.Scalar) {
+	g := p.suite.ReturnSuite()
+	row := make([]kyber.Scalar, p.params.D_1+1)
+	hiding := make([]kyber.Scalar, p.params.D_1+1)
+	for c := range row {
+		row[c], hiding[c] = g.G1().Scalar().Zero(), g.G1().Scalar().Zero()
+	}
+	for _, d := range p.set {
+		r := p.dealings[d].ReturnRow()
+		for c := range row {
+			row[c].Add(row[c], r.Coefficients()[c])
+			hiding[c].Add(hiding[c], r.Coefficients_2()[c])
+		}
+	}
+	return row, hiding
+}
+
+// checkPublicShares verifies the pending public shares against Σ_{d∈S} cm_d[j].
+func (p *Party) checkPublicShares() error {
+	if p.share == nil {
+		return nil
+	}
+	g := p.suite.ReturnSuite()
+
+	var invalid []int
+	for j := 1; j <= p.params.N; j++ {
+		m := p.pending[j]
+		if m == nil {
+			continue
+		}
+		delete(p.pending, j)
+		if _, ok := p.publicFrom[j]; ok || p.faulty[j] {
+			continue
+		}
+
+		c := g.G1().Point().Null()
+		for _, d := range p.set {
+			c.Add(c, p.dealings[d].ReturnCommitments()[j])
+		}
+		if m.X == nil || m.V == nil || m.Opening == nil ||
+			!kzg.KZGVerifyCommitted(p.sh, c, m.Opening, g.G1().Scalar().Zero(), m.V) ||
+			!proofs.VerifyShare(g, p.sh.ReturnG_u(), g.G1().Point().Base(), proofTag, j, m.X, m.V, m.Proof) {
+			p.faulty[j] = true
+			invalid = append(invalid, j)
+			continue
+		}
+		p.publicFrom[j] = m.X
+	}
+
+	if len(invalid) > 0 {
+		return fmt.Errorf("adkg: invalid public shares from %v", invalid)
+	}
+	return nil
+}
+
+// interpolatePublicShares interpolates x·g and every x_j·g in the exponent from d_2+1 valid public
+// shares, as the key shares lie on φ(0, Y) of degree d_2.
+func (p *Party) interpolatePublicShares() error {
+	g := p.suite.ReturnSuite()
+
+	var ids []int
+	for j := 1; j <= p.params.N && len(ids) < p.params.D_2+1; j++ {
+		if _, ok := p.publicFrom[j]; ok {
+			ids = append(ids, j)
+		}
+	}
+	y := make([]kyber.Scalar, len(ids))
+	for k, j := range ids {
+		y[k] = g.G1().Scalar().SetInt64(int64(j))
+	}
+	l, err := polynomial.NewLagrange(g, y)
+	if err != nil {
+		return err
+	}
+
+	shares := make([]kyber.Point, p.params.N+1)
+	for j := range shares {
+		shares[j] = g.G1().Point().Null()
+		for k, lambda := range l.At(g.G1().Scalar().SetInt64(int64(j))) {
+			shares[j].Add(shares[j], g.G1().Point().Mul(lambda, p.publicFrom[ids[k]]))
+		}
+	}
+	p.publicShares = shares
+	return nil
+}
+
+func (p *Party) agreementMessages(msgs []agreement.SubsetEnvelope) []vss.Envelope {
+	out := make([]vss.Envelope, 0, len(msgs))
+	for _, env := range msgs {
+		out = append(out, vss.Envelope{From: p.id, To: env.To, Msg: &AgreementMessage{Dealer: env.Proposer, Msg: env.Msg}})
+	}
+	return out
+}
+
+// ErrCannotComplete is wrapped by the error of Incomplete when the key generation provably cannot complete.
+var ErrCannotComplete = errors.New("adkg: the key generation cannot complete")
+
+// Incomplete returns nil once the party is done, and otherwise what it still waits for. Only an
+// error wrapping ErrCannotComplete is final.
+func (p *Party) Incomplete() error {
+	faulty := 0
+	for _, b := range p.faulty {
+		if b {
+			faulty++
+		}
+	}
+	if faulty > p.params.F {
+		return fmt.Errorf("%w: %d parties sent invalid public shares, at most %d may be faulty", ErrCannotComplete, faulty, p.params.F)
+	}
+
+	switch p.ReturnState() {
+	case StateDone:
+		return nil
+	case StateDealing:
+		return fmt.Errorf("adkg: waiting for the agreements: %d of %d decided", p.subset.Decisions(), p.params.N)
+	case StateAgreed:
+		for _, d := range p.set {
+			if err := p.dealings[d].Incomplete(); p.dealings[d].ReturnRow() == nil {
+				return fmt.Errorf("adkg: waiting for the row of the dealing of %d: %v", d, err)
+			}
+		}
+	}
+	return fmt.Errorf("adkg: waiting for public shares: %d of %d", len(p.publicFrom), p.params.D_2+1)
+}
@@ -0,0 +1,163 @@
+package adkg
+
+import (
+	vss "BingoVSS/Bingo"
+	"BingoVSS/Internal/Agreement"
+	kzg "BingoVSS/Internal/Biv_KZG"
+	"BingoVSS/Internal/Polynomial"
+	"BingoVSS/Internal/Testnet"
+	"testing"
+
+	"github.com/drand/kyber"
+	"github.com/stretchr/testify/require"
+)
+
+func newParties(t *testing.T, g *vss.Suite, params vss.Params) []*Party {
+	setup, err := kzg.NewKzgSetup(params.D_1+1, g.ReturnSuite())
+	require.NoError(t, err)
+
+	ids := make([]int, params.N)
+	for i := range ids {
+		ids[i] = i + 1
+	}
+	coins, err := agreement.DealCoinKeys(g.ReturnSuite(), ids, params.F+1, g.ReturnSuite().RandomStream())
+	require.NoError(t, err)
+
+	parties := make([]*Party, params.N+1)
+	for i := 1; i <= params.N; i++ {
+		p, err := NewParty(i, params, g, setup, coins[i])
+		require.NoError(t, err)
+		parties[i] = p
+	}
+	return parties
+}
+
+// checkKey checks that the honest parties agree on the set and the public key, and that their
+// shares interpolate to the secret key of the public key.
+func checkKey(t *testing.T, g *vss.Suite, params vss.Params, parties []*Party, honest []int) {
+	s := g.ReturnSuite()
+	first := parties[honest[0]]
+	require.GreaterOrEqual(t, len(first.ReturnSet()), params.N-params.F)
+
+	var x, y []kyber.Scalar
+	for _, i := range honest {
+		p := parties[i]
+		require.NoError(t, p.Incomplete())
+		require.Equal(t, StateDone, p.ReturnState())
+		require.Equal(t, first.ReturnSet(), p.ReturnSet())
+		require.True(t, first.ReturnPublicKey().Equal(p.ReturnPublicKey()))
+		for j, X := range p.ReturnPublicShares() {
+			require.True(t, first.ReturnPublicShares()[j].Equal(X))
+		}
+		require.True(t, s.G1().Point().Mul(p.ReturnShare(), nil).Equal(first.ReturnPublicShares()[i]))
+
+		x = append(x, s.G1().Scalar().SetInt64(int64(i)))
+		y = append(y, p.ReturnShare())
+	}
+
+	coeffs, err := polynomial.Interpolate(s, x[:params.D_2+1], y[:params.D_2+1])
+	require.NoError(t, err)
+	require.True(t, s.G1().Point().Mul(coeffs[0], nil).Equal(first.ReturnPublicKey()))
+
+	// Any d_2+1 shares give the same key, fewer give another one
+	coeffs, err = polynomial.Interpolate(s, x[len(x)-params.D_2-1:], y[len(y)-params.D_2-1:])
+	require.NoError(t, err)
+	require.True(t, s.G1().Point().Mul(coeffs[0], nil).Equal(first.ReturnPublicKey()))
+	coeffs, err = polynomial.Interpolate(s, x[:params.D_2], y[:params.D_2])
+	require.NoError(t, err)
+	require.False(t, s.G1().Point().Mul(coeffs[0], nil).Equal(first.ReturnPublicKey()))
+}
+
+func TestADKGHonest(t *testing.T) {
+	g := vss.NewSuite()
+	params := vss.NewParams(4, 1)
+	for seed := int64(0); seed < 3; seed++ {
+		parties := newParties(t, g, params)
+		require.NoError(t, testnet.Run(parties, nil, nil, seed))
+		checkKey(t, g, params, parties, []int{1, 2, 3, 4})
+	}
+}
+
+func TestADKGHigherDegreeInY(t *testing.T) {
+	// The key shares lie on φ(0, Y) of degree d_2 > f, so f+1 public shares are not enough
+	g := vss.NewSuite()
+	params := vss.Params{N: 4, F: 1, D_1: 2, D_2: 2}
+	parties := newParties(t, g, params)
+	require.NoError(t, testnet.Run(parties, nil, nil, 4))
+	checkKey(t, g, params, parties, []int{1, 2, 3, 4})
+}
+
+func TestADKGCrashedParties(t *testing.T) {
+	g := vss.NewSuite()
+	params := vss.NewParams(7, 2)
+	parties := newParties(t, g, params)
+	require.NoError(t, testnet.Run(parties, map[int]bool{2: true, 6: true}, nil, 1))
+	checkKey(t, g, params, parties, []int{1, 3, 4, 5, 7})
+
+	for _, d := range parties[1].ReturnSet() {
+		require.NotContains(t, []int{2, 6}, d)
+	}
+}
+
+func TestADKGInvalidPublicShares(t *testing.T) {
+	g := vss.NewSuite()
+	params := vss.NewParams(4, 1)
+	parties := newParties(t, g, params)
+
+	// Party 3 publishes a public share that does not match its key share
+	tamper := func(env vss.Envelope) vss.Envelope {
+		if m, ok := env.Msg.(*PublicShareMessage); ok && env.From == 3 {
+			bad := *m
+			bad.X = g.ReturnSuite().G1().Point().Add(m.X, g.ReturnSuite().G1().Point().Base())
+			env.Msg = &bad
+		}
+		return env
+	}
+	require.NoError(t, testnet.Run(parties, nil, tamper, 2))
+	checkKey(t, g, params, parties, []int{1, 2, 4})
+
+	X := parties[3].ReturnPublicShares()[3]
+	require.True(t, g.ReturnSuite().G1().Point().Mul(parties[3].ReturnShare(), nil).Equal(X))
+	require.True(t, parties[1].ReturnPublicShares()[3].Equal(X))
+}
+
+func TestADKGRejectsInvalidMessages(t *testing.T) {
+	g := vss.NewSuite()
+	params := vss.NewParams(4, 1)
+	parties := newParties(t, g, params)
+	p := parties[1]
+
+	_, err := NewParty(5, params, g, nil, nil)
+	require.Error(t, err)
+	_, err = NewParty(1, params, g, nil, nil)
+	require.Error(t, err)
+
+	_, err = p.Handle(vss.Envelope{From: 2, To: 3, Msg: &PublicShareMessage{}})
+	require.Error(t, err)
+	_, err = p.Handle(vss.Envelope{From: 9, To: 1, Msg: &PublicShareMessage{}})
+	require.Error(t, err)
+	_, err = p.Handle(vss.Envelope{From: 2, To: 1, Msg: nil})
+	require.Error(t, err)
+	_, err = p.Handle(vss.Envelope{From: 2, To: 1, Msg: &DealingMessage{Dealer: 7}})
+	require.Error(t, err)
+	_, err = p.Handle(vss.Envelope{From: 2, To: 1, Msg: &AgreementMessage{Dealer: 0}})
+	require.Error(t, err)
+	_, err = p.Handle(vss.Envelope{From: 2, To: 1, Msg: &vss.DoneMessage{}})
+	require.Error(t, err)
+
+	// Before it holds its key share, the party keeps one public share per sender
+	for k := 0; k < 50; k++ {
+		_, err = p.Handle(vss.Envelope{From: 2, To: 1, Msg: &PublicShareMessage{}})
+		require.NoError(t, err)
+		_, err = p.Handle(vss.Envelope{From: 3, To: 1, Msg: &PublicShareMessage{}})
+		require.NoError(t, err)
+	}
+	require.Len(t, p.pending, 2)
+
+	_, err = p.Start()
+	require.NoError(t, err)
+	_, err = p.Start()
+	require.Error(t, err)
+	require.Equal(t, StateDealing, p.ReturnState())
+	require.Error(t, p.Incomplete())
+}
@@ -0,0 +1,418 @@
+package vss
+
+import (
+	poly "BingoVSS/Internal/BivPoly"
+	kzg "BingoVSS/Internal/Biv_KZG"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/drand/kyber"
+)
+
+/*
+A Checkpoint is the position of a Node in BingoShare, taken after Handle and before the messages
+it returned are sent: the echo and ready it sent for the commitment, the delivered commitment CM,
+its row, the row and column points it verified, whether it sent its columns and the done and
+faulty participants. Points that were received but not verified yet are not part of it; a
+resumed node asks for them again.
+*/
+type Checkpoint struct {
+	Curve        string
+	ID           int
+	Params       Params
+	Echoed       []kyber.Point // nil if the node had not echoed a commitment
+	Readied      []kyber.Point // nil if the node had not sent ready
+	Commitments  []kyber.Point // CM of the dealer, nil before it was delivered
+	Row          []kyber.Scalar
+	RowHiding    []kyber.Scalar
+	RowPoints    []kzg.Proof
+	ColumnPoints []kzg.Proof
+	SentColumns  bool
+	Done         []bool
+	Faulty       []bool
+}
+
+// Checkpoint returns the position of the node. The slices are copies, the scalars and points are shared.
+func (nd *Node) Checkpoint() Checkpoint {
+	cp := Checkpoint{
+		Curve:        nd.suite.ReturnCurve(),
+		ID:           nd.id,
+		Params:       nd.params,
+		Echoed:       nd.echoed,
+		Readied:      nd.readied,
+		Commitments:  nd.commitments,
+		RowPoints:    append([]kzg.Proof{}, nd.rowPoints...),
+		ColumnPoints: append([]kzg.Proof{}, nd.columnPoints...),
+		SentColumns:  nd.sentColumns,
+		Done:         append([]bool{}, nd.done...),
+		Faulty:       append([]bool{}, nd.faulty...),
+	}
+	if nd.row != nil {
+		cp.Row = append([]kyber.Scalar{}, nd.row.Coefficients()...)
+		cp.RowHiding = append([]kyber.Scalar{}, nd.row.Coefficients_2()...)
+	}
+	return cp
+}
+
+/*
+This function checks a checkpoint before a node resumes from it: the shape of every field, the
+row against cm[i] and every verified point against the row commitments cm[0..n] of the stored
+commitment.
+*/
+func (cp Checkpoint) Verify(setup *kzg.KzgSetup) error {
+	p := cp.Params
+	if err := p.Validate(); err != nil {
+		return err
+	}
+	if cp.ID < 1 || cp.ID > p.N {
+		return fmt.Errorf("vss: participant index %d outside 1..%d", cp.ID, p.N)
+	}
+	if len(cp.RowPoints) != p.N+1 || len(cp.ColumnPoints) != p.N+1 || len(cp.Done) != p.N+1 || len(cp.Faulty) != p.N+1 {
+		return fmt.Errorf("vss: checkpoint of %d participants with lists of another length", p.N)
+	}
+	for _, c := range [][]kyber.Point{cp.Echoed, cp.Readied, cp.Commitments} {
+		if c != nil && !validPoints(c, p.D_2+1) {
+			return fmt.Errorf("vss: checkpoint with a commitment that is not of length %d", p.D_2+1)
+		}
+	}
+
+	if cp.Commitments == nil {
+		if cp.Row != nil || cp.SentColumns || checkForNotNil(cp.RowPoints) > 0 || checkForNotNil(cp.ColumnPoints) > 0 {
+			return errors.New("vss: checkpoint with points but without the commitment")
+		}
+		return nil
+	}
+	if cp.SentColumns && checkForNotNil(cp.RowPoints) < p.D_2+1 {
+		return fmt.Errorf("vss: columns sent with %d of %d row points", checkForNotNil(cp.RowPoints), p.D_2+1)
+	}
+
+	g, err := NewSuiteForCurve(cp.Curve)
+	if err != nil {
+		return err
+	}
+	sh := kzg.NewShareSetup(setup.ReturnT_1(), setup.ReturnT_2(), setup.ReturnT_u(), g.suite, setup.ReturnG_u(), setup.ReturnG_1())
+	vn := make([]kyber.Scalar, p.N+1)
+	for i := range vn {
+		vn[i] = g.suite.G1().Scalar().SetInt64(int64(i))
+	}
+	cm := kzg.PartialEval(setup, cp.Commitments, vn)
+
+	if cp.Row != nil {
+		if len(cp.Row) != p.D_1+1 || len(cp.RowHiding) != p.D_1+1 {
+			return fmt.Errorf("vss: checkpoint with a row that is not of degree %d", p.D_1)
+		}
+		if !kzg.KZGCommits(sh, cp.Row, cp.RowHiding).Equal(cm[cp.ID]) {
+			return errors.New("vss: the row of the checkpoint does not match the commitment")
+		}
+	} else if cp.RowHiding != nil {
+		return errors.New("vss: checkpoint with a hiding row but no row")
+	}
+
+	// φ(i, j) lies on the row of j at X = i, φ(j, i) on our own row at X = j
+	var openings []kzg.Opening
+	for j, pr := range cp.RowPoints {
+		if pr.ReturnP() != nil {
+			openings = append(openings, kzg.Opening{Index: j, Z: vn[cp.ID], Y_1: pr.ReturnY_1(), Y_2: pr.ReturnY_2(), Proof: pr.ReturnP()})
+		}
+	}
+	for j, pr := range cp.ColumnPoints {
+		if pr.ReturnP() != nil {
+			openings = append(openings, kzg.Opening{Index: cp.ID, Z: vn[j], Y_1: pr.ReturnY_1(), Y_2: pr.ReturnY_2(), Proof: pr.ReturnP()})
+		}
+	}
+	if len(openings) > 0 && !kzg.KZGBatchVerify(sh, cm, openings) {
+		return errors.New("vss: the points of the checkpoint do not match the commitment")
+	}
+	return nil
+}
+
+/*
+This function restarts a participant from a checkpoint. It returns the node together with the
+messages to send: everything the node had sent before, since the crash may have lost it (the
+peers ignore what they already have), and a ResumeMessage to every peer asking only for what is
+missing from that peer. If the row of the dealer was lost, the node gets it from the column
+points of the others as in step 3 of BingoShare.
+*/
+func ResumeNode(cp Checkpoint, suite *Suite, setup *kzg.KzgSetup) (*Node, []Envelope, error) {
+	if cp.Curve != suite.ReturnCurve() {
+		return nil, nil, fmt.Errorf("vss: checkpoint on %s, the suite is on %s", cp.Curve, suite.ReturnCurve())
+	}
+	if err := cp.Verify(setup); err != nil {
+		return nil, nil, err
+	}
+	nd, err := NewNode(cp.ID, cp.Params, suite, setup)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var echo, ready []byte
+	if cp.Echoed != nil {
+		if echo, err = MarshalMessage(&CommitmentMessage{Commitments: cp.Echoed}); err != nil {
+			return nil, nil, err
+		}
+	}
+	if cp.Readied != nil {
+		if ready, err = MarshalMessage(&CommitmentMessage{Commitments: cp.Readied}); err != nil {
+			return nil, nil, err
+		}
+	}
+	steps, err := nd.rbc.Resume(echo, ready)
+	if err != nil {
+		return nil, nil, fmt.Errorf("vss: %w", err)
+	}
+	nd.echoed, nd.readied = cp.Echoed, cp.Readied
+
+	var out []Envelope
+	for _, env := range steps {
+		msg, err := nd.broadcastMessage(env.Msg)
+		if err != nil {
+			return nil, nil, err
+		}
+		out = append(out, Envelope{From: nd.id, To: env.To, Msg: msg})
+	}
+
+	copy(nd.rowPoints, cp.RowPoints)
+	copy(nd.columnPoints, cp.ColumnPoints)
+	copy(nd.done, cp.Done)
+	copy(nd.faulty, cp.Faulty)
+	if cp.Commitments != nil {
+		nd.commitments = cp.Commitments
+		nd.cm = kzg.PartialEval(setup, cp.Commitments, nd.indices())
+	}
+
+	// Send again what the node had sent, which also puts it back into its state
+	if cp.Row != nil {
+		nd.row = poly.NewPriPoly(suite.suite, cp.Params.D_2, cp.Row, cp.RowHiding, nil)
+		rows, _, err := nd.rowPointMessages()
+		if err != nil {
+			return nil, nil, err
+		}
+		out = append(out, rows...)
+		nd.state = StateRowsSent
+	}
+	if cp.SentColumns {
+		// Completes the node if it has its row, and counts the done messages of the checkpoint
+		columns, err := nd.sendColumns()
+		if err != nil {
+			return nil, nil, err
+		}
+		out = append(out, columns...)
+	}
+
+	return nd, append(out, nd.resumeRequests()...), nil
+}
+
+// resumeRequests asks every peer for what the node still misses from it.
+func (nd *Node) resumeRequests() []Envelope {
+	var out []Envelope
+	for j := 1; j <= nd.params.N; j++ {
+		if j == nd.id {
+			continue
+		}
+		m := &ResumeMessage{
+			Commitment:  nd.cm == nil,
+			RowPoint:    nd.cm != nil && !nd.sentColumns && nd.rowPoints[j].ReturnP() == nil,
+			ColumnPoint: nd.cm != nil && nd.row == nil && nd.columnPoints[j].ReturnP() == nil,
+			Done:        nd.state != StateTerminated && !nd.done[j],
+		}
+		if nd.cm == nil {
+			// Nothing can be verified before the commitment, so ask for everything
+			m.RowPoint, m.ColumnPoint = true, true
+		}
+		if m.Commitment || m.RowPoint || m.ColumnPoint || m.Done {
+			out = append(out, Envelope{From: nd.id, To: j, Msg: m})
+		}
+	}
+	return out
+}
+
+func validPoints(points []kyber.Point, l int) bool {
+	if len(points) != l {
+		return false
+	}
+	for _, p := range points {
+		if p == nil {
+			return false
+		}
+	}
+	return true
+}
+
+/*
+A Checkpoint is encoded in the following binary format (all integers big-endian):
+
+	version      uint8    checkpointVersion
+	curve        uint16 length, then the name
+	id           uint32
+	params       uint32 N, F, D_1 and D_2
+	commitments  Echoed, Readied and Commitments, each a byte 0 if it is nil, else 1 and a uint16
+	             count followed by the points
+	row          a byte 0 if there is no row, else 1 and the coefficients and hiding coefficients
+	             as in a VerifierState
+	points       RowPoints and ColumnPoints as the proofs of a VerifierState
+	progress     a byte 1 if the columns were sent, else 0, then Done and Faulty as a uint16
+	             count followed by one byte 0 or 1 per participant
+*/
+const checkpointVersion uint8 = 1
+
+// MarshalCheckpoint returns the binary encoding of cp.
+func MarshalCheckpoint(cp Checkpoint) ([]byte, error) {
+	p := cp.Params
+	if cp.ID < 0 || p.N < 0 || p.F < 0 || p.D_1 < 0 || p.D_2 < 0 || len(cp.Curve) > 0xffff {
+		return nil, errors.New("vss: cannot encode the checkpoint")
+	}
+
+	var buf bytes.Buffer
+	buf.WriteByte(checkpointVersion)
+	_ = binary.Write(&buf, binary.BigEndian, uint16(len(cp.Curve)))
+	buf.WriteString(cp.Curve)
+	for _, v := range []int{cp.ID, p.N, p.F, p.D_1, p.D_2} {
+		_ = binary.Write(&buf, binary.BigEndian, uint32(v))
+	}
+	for _, c := range [][]kyber.Point{cp.Echoed, cp.Readied, cp.Commitments} {
+		buf.WriteByte(flag(c != nil))
+		if c == nil {
+			continue
+		}
+		if err := writePointList(&buf, c); err != nil {
+			return nil, err
+		}
+	}
+	buf.WriteByte(flag(cp.Row != nil))
+	if cp.Row != nil {
+		if err := writeScalarList(&buf, cp.Row); err != nil {
+			return nil, err
+		}
+		if err := writeScalarList(&buf, cp.RowHiding); err != nil {
+			return nil, err
+		}
+	}
+	for _, proofs := range [][]kzg.Proof{cp.RowPoints, cp.ColumnPoints} {
+		if err := writeProofList(&buf, proofs); err != nil {
+			return nil, err
+		}
+	}
+	buf.WriteByte(flag(cp.SentColumns))
+	for _, set := range [][]bool{cp.Done, cp.Faulty} {
+		if len(set) > 0xffff {
+			return nil, errors.New("vss: cannot encode the checkpoint")
+		}
+		_ = binary.Write(&buf, binary.BigEndian, uint16(len(set)))
+		for _, b := range set {
+			buf.WriteByte(flag(b))
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// UnmarshalCheckpoint decodes a checkpoint produced by MarshalCheckpoint, in the groups of its curve.
+func UnmarshalCheckpoint(data []byte) (Checkpoint, error) {
+	var cp Checkpoint
+	r := bytes.NewReader(data)
+
+	version, err := r.ReadByte()
+	if err != nil {
+		return cp, errors.New("vss: checkpoint too short")
+	}
+	if version != checkpointVersion {
+		return cp, fmt.Errorf("vss: unsupported checkpoint version %d", version)
+	}
+	var l uint16
+	if err := binary.Read(r, binary.BigEndian, &l); err != nil {
+		return cp, fmt.Errorf("vss: malformed checkpoint: %w", err)
+	}
+	curve := make([]byte, l)
+	if _, err := io.ReadFull(r, curve); err != nil {
+		return cp, fmt.Errorf("vss: malformed checkpoint: %w", err)
+	}
+	cp.Curve = string(curve)
+	suite, err := NewSuiteForCurve(cp.Curve)
+	if err != nil {
+		return Checkpoint{}, err
+	}
+
+	var v [5]uint32
+	if err := binary.Read(r, binary.BigEndian, &v); err != nil {
+		return Checkpoint{}, fmt.Errorf("vss: malformed checkpoint: %w", err)
+	}
+	cp.ID = int(v[0])
+	cp.Params = Params{N: int(v[1]), F: int(v[2]), D_1: int(v[3]), D_2: int(v[4])}
+
+	for _, c := range []*[]kyber.Point{&cp.Echoed, &cp.Readied, &cp.Commitments} {
+		var present bool
+		if present, err = readFlag(r); err == nil && present {
+			*c, err = readPointList(r, suite)
+		}
+		if err != nil {
+			return Checkpoint{}, fmt.Errorf("vss: malformed checkpoint: %w", err)
+		}
+	}
+	present, err := readFlag(r)
+	if err == nil && present {
+		if cp.Row, err = readScalarList(r, suite); err == nil {
+			cp.RowHiding, err = readScalarList(r, suite)
+		}
+	}
+	if err == nil {
+		cp.RowPoints, err = readProofList(r, suite)
+	}
+	if err == nil {
+		cp.ColumnPoints, err = readProofList(r, suite)
+	}
+	if err == nil {
+		cp.SentColumns, err = readFlag(r)
+	}
+	for _, set := range []*[]bool{&cp.Done, &cp.Faulty} {
+		if err != nil {
+			break
+		}
+		*set, err = readFlags(r)
+	}
+	if err != nil {
+		return Checkpoint{}, fmt.Errorf("vss: malformed checkpoint: %w", err)
+	}
+	if r.Len() != 0 {
+		return Checkpoint{}, fmt.Errorf("vss: %d trailing bytes after the checkpoint", r.Len())
+	}
+	return cp, nil
+}
+
+func flag(b bool) byte {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func readFlag(r *bytes.Reader) (bool, error) {
+	b, err := r.ReadByte()
+	if err != nil {
+		return false, err
+	}
+	if b > 1 {
+		return false, fmt.Errorf("invalid flag %d", b)
+	}
+	return b == 1, nil
+}
+
+func readFlags(r *bytes.Reader) ([]bool, error) {
+	var l uint16
+	if err := binary.Read(r, binary.BigEndian, &l); err != nil {
+		return nil, err
+	}
+	if int(l) > r.Len() {
+		return nil, fmt.Errorf("%d flags announced, %d bytes left", l, r.Len())
+	}
+	set := make([]bool, l)
+	for i := range set {
+		b, err := readFlag(r)
+		if err != nil {
+			return nil, err
+		}
+		set[i] = b
+	}
+	return set, nil
+}
@@ -0,0 +1,220 @@
+package vss
+
+import (
+	kzg "BingoVSS/Internal/Biv_KZG"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+/*
+deliverWithCrash passes the messages between the nodes in FIFO order and checkpoints the victims
+after every message they handle. Once crash returns true for a victim, all victims crash: the
+messages that victim just returned and every message to a victim are lost until nothing else can
+be delivered. Then the victims resume from their checkpoints, which go through the binary
+encoding, and the delivery goes on. It reports whether the crash happened.
+*/
+func deliverWithCrash(t *testing.T, g *Suite, setup *kzg.KzgSetup, nodes []*Node, queue []Envelope, victims []int, crash func(*Node) bool, drop func(Envelope) bool) bool {
+	victim := make(map[int]bool)
+	checkpoints := make(map[int][]byte)
+	save := func(i int) {
+		data, err := MarshalCheckpoint(nodes[i].Checkpoint())
+		require.NoError(t, err)
+		checkpoints[i] = data
+	}
+	for _, i := range victims {
+		victim[i] = true
+		save(i)
+	}
+
+	crashed, down := false, false
+	for {
+		for len(queue) > 0 {
+			env := queue[0]
+			queue = queue[1:]
+			if (drop != nil && drop(env)) || (down && victim[env.To]) {
+				continue
+			}
+
+			out, err := nodes[env.To].Handle(env)
+			require.NoError(t, err)
+			if victim[env.To] {
+				save(env.To)
+				if !crashed && crash(nodes[env.To]) {
+					crashed, down = true, true
+					continue
+				}
+			}
+			queue = append(queue, out...)
+		}
+		if !down {
+			return crashed
+		}
+
+		down = false
+		for _, i := range victims {
+			cp, err := UnmarshalCheckpoint(checkpoints[i])
+			require.NoError(t, err)
+			nd, out, err := ResumeNode(cp, g, setup)
+			require.NoError(t, err)
+			nodes[i] = nd
+			queue = append(queue, out...)
+		}
+	}
+}
+
+// requireShared checks that every node terminated with the row the dealer meant for it.
+func requireShared(t *testing.T, nodes []*Node, dealer *Dealer) {
+	for i := 1; i < len(nodes); i++ {
+		require.NoError(t, nodes[i].Incomplete(), "node %d", i)
+		require.Equal(t, StateTerminated, nodes[i].ReturnState())
+		row := nodes[i].ReturnRow().Coefficients()
+		for k, c := range dealer.sharePolys[i].Coefficients() {
+			require.True(t, c.Equal(row[k]), "node %d", i)
+		}
+	}
+}
+
+func TestNodeResumesAtEveryPhase(t *testing.T) {
+	g := NewSuite()
+	params := NewParams(4, 1)
+
+	phases := []struct {
+		name    string
+		lostRow bool // the row of the dealer never reaches the victim
+		reached func(*Node) bool
+	}{
+		{"echoed", false, func(nd *Node) bool { return nd.ReturnCommitments() == nil }},
+		{"commitment delivered", true, func(nd *Node) bool { return nd.ReturnCommitments() != nil }},
+		{"rows sent", false, func(nd *Node) bool { return nd.ReturnState() == StateRowsSent }},
+		{"columns sent", true, func(nd *Node) bool { return nd.ReturnState() == StateColumnsSent }},
+		{"complete", false, func(nd *Node) bool { return nd.ReturnState() == StateComplete }},
+		{"terminated", false, func(nd *Node) bool { return nd.ReturnState() == StateTerminated }},
+	}
+
+	for _, phase := range phases {
+		for victim := 1; victim <= params.N; victim++ {
+			msgs, dealer, setup := dealMessages(t, g, params)
+			nodes := newNodes(t, g, params, setup)
+
+			drop := func(env Envelope) bool {
+				return phase.lostRow && env.To == victim && env.Msg.Type() == MsgPolynomial
+			}
+			crashed := deliverWithCrash(t, g, setup, nodes, msgs, []int{victim}, phase.reached, drop)
+			require.True(t, crashed, "%s: node %d never got there", phase.name, victim)
+			requireShared(t, nodes, dealer)
+		}
+	}
+}
+
+func TestNodesResumeAfterCrashOfAll(t *testing.T) {
+	forEachCurve(t, func(t *testing.T, g *Suite) {
+		params := NewParams(7, 2)
+		msgs, dealer, setup := dealMessages(t, g, params)
+		nodes := newNodes(t, g, params, setup)
+
+		// Everyone crashes as soon as one participant completes; the rows still in flight are
+		// lost, and the participants without one interpolate it from the columns
+		all := []int{1, 2, 3, 4, 5, 6, 7}
+		crashed := deliverWithCrash(t, g, setup, nodes, msgs, all, func(nd *Node) bool {
+			return nd.ReturnState() == StateComplete
+		}, nil)
+		require.True(t, crashed)
+		requireShared(t, nodes, dealer)
+	})
+}
+
+func TestResumeAsksOnlyForMissingData(t *testing.T) {
+	g := NewSuite()
+	params := NewParams(4, 1)
+	msgs, _, setup := dealMessages(t, g, params)
+	nodes := newNodes(t, g, params, setup)
+
+	// Node 1 gets the commitment and its row, and nothing from the others
+	deliver(t, nodes, msgs, func(env Envelope) bool {
+		return env.To == 1 && (env.Msg.Type() == MsgRowPoint || env.Msg.Type() == MsgColumnPoint || env.Msg.Type() == MsgDone)
+	})
+	require.Equal(t, StateRowsSent, nodes[1].ReturnState())
+
+	nd, out, err := ResumeNode(nodes[1].Checkpoint(), g, setup)
+	require.NoError(t, err)
+	require.Equal(t, StateRowsSent, nd.ReturnState())
+
+	requests := 0
+	for _, env := range out {
+		require.Equal(t, 1, env.From)
+		switch m := env.Msg.(type) {
+		case *ResumeMessage:
+			requests++
+			require.Equal(t, ResumeMessage{RowPoint: true, Done: true}, *m)
+		case *RowPointMessage, *EchoMessage, *ReadyMessage:
+		default:
+			t.Fatalf("unexpected %s message", env.Msg.Type())
+		}
+	}
+	require.Equal(t, params.N-1, requests)
+
+	// The answers let the node complete
+	nodes[1] = nd
+	deliver(t, nodes, out, nil)
+	require.Equal(t, StateTerminated, nodes[1].ReturnState())
+}
+
+func TestResumeRejectsInvalidCheckpoints(t *testing.T) {
+	g := NewSuite()
+	params := NewParams(4, 1)
+	msgs, _, setup := dealMessages(t, g, params)
+	nodes := newNodes(t, g, params, setup)
+	deliver(t, nodes, msgs, nil)
+	cp := nodes[2].Checkpoint()
+
+	data, err := MarshalCheckpoint(cp)
+	require.NoError(t, err)
+	decoded, err := UnmarshalCheckpoint(data)
+	require.NoError(t, err)
+	again, err := MarshalCheckpoint(decoded)
+	require.NoError(t, err)
+	require.Equal(t, data, again)
+	_, _, err = ResumeNode(decoded, g, setup)
+	require.NoError(t, err)
+
+	// Malformed encodings
+	for l := 0; l < len(data); l += 7 {
+		_, err := UnmarshalCheckpoint(data[:l])
+		require.Error(t, err, "truncated to %d bytes", l)
+	}
+	_, err = UnmarshalCheckpoint(append(append([]byte{}, data...), 0))
+	require.Error(t, err)
+	bad := append([]byte{}, data...)
+	bad[0] = 9
+	_, err = UnmarshalCheckpoint(bad)
+	require.Error(t, err)
+
+	// Checkpoints that do not match the commitment
+	tamper := []func(cp *Checkpoint){
+		func(cp *Checkpoint) {
+			cp.Row = append(cp.Row[:0:0], cp.Row...)
+			cp.Row[0] = g.suite.G1().Scalar().Add(cp.Row[0], g.suite.G1().Scalar().One())
+		},
+		func(cp *Checkpoint) {
+			for j, p := range cp.RowPoints {
+				if p.ReturnP() != nil {
+					cp.RowPoints[j] = *kzg.NewProof(j, p.ReturnP(), p.ReturnY_2(), p.ReturnY_1())
+					return
+				}
+			}
+		},
+		func(cp *Checkpoint) { cp.Commitments = cp.Commitments[:1] },
+		func(cp *Checkpoint) { cp.Commitments, cp.Row, cp.RowHiding = nil, nil, nil },
+		func(cp *Checkpoint) { cp.Done = cp.Done[1:] },
+		func(cp *Checkpoint) { cp.ID = params.N + 1 },
+		func(cp *Checkpoint) { cp.Curve = CurveBLS12381 },
+	}
+	for k, f := range tamper {
+		bad, err := UnmarshalCheckpoint(data)
+		require.NoError(t, err)
+		f(&bad)
+		_, _, err = ResumeNode(bad, g, setup)
+		require.Error(t, err, "tampering %d", k)
+	}
+}
@@ -0,0 +1,528 @@
+package vss
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/drand/kyber"
+)
+
+/*
+Messages are encoded in the following binary format (all integers big-endian):
+
+	version    uint8    wireVersion
+	type       uint8    MessageType
+	payload    depending on the type:
+	  commitment         uint16 count, then the points
+	  echo/ready         as a commitment
+	  polynomial         uint16 count, the row coefficients, uint16 count, the hiding coefficients
+	  row/column point   y_1, y_2, proof
+	  reconstruct share  uint32 secret index, y_1, y_2, proof
+	  done               empty
+	  resume             uint8 flags: 1 commitment, 2 row point, 4 column point, 8 done
+
+Points and scalars are written with their canonical MarshalBinary encoding, so a message has
+exactly one encoding. On a stream every message is preceded by its length as a uint32.
+*/
+
+const wireVersion uint8 = 1
+
+// maxMessageSize bounds the length of a message read from a stream.
+const maxMessageSize = 1 << 24
+
+// MarshalMessage returns the binary encoding of m.
+func MarshalMessage(m Message) ([]byte, error) {
+	if m == nil {
+		return nil, errors.New("vss: cannot encode an empty message")
+	}
+
+	var buf bytes.Buffer
+	buf.WriteByte(wireVersion)
+	buf.WriteByte(byte(m.Type()))
+
+	var err error
+	switch m := m.(type) {
+	case *CommitmentMessage:
+		err = writePointList(&buf, m.Commitments)
+	case *EchoMessage:
+		err = writePointList(&buf, m.Commitments)
+	case *ReadyMessage:
+		err = writePointList(&buf, m.Commitments)
+	case *DoneMessage:
+	case *ResumeMessage:
+		buf.WriteByte(resumeFlags(m))
+	case *PolynomialMessage:
+		if err = writeScalarList(&buf, m.Row); err == nil {
+			err = writeScalarList(&buf, m.RowHiding)
+		}
+	case *RowPointMessage:
+		err = writeEvaluation(&buf, m.Y_1, m.Y_2, m.Proof)
+	case *ColumnPointMessage:
+		err = writeEvaluation(&buf, m.Y_1, m.Y_2, m.Proof)
+	case *ReconstructShareMessage:
+		if m.Secret < 0 {
+			return nil, fmt.Errorf("vss: negative secret index %d", m.Secret)
+		}
+		_ = binary.Write(&buf, binary.BigEndian, uint32(m.Secret))
+		err = writeEvaluation(&buf, m.Y_1, m.Y_2, m.Proof)
+	default:
+		return nil, fmt.Errorf("vss: cannot encode %s message", m.Type())
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// UnmarshalMessage decodes a message produced by MarshalMessage. Points and scalars are read in
+// the groups of the suite. It only checks the encoding; the proofs are checked by the Node.
+func UnmarshalMessage(data []byte, suite *Suite) (Message, error) {
+	r := bytes.NewReader(data)
+
+	var header [2]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return nil, errors.New("vss: message too short")
+	}
+	if header[0] != wireVersion {
+		return nil, fmt.Errorf("vss: unsupported message version %d", header[0])
+	}
+
+	var m Message
+	var err error
+	switch MessageType(header[1]) {
+	case MsgCommitment:
+		c := &CommitmentMessage{}
+		c.Commitments, err = readPointList(r, suite)
+		m = c
+	case MsgEcho:
+		c := &EchoMessage{}
+		c.Commitments, err = readPointList(r, suite)
+		m = c
+	case MsgReady:
+		c := &ReadyMessage{}
+		c.Commitments, err = readPointList(r, suite)
+		m = c
+	case MsgDone:
+		m = &DoneMessage{}
+	case MsgResume:
+		var flags byte
+		if flags, err = r.ReadByte(); err == nil {
+			m, err = resumeMessage(flags)
+		}
+	case MsgPolynomial:
+		p := &PolynomialMessage{}
+		if p.Row, err = readScalarList(r, suite); err == nil {
+			p.RowHiding, err = readScalarList(r, suite)
+		}
+		m = p
+	case MsgRowPoint:
+		p := &RowPointMessage{}
+		p.Y_1, p.Y_2, p.Proof, err = readEvaluation(r, suite)
+		m = p
+	case MsgColumnPoint:
+		p := &ColumnPointMessage{}
+		p.Y_1, p.Y_2, p.Proof, err = readEvaluation(r, suite)
+		m = p
+	case MsgReconstructShare:
+		p := &ReconstructShareMessage{}
+		var k uint32
+		if err = binary.Read(r, binary.BigEndian, &k); err == nil {
+			p.Secret = int(k)
+			p.Y_1, p.Y_2, p.Proof, err = readEvaluation(r, suite)
+		}
+		m = p
+	default:
+		return nil, fmt.Errorf("vss: unknown message type %d", header[1])
+	}
+	if err != nil {
+		return nil, fmt.Errorf("vss: malformed %s message: %w", MessageType(header[1]), err)
+	}
+	if r.Len() != 0 {
+		return nil, fmt.Errorf("vss: %d trailing bytes after %s message", r.Len(), m.Type())
+	}
+
+	return m, nil
+}
+
+// WriteMessage writes the binary encoding of m to w, preceded by its length.
+func WriteMessage(w io.Writer, m Message) error {
+	data, err := MarshalMessage(m)
+	if err != nil {
+		return err
+	}
+	if len(data) > maxMessageSize {
+		return fmt.Errorf("vss: message of %d bytes is too large", len(data))
+	}
+
+	frame := make([]byte, 4, 4+len(data))
+	binary.BigEndian.PutUint32(frame, uint32(len(data)))
+	_, err = w.Write(append(frame, data...))
+	return err
+}
+
+// ReadMessage reads a single message written by WriteMessage from r.
+func ReadMessage(r io.Reader, suite *Suite) (Message, error) {
+	var l uint32
+	if err := binary.Read(r, binary.BigEndian, &l); err != nil {
+		return nil, err
+	}
+	if l > maxMessageSize {
+		return nil, fmt.Errorf("vss: message of %d bytes is too large", l)
+	}
+
+	data := make([]byte, l)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return nil, err
+	}
+
+	return UnmarshalMessage(data, suite)
+}
+
+// jsonMessage is the debug encoding of a message, with points and scalars as hex strings.
+type jsonMessage struct {
+	Type        string   `json:"type"`
+	Commitments []string `json:"commitments,omitempty"`
+	Row         []string `json:"row,omitempty"`
+	RowHiding   []string `json:"row_hiding,omitempty"`
+	Secret      *int     `json:"secret,omitempty"`
+	Y_1         string   `json:"y_1,omitempty"`
+	Y_2         string   `json:"y_2,omitempty"`
+	Proof       string   `json:"proof,omitempty"`
+	Missing     []string `json:"missing,omitempty"`
+}
+
+// MarshalMessageJSON returns a human readable JSON encoding of m, meant for logs and debugging.
+func MarshalMessageJSON(m Message) ([]byte, error) {
+	if m == nil {
+		return nil, errors.New("vss: cannot encode an empty message")
+	}
+
+	j := jsonMessage{Type: m.Type().String()}
+	var err error
+	switch m := m.(type) {
+	case *CommitmentMessage:
+		j.Commitments, err = hexList(pointsToMarshalers(m.Commitments))
+	case *EchoMessage:
+		j.Commitments, err = hexList(pointsToMarshalers(m.Commitments))
+	case *ReadyMessage:
+		j.Commitments, err = hexList(pointsToMarshalers(m.Commitments))
+	case *DoneMessage:
+	case *ResumeMessage:
+		for bit, t := range resumeTypes {
+			if resumeFlags(m)&(1<<bit) != 0 {
+				j.Missing = append(j.Missing, t.String())
+			}
+		}
+	case *PolynomialMessage:
+		if j.Row, err = hexList(scalarsToMarshalers(m.Row)); err == nil {
+			j.RowHiding, err = hexList(scalarsToMarshalers(m.RowHiding))
+		}
+	case *RowPointMessage:
+		j.Y_1, j.Y_2, j.Proof, err = hexEvaluation(m.Y_1, m.Y_2, m.Proof)
+	case *ColumnPointMessage:
+		j.Y_1, j.Y_2, j.Proof, err = hexEvaluation(m.Y_1, m.Y_2, m.Proof)
+	case *ReconstructShareMessage:
+		k := m.Secret
+		j.Secret = &k
+		j.Y_1, j.Y_2, j.Proof, err = hexEvaluation(m.Y_1, m.Y_2, m.Proof)
+	default:
+		return nil, fmt.Errorf("vss: cannot encode %s message", m.Type())
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(j)
+}
+
+// UnmarshalMessageJSON decodes a message produced by MarshalMessageJSON.
+func UnmarshalMessageJSON(data []byte, suite *Suite) (Message, error) {
+	var j jsonMessage
+	if err := json.Unmarshal(data, &j); err != nil {
+		return nil, fmt.Errorf("vss: malformed JSON message: %w", err)
+	}
+
+	var err error
+	switch j.Type {
+	case MsgCommitment.String():
+		c := &CommitmentMessage{}
+		c.Commitments, err = parsePoints(j.Commitments, suite)
+		return c, wrapJSON(err)
+	case MsgEcho.String():
+		c := &EchoMessage{}
+		c.Commitments, err = parsePoints(j.Commitments, suite)
+		return c, wrapJSON(err)
+	case MsgReady.String():
+		c := &ReadyMessage{}
+		c.Commitments, err = parsePoints(j.Commitments, suite)
+		return c, wrapJSON(err)
+	case MsgDone.String():
+		return &DoneMessage{}, nil
+	case MsgResume.String():
+		var flags byte
+		for _, name := range j.Missing {
+			bit := -1
+			for b, t := range resumeTypes {
+				if t.String() == name {
+					bit = b
+				}
+			}
+			if bit < 0 {
+				return nil, fmt.Errorf("vss: malformed JSON message: cannot resume %q", name)
+			}
+			flags |= 1 << bit
+		}
+		m, err := resumeMessage(flags)
+		if err != nil {
+			return nil, wrapJSON(err)
+		}
+		return m, nil
+	case MsgPolynomial.String():
+		p := &PolynomialMessage{}
+		if p.Row, err = parseScalars(j.Row, suite); err == nil {
+			p.RowHiding, err = parseScalars(j.RowHiding, suite)
+		}
+		return p, wrapJSON(err)
+	case MsgRowPoint.String():
+		p := &RowPointMessage{}
+		p.Y_1, p.Y_2, p.Proof, err = parseEvaluation(j, suite)
+		return p, wrapJSON(err)
+	case MsgColumnPoint.String():
+		p := &ColumnPointMessage{}
+		p.Y_1, p.Y_2, p.Proof, err = parseEvaluation(j, suite)
+		return p, wrapJSON(err)
+	case MsgReconstructShare.String():
+		if j.Secret == nil || *j.Secret < 0 {
+			return nil, errors.New("vss: reconstruct share without a valid secret index")
+		}
+		p := &ReconstructShareMessage{Secret: *j.Secret}
+		p.Y_1, p.Y_2, p.Proof, err = parseEvaluation(j, suite)
+		return p, wrapJSON(err)
+	}
+
+	return nil, fmt.Errorf("vss: unknown message type %q", j.Type)
+}
+
+// resumeTypes names the flags of a resume message, bit k asks for a message of type resumeTypes[k].
+// The commitment stands for the echo and ready of the peer.
+var resumeTypes = []MessageType{MsgCommitment, MsgRowPoint, MsgColumnPoint, MsgDone}
+
+func resumeFlags(m *ResumeMessage) byte {
+	var flags byte
+	for bit, set := range []bool{m.Commitment, m.RowPoint, m.ColumnPoint, m.Done} {
+		if set {
+			flags |= 1 << bit
+		}
+	}
+	return flags
+}
+
+func resumeMessage(flags byte) (*ResumeMessage, error) {
+	if flags>>len(resumeTypes) != 0 {
+		return nil, fmt.Errorf("unknown resume flags %#x", flags)
+	}
+	return &ResumeMessage{Commitment: flags&1 != 0, RowPoint: flags&2 != 0, ColumnPoint: flags&4 != 0, Done: flags&8 != 0}, nil
+}
+
+func wrapJSON(err error) error {
+	if err != nil {
+		return fmt.Errorf("vss: malformed JSON message: %w", err)
+	}
+	return nil
+}
+
+func writePointList(w *bytes.Buffer, points []kyber.Point) error {
+	if len(points) > 0xffff {
+		return fmt.Errorf("vss: %d points do not fit in a message", len(points))
+	}
+	_ = binary.Write(w, binary.BigEndian, uint16(len(points)))
+	for _, p := range points {
+		if p == nil {
+			return errors.New("vss: cannot encode a missing point")
+		}
+		if _, err := p.MarshalTo(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeScalarList(w *bytes.Buffer, scalars []kyber.Scalar) error {
+	if len(scalars) > 0xffff {
+		return fmt.Errorf("vss: %d scalars do not fit in a message", len(scalars))
+	}
+	_ = binary.Write(w, binary.BigEndian, uint16(len(scalars)))
+	for _, s := range scalars {
+		if s == nil {
+			return errors.New("vss: cannot encode a missing scalar")
+		}
+		if _, err := s.MarshalTo(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeEvaluation(w *bytes.Buffer, y_1, y_2 kyber.Scalar, proof kyber.Point) error {
+	if y_1 == nil || y_2 == nil || proof == nil {
+		return errors.New("vss: cannot encode an incomplete evaluation")
+	}
+	for _, s := range []kyber.Scalar{y_1, y_2} {
+		if _, err := s.MarshalTo(w); err != nil {
+			return err
+		}
+	}
+	_, err := proof.MarshalTo(w)
+	return err
+}
+
+func readPointList(r *bytes.Reader, suite *Suite) ([]kyber.Point, error) {
+	var l uint16
+	if err := binary.Read(r, binary.BigEndian, &l); err != nil {
+		return nil, err
+	}
+	// Every point takes PointLen bytes, so a count larger than what is left is malformed
+	if int(l)*suite.suite.G1().PointLen() > r.Len() {
+		return nil, fmt.Errorf("%d points announced, %d bytes left", l, r.Len())
+	}
+
+	points := make([]kyber.Point, l)
+	for i := range points {
+		points[i] = suite.suite.G1().Point()
+		if _, err := points[i].UnmarshalFrom(r); err != nil {
+			return nil, err
+		}
+	}
+	return points, nil
+}
+
+func readScalarList(r *bytes.Reader, suite *Suite) ([]kyber.Scalar, error) {
+	var l uint16
+	if err := binary.Read(r, binary.BigEndian, &l); err != nil {
+		return nil, err
+	}
+	if int(l)*suite.suite.G1().ScalarLen() > r.Len() {
+		return nil, fmt.Errorf("%d scalars announced, %d bytes left", l, r.Len())
+	}
+
+	scalars := make([]kyber.Scalar, l)
+	for i := range scalars {
+		scalars[i] = suite.suite.G1().Scalar()
+		if _, err := scalars[i].UnmarshalFrom(r); err != nil {
+			return nil, err
+		}
+	}
+	return scalars, nil
+}
+
+func readEvaluation(r *bytes.Reader, suite *Suite) (kyber.Scalar, kyber.Scalar, kyber.Point, error) {
+	y_1 := suite.suite.G1().Scalar()
+	y_2 := suite.suite.G1().Scalar()
+	proof := suite.suite.G1().Point()
+
+	if _, err := y_1.UnmarshalFrom(r); err != nil {
+		return nil, nil, nil, err
+	}
+	if _, err := y_2.UnmarshalFrom(r); err != nil {
+		return nil, nil, nil, err
+	}
+	if _, err := proof.UnmarshalFrom(r); err != nil {
+		return nil, nil, nil, err
+	}
+	return y_1, y_2, proof, nil
+}
+
+func pointsToMarshalers(points []kyber.Point) []kyber.Marshaling {
+	m := make([]kyber.Marshaling, len(points))
+	for i, p := range points {
+		m[i] = p
+	}
+	return m
+}
+
+func scalarsToMarshalers(scalars []kyber.Scalar) []kyber.Marshaling {
+	m := make([]kyber.Marshaling, len(scalars))
+	for i, s := range scalars {
+		m[i] = s
+	}
+	return m
+}
+
+func hexList(values []kyber.Marshaling) ([]string, error) {
+	out := make([]string, len(values))
+	for i, v := range values {
+		if v == nil {
+			return nil, errors.New("vss: cannot encode a missing value")
+		}
+		b, err := v.MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		out[i] = hex.EncodeToString(b)
+	}
+	return out, nil
+}
+
+func hexEvaluation(y_1, y_2 kyber.Scalar, proof kyber.Point) (string, string, string, error) {
+	if y_1 == nil || y_2 == nil || proof == nil {
+		return "", "", "", errors.New("vss: cannot encode an incomplete evaluation")
+	}
+	values, err := hexList([]kyber.Marshaling{y_1, y_2, proof})
+	if err != nil {
+		return "", "", "", err
+	}
+	return values[0], values[1], values[2], nil
+}
+
+func parsePoints(values []string, suite *Suite) ([]kyber.Point, error) {
+	points := make([]kyber.Point, len(values))
+	for i, v := range values {
+		points[i] = suite.suite.G1().Point()
+		if err := unmarshalHex(points[i], v); err != nil {
+			return nil, err
+		}
+	}
+	return points, nil
+}
+
+func parseScalars(values []string, suite *Suite) ([]kyber.Scalar, error) {
+	scalars := make([]kyber.Scalar, len(values))
+	for i, v := range values {
+		scalars[i] = suite.suite.G1().Scalar()
+		if err := unmarshalHex(scalars[i], v); err != nil {
+			return nil, err
+		}
+	}
+	return scalars, nil
+}
+
+func parseEvaluation(j jsonMessage, suite *Suite) (kyber.Scalar, kyber.Scalar, kyber.Point, error) {
+	y_1 := suite.suite.G1().Scalar()
+	y_2 := suite.suite.G1().Scalar()
+	proof := suite.suite.G1().Point()
+
+	if err := unmarshalHex(y_1, j.Y_1); err != nil {
+		return nil, nil, nil, err
+	}
+	if err := unmarshalHex(y_2, j.Y_2); err != nil {
+		return nil, nil, nil, err
+	}
+	if err := unmarshalHex(proof, j.Proof); err != nil {
+		return nil, nil, nil, err
+	}
+	return y_1, y_2, proof, nil
+}
+
+func unmarshalHex(v kyber.Marshaling, s string) error {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		return err
+	}
+	return v.UnmarshalBinary(b)
+}
@@ -0,0 +1,154 @@
+package vss
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"github.com/drand/kyber"
+	"github.com/stretchr/testify/require"
+)
+
+// sampleMessages returns one message of every type with random content.
+func sampleMessages(g *Suite) []Message {
+	s := func() kyber.Scalar { return g.suite.G1().Scalar().Pick(g.suite.RandomStream()) }
+	p := func() kyber.Point { return g.suite.G1().Point().Pick(g.suite.RandomStream()) }
+
+	return []Message{
+		&CommitmentMessage{Commitments: []kyber.Point{p(), p(), p()}},
+		&PolynomialMessage{Row: []kyber.Scalar{s(), s(), s(), s(), s()}, RowHiding: []kyber.Scalar{s(), s(), s(), s(), s()}},
+		&RowPointMessage{Y_1: s(), Y_2: s(), Proof: p()},
+		&ColumnPointMessage{Y_1: s(), Y_2: s(), Proof: p()},
+		&ReconstructShareMessage{Secret: 3, Y_1: s(), Y_2: s(), Proof: p()},
+		&EchoMessage{Commitments: []kyber.Point{p(), p()}},
+		&ReadyMessage{Commitments: []kyber.Point{p(), p()}},
+		&DoneMessage{},
+		&ResumeMessage{Commitment: true, ColumnPoint: true},
+	}
+}
+
+func TestCodecRoundTrip(t *testing.T) {
+	forEachCurve(t, func(t *testing.T, g *Suite) {
+		var stream bytes.Buffer
+
+		for _, m := range sampleMessages(g) {
+			data, err := MarshalMessage(m)
+			require.NoError(t, err)
+
+			decoded, err := UnmarshalMessage(data, g)
+			require.NoError(t, err)
+			require.Equal(t, m.Type(), decoded.Type())
+
+			// The encoding is deterministic, so decoding and encoding again gives the same bytes
+			again, err := MarshalMessage(decoded)
+			require.NoError(t, err)
+			require.Equal(t, data, again)
+
+			js, err := MarshalMessageJSON(m)
+			require.NoError(t, err)
+			fromJSON, err := UnmarshalMessageJSON(js, g)
+			require.NoError(t, err)
+			again, err = MarshalMessage(fromJSON)
+			require.NoError(t, err)
+			require.Equal(t, data, again)
+
+			require.NoError(t, WriteMessage(&stream, m))
+		}
+
+		for _, m := range sampleMessages(g) {
+			decoded, err := ReadMessage(&stream, g)
+			require.NoError(t, err)
+			require.Equal(t, m.Type(), decoded.Type())
+		}
+		require.Equal(t, 0, stream.Len())
+	})
+}
+
+func TestCodecPreservesValues(t *testing.T) {
+	g := NewSuite()
+	m := sampleMessages(g)[4].(*ReconstructShareMessage)
+
+	data, err := MarshalMessage(m)
+	require.NoError(t, err)
+	decoded, err := UnmarshalMessage(data, g)
+	require.NoError(t, err)
+
+	d := decoded.(*ReconstructShareMessage)
+	require.Equal(t, 3, d.Secret)
+	require.True(t, m.Y_1.Equal(d.Y_1))
+	require.True(t, m.Y_2.Equal(d.Y_2))
+	require.True(t, m.Proof.Equal(d.Proof))
+}
+
+func TestCodecRejectsMalformedInput(t *testing.T) {
+	g := NewSuite()
+
+	for _, m := range sampleMessages(g) {
+		data, err := MarshalMessage(m)
+		require.NoError(t, err)
+
+		// Every truncation is rejected
+		for l := 0; l < len(data); l++ {
+			_, err := UnmarshalMessage(data[:l], g)
+			require.Error(t, err, "%s truncated to %d bytes", m.Type(), l)
+		}
+
+		// Trailing data
+		_, err = UnmarshalMessage(append(append([]byte{}, data...), 0), g)
+		require.Error(t, err)
+
+		// Unknown version and type
+		bad := append([]byte{}, data...)
+		bad[0] = 2
+		_, err = UnmarshalMessage(bad, g)
+		require.Error(t, err)
+
+		bad = append([]byte{}, data...)
+		bad[1] = 99
+		_, err = UnmarshalMessage(bad, g)
+		require.Error(t, err)
+	}
+
+	// A count far beyond the data
+	_, err := UnmarshalMessage([]byte{wireVersion, byte(MsgCommitment), 0xff, 0xff, 1, 2, 3}, g)
+	require.Error(t, err)
+
+	// Resume flags that ask for nothing the protocol has
+	_, err = UnmarshalMessage([]byte{wireVersion, byte(MsgResume), 0x10}, g)
+	require.Error(t, err)
+
+	// Bytes that are not a point of the group
+	m := sampleMessages(g)[2]
+	data, _ := MarshalMessage(m)
+	for i := len(data) - g.suite.G1().PointLen(); i < len(data); i++ {
+		data[i] = 0xff
+	}
+	_, err = UnmarshalMessage(data, g)
+	require.Error(t, err)
+
+	// Messages with missing values cannot be encoded
+	_, err = MarshalMessage(&RowPointMessage{})
+	require.Error(t, err)
+	_, err = MarshalMessage(nil)
+	require.Error(t, err)
+
+	// Streams announcing huge messages are rejected before reading them
+	var frame [4]byte
+	binary.BigEndian.PutUint32(frame[:], maxMessageSize+1)
+	_, err = ReadMessage(bytes.NewReader(frame[:]), g)
+	require.Error(t, err)
+
+	// Broken JSON
+	for _, js := range []string{
+		`{`,
+		`{"type":"gossip"}`,
+		`{"type":"row point","y_1":"zz","y_2":"00","proof":"00"}`,
+		`{"type":"row point"}`,
+		`{"type":"reconstruct share","y_1":"00","y_2":"00","proof":"00"}`,
+		`{"type":"commitment","commitments":["0102"]}`,
+		`{"type":"resume","missing":["polynomial"]}`,
+	} {
+		_, err := UnmarshalMessageJSON([]byte(js), g)
+		require.Error(t, err, js)
+	}
+}
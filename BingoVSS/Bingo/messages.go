@@ -0,0 +1,136 @@
+package vss
+
+import (
+	kzg "BingoVSS/Internal/Biv_KZG"
+
+	"github.com/drand/kyber"
+)
+
+// DealerID is the index of the dealer, participants are indexed 1..n.
+const DealerID = 0
+
+// MessageType identifies the kind of a BingoShare message.
+type MessageType uint8
+
+const (
+	MsgCommitment       MessageType = iota + 1 // the commitment vector CM of the dealer
+	MsgPolynomial                              // the row polynomials φ(X, i), φ'(X, i) sent by the dealer to i
+	MsgRowPoint                                // φ(j, i), φ'(j, i) with a proof against cm[i], sent by i to j
+	MsgColumnPoint                             // φ(i, j), φ'(i, j) with a proof against cm[j], sent by i to j
+	MsgReconstructShare                        // φ(-k, i), φ'(-k, i) with a proof against cm[i], sent by i to reconstruct secret k
+	MsgEcho                                    // a participant echoes the commitment it received from the dealer
+	MsgReady                                   // a participant is ready to accept the commitment
+	MsgDone                                    // a participant holds its row and has sent its row and column points
+	MsgResume                                  // a restarted participant asks a peer for what it lost
+)
+
+func (t MessageType) String() string {
+	switch t {
+	case MsgCommitment:
+		return "commitment"
+	case MsgPolynomial:
+		return "polynomial"
+	case MsgRowPoint:
+		return "row point"
+	case MsgColumnPoint:
+		return "column point"
+	case MsgReconstructShare:
+		return "reconstruct share"
+	case MsgEcho:
+		return "echo"
+	case MsgReady:
+		return "ready"
+	case MsgDone:
+		return "done"
+	case MsgResume:
+		return "resume"
+	}
+	return "unknown"
+}
+
+// Message is a typed BingoShare message.
+type Message interface {
+	Type() MessageType
+}
+
+// Envelope carries a message from one party to another. From is set by the
+// channel the message arrived on, never by the message itself. When a dealing
+// is handed over to a new committee, the channel also sets Old to the index of
+// a sender from the old committee, which sends as DealerID; it is 0 otherwise.
+type Envelope struct {
+	From int
+	To   int
+	Old  int
+	Msg  Message
+}
+
+// CommitmentMessage publishes the commitments c_0..c_d2 to the Y-coefficients of φ.
+type CommitmentMessage struct {
+	Commitments []kyber.Point
+}
+
+// PolynomialMessage delivers the row polynomial φ(X, i) and its hiding polynomial φ'(X, i).
+type PolynomialMessage struct {
+	Row       []kyber.Scalar
+	RowHiding []kyber.Scalar
+}
+
+// RowPointMessage carries the evaluation of the sender's row at the column of the recipient.
+type RowPointMessage struct {
+	Y_1   kyber.Scalar
+	Y_2   kyber.Scalar
+	Proof kyber.Point
+}
+
+// ColumnPointMessage carries the evaluation of the sender's column at the row of the recipient.
+type ColumnPointMessage struct {
+	Y_1   kyber.Scalar
+	Y_2   kyber.Scalar
+	Proof kyber.Point
+}
+
+// ReconstructShareMessage carries the share of the sender for the secret with index k, that is its row evaluated at X = -k.
+type ReconstructShareMessage struct {
+	Secret int
+	Y_1    kyber.Scalar
+	Y_2    kyber.Scalar
+	Proof  kyber.Point
+}
+
+// EchoMessage and ReadyMessage carry the commitment through the reliable broadcast among the
+// participants, so that all of them accept the same commitment even if the dealer equivocates.
+type EchoMessage struct {
+	Commitments []kyber.Point
+}
+
+type ReadyMessage struct {
+	Commitments []kyber.Point
+}
+
+// DoneMessage tells that the sender completed its part of BingoShare.
+type DoneMessage struct{}
+
+// ResumeMessage is sent by a participant that restarted from a checkpoint to every peer, asking
+// only for what it still misses from that peer: its echo and ready of the commitment, its row
+// point, its column point and its done message. The peer answers with what it already sent.
+type ResumeMessage struct {
+	Commitment  bool
+	RowPoint    bool
+	ColumnPoint bool
+	Done        bool
+}
+
+func (m *CommitmentMessage) Type() MessageType       { return MsgCommitment }
+func (m *PolynomialMessage) Type() MessageType       { return MsgPolynomial }
+func (m *RowPointMessage) Type() MessageType         { return MsgRowPoint }
+func (m *ColumnPointMessage) Type() MessageType      { return MsgColumnPoint }
+func (m *ReconstructShareMessage) Type() MessageType { return MsgReconstructShare }
+func (m *EchoMessage) Type() MessageType             { return MsgEcho }
+func (m *ReadyMessage) Type() MessageType            { return MsgReady }
+func (m *DoneMessage) Type() MessageType             { return MsgDone }
+func (m *ResumeMessage) Type() MessageType           { return MsgResume }
+
+// pointProof converts a point message received from the party from into a kzg.Proof.
+func pointProof(from int, p kyber.Point, y_1, y_2 kyber.Scalar) kzg.Proof {
+	return *kzg.NewProof(from, p, y_1, y_2)
+}
@@ -0,0 +1,768 @@
+package vss
+
+import (
+	poly "BingoVSS/Internal/BivPoly"
+	kzg "BingoVSS/Internal/Biv_KZG"
+	"BingoVSS/Internal/Broadcast"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/drand/kyber"
+)
+
+// Params describes a BingoShare instance with n participants of which at most f are faulty.
+// The shared polynomial φ has degree D_1 in X and degree D_2 in Y.
+type Params struct {
+	N   int
+	F   int
+	D_1 int
+	D_2 int
+}
+
+// NewParams returns the parameters of the paper for n participants tolerating f faults:
+// degree 2f in X and degree f in Y.
+func NewParams(n, f int) Params {
+	return Params{N: n, F: f, D_1: 2 * f, D_2: f}
+}
+
+// Validate checks that the parameters allow the protocol to complete.
+func (p Params) Validate() error {
+	if p.F < 0 || p.N < 3*p.F+1 {
+		return fmt.Errorf("vss: need n >= 3f+1, got n = %d and f = %d", p.N, p.F)
+	}
+	if p.D_2 < 0 || p.D_2+1 > p.N-p.F {
+		return fmt.Errorf("vss: degree %d in Y cannot be interpolated from %d honest rows", p.D_2, p.N-p.F)
+	}
+	if p.D_1 < 0 || p.D_1+1 > p.N-p.F {
+		return fmt.Errorf("vss: degree %d in X cannot be interpolated from %d honest columns", p.D_1, p.N-p.F)
+	}
+	return nil
+}
+
+// NodeState is the progress of a participant in BingoShare.
+type NodeState int
+
+const (
+	StateWaiting     NodeState = iota // waiting for a valid row
+	StateRowsSent                     // holds a valid row and has sent its row points
+	StateColumnsSent                  // has sent its column points but still misses its row
+	StateComplete                     // holds a valid row and has sent both row and column points
+	StateTerminated                   // complete, and 2f+1 participants reported that they are complete
+)
+
+func (s NodeState) String() string {
+	switch s {
+	case StateWaiting:
+		return "waiting"
+	case StateRowsSent:
+		return "rows sent"
+	case StateColumnsSent:
+		return "columns sent"
+	case StateComplete:
+		return "complete"
+	case StateTerminated:
+		return "terminated"
+	}
+	return "unknown"
+}
+
+/*
+Node is a single BingoShare participant. It only learns about the other parties through the
+messages passed to Handle and only influences them through the messages Handle returns, so
+it can run in its own process. The steps follow BingoShare of the paper for party i:
+
+ 0. the commitment CM of the dealer is agreed on with Bracha's reliable broadcast: echo the
+    commitment received from the dealer and accept it once 2f+1 participants are ready for it
+ 1. on a row φ(X, i), φ'(X, i) that matches cm[i], send φ(j, i) with a proof to every j
+ 2. on d_2+1 valid row points, interpolate the column φ(i, Y) and send φ(i, j) with a proof to every j
+ 3. on d_1+1 valid column points without a valid row, interpolate the row and continue with step 1
+ 4. once complete, send done to every participant and terminate on 2f+1 done messages
+
+Step 4 is the termination rule: 2f+1 done messages include f+1 = d_2+1 honest participants with
+a row, whose row points let every honest participant send its column, whose column points in
+turn let every honest participant recover its row. So once one honest participant terminates,
+all of them eventually do, no matter which rows the dealer left out. Nothing waits on a
+particular message; a participant that cannot make progress just stays in its state, and
+Incomplete tells what it is missing.
+*/
+type Node struct {
+	id     int
+	params Params
+	suite  *Suite
+	setup  *kzg.KzgSetup
+	sh     *kzg.KzgShareSetup
+
+	rbc         *broadcast.Instance // reliable broadcast of the commitment
+	echoed      []kyber.Point       // the commitment we echoed, nil before
+	readied     []kyber.Point       // the commitment we sent ready for, nil before
+	state       NodeState
+	commitments []kyber.Point // CM of the dealer
+	cm          []kyber.Point // row commitments cm[0..n]
+	row         *poly.PriPoly
+
+	rowPoints     []kzg.Proof // rowPoints[j] = φ(i, j), verified against cm[j]
+	columnPoints  []kzg.Proof // columnPoints[j] = φ(j, i), verified against cm[i]
+	rowPending    []kzg.Proof // row points waiting to be verified in one batch
+	columnPending []kzg.Proof // column points waiting to be verified in one batch
+	sentColumns   bool
+
+	done    []bool     // done[j] is set once j reported that it is complete
+	faulty  []bool     // faulty[j] is set once j sent a point with an invalid proof
+	pending []Envelope // messages that arrived before the commitment
+}
+
+/* This function constructs a new participant with index id in 1..n */
+func NewNode(id int, params Params, suite *Suite, setup *kzg.KzgSetup) (*Node, error) {
+	if err := params.Validate(); err != nil {
+		return nil, err
+	}
+	if id < 1 || id > params.N {
+		return nil, fmt.Errorf("vss: participant index %d outside 1..%d", id, params.N)
+	}
+	if len(setup.ReturnT_1()) < params.D_1+1 {
+		return nil, fmt.Errorf("vss: the setup supports degree %d, %d is needed", len(setup.ReturnT_1())-1, params.D_1)
+	}
+
+	parties := make([]int, params.N)
+	for j := range parties {
+		parties[j] = j + 1
+	}
+	rbc, err := broadcast.NewInstance(id, DealerID, parties, params.F)
+	if err != nil {
+		return nil, err
+	}
+
+	sh := kzg.NewShareSetup(setup.ReturnT_1(), setup.ReturnT_2(), setup.ReturnT_u(), suite.suite, setup.ReturnG_u(), setup.ReturnG_1())
+
+	return &Node{
+		id:            id,
+		params:        params,
+		suite:         suite,
+		setup:         setup,
+		sh:            sh,
+		rbc:           rbc,
+		state:         StateWaiting,
+		rowPoints:     make([]kzg.Proof, params.N+1),
+		columnPoints:  make([]kzg.Proof, params.N+1),
+		rowPending:    make([]kzg.Proof, params.N+1),
+		columnPending: make([]kzg.Proof, params.N+1),
+		done:          make([]bool, params.N+1),
+		faulty:        make([]bool, params.N+1),
+	}, nil
+}
+
+func (nd *Node) ReturnID() int {
+	return nd.id
+}
+
+func (nd *Node) ReturnState() NodeState {
+	return nd.state
+}
+
+// ReturnRow returns the row polynomials φ(X, i), φ'(X, i), or nil while the node has no valid row.
+func (nd *Node) ReturnRow() *poly.PriPoly {
+	return nd.row
+}
+
+// ReturnCommitments returns the row commitments cm[0..n], or nil before the commitment arrived.
+func (nd *Node) ReturnCommitments() []kyber.Point {
+	return nd.cm
+}
+
+// Handle processes one inbound message and returns the messages the node sends in response.
+// An error means the message was invalid and has been dropped; the node remains usable. Row and
+// column points are verified in batches once enough of them arrived, so an error may also name
+// points that came earlier, and it may come together with messages to send.
+func (nd *Node) Handle(env Envelope) ([]Envelope, error) {
+	if env.To != nd.id {
+		return nil, fmt.Errorf("vss: message for %d delivered to %d", env.To, nd.id)
+	}
+	if env.Msg == nil || reflect.ValueOf(env.Msg).IsNil() {
+		return nil, fmt.Errorf("vss: empty message from %d", env.From)
+	}
+
+	switch m := env.Msg.(type) {
+	case *CommitmentMessage:
+		if env.From != DealerID {
+			return nil, fmt.Errorf("vss: commitment from %d instead of the dealer", env.From)
+		}
+		return nd.handleCommitment(env.From, broadcast.KindSend, m.Commitments)
+	case *EchoMessage:
+		return nd.handleCommitment(env.From, broadcast.KindEcho, m.Commitments)
+	case *ReadyMessage:
+		return nd.handleCommitment(env.From, broadcast.KindReady, m.Commitments)
+	case *DoneMessage:
+		return nil, nd.handleDone(env.From)
+	case *ResumeMessage:
+		return nd.handleResume(env.From, m)
+	}
+
+	// Everything else is verified against the commitment, so keep it until the commitment is delivered
+	if nd.cm == nil {
+		if env.From < DealerID || env.From > nd.params.N {
+			return nil, fmt.Errorf("vss: unknown sender %d", env.From)
+		}
+		return nil, nd.buffer(env)
+	}
+
+	return nd.handle(env)
+}
+
+// buffer keeps a message that arrived before the commitment. At most one message of each type is
+// kept per sender, which is all an honest sender ever sends before the commitment, so a faulty
+// sender cannot grow the buffer.
+func (nd *Node) buffer(env Envelope) error {
+	switch env.Msg.(type) {
+	case *PolynomialMessage, *RowPointMessage, *ColumnPointMessage:
+	default:
+		return fmt.Errorf("vss: unexpected %s message from %d", env.Msg.Type(), env.From)
+	}
+
+	for _, p := range nd.pending {
+		if p.From == env.From && p.Msg.Type() == env.Msg.Type() {
+			return nil
+		}
+	}
+	nd.pending = append(nd.pending, env)
+	return nil
+}
+
+func (nd *Node) handle(env Envelope) ([]Envelope, error) {
+	switch m := env.Msg.(type) {
+	case *PolynomialMessage:
+		return nd.handlePolynomial(env.From, m)
+	case *RowPointMessage:
+		return nd.handleRowPoint(env.From, m)
+	case *ColumnPointMessage:
+		return nd.handleColumnPoint(env.From, m)
+	}
+	return nil, fmt.Errorf("vss: unexpected %s message from %d", env.Msg.Type(), env.From)
+}
+
+// handleCommitment runs one step of the reliable broadcast of the commitment and accepts the
+// commitment once it is delivered.
+func (nd *Node) handleCommitment(from int, kind broadcast.Kind, commitments []kyber.Point) ([]Envelope, error) {
+	if len(commitments) != nd.params.D_2+1 {
+		return nil, fmt.Errorf("vss: commitment of length %d from %d, expected %d", len(commitments), from, nd.params.D_2+1)
+	}
+	for _, c := range commitments {
+		if c == nil {
+			return nil, fmt.Errorf("vss: commitment with a missing point from %d", from)
+		}
+	}
+
+	value, err := MarshalMessage(&CommitmentMessage{Commitments: commitments})
+	if err != nil {
+		return nil, err
+	}
+	next, err := nd.rbc.Handle(from, broadcast.Message{Kind: kind, Value: value})
+	if err != nil {
+		return nil, fmt.Errorf("vss: %w", err)
+	}
+
+	out := make([]Envelope, 0, len(next))
+	for _, env := range next {
+		msg, err := nd.broadcastMessage(env.Msg)
+		if err != nil {
+			return nil, err
+		}
+		switch m := msg.(type) {
+		case *EchoMessage:
+			nd.echoed = m.Commitments
+		case *ReadyMessage:
+			nd.readied = m.Commitments
+		}
+		out = append(out, Envelope{From: nd.id, To: env.To, Msg: msg})
+	}
+
+	delivered, ok := nd.rbc.Delivered()
+	if !ok || nd.cm != nil {
+		return out, nil
+	}
+	m, err := UnmarshalMessage(delivered, nd.suite)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(out, nd.acceptCommitment(m.(*CommitmentMessage).Commitments)...), nil
+}
+
+// broadcastMessage converts a step of the reliable broadcast into the message carrying it.
+func (nd *Node) broadcastMessage(m broadcast.Message) (Message, error) {
+	decoded, err := UnmarshalMessage(m.Value, nd.suite)
+	if err != nil {
+		return nil, err
+	}
+	commitments := decoded.(*CommitmentMessage).Commitments
+
+	switch m.Kind {
+	case broadcast.KindEcho:
+		return &EchoMessage{Commitments: commitments}, nil
+	case broadcast.KindReady:
+		return &ReadyMessage{Commitments: commitments}, nil
+	}
+	return nil, fmt.Errorf("vss: unexpected %s step of the broadcast", m.Kind)
+}
+
+// acceptCommitment stores the delivered commitment and replays the messages that were waiting for it.
+func (nd *Node) acceptCommitment(commitments []kyber.Point) []Envelope {
+	nd.commitments = commitments
+	nd.cm = kzg.PartialEval(nd.setup, commitments, nd.indices())
+
+	// Replay what arrived early, dropping whatever turns out to be invalid
+	var out []Envelope
+	pending := nd.pending
+	nd.pending = nil
+	for _, env := range pending {
+		next, _ := nd.handle(env)
+		out = append(out, next...)
+	}
+
+	return out
+}
+
+func (nd *Node) handlePolynomial(from int, m *PolynomialMessage) ([]Envelope, error) {
+	if from != DealerID {
+		return nil, fmt.Errorf("vss: row polynomial from %d instead of the dealer", from)
+	}
+	if nd.row != nil {
+		return nil, nil
+	}
+	if !nd.validScalars(m.Row, nd.params.D_1+1) || !nd.validScalars(m.RowHiding, nd.params.D_1+1) {
+		return nil, errors.New("vss: row polynomial of the wrong degree")
+	}
+	if !kzg.KZGCommits(nd.sh, m.Row, m.RowHiding).Equal(nd.cm[nd.id]) {
+		return nil, errors.New("vss: row polynomial does not match the commitment")
+	}
+
+	return nd.acceptRow(m.Row, m.RowHiding)
+}
+
+func (nd *Node) handleRowPoint(from int, m *RowPointMessage) ([]Envelope, error) {
+	if err := nd.checkSender(from); err != nil {
+		return nil, err
+	}
+	// A participant that sent an invalid point gets no further pairings out of us
+	if nd.faulty[from] {
+		return nil, fmt.Errorf("vss: row point from faulty participant %d", from)
+	}
+	if nd.rowPoints[from].ReturnP() != nil || nd.rowPending[from].ReturnP() != nil || nd.sentColumns {
+		return nil, nil
+	}
+	if m.Proof == nil || m.Y_1 == nil || m.Y_2 == nil {
+		return nil, fmt.Errorf("vss: incomplete row point from %d", from)
+	}
+
+	// Nothing can be done with fewer than d_2+1 points, so they are only verified once there are
+	// enough of them, all in one batch
+	nd.rowPending[from] = pointProof(from, m.Proof, m.Y_1, m.Y_2)
+	if checkForNotNil(nd.rowPoints)+checkForNotNil(nd.rowPending) < nd.params.D_2+1 {
+		return nil, nil
+	}
+
+	// φ(i, j) is an evaluation of the row of j at X = i
+	x := nd.scalar(nd.id)
+	err := nd.verifyPending("row", nd.rowPending, nd.rowPoints, func(j int, p kzg.Proof) kzg.Opening {
+		return kzg.Opening{Index: j, Z: x, Y_1: p.ReturnY_1(), Y_2: p.ReturnY_2(), Proof: p.ReturnP()}
+	})
+	if checkForNotNil(nd.rowPoints) < nd.params.D_2+1 {
+		return nil, err
+	}
+
+	out, sendErr := nd.sendColumns()
+	if sendErr != nil {
+		return out, sendErr
+	}
+	return out, err
+}
+
+func (nd *Node) handleColumnPoint(from int, m *ColumnPointMessage) ([]Envelope, error) {
+	if err := nd.checkSender(from); err != nil {
+		return nil, err
+	}
+	if nd.faulty[from] {
+		return nil, fmt.Errorf("vss: column point from faulty participant %d", from)
+	}
+	if nd.columnPoints[from].ReturnP() != nil || nd.columnPending[from].ReturnP() != nil || nd.row != nil {
+		return nil, nil
+	}
+	if m.Proof == nil || m.Y_1 == nil || m.Y_2 == nil {
+		return nil, fmt.Errorf("vss: incomplete column point from %d", from)
+	}
+
+	nd.columnPending[from] = pointProof(from, m.Proof, m.Y_1, m.Y_2)
+	if checkForNotNil(nd.columnPoints)+checkForNotNil(nd.columnPending) < nd.params.D_1+1 {
+		return nil, nil
+	}
+
+	// φ(j, i) is an evaluation of our own row at X = j
+	err := nd.verifyPending("column", nd.columnPending, nd.columnPoints, func(j int, p kzg.Proof) kzg.Opening {
+		return kzg.Opening{Index: nd.id, Z: nd.scalar(j), Y_1: p.ReturnY_1(), Y_2: p.ReturnY_2(), Proof: p.ReturnP()}
+	})
+	if checkForNotNil(nd.columnPoints) < nd.params.D_1+1 {
+		return nil, err
+	}
+
+	// Enough verified points on our row, so the row can be interpolated
+	row, rowHiding, interpolateErr := nd.interpolateRow()
+	if interpolateErr != nil {
+		return nil, fmt.Errorf("vss: cannot interpolate the row: %w", interpolateErr)
+	}
+	if !kzg.KZGCommits(nd.sh, row, rowHiding).Equal(nd.cm[nd.id]) {
+		return nil, errors.New("vss: interpolated row does not match the commitment")
+	}
+	out, acceptErr := nd.acceptRow(row, rowHiding)
+	if acceptErr != nil {
+		return out, acceptErr
+	}
+	return out, err
+}
+
+// verifyPending checks the pending points in one batch, moves the valid ones to verified and
+// marks the senders of the others as faulty.
+func (nd *Node) verifyPending(kind string, pending, verified []kzg.Proof, opening func(j int, p kzg.Proof) kzg.Opening) error {
+	var senders []int
+	var openings []kzg.Opening
+	for j, p := range pending {
+		if p.ReturnP() != nil {
+			senders = append(senders, j)
+			openings = append(openings, opening(j, p))
+		}
+	}
+
+	var invalid []int
+	for k, ok := range kzg.KZGVerifyEach(nd.sh, nd.cm, openings) {
+		j := senders[k]
+		if ok {
+			verified[j] = pending[j]
+		} else {
+			nd.faulty[j] = true
+			invalid = append(invalid, j)
+		}
+		pending[j] = kzg.Proof{}
+	}
+
+	if len(invalid) > 0 {
+		return fmt.Errorf("vss: invalid %s points from %v", kind, invalid)
+	}
+	return nil
+}
+
+// acceptRow stores a verified row and sends its evaluations φ(j, i) to every participant j.
+func (nd *Node) acceptRow(row, rowHiding []kyber.Scalar) ([]Envelope, error) {
+	nd.row = poly.NewPriPoly(nd.suite.suite, nd.params.D_2, row, rowHiding, nil)
+
+	out, own, err := nd.rowPointMessages()
+	if err != nil {
+		return nil, err
+	}
+
+	if nd.sentColumns {
+		return append(out, nd.complete()...), nil
+	}
+	nd.state = StateRowsSent
+
+	// Our own row point counts towards the column like everyone else's
+	next, err := nd.handleRowPoint(nd.id, own)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(out, next...), nil
+}
+
+// rowPointMessages evaluates our row at every X = j and returns the messages for the others
+// together with our own point.
+func (nd *Node) rowPointMessages() ([]Envelope, *RowPointMessage, error) {
+	proofs, y_1, y_2, err := kzg.KZGEvalAll(nd.sh, nd.row.Coefficients(), nd.row.Coefficients_2(), nd.params.N)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var out []Envelope
+	var own *RowPointMessage
+	for j := 1; j <= nd.params.N; j++ {
+		msg := &RowPointMessage{Y_1: y_1[j], Y_2: y_2[j], Proof: proofs[j]}
+		if j == nd.id {
+			own = msg
+			continue
+		}
+		out = append(out, Envelope{From: nd.id, To: j, Msg: msg})
+	}
+	return out, own, nil
+}
+
+// sendColumns interpolates the column φ(i, Y) from the verified row points and sends φ(i, j)
+// to every participant j. The proofs are interpolated in the exponent, so they verify against cm[j].
+func (nd *Node) sendColumns() ([]Envelope, error) {
+	nd.sentColumns = true
+
+	pr, y_1, y_2 := kzg.GetProofs(nd.rowPoints, nd.indices(), nd.setup, nd.params.D_2+1)
+
+	var out []Envelope
+	for j := 1; j <= nd.params.N; j++ {
+		msg := &ColumnPointMessage{Y_1: y_1[j], Y_2: y_2[j], Proof: pr[j]}
+		if j == nd.id {
+			continue
+		}
+		out = append(out, Envelope{From: nd.id, To: j, Msg: msg})
+	}
+
+	if nd.row != nil {
+		return append(out, nd.complete()...), nil
+	}
+	nd.state = StateColumnsSent
+
+	// Our own column point lies on our row as well
+	next, err := nd.handleColumnPoint(nd.id, &ColumnPointMessage{Y_1: y_1[nd.id], Y_2: y_2[nd.id], Proof: pr[nd.id]})
+	if err != nil {
+		return nil, err
+	}
+
+	return append(out, next...), nil
+}
+
+// complete marks the node as complete and tells every participant so.
+func (nd *Node) complete() []Envelope {
+	nd.state = StateComplete
+
+	out := make([]Envelope, 0, nd.params.N-1)
+	for j := 1; j <= nd.params.N; j++ {
+		if j != nd.id {
+			out = append(out, Envelope{From: nd.id, To: j, Msg: &DoneMessage{}})
+		}
+	}
+	_ = nd.handleDone(nd.id)
+
+	return out
+}
+
+// handleDone counts the done message of a participant and terminates on 2f+1 of them.
+func (nd *Node) handleDone(from int) error {
+	if err := nd.checkSender(from); err != nil {
+		return err
+	}
+	nd.done[from] = true
+
+	if nd.state == StateComplete && nd.count(nd.done) >= 2*nd.params.F+1 {
+		nd.state = StateTerminated
+	}
+	return nil
+}
+
+/*
+handleResume answers a participant that restarted from a checkpoint with what we already sent
+it before and it asks for again. Whatever we have not sent yet goes to everyone once we get to
+it, so it is not sent here.
+*/
+func (nd *Node) handleResume(from int, m *ResumeMessage) ([]Envelope, error) {
+	if err := nd.checkSender(from); err != nil {
+		return nil, err
+	}
+	if from == nd.id {
+		return nil, errors.New("vss: resume request from ourselves")
+	}
+
+	var out []Envelope
+	send := func(msg Message) {
+		out = append(out, Envelope{From: nd.id, To: from, Msg: msg})
+	}
+	if m.Commitment && nd.echoed != nil {
+		send(&EchoMessage{Commitments: nd.echoed})
+	}
+	if m.Commitment && nd.readied != nil {
+		send(&ReadyMessage{Commitments: nd.readied})
+	}
+	if m.RowPoint && nd.row != nil {
+		proof, y_1, y_2, err := kzg.KZGEval(nd.sh, nd.row.Coefficients(), nd.row.Coefficients_2(), nd.scalar(from))
+		if err != nil {
+			return nil, err
+		}
+		send(&RowPointMessage{Y_1: y_1, Y_2: y_2, Proof: proof})
+	}
+	if m.ColumnPoint && nd.sentColumns {
+		// The row points were frozen when the columns were sent, so this is the same column point
+		pr, y_1, y_2 := kzg.GetProofs(nd.rowPoints, nd.indices(), nd.setup, nd.params.D_2+1)
+		send(&ColumnPointMessage{Y_1: y_1[from], Y_2: y_2[from], Proof: pr[from]})
+	}
+	if m.Done && nd.state >= StateComplete {
+		send(&DoneMessage{})
+	}
+	return out, nil
+}
+
+// ErrCannotComplete is wrapped by the error of Incomplete when the sharing provably cannot complete.
+var ErrCannotComplete = errors.New("vss: the sharing cannot complete")
+
+// Incomplete returns nil once the node terminated, and otherwise what it still waits for. In an
+// asynchronous network a missing message may still arrive, so only an error wrapping
+// ErrCannotComplete is final: it means more than f participants misbehaved.
+func (nd *Node) Incomplete() error {
+	if nd.state == StateTerminated {
+		return nil
+	}
+	if faulty := nd.count(nd.faulty); faulty > nd.params.F {
+		return fmt.Errorf("%w: %d participants sent invalid proofs, at most %d may be faulty", ErrCannotComplete, faulty, nd.params.F)
+	}
+
+	switch {
+	case nd.cm == nil:
+		return errors.New("vss: waiting for the commitment of the dealer")
+	case nd.row == nil && !nd.sentColumns:
+		return fmt.Errorf("vss: waiting for the row: no row from the dealer and %d of %d row points", checkForNotNil(nd.rowPoints), nd.params.D_2+1)
+	case nd.row == nil:
+		return fmt.Errorf("vss: waiting for the row: %d of %d column points", checkForNotNil(nd.columnPoints), nd.params.D_1+1)
+	case !nd.sentColumns:
+		return fmt.Errorf("vss: waiting for row points: %d of %d", checkForNotNil(nd.rowPoints), nd.params.D_2+1)
+	}
+	return fmt.Errorf("vss: waiting for done messages: %d of %d", nd.count(nd.done), 2*nd.params.F+1)
+}
+
+// interpolateRow recovers φ(X, i) and φ'(X, i) from the first d_1+1 verified column points.
+func (nd *Node) interpolateRow() ([]kyber.Scalar, []kyber.Scalar, error) {
+	x := make([]kyber.Scalar, 0, nd.params.D_1+1)
+	y_1 := make([]kyber.Scalar, 0, nd.params.D_1+1)
+	y_2 := make([]kyber.Scalar, 0, nd.params.D_1+1)
+
+	for j, p := range nd.columnPoints {
+		if p.ReturnP() != nil && len(x) < nd.params.D_1+1 {
+			x = append(x, nd.scalar(j))
+			y_1 = append(y_1, p.ReturnY_1())
+			y_2 = append(y_2, p.ReturnY_2())
+		}
+	}
+
+	g := nd.suite.suite
+	row, err := poly.RecoverVandermondeGivenX(g, x, y_1, nd.params.D_1+1)
+	if err != nil {
+		return nil, nil, err
+	}
+	rowHiding, err := poly.RecoverVandermondeGivenX(g, x, y_2, nd.params.D_1+1)
+	if err != nil {
+		return nil, nil, err
+	}
+	return row, rowHiding, nil
+}
+
+func (nd *Node) checkSender(from int) error {
+	if from < 1 || from > nd.params.N {
+		return fmt.Errorf("vss: unknown participant %d", from)
+	}
+	return nil
+}
+
+func (nd *Node) count(set []bool) int {
+	c := 0
+	for _, b := range set {
+		if b {
+			c++
+		}
+	}
+	return c
+}
+
+func (nd *Node) validScalars(s []kyber.Scalar, l int) bool {
+	if len(s) != l {
+		return false
+	}
+	for _, v := range s {
+		if v == nil {
+			return false
+		}
+	}
+	return true
+}
+
+func (nd *Node) scalar(i int) kyber.Scalar {
+	return nd.suite.suite.G1().Scalar().SetInt64(int64(i))
+}
+
+// indices returns the evaluation points 0..n of the rows.
+func (nd *Node) indices() []kyber.Scalar {
+	vn := make([]kyber.Scalar, nd.params.N+1)
+	for i := range vn {
+		vn[i] = nd.scalar(i)
+	}
+	return vn
+}
+
+// DealMessages runs BingoDeal and returns the messages of the dealer: the commitment, which the
+// participants agree on with the reliable broadcast, and the row polynomials φ(X, i), φ'(X, i) for every participant i in 1..n.
+func (d *Dealer) DealMessages(secrets []Secret, params Params, setup *kzg.KzgSetup) ([]Envelope, error) {
+	if err := params.Validate(); err != nil {
+		return nil, err
+	}
+	if len(secrets) > params.D_1+1 {
+		return nil, fmt.Errorf("vss: at most %d secrets fit in degree %d, got %d", params.D_1+1, params.D_1, len(secrets))
+	}
+
+	if err := d.BingoDeal(secrets, params.D_1, params.D_2, params.N, setup); err != nil {
+		return nil, err
+	}
+
+	return d.messages(params.N), nil
+}
+
+/*
+This function deals a sharing of zero for a refresh, with the same messages as DealMessages. Both
+φ(X, 0) and φ'(X, 0) are zero, so the commitment of row 0 is the identity, which anyone can check,
+and adding the rows to those of another dealing keeps all of its secrets.
+*/
+func (d *Dealer) DealZeroMessages(params Params, setup *kzg.KzgSetup) ([]Envelope, error) {
+	if err := params.Validate(); err != nil {
+		return nil, err
+	}
+
+	g := d.suite.suite
+	d.secretPoly = poly.PrivBivPoly{BivPoly: *poly.NewBivPolyZeroRow(g, params.D_1, params.D_2, g.RandomStream())}
+	d.randomPoly = *poly.NewBivPolyZeroRow(g, params.D_1, params.D_2, g.RandomStream())
+	if err := d.commitAndShare(params.N, setup); err != nil {
+		return nil, err
+	}
+
+	return d.messages(params.N), nil
+}
+
+/*
+This function deals a sharing whose rows at Y = 0 are given, φ(X, 0) = row and φ'(X, 0) = hiding,
+with the same messages as DealMessages. The resharing uses it to hand a row of an old dealing to
+a new set of participants.
+*/
+func (d *Dealer) DealRowMessages(row, hiding []kyber.Scalar, params Params, setup *kzg.KzgSetup) ([]Envelope, error) {
+	if err := params.Validate(); err != nil {
+		return nil, err
+	}
+	if len(row) != params.D_1+1 || len(hiding) != params.D_1+1 {
+		return nil, fmt.Errorf("vss: the rows at 0 must have degree %d", params.D_1)
+	}
+
+	g := d.suite.suite
+	secretPoly, err := poly.NewBivPolyWithRow(g, params.D_2, row, g.RandomStream())
+	if err != nil {
+		return nil, err
+	}
+	randomPoly, err := poly.NewBivPolyWithRow(g, params.D_2, hiding, g.RandomStream())
+	if err != nil {
+		return nil, err
+	}
+	d.secretPoly = poly.PrivBivPoly{BivPoly: *secretPoly}
+	d.randomPoly = *randomPoly
+	if err := d.commitAndShare(params.N, setup); err != nil {
+		return nil, err
+	}
+
+	return d.messages(params.N), nil
+}
+
+// messages returns the commitment and the rows for the participants 1..n.
+func (d *Dealer) messages(n int) []Envelope {
+	out := d.Broadcast(n)
+	for i := 1; i <= n; i++ {
+		out = append(out, Envelope{From: DealerID, To: i, Msg: &PolynomialMessage{
+			Row:       d.sharePolys[i].Coefficients(),
+			RowHiding: d.sharePolys[i].Coefficients_2(),
+		}})
+	}
+	return out
+}
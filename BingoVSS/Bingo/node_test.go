@@ -0,0 +1,488 @@
+package vss
+
+import (
+	kzg "BingoVSS/Internal/Biv_KZG"
+	"testing"
+
+	"github.com/drand/kyber"
+	"github.com/stretchr/testify/require"
+)
+
+// deliver passes the messages between the nodes in FIFO order until none are left.
+// Messages for which drop returns true are never delivered.
+func deliver(t *testing.T, nodes []*Node, queue []Envelope, drop func(Envelope) bool) {
+	for len(queue) > 0 {
+		env := queue[0]
+		queue = queue[1:]
+		if drop != nil && drop(env) {
+			continue
+		}
+
+		out, err := nodes[env.To].Handle(env)
+		require.NoError(t, err)
+		queue = append(queue, out...)
+	}
+}
+
+func newNodes(t *testing.T, g *Suite, params Params, setup *kzg.KzgSetup) []*Node {
+	nodes := make([]*Node, params.N+1)
+	for i := 1; i <= params.N; i++ {
+		nd, err := NewNode(i, params, g, setup)
+		require.NoError(t, err)
+		nodes[i] = nd
+	}
+	return nodes
+}
+
+func dealMessages(t *testing.T, g *Suite, params Params) ([]Envelope, *Dealer, *kzg.KzgSetup) {
+	setup, err := kzg.NewKzgSetup(params.D_1+1, g.suite)
+	require.NoError(t, err)
+
+	secrets := make([]Secret, params.F+1)
+	for i := range secrets {
+		secrets[i] = *NewSecret(i, *g)
+	}
+
+	dealer := NewDealerWithSuite(g)
+	msgs, err := dealer.DealMessages(secrets, params, setup)
+	require.NoError(t, err)
+
+	return msgs, dealer, setup
+}
+
+func TestNodeHonestCase(t *testing.T) {
+	forEachCurve(t, func(t *testing.T, g *Suite) {
+		params := NewParams(4, 1)
+		msgs, dealer, setup := dealMessages(t, g, params)
+		nodes := newNodes(t, g, params, setup)
+
+		deliver(t, nodes, msgs, nil)
+
+		for i := 1; i <= params.N; i++ {
+			require.Equal(t, StateTerminated, nodes[i].ReturnState())
+			row := nodes[i].ReturnRow().Coefficients()
+			for k, c := range dealer.sharePolys[i].Coefficients() {
+				require.True(t, c.Equal(row[k]))
+			}
+		}
+	})
+}
+
+func TestNodeRecoversMissingRows(t *testing.T) {
+	forEachCurve(t, func(t *testing.T, g *Suite) {
+		params := NewParams(7, 2)
+		msgs, dealer, setup := dealMessages(t, g, params)
+		nodes := newNodes(t, g, params, setup)
+
+		// The dealer never sends rows to f participants; they interpolate them from the columns
+		deliver(t, nodes, msgs, func(env Envelope) bool {
+			return env.Msg.Type() == MsgPolynomial && env.To > params.N-params.F
+		})
+
+		for i := 1; i <= params.N; i++ {
+			require.Equal(t, StateTerminated, nodes[i].ReturnState())
+			row := nodes[i].ReturnRow().Coefficients()
+			for k, c := range dealer.sharePolys[i].Coefficients() {
+				require.True(t, c.Equal(row[k]))
+			}
+		}
+	})
+}
+
+func TestNodeBuffersEarlyMessages(t *testing.T) {
+	g := NewSuite()
+	params := NewParams(4, 1)
+	msgs, _, setup := dealMessages(t, g, params)
+	nodes := newNodes(t, g, params, setup)
+
+	// Deliver every row before the commitment it is checked against
+	for i, j := 0, len(msgs)-1; i < j; i, j = i+1, j-1 {
+		msgs[i], msgs[j] = msgs[j], msgs[i]
+	}
+	deliver(t, nodes, msgs, nil)
+
+	for i := 1; i <= params.N; i++ {
+		require.Equal(t, StateTerminated, nodes[i].ReturnState())
+	}
+}
+
+func TestNodeBoundsEarlyMessages(t *testing.T) {
+	g := NewSuite()
+	params := NewParams(4, 1)
+	msgs, _, setup := dealMessages(t, g, params)
+	nodes := newNodes(t, g, params, setup)
+
+	// A faulty participant floods node 1 with points before the commitment arrived
+	point := &RowPointMessage{Y_1: g.suite.G1().Scalar().One(), Y_2: g.suite.G1().Scalar().One(), Proof: g.suite.G1().Point().Base()}
+	for k := 0; k < 100; k++ {
+		_, err := nodes[1].Handle(Envelope{From: 2, To: 1, Msg: point})
+		require.NoError(t, err)
+		_, err = nodes[1].Handle(Envelope{From: 2, To: 1, Msg: &ColumnPointMessage{Y_1: g.suite.G1().Scalar().One(), Y_2: g.suite.G1().Scalar().One(), Proof: g.suite.G1().Point().Base()}})
+		require.NoError(t, err)
+	}
+	require.Len(t, nodes[1].pending, 2)
+
+	// Messages that never wait for the commitment are not buffered at all
+	_, err := nodes[1].Handle(Envelope{From: 2, To: 1, Msg: &ReconstructShareMessage{Y_1: g.suite.G1().Scalar().One(), Y_2: g.suite.G1().Scalar().One(), Proof: g.suite.G1().Point().Base()}})
+	require.Error(t, err)
+	require.Len(t, nodes[1].pending, 2)
+
+	// The junk is dropped once the commitment arrives, node 1 no longer hears from 2 and the
+	// sharing still completes
+	deliverDroppingInvalid(nodes, msgs)
+	for i := 1; i <= params.N; i++ {
+		require.Equal(t, StateTerminated, nodes[i].ReturnState())
+	}
+}
+
+func TestNodeRejectsInvalidMessages(t *testing.T) {
+	g := NewSuite()
+	params := NewParams(4, 1)
+	msgs, _, setup := dealMessages(t, g, params)
+	nodes := newNodes(t, g, params, setup)
+
+	var commitment, row Envelope
+	for _, env := range msgs {
+		if env.To == 1 && env.Msg.Type() == MsgCommitment {
+			commitment = env
+		}
+		if env.To == 1 && env.Msg.Type() == MsgPolynomial {
+			row = env
+		}
+	}
+
+	// Only the dealer may send the commitment and the rows
+	_, err := nodes[1].Handle(Envelope{From: 2, To: 1, Msg: commitment.Msg})
+	require.Error(t, err)
+
+	// A commitment of the wrong length is not echoed
+	short := &CommitmentMessage{Commitments: commitment.Msg.(*CommitmentMessage).Commitments[:1]}
+	_, err = nodes[1].Handle(Envelope{From: DealerID, To: 1, Msg: short})
+	require.Error(t, err)
+	_, err = nodes[1].Handle(Envelope{From: DealerID, To: 1, Msg: &EchoMessage{Commitments: commitment.Msg.(*CommitmentMessage).Commitments}})
+	require.Error(t, err)
+
+	// Run the broadcast of the commitment and nothing else
+	deliver(t, nodes, msgs, func(env Envelope) bool {
+		return env.Msg.Type() == MsgPolynomial || env.Msg.Type() == MsgRowPoint || env.Msg.Type() == MsgColumnPoint
+	})
+	require.NotNil(t, nodes[1].ReturnCommitments())
+
+	_, err = nodes[1].Handle(Envelope{From: 2, To: 1, Msg: row.Msg})
+	require.Error(t, err)
+
+	// A row that does not match the commitment
+	m := row.Msg.(*PolynomialMessage)
+	bad := &PolynomialMessage{Row: append(m.Row[:0:0], m.Row...), RowHiding: m.RowHiding}
+	bad.Row[0] = g.suite.G1().Scalar().One()
+	_, err = nodes[1].Handle(Envelope{From: DealerID, To: 1, Msg: bad})
+	require.Error(t, err)
+	require.Equal(t, StateWaiting, nodes[1].ReturnState())
+
+	// A row point with a value that does not match the proof
+	out, err := nodes[1].Handle(row)
+	require.NoError(t, err)
+	require.Equal(t, StateRowsSent, nodes[1].ReturnState())
+
+	for _, env := range out {
+		if env.To == 2 {
+			p := env.Msg.(*RowPointMessage)
+			forged := &RowPointMessage{Y_1: g.suite.G1().Scalar().Add(p.Y_1, g.suite.G1().Scalar().One()), Y_2: p.Y_2, Proof: p.Proof}
+			_, err = nodes[2].Handle(Envelope{From: 1, To: 2, Msg: forged})
+			require.NoError(t, err)
+
+			// The same point claimed by another participant does not verify either; with d_2+1
+			// points the batch is checked and both are rejected
+			_, err = nodes[2].Handle(Envelope{From: 3, To: 2, Msg: p})
+			require.ErrorContains(t, err, "invalid row points from [1 3]")
+
+			// Once marked faulty, 1 is not heard again, even with its valid point
+			_, err = nodes[2].Handle(env)
+			require.ErrorContains(t, err, "faulty participant 1")
+		}
+	}
+
+	_, err = nodes[1].Handle(Envelope{From: 9, To: 1, Msg: &RowPointMessage{}})
+	require.Error(t, err)
+}
+
+func TestParamsValidate(t *testing.T) {
+	require.NoError(t, NewParams(4, 1).Validate())
+	require.NoError(t, NewParams(10, 3).Validate())
+	require.Error(t, NewParams(3, 1).Validate())
+	require.Error(t, Params{N: 4, F: 1, D_1: 3, D_2: 1}.Validate())
+}
+
+// deliverDroppingInvalid is deliver for runs with a faulty dealer, where some messages are expected to be rejected.
+func deliverDroppingInvalid(nodes []*Node, queue []Envelope) int {
+	rejected := 0
+	for len(queue) > 0 {
+		env := queue[0]
+		queue = queue[1:]
+
+		out, err := nodes[env.To].Handle(env)
+		if err != nil {
+			rejected++
+		}
+		queue = append(queue, out...)
+	}
+	return rejected
+}
+
+// equivocate returns the messages of a dealer that sends one dealing to the participants in
+// first and another to everyone else, and the dealings themselves.
+func equivocate(t *testing.T, g *Suite, params Params, first map[int]bool) ([]Envelope, *Dealer, *Dealer, []*Node) {
+	msgs_a, dealer_a, setup := dealMessages(t, g, params)
+
+	secrets := []Secret{*NewSecret(7, *g)}
+	dealer_b := NewDealerWithSuite(g)
+	msgs_b, err := dealer_b.DealMessages(secrets, params, setup)
+	require.NoError(t, err)
+
+	var msgs []Envelope
+	for _, env := range msgs_a {
+		if first[env.To] {
+			msgs = append(msgs, env)
+		}
+	}
+	for _, env := range msgs_b {
+		if !first[env.To] {
+			msgs = append(msgs, env)
+		}
+	}
+
+	return msgs, dealer_a, dealer_b, newNodes(t, g, params, setup)
+}
+
+func TestNodeEquivocatingDealer(t *testing.T) {
+	g := NewSuite()
+	params := NewParams(4, 1)
+
+	// An even split: no commitment gets enough echoes, so nobody accepts either of them
+	split, _, _, nodes := equivocate(t, g, params, map[int]bool{1: true, 2: true})
+	deliverDroppingInvalid(nodes, split)
+	for i := 1; i <= params.N; i++ {
+		require.Nil(t, nodes[i].ReturnCommitments())
+		require.Equal(t, StateWaiting, nodes[i].ReturnState())
+	}
+
+	// Three participants see the first dealing. Everyone accepts its commitment, and the
+	// participant that got the other dealing rejects its row and recovers the right one
+	msgs, dealer_a, _, nodes := equivocate(t, g, params, map[int]bool{1: true, 2: true, 3: true})
+	deliverDroppingInvalid(nodes, msgs)
+	for i := 1; i <= params.N; i++ {
+		commitments := nodes[i].commitments
+		require.Len(t, commitments, params.D_2+1)
+		for k, c := range dealer_a.publicCommitsCM {
+			require.True(t, c.Equal(commitments[k]))
+		}
+
+		require.Equal(t, StateTerminated, nodes[i].ReturnState())
+		row := nodes[i].ReturnRow().Coefficients()
+		for k, c := range dealer_a.sharePolys[i].Coefficients() {
+			require.True(t, c.Equal(row[k]))
+		}
+	}
+}
+
+func TestNodeFaultyParticipantsCannotForceCommitment(t *testing.T) {
+	g := NewSuite()
+	params := NewParams(4, 1)
+	msgs, dealer, setup := dealMessages(t, g, params)
+	_, other, _ := dealMessages(t, g, params)
+	nodes := newNodes(t, g, params, setup)
+
+	// Participant 4 is faulty and pushes another commitment to everyone, repeatedly
+	var queue []Envelope
+	for _, env := range msgs {
+		if env.To != 4 {
+			queue = append(queue, env)
+		}
+	}
+	forged := other.publicCommitsCM
+	for i := 0; i < 3; i++ {
+		for j := 1; j <= 3; j++ {
+			queue = append([]Envelope{
+				{From: 4, To: j, Msg: &EchoMessage{Commitments: forged}},
+				{From: 4, To: j, Msg: &ReadyMessage{Commitments: forged}},
+			}, queue...)
+		}
+	}
+	nodes[4] = nil
+
+	for len(queue) > 0 {
+		env := queue[0]
+		queue = queue[1:]
+		if nodes[env.To] == nil {
+			continue
+		}
+		out, err := nodes[env.To].Handle(env)
+		require.NoError(t, err)
+		queue = append(queue, out...)
+	}
+
+	for i := 1; i <= 3; i++ {
+		require.Equal(t, StateTerminated, nodes[i].ReturnState())
+		row := nodes[i].ReturnRow().Coefficients()
+		for k, c := range dealer.sharePolys[i].Coefficients() {
+			require.True(t, c.Equal(row[k]))
+		}
+	}
+}
+
+func TestNodeTerminatesWithCrashedParticipants(t *testing.T) {
+	g := NewSuite()
+	params := NewParams(7, 2)
+	msgs, dealer, setup := dealMessages(t, g, params)
+	nodes := newNodes(t, g, params, setup)
+
+	// Participants 6 and 7 crashed, and participant 5 never gets its row
+	crashed := func(i int) bool { return i > 5 }
+	deliver(t, nodes, msgs, func(env Envelope) bool {
+		return crashed(env.From) || crashed(env.To) || (env.To == 5 && env.Msg.Type() == MsgPolynomial)
+	})
+
+	for i := 1; i <= 5; i++ {
+		require.Equal(t, StateTerminated, nodes[i].ReturnState())
+		require.NoError(t, nodes[i].Incomplete())
+		row := nodes[i].ReturnRow().Coefficients()
+		for k, c := range dealer.sharePolys[i].Coefficients() {
+			require.True(t, c.Equal(row[k]))
+		}
+	}
+	for i := 6; i <= 7; i++ {
+		require.Error(t, nodes[i].Incomplete())
+	}
+}
+
+func TestNodeReportsIncompleteSharing(t *testing.T) {
+	g := NewSuite()
+	params := NewParams(4, 1)
+	msgs, _, setup := dealMessages(t, g, params)
+	nodes := newNodes(t, g, params, setup)
+
+	// Nobody can finish without a commitment
+	for i := 1; i <= params.N; i++ {
+		require.ErrorContains(t, nodes[i].Incomplete(), "commitment")
+	}
+
+	// The dealer sends a single row, which is not enough for anyone to build a column
+	deliver(t, nodes, msgs, func(env Envelope) bool {
+		return env.Msg.Type() == MsgPolynomial && env.To != 1
+	})
+
+	require.Equal(t, StateRowsSent, nodes[1].ReturnState())
+	require.ErrorContains(t, nodes[1].Incomplete(), "row points")
+	for i := 2; i <= params.N; i++ {
+		require.Equal(t, StateWaiting, nodes[i].ReturnState())
+		err := nodes[i].Incomplete()
+		require.ErrorContains(t, err, "waiting for the row")
+		require.NotErrorIs(t, err, ErrCannotComplete)
+	}
+}
+
+func TestNodeReportsTooManyFaults(t *testing.T) {
+	g := NewSuite()
+	params := NewParams(4, 1)
+	msgs, _, setup := dealMessages(t, g, params)
+	nodes := newNodes(t, g, params, setup)
+
+	// Run the broadcast of the commitment only
+	deliver(t, nodes, msgs, func(env Envelope) bool {
+		return env.Msg.Type() == MsgPolynomial
+	})
+
+	// Two participants send row points that do not verify, more than f = 1 may. The first point
+	// waits until there are d_2+1 of them, then both are verified in one batch
+	forged := &RowPointMessage{Y_1: g.suite.G1().Scalar().One(), Y_2: g.suite.G1().Scalar().One(), Proof: g.suite.G1().Point().Base()}
+	_, err := nodes[1].Handle(Envelope{From: 2, To: 1, Msg: forged})
+	require.NoError(t, err)
+	_, err = nodes[1].Handle(Envelope{From: 3, To: 1, Msg: forged})
+	require.ErrorContains(t, err, "invalid row points from [2 3]")
+	require.ErrorIs(t, nodes[1].Incomplete(), ErrCannotComplete)
+
+	// Resending the invalid points is dropped before it is buffered, so no batch is verified again
+	for k := 0; k < 3; k++ {
+		_, err = nodes[1].Handle(Envelope{From: 2, To: 1, Msg: forged})
+		require.ErrorContains(t, err, "faulty participant 2")
+		_, err = nodes[1].Handle(Envelope{From: 3, To: 1, Msg: forged})
+		require.ErrorContains(t, err, "faulty participant 3")
+	}
+	require.Zero(t, checkForNotNil(nodes[1].rowPending))
+	_, err = nodes[1].Handle(Envelope{From: 2, To: 1, Msg: &ColumnPointMessage{Y_1: forged.Y_1, Y_2: forged.Y_2, Proof: forged.Proof}})
+	require.ErrorContains(t, err, "faulty participant 2")
+	require.Zero(t, checkForNotNil(nodes[1].columnPending))
+
+	// Messages without content are rejected instead of crashing the node
+	_, err = nodes[2].Handle(Envelope{From: 3, To: 2, Msg: (*RowPointMessage)(nil)})
+	require.Error(t, err)
+	_, err = nodes[2].Handle(Envelope{From: 3, To: 2, Msg: &ColumnPointMessage{}})
+	require.Error(t, err)
+	_, err = nodes[2].Handle(Envelope{From: 9, To: 2, Msg: &DoneMessage{}})
+	require.Error(t, err)
+}
+
+func TestNodeZeroSharing(t *testing.T) {
+	forEachCurve(t, func(t *testing.T, g *Suite) {
+		params := NewParams(4, 1)
+		msgs, _, setup := dealMessages(t, g, params)
+		nodes := newNodes(t, g, params, setup)
+		deliver(t, nodes, msgs, nil)
+
+		zero := NewDealerWithSuite(g)
+		zeroMsgs, err := zero.DealZeroMessages(params, setup)
+		require.NoError(t, err)
+		zeroNodes := newNodes(t, g, params, setup)
+		deliver(t, zeroNodes, zeroMsgs, nil)
+
+		// The commitment of row 0 is the identity, and the sum of the rows keeps every secret
+		ids := []int{1, 2}
+		rows := make([][]kyber.Scalar, len(ids))
+		old := make([][]kyber.Scalar, len(ids))
+		for j, i := range ids {
+			require.Equal(t, StateTerminated, zeroNodes[i].ReturnState())
+			require.True(t, zeroNodes[i].ReturnCommitments()[0].Equal(g.suite.G1().Point().Null()))
+
+			a, b := nodes[i].ReturnRow().Coefficients(), zeroNodes[i].ReturnRow().Coefficients()
+			old[j] = a
+			rows[j] = make([]kyber.Scalar, len(a))
+			for c := range a {
+				rows[j][c] = g.suite.G1().Scalar().Add(a[c], b[c])
+			}
+		}
+		want, err := RecoverSecrets(g.suite, ids, old, params.F+1)
+		require.NoError(t, err)
+		got, err := RecoverSecrets(g.suite, ids, rows, params.F+1)
+		require.NoError(t, err)
+		for k := range want {
+			require.True(t, want[k].Equal(got[k]), "secret %d", k)
+		}
+	})
+}
+
+func TestNodeRowSharing(t *testing.T) {
+	g := NewSuite()
+	params := NewParams(4, 1)
+	setup, err := kzg.NewKzgSetup(params.D_1+1, g.suite)
+	require.NoError(t, err)
+
+	row, hiding := make([]kyber.Scalar, params.D_1+1), make([]kyber.Scalar, params.D_1+1)
+	for c := range row {
+		row[c] = g.suite.G1().Scalar().Pick(g.suite.RandomStream())
+		hiding[c] = g.suite.G1().Scalar().Pick(g.suite.RandomStream())
+	}
+	msgs, err := NewDealerWithSuite(g).DealRowMessages(row, hiding, params, setup)
+	require.NoError(t, err)
+	nodes := newNodes(t, g, params, setup)
+	deliver(t, nodes, msgs, nil)
+
+	// The commitment of row 0 is that of the given rows, and the rows interpolate to them
+	sh := kzg.NewShareSetup(setup.ReturnT_1(), setup.ReturnT_2(), setup.ReturnT_u(), g.suite, setup.ReturnG_u(), setup.ReturnG_1())
+	require.True(t, kzg.KZGCommits(sh, row, hiding).Equal(nodes[1].ReturnCommitments()[0]))
+	want, err := RecoverSecrets(g.suite, []int{3, 4}, [][]kyber.Scalar{nodes[3].ReturnRow().Coefficients(), nodes[4].ReturnRow().Coefficients()}, 1)
+	require.NoError(t, err)
+	require.True(t, row[0].Equal(want[0]))
+
+	_, err = NewDealerWithSuite(g).DealRowMessages(row[1:], hiding, params, setup)
+	require.Error(t, err)
+}
@@ -0,0 +1,158 @@
+package vss
+
+import (
+	kzg "BingoVSS/Internal/Biv_KZG"
+	"BingoVSS/Internal/Polynomial"
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/drand/kyber"
+)
+
+/*
+A payload is shared as a frame
+
+	length (8 bytes, big endian) || data || SHA-256(length || data)
+
+padded with zeros to a whole number of sharings. The frame is cut into chunks of
+PayloadChunkSize bytes, each chunk is the value of one secret, and every sharing packs slots
+secrets φ(-k, 0) for k = 0..slots-1.
+*/
+const (
+	payloadLengthSize = 8
+	payloadDigestSize = sha256.Size
+)
+
+// PayloadChunkSize returns the number of payload bytes carried by one secret: the largest byte
+// count whose values all lie below the order of the scalar field.
+func (d *Suite) PayloadChunkSize() (int, error) {
+	f, err := polynomial.FieldOf(d.suite)
+	if err != nil {
+		return 0, err
+	}
+	return chunkSize(f), nil
+}
+
+func chunkSize(f *polynomial.Field) int {
+	return (f.Modulus().BitLen() - 1) / 8
+}
+
+/* This function constructs a new secret with the given value, shared at -val */
+func NewSecretWithValue(val int, s kyber.Scalar, suite Suite) *Secret {
+	eval := suite.suite.G1().Scalar().Neg(suite.suite.G1().Scalar().SetInt64((int64(val))))
+	return &Secret{suite.suite.G1().Scalar().Set(s), eval, val}
+}
+
+/*
+This function encodes data into the secrets of one or more sharings with slots secrets each.
+The secrets of sharing b are meant for one BingoDeal, in slot order.
+*/
+func EncodePayload(suite Suite, data []byte, slots int) ([][]Secret, error) {
+	if slots < 1 {
+		return nil, fmt.Errorf("vss: %d secret slots per sharing", slots)
+	}
+	f, err := polynomial.FieldOf(suite.suite)
+	if err != nil {
+		return nil, err
+	}
+	chunk := chunkSize(f)
+
+	frame := make([]byte, payloadLengthSize, payloadLengthSize+len(data)+payloadDigestSize)
+	binary.BigEndian.PutUint64(frame, uint64(len(data)))
+	frame = append(frame, data...)
+	digest := sha256.Sum256(frame)
+	frame = append(frame, digest[:]...)
+
+	perSharing := chunk * slots
+	sharings := (len(frame) + perSharing - 1) / perSharing
+	frame = append(frame, make([]byte, sharings*perSharing-len(frame))...)
+
+	out := make([][]Secret, sharings)
+	for b := range out {
+		out[b] = make([]Secret, slots)
+		for k := range out[b] {
+			start := (b*slots + k) * chunk
+			v := new(big.Int).SetBytes(frame[start : start+chunk])
+			out[b][k] = *NewSecretWithValue(k, f.SetInt(v), suite)
+		}
+	}
+	return out, nil
+}
+
+/*
+This function decodes the payload from the reconstructed secrets, secrets[b][k] being the secret
+of slot k of sharing b. It returns an error when the secrets are not a well formed frame or the
+digest does not match.
+*/
+func DecodePayload(suite Suite, secrets [][]kyber.Scalar) ([]byte, error) {
+	f, err := polynomial.FieldOf(suite.suite)
+	if err != nil {
+		return nil, err
+	}
+	chunk := chunkSize(f)
+
+	var frame []byte
+	for b := range secrets {
+		if len(secrets[b]) != len(secrets[0]) {
+			return nil, fmt.Errorf("vss: sharing %d has %d secrets, sharing 0 has %d", b, len(secrets[b]), len(secrets[0]))
+		}
+		for k, s := range secrets[b] {
+			if s == nil {
+				return nil, fmt.Errorf("vss: secret %d of sharing %d is missing", k, b)
+			}
+			v := f.Int(s)
+			if v.BitLen() > 8*chunk {
+				return nil, fmt.Errorf("vss: secret %d of sharing %d is not a payload chunk", k, b)
+			}
+			frame = append(frame, v.FillBytes(make([]byte, chunk))...)
+		}
+	}
+
+	if len(frame) < payloadLengthSize+payloadDigestSize {
+		return nil, errors.New("vss: payload frame too short")
+	}
+	length := binary.BigEndian.Uint64(frame)
+	if length > uint64(len(frame)-payloadLengthSize-payloadDigestSize) {
+		return nil, fmt.Errorf("vss: payload of %d bytes does not fit in %d bytes of secrets", length, len(frame))
+	}
+	end := payloadLengthSize + int(length)
+
+	digest := sha256.Sum256(frame[:end])
+	if !bytes.Equal(digest[:], frame[end:end+payloadDigestSize]) {
+		return nil, errors.New("vss: payload digest mismatch")
+	}
+	for _, c := range frame[end+payloadDigestSize:] {
+		if c != 0 {
+			return nil, errors.New("vss: payload padding is not zero")
+		}
+	}
+	return frame[payloadLengthSize:end], nil
+}
+
+/*
+This function reconstructs a payload from its sharings: verifiers[b] and cm[b] are the verifiers
+and the commitments of sharing b. All the slots of a sharing are reconstructed at once with
+BingoReconstructAll.
+*/
+func ReconstructPayload(suite Suite, verifiers [][]Verifier, cm [][]kyber.Point, set *kzg.KzgShareSetup, slots, d_2 int) ([]byte, error) {
+	if len(verifiers) != len(cm) {
+		return nil, fmt.Errorf("vss: %d sharings with %d commitments", len(verifiers), len(cm))
+	}
+
+	secrets := make([][]kyber.Scalar, len(verifiers))
+	for b := range verifiers {
+		r, err := BingoReconstructAll(verifiers[b], set, slots, d_2, cm[b])
+		if err != nil {
+			return nil, fmt.Errorf("vss: sharing %d: %w", b, err)
+		}
+		secrets[b] = make([]kyber.Scalar, slots)
+		for k := range secrets[b] {
+			secrets[b][k] = r.Secrets[k]
+		}
+	}
+	return DecodePayload(suite, secrets)
+}
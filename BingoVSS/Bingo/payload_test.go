@@ -0,0 +1,142 @@
+package vss
+
+import (
+	kzg "BingoVSS/Internal/Biv_KZG"
+	"bytes"
+	"testing"
+
+	"github.com/drand/kyber"
+	"github.com/stretchr/testify/require"
+)
+
+// shareHonestly runs BingoShare for the secrets with all participants honest.
+func shareHonestly(t *testing.T, g *Suite, secrets []Secret, f int, setup *kzg.KzgSetup, sh_setup *kzg.KzgShareSetup) ([]Verifier, []kyber.Point) {
+	d_1, d_2, n := 2*f+1, f, 3*f+1
+
+	vn := make([]kyber.Scalar, n+1)
+	for i := range vn {
+		vn[i] = g.suite.G1().Scalar().SetInt64(int64(i))
+	}
+
+	CM, verifiers, err := BingoShareDealer(secrets, d_1, d_2, n, 0, *g, setup)
+	require.NoError(t, err)
+	cm := kzg.PartialEval(setup, CM, vn)
+	for i := 0; i <= n; i++ {
+		require.NoError(t, BingoShare(verifiers, d_1, d_2, n, i, cm, *g, sh_setup, setup))
+		verifiers[i].UpdateStatus("has sent rows")
+	}
+	for i := 0; i <= n; i++ {
+		require.NoError(t, BingoShare(verifiers, d_1, d_2, n, i, cm, *g, sh_setup, setup))
+		verifiers[i].UpdateStatus("has sent columns")
+	}
+	return verifiers, cm
+}
+
+func TestPayloadChunkSize(t *testing.T) {
+	for _, curve := range Curves() {
+		g, err := NewSuiteForCurve(curve)
+		require.NoError(t, err)
+		chunk, err := g.PayloadChunkSize()
+		require.NoError(t, err)
+		require.Equal(t, 31, chunk, curve)
+	}
+}
+
+func TestEncodeDecodePayload(t *testing.T) {
+	forEachCurve(t, func(t *testing.T, g *Suite) {
+		chunk, err := g.PayloadChunkSize()
+		require.NoError(t, err)
+		for _, size := range []int{0, 1, chunk, 3*chunk - payloadLengthSize - payloadDigestSize, 500} {
+			data := bytes.Repeat([]byte{0xff, 0x01, 0x7a}, size)[:size]
+			sharings, err := EncodePayload(*g, data, 3)
+			require.NoError(t, err)
+			require.Equal(t, (payloadLengthSize+size+payloadDigestSize+3*chunk-1)/(3*chunk), len(sharings), "size %d", size)
+
+			secrets := make([][]kyber.Scalar, len(sharings))
+			for b := range sharings {
+				require.Len(t, sharings[b], 3)
+				for k := range sharings[b] {
+					require.Equal(t, k, sharings[b][k].id)
+					secrets[b] = append(secrets[b], sharings[b][k].SendSecret())
+				}
+			}
+			out, err := DecodePayload(*g, secrets)
+			require.NoError(t, err)
+			require.Equal(t, data, append([]byte{}, out...), "size %d", size)
+		}
+	})
+}
+
+func TestDecodePayloadErrors(t *testing.T) {
+	g := NewSuite()
+	sharings, err := EncodePayload(*g, []byte("a secret configuration value"), 2)
+	require.NoError(t, err)
+	require.Len(t, sharings, 2)
+
+	secrets := func() [][]kyber.Scalar {
+		out := make([][]kyber.Scalar, len(sharings))
+		for b := range sharings {
+			for k := range sharings[b] {
+				out[b] = append(out[b], sharings[b][k].SendSecret())
+			}
+		}
+		return out
+	}
+
+	s := secrets()
+	s[0][1] = g.suite.G1().Scalar().Add(s[0][1], g.suite.G1().Scalar().One())
+	_, err = DecodePayload(*g, s)
+	require.ErrorContains(t, err, "digest mismatch")
+
+	s = secrets()
+	s[1][1] = g.suite.G1().Scalar().Neg(g.suite.G1().Scalar().One())
+	_, err = DecodePayload(*g, s)
+	require.ErrorContains(t, err, "not a payload chunk")
+
+	s = secrets()
+	_, err = DecodePayload(*g, s[:1])
+	require.Error(t, err)
+
+	s = secrets()
+	s[1] = s[1][:1]
+	_, err = DecodePayload(*g, s)
+	require.Error(t, err)
+
+	s = secrets()
+	s[1][0] = nil
+	_, err = DecodePayload(*g, s)
+	require.Error(t, err)
+
+	_, err = DecodePayload(*g, nil)
+	require.Error(t, err)
+	_, err = EncodePayload(*g, nil, 0)
+	require.Error(t, err)
+}
+
+func TestSharePayload(t *testing.T) {
+	g := NewSuite()
+	f := 1
+	setup, err := kzg.NewKzgSetup(2*f+2, g.suite)
+	require.NoError(t, err)
+	sh_setup := kzg.NewShareSetup(setup.ReturnT_1(), setup.ReturnT_2(), setup.ReturnT_u(), g.suite, setup.ReturnG_u(), setup.ReturnG_1())
+
+	data := []byte("-----BEGIN KEY-----\nan arbitrary payload that does not fit in one sharing\n-----END KEY-----\n")
+	sharings, err := EncodePayload(*g, data, f+1)
+	require.NoError(t, err)
+	require.Len(t, sharings, 3)
+
+	verifiers := make([][]Verifier, len(sharings))
+	cm := make([][]kyber.Point, len(sharings))
+	for b := range sharings {
+		verifiers[b], cm[b] = shareHonestly(t, g, sharings[b], f, setup, sh_setup)
+	}
+
+	out, err := ReconstructPayload(*g, verifiers, cm, sh_setup, f+1, f)
+	require.NoError(t, err)
+	require.Equal(t, data, out)
+
+	// the commitments of another sharing do not match the rows
+	cm[0], cm[1] = cm[1], cm[0]
+	_, err = ReconstructPayload(*g, verifiers, cm, sh_setup, f+1, f)
+	require.Error(t, err)
+}
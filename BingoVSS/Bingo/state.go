@@ -0,0 +1,254 @@
+package vss
+
+import (
+	poly "BingoVSS/Internal/BivPoly"
+	kzg "BingoVSS/Internal/Biv_KZG"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/drand/kyber"
+)
+
+/*
+VerifierState is everything a Verifier knows, in a form that can be stored and restored: the
+verifier with ID i holds the row φ(X, i-1) and φ'(X, i-1), checked against cm[i-1], its status in
+BingoShare and the proofs it received (RowProofs, ColProofs) and verified (VrowProofs,
+CrowProofs). A proof that was never received has no point.
+*/
+type VerifierState struct {
+	ID         int
+	Status     string
+	Row        []kyber.Scalar
+	RowHiding  []kyber.Scalar
+	RowProofs  []kzg.Proof
+	CrowProofs []kzg.Proof
+	VrowProofs []kzg.Proof
+	ColProofs  []kzg.Proof
+}
+
+// rowStatuses are the statuses in which a verifier holds a row that matched its commitment.
+var rowStatuses = map[string]bool{"correct polynomial": true, "has sent rows": true, "has sent columns": true, "Done": true}
+
+// State returns the state of the verifier. The slices are copies, the scalars and points are shared.
+func (v *Verifier) State() VerifierState {
+	return VerifierState{
+		ID:         v.id,
+		Status:     v.status,
+		Row:        append([]kyber.Scalar{}, v.polynomial.Coefficients()...),
+		RowHiding:  append([]kyber.Scalar{}, v.polynomial.Coefficients_2()...),
+		RowProofs:  append([]kzg.Proof{}, v.rowProofs...),
+		CrowProofs: append([]kzg.Proof{}, v.CrowProofs...),
+		VrowProofs: append([]kzg.Proof{}, v.VrowProofs...),
+		ColProofs:  append([]kzg.Proof{}, v.colProofs...),
+	}
+}
+
+/* This function constructs the verifier of a state returned by Verifier.State */
+func RestoreVerifier(suite *Suite, s VerifierState) (*Verifier, error) {
+	if s.ID < 1 {
+		return nil, fmt.Errorf("vss: invalid verifier id %d", s.ID)
+	}
+	if len(s.Row) != len(s.RowHiding) {
+		return nil, fmt.Errorf("vss: row of %d coefficients with a hiding row of %d", len(s.Row), len(s.RowHiding))
+	}
+	n := len(s.RowProofs)
+	for _, proofs := range [][]kzg.Proof{s.CrowProofs, s.VrowProofs, s.ColProofs} {
+		if len(proofs) != n {
+			return nil, fmt.Errorf("vss: proof lists of %d and %d entries", n, len(proofs))
+		}
+	}
+	return &Verifier{
+		polynomial: *poly.NewPriPoly(suite.suite, 0, s.Row, s.RowHiding, nil),
+		id:         s.ID,
+		status:     s.Status,
+		rowProofs:  append([]kzg.Proof{}, s.RowProofs...),
+		CrowProofs: append([]kzg.Proof{}, s.CrowProofs...),
+		VrowProofs: append([]kzg.Proof{}, s.VrowProofs...),
+		colProofs:  append([]kzg.Proof{}, s.ColProofs...),
+	}, nil
+}
+
+/*
+This function checks a state against the row commitments cm[0..n] it was dealt under: the row,
+whatever the status, and every verified proof. A status that says the row was checked needs one. VrowProofs[c] opens cm[c] at the row
+index of the verifier, and CrowProofs[c] opens the commitment of the verifier at c. The proofs
+that were only received are not checked here; BingoShare checks them before using them.
+*/
+func (s VerifierState) Verify(setup *kzg.KzgShareSetup, cm []kyber.Point) error {
+	i := s.ID - 1
+	if i < 0 || i >= len(cm) {
+		return fmt.Errorf("vss: verifier %d has no commitment among %d", s.ID, len(cm))
+	}
+	if len(s.Row) != len(s.RowHiding) {
+		return fmt.Errorf("vss: verifier %d has a row of %d coefficients with a hiding row of %d", s.ID, len(s.Row), len(s.RowHiding))
+	}
+	if len(s.Row) == 0 && rowStatuses[s.Status] {
+		return fmt.Errorf("vss: verifier %d is %q without a row", s.ID, s.Status)
+	}
+	if len(s.Row) > 0 && !kzg.KZGCommits(setup, s.Row, s.RowHiding).Equal(cm[i]) {
+		return fmt.Errorf("vss: the row of verifier %d does not match its commitment", s.ID)
+	}
+
+	g := setup.ReturnSuite().G1()
+	for _, check := range []struct {
+		kind   string
+		proofs []kzg.Proof
+		at     func(c int) (int, kyber.Scalar)
+	}{
+		{"row", s.VrowProofs, func(c int) (int, kyber.Scalar) { return c, g.Scalar().SetInt64(int64(i)) }},
+		{"column", s.CrowProofs, func(c int) (int, kyber.Scalar) { return i, g.Scalar().SetInt64(int64(c)) }},
+	} {
+		if len(check.proofs) > len(cm) {
+			return fmt.Errorf("vss: %d verified %s points for %d commitments", len(check.proofs), check.kind, len(cm))
+		}
+		for c, ok := range verifyProofs(setup, cm, check.proofs, check.at) {
+			if check.proofs[c].ReturnP() != nil && !ok {
+				return fmt.Errorf("vss: verified %s point %d of verifier %d does not match the commitments", check.kind, c, s.ID)
+			}
+		}
+	}
+	return nil
+}
+
+/*
+A VerifierState is encoded in the following binary format (all integers big-endian):
+
+	version     uint8    stateVersion
+	id          uint32
+	status      uint16 length, then the bytes
+	row         uint16 count, the coefficients, uint16 count, the hiding coefficients
+	proofs      RowProofs, CrowProofs, VrowProofs and ColProofs, each a uint16 count followed by
+	            one byte per proof: 0 if it was never received, else 1, the uint32 id of the
+	            sender, y_1, y_2 and the proof
+*/
+const stateVersion uint8 = 1
+
+// MarshalVerifierState returns the binary encoding of s.
+func MarshalVerifierState(s VerifierState) ([]byte, error) {
+	if s.ID < 0 || len(s.Status) > 0xffff {
+		return nil, errors.New("vss: cannot encode the verifier state")
+	}
+
+	var buf bytes.Buffer
+	buf.WriteByte(stateVersion)
+	_ = binary.Write(&buf, binary.BigEndian, uint32(s.ID))
+	_ = binary.Write(&buf, binary.BigEndian, uint16(len(s.Status)))
+	buf.WriteString(s.Status)
+	if err := writeScalarList(&buf, s.Row); err != nil {
+		return nil, err
+	}
+	if err := writeScalarList(&buf, s.RowHiding); err != nil {
+		return nil, err
+	}
+	for _, proofs := range [][]kzg.Proof{s.RowProofs, s.CrowProofs, s.VrowProofs, s.ColProofs} {
+		if err := writeProofList(&buf, proofs); err != nil {
+			return nil, err
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// UnmarshalVerifierState decodes a state produced by MarshalVerifierState in the groups of the suite.
+func UnmarshalVerifierState(data []byte, suite *Suite) (VerifierState, error) {
+	var s VerifierState
+	r := bytes.NewReader(data)
+
+	version, err := r.ReadByte()
+	if err != nil {
+		return s, errors.New("vss: verifier state too short")
+	}
+	if version != stateVersion {
+		return s, fmt.Errorf("vss: unsupported verifier state version %d", version)
+	}
+
+	var id uint32
+	var l uint16
+	if err := binary.Read(r, binary.BigEndian, &id); err != nil {
+		return s, fmt.Errorf("vss: malformed verifier state: %w", err)
+	}
+	if err := binary.Read(r, binary.BigEndian, &l); err != nil {
+		return s, fmt.Errorf("vss: malformed verifier state: %w", err)
+	}
+	status := make([]byte, l)
+	if _, err := io.ReadFull(r, status); err != nil {
+		return s, fmt.Errorf("vss: malformed verifier state: %w", err)
+	}
+	s.ID, s.Status = int(id), string(status)
+
+	if s.Row, err = readScalarList(r, suite); err == nil {
+		s.RowHiding, err = readScalarList(r, suite)
+	}
+	for _, proofs := range []*[]kzg.Proof{&s.RowProofs, &s.CrowProofs, &s.VrowProofs, &s.ColProofs} {
+		if err != nil {
+			break
+		}
+		*proofs, err = readProofList(r, suite)
+	}
+	if err != nil {
+		return VerifierState{}, fmt.Errorf("vss: malformed verifier state: %w", err)
+	}
+	if r.Len() != 0 {
+		return VerifierState{}, fmt.Errorf("vss: %d trailing bytes after the verifier state", r.Len())
+	}
+	return s, nil
+}
+
+func writeProofList(w *bytes.Buffer, proofs []kzg.Proof) error {
+	if len(proofs) > 0xffff {
+		return fmt.Errorf("vss: %d proofs do not fit in a verifier state", len(proofs))
+	}
+	_ = binary.Write(w, binary.BigEndian, uint16(len(proofs)))
+	for _, p := range proofs {
+		if p.ReturnP() == nil {
+			w.WriteByte(0)
+			continue
+		}
+		if p.ReturnID() < 0 {
+			return fmt.Errorf("vss: negative sender %d of a proof", p.ReturnID())
+		}
+		w.WriteByte(1)
+		_ = binary.Write(w, binary.BigEndian, uint32(p.ReturnID()))
+		if err := writeEvaluation(w, p.ReturnY_1(), p.ReturnY_2(), p.ReturnP()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func readProofList(r *bytes.Reader, suite *Suite) ([]kzg.Proof, error) {
+	var l uint16
+	if err := binary.Read(r, binary.BigEndian, &l); err != nil {
+		return nil, err
+	}
+	if int(l) > r.Len() {
+		return nil, fmt.Errorf("%d proofs announced, %d bytes left", l, r.Len())
+	}
+
+	proofs := make([]kzg.Proof, l)
+	for c := range proofs {
+		present, err := r.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		switch present {
+		case 0:
+			continue
+		case 1:
+		default:
+			return nil, fmt.Errorf("invalid marker %d of proof %d", present, c)
+		}
+		var id uint32
+		if err := binary.Read(r, binary.BigEndian, &id); err != nil {
+			return nil, err
+		}
+		y_1, y_2, p, err := readEvaluation(r, suite)
+		if err != nil {
+			return nil, err
+		}
+		proofs[c] = *kzg.NewProof(int(id), p, y_1, y_2)
+	}
+	return proofs, nil
+}
@@ -0,0 +1,104 @@
+package vss
+
+import (
+	kzg "BingoVSS/Internal/Biv_KZG"
+	"testing"
+
+	"github.com/drand/kyber"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerifierState(t *testing.T) {
+	forEachCurve(t, testVerifierState)
+}
+
+func testVerifierState(t *testing.T, g *Suite) {
+	f := 1
+	d_1, d_2, n := 2*f+1, f, 3*f+1
+	secrets := []Secret{*NewSecret(0, *g), *NewSecret(1, *g)}
+
+	setup, err := kzg.NewKzgSetup(d_1+1, g.suite)
+	require.NoError(t, err)
+	sh_setup := kzg.NewShareSetup(setup.ReturnT_1(), setup.ReturnT_2(), setup.ReturnT_u(), g.suite, setup.ReturnG_u(), setup.ReturnG_1())
+	vn := make([]kyber.Scalar, n+1)
+	for i := range vn {
+		vn[i] = g.suite.G1().Scalar().SetInt64(int64(i))
+	}
+	CM, verifiers, err := BingoShareDealer(secrets, d_1, d_2, n, 0, *g, setup)
+	require.NoError(t, err)
+	cm := kzg.PartialEval(setup, CM, vn)
+	for i := 0; i <= n; i++ {
+		require.NoError(t, BingoShare(verifiers, d_1, d_2, n, i, cm, *g, sh_setup, setup))
+		verifiers[i].UpdateStatus("has sent rows")
+	}
+	for i := 0; i <= n; i++ {
+		require.NoError(t, BingoShare(verifiers, d_1, d_2, n, i, cm, *g, sh_setup, setup))
+		verifiers[i].UpdateStatus("Done")
+	}
+
+	// Every state survives the encoding and still matches the commitments
+	restored := make([]Verifier, n+1)
+	for i := range verifiers {
+		data, err := MarshalVerifierState(verifiers[i].State())
+		require.NoError(t, err)
+		s, err := UnmarshalVerifierState(data, g)
+		require.NoError(t, err)
+		require.NoError(t, s.Verify(sh_setup, cm))
+		again, err := MarshalVerifierState(s)
+		require.NoError(t, err)
+		require.Equal(t, data, again)
+
+		v, err := RestoreVerifier(g, s)
+		require.NoError(t, err)
+		restored[i] = *v
+	}
+	for k := range secrets {
+		secret, err := BingoReconstruct(restored, 0, sh_setup, k, d_2, cm)
+		require.NoError(t, err)
+		require.True(t, secrets[k].s.Equal(secret))
+	}
+
+	// A changed row, a changed verified point or other commitments do not verify
+	s := verifiers[2].State()
+	s.Row = append([]kyber.Scalar{}, s.Row...)
+	s.Row[0] = g.suite.G1().Scalar().Add(s.Row[0], g.suite.G1().Scalar().One())
+	require.Error(t, s.Verify(sh_setup, cm))
+	for _, status := range []string{"null", "not correct polynomials", "missing polynomial", "anything"} {
+		s.Status = status
+		require.Error(t, s.Verify(sh_setup, cm))
+	}
+
+	// A status that claims a row needs one, and a hiding row needs a row
+	s = verifiers[2].State()
+	s.Row, s.RowHiding = nil, nil
+	require.Error(t, s.Verify(sh_setup, cm))
+	s.Status = "null"
+	require.NoError(t, s.Verify(sh_setup, cm))
+	s.RowHiding = verifiers[2].State().RowHiding
+	require.Error(t, s.Verify(sh_setup, cm))
+
+	s = verifiers[2].State()
+	for c, p := range s.VrowProofs {
+		if p.ReturnP() != nil {
+			s.VrowProofs[c] = *kzg.NewProof(p.ReturnID(), p.ReturnP(), p.ReturnY_2(), p.ReturnY_1())
+			break
+		}
+	}
+	require.Error(t, s.Verify(sh_setup, cm))
+	require.Error(t, verifiers[2].State().Verify(sh_setup, cm[:2]))
+	require.Error(t, verifiers[2].State().Verify(sh_setup, append([]kyber.Point{cm[1]}, cm[1:]...)))
+
+	// Malformed encodings
+	data, err := MarshalVerifierState(verifiers[1].State())
+	require.NoError(t, err)
+	_, err = UnmarshalVerifierState(data[:len(data)-1], g)
+	require.Error(t, err)
+	_, err = UnmarshalVerifierState(append(data, 0), g)
+	require.Error(t, err)
+	bad := append([]byte{}, data...)
+	bad[0] = 2
+	_, err = UnmarshalVerifierState(bad, g)
+	require.Error(t, err)
+	_, err = RestoreVerifier(g, VerifierState{ID: 1, RowProofs: make([]kzg.Proof, 2)})
+	require.Error(t, err)
+}
@@ -3,14 +3,27 @@ package vss
 import (
 	poly "BingoVSS/Internal/BivPoly"
 	kzg "BingoVSS/Internal/Biv_KZG"
+	"BingoVSS/Internal/Polynomial"
+
+	"fmt"
 
 	"github.com/drand/kyber"
+	bls "github.com/drand/kyber-bls12381"
+	"github.com/drand/kyber/pairing"
 	"github.com/drand/kyber/pairing/bn256"
 )
 
+// Names of the pairing curves the protocol can run on, as accepted by NewSuiteForCurve.
+const (
+	CurveBN256    = "bn256"
+	CurveBLS12381 = "bls12-381"
+	DefaultCurve  = CurveBN256
+)
+
 // The Suite defines the capabilities required by the bingoVss package.
 type Suite struct {
-	suite *bn256.Suite
+	suite pairing.Suite
+	curve string
 }
 
 type Secret struct {
@@ -29,7 +42,6 @@ type Dealer struct {
 	randomPoly      poly.BivPoly
 	suite           *Suite
 	publicCommitsCM []kyber.Point
-	CM_coeffs       []kyber.Scalar
 	id              int
 	sharePolys      []poly.PriPoly
 	verifiers       []Verifier
@@ -73,15 +85,35 @@ func NewSecret(val int, suite Suite) *Secret {
 	return &Secret{s, eval, id}
 }
 
-/* This function constructs a new dealer */
+/* This function constructs a new suite on the default curve */
 func NewSuite() *Suite {
-	suite := bn256.NewSuite()
-	return &Suite{suite: suite}
+	suite, _ := NewSuiteForCurve(DefaultCurve)
+	return suite
+}
+
+/* This function constructs a new suite on the pairing curve with the given name */
+func NewSuiteForCurve(curve string) (*Suite, error) {
+	switch curve {
+	case CurveBN256:
+		return &Suite{suite: bn256.NewSuite(), curve: curve}, nil
+	case CurveBLS12381:
+		return &Suite{suite: bls.NewBLS12381Suite(), curve: curve}, nil
+	}
+	return nil, fmt.Errorf("vss: unknown curve %q, expected %q or %q", curve, CurveBN256, CurveBLS12381)
+}
+
+// Curves returns the names of all the supported curves.
+func Curves() []string {
+	return []string{CurveBN256, CurveBLS12381}
 }
 
 /* This function constructs a new dealer */
 func NewDealer() *Dealer {
-	suite := NewSuite()
+	return NewDealerWithSuite(NewSuite())
+}
+
+/* This function constructs a new dealer that works on the given suite */
+func NewDealerWithSuite(suite *Suite) *Dealer {
 	return &Dealer{suite: suite, id: 0}
 }
 
@@ -94,7 +126,7 @@ func NewVerifier(poly poly.PriPoly, id int, n int) *Verifier {
 	return &Verifier{poly, id, "null", proofs, proofs_s, proofs_ss, proofs_sss}
 }
 
-func (d *Dealer) BingoDeal(secrets []Secret, x, y, par int, setup *kzg.KzgSetup) {
+func (d *Dealer) BingoDeal(secrets []Secret, x, y, par int, setup *kzg.KzgSetup) error {
 
 	//Select degree of the polynomial (for example purposes we pre-define this)
 	d_1 := x //Degree in X
@@ -113,37 +145,61 @@ func (d *Dealer) BingoDeal(secrets []Secret, x, y, par int, setup *kzg.KzgSetup)
 		secret_scalar[i] = secrets[i].s
 	}
 
-	d.secretPoly = *poly.NewPrivBivPoly(d.suite.suite, &f_x, d_1+1, d_2+1, secret_scalar)
+	secretPoly, err := poly.NewPrivBivPoly(d.suite.suite, &f_x, secret_scalar)
+	if err != nil {
+		return err
+	}
+	d.secretPoly = *secretPoly
 
-	// ThirdStep: Dealer commits the polynomials
+	return d.commitAndShare(n, setup)
+}
 
-	d.publicCommitsCM, d.CM_coeffs = kzg.Commits(setup, d.secretPoly.ReturnCoefficients(), d.randomPoly.ReturnCoefficients(), d_1+1, d_2+1)
+// commitAndShare commits to the polynomials of the dealer and projects them on the rows of the
+// participants 0..n.
+func (d *Dealer) commitAndShare(n int, setup *kzg.KzgSetup) error {
+	// ThirdStep: Dealer commits the polynomials
+	CM, err := kzg.Commits(setup, &d.secretPoly.BivPoly, &d.randomPoly)
+	if err != nil {
+		return err
+	}
+	d.publicCommitsCM = CM
 
 	share_poly := make([]poly.PriPoly, n+1)
 	// FourthStep: Create the projections, that means create the share-polynomials that you are giving to verifiers.
-	SharePolynomials_f_x := poly.CreateProjectionPolynomials(d.suite.suite, d.secretPoly.ReturnCoefficients(), d_1+1, d_2+1, n+1)
-	SharePolynomials_f_x_h := poly.CreateProjectionPolynomials(d.suite.suite, d.randomPoly.ReturnCoefficients(), d_1+1, d_2+1, n+1)
+	SharePolynomials_f_x := d.secretPoly.Rows(n)
+	SharePolynomials_f_x_h := d.randomPoly.Rows(n)
 
 	//CreateTheSharePolynomials
 	for i := 0; i < n+1; i++ {
 		share_poly[i] = *poly.NewPriPoly(d.suite.suite, n, SharePolynomials_f_x[i], SharePolynomials_f_x_h[i], nil)
 	}
 	d.sharePolys = share_poly
-	d.Broadcast()
 	d.SharePolynomials()
 
+	return nil
 }
 
-func (d *Suite) ReturnSuite() *bn256.Suite {
+func (d *Suite) ReturnSuite() pairing.Suite {
 	return d.suite
 }
 
+func (d *Suite) ReturnCurve() string {
+	return d.curve
+}
+
 func (d *Dealer) ReturnSuite() Suite {
 	return *d.suite
 }
 
-func (d *Dealer) Broadcast() {
-	//fmt.Println("Commits", d.publicCommitsCM)
+// Broadcast returns the first step of the reliable broadcast of the commitment CM: the
+// commitment sent by the dealer to every participant 1..n. The participants echo it among
+// themselves, see Node.
+func (d *Dealer) Broadcast(n int) []Envelope {
+	out := make([]Envelope, 0, 2*n)
+	for i := 1; i <= n; i++ {
+		out = append(out, Envelope{From: DealerID, To: i, Msg: &CommitmentMessage{Commitments: d.publicCommitsCM}})
+	}
+	return out
 }
 
 func (d *Dealer) SharePolynomials() {
@@ -153,16 +209,28 @@ func (d *Dealer) SharePolynomials() {
 	}
 }
 
-func BingoShareDealer(secrets []Secret, d_1, d_2, n int, Id int, suite Suite, setup *kzg.KzgSetup) ([]kyber.Point, []kyber.Scalar, []Verifier) {
+func BingoShareDealer(secrets []Secret, d_1, d_2, n int, Id int, suite Suite, setup *kzg.KzgSetup) ([]kyber.Point, []Verifier, error) {
 
-	d := NewDealer()
-	d.BingoDeal(secrets, d_1, d_2, n, setup)
+	d := NewDealerWithSuite(&suite)
+	d.id = Id
+	if err := d.BingoDeal(secrets, d_1, d_2, n, setup); err != nil {
+		return nil, nil, err
+	}
 
-	return d.publicCommitsCM, d.CM_coeffs, d.verifiers
+	return d.publicCommitsCM, d.verifiers, nil
 
 }
 
-func BingoShare(verifier []Verifier, d_1, d_2, n int, id int, cm []kyber.Point, suite Suite, setup *kzg.KzgShareSetup, set *kzg.KzgSetup) {
+/*
+This function runs the step of BingoShare that matches the status of verifier id. It returns an
+error instead of waiting when the verifier does not hold enough valid proofs for the step; the
+status is left unchanged, so the step can be retried once more proofs arrived.
+*/
+func BingoShare(verifier []Verifier, d_1, d_2, n int, id int, cm []kyber.Point, suite Suite, setup *kzg.KzgShareSetup, set *kzg.KzgSetup) error {
+	if id < 0 || id >= len(verifier) || len(cm) < len(verifier) {
+		return fmt.Errorf("vss: no verifier %d among %d with %d commitments", id, len(verifier), len(cm))
+	}
+
 	//Check with KZGcommit
 	if verifier[id].status == "null" {
 		if kzg.KZGCommits(setup, verifier[id].polynomial.Coefficients(), verifier[id].polynomial.Coefficients_2()).Equal(cm[id]) {
@@ -173,13 +241,13 @@ func BingoShare(verifier []Verifier, d_1, d_2, n int, id int, cm []kyber.Point,
 	}
 
 	if verifier[id].status == "correct polynomial" {
+		// Step_6 : create the evaluation proofs for every verifier at once
+		proofs, y_1, y_2, err := kzg.KZGEvalAll(setup, verifier[id].polynomial.Coefficients(), verifier[id].polynomial.Coefficients_2(), len(verifier)-1)
+		if err != nil {
+			return err
+		}
 		for j := 0; j < len(verifier); j++ {
-			a := suite.suite.G1().Scalar().SetInt64(int64(j))
-
-			// Step_6 : create an evaluation proof
-			proof, y_1, y_2, co, _ := kzg.KZGEvaluationProof(set, verifier[id].polynomial.Coefficients(), verifier[id].polynomial.Coefficients_2(), a)
-
-			proof_a := kzg.NewProof(id, proof, y_1, y_2, co)
+			proof_a := kzg.NewProof(id, proofs[j], y_1[j], y_2[j])
 
 			verifier[j].rowProofs[id] = *proof_a
 
@@ -190,17 +258,17 @@ func BingoShare(verifier []Verifier, d_1, d_2, n int, id int, cm []kyber.Point,
 
 	if verifier[id].status == "has sent rows" {
 		if len(verifier[id].rowProofs) > d_2+1 {
-			c := 0
-			for !(checkForNotNil(verifier[id].VrowProofs) == d_2+2) { //line 20
-				if verifier[id].rowProofs[c].ReturnP() != nil {
-					a := suite.suite.G1().Scalar().SetInt64(int64(id))
-					if kzg.KZGVerify(setup, cm, c, verifier[id].rowProofs[c].ReturnP(), a, verifier[id].rowProofs[c].ReturnY_1(), verifier[id].rowProofs[c].ReturnY_2()) {
-						verifier[id].VrowProofs[c] = verifier[id].rowProofs[c] //line 19
-					}
+			a := suite.suite.G1().Scalar().SetInt64(int64(id))
+			valid := verifyProofs(setup, cm, verifier[id].rowProofs, func(c int) (int, kyber.Scalar) { return c, a })
+			for c := 0; c < len(valid) && checkForNotNil(verifier[id].VrowProofs) < d_2+2; c++ { //line 20
+				if valid[c] {
+					verifier[id].VrowProofs[c] = verifier[id].rowProofs[c] //line 19
 				}
-				c++
 			}
 		}
+		if valid := checkForNotNil(verifier[id].VrowProofs); valid < d_2+1 {
+			return fmt.Errorf("vss: verifier %d holds %d valid row points, %d are needed", id, valid, d_2+1)
+		}
 
 		vn := make([]kyber.Scalar, n+1)
 		for i := 0; i < n+1; i++ {
@@ -210,7 +278,7 @@ func BingoShare(verifier []Verifier, d_1, d_2, n int, id int, cm []kyber.Point,
 		//now we can compute the columns based on that okay-> so we use get proofs
 		pr, y_1, y_2 := kzg.GetProofs(verifier[id].VrowProofs, vn, set, d_2+1)
 		for j := 0; j < len(verifier); j++ {
-			proof_a := kzg.NewProof(id, pr[j], y_1[j], y_2[j], nil)
+			proof_a := kzg.NewProof(id, pr[j], y_1[j], y_2[j])
 			verifier[j].colProofs[id] = *proof_a                  //b_j_i
 			verifier[id].ShareStatus("column to participant ", j) //this line 23
 		}
@@ -220,27 +288,30 @@ func BingoShare(verifier []Verifier, d_1, d_2, n int, id int, cm []kyber.Point,
 	if verifier[id].status == "missing polynomial" { //line 26
 
 		if len(verifier[id].colProofs) > 2*d_2+1 {
-			c := 0
-
-			for !(checkForNotNil(verifier[id].CrowProofs)-1 == 2*d_2+1) { //line 20
-				if verifier[id].colProofs[c].ReturnP() != nil {
-					a := suite.suite.G1().Scalar().SetInt64(int64(c))
-					if kzg.KZGVerify(setup, cm, id, verifier[id].colProofs[c].ReturnP(), a, verifier[id].colProofs[c].ReturnY_1(), verifier[id].colProofs[c].ReturnY_2()) { //line 27
-						verifier[id].CrowProofs[c] = verifier[id].colProofs[c] //line 19
-					}
+			valid := verifyProofs(setup, cm, verifier[id].colProofs, func(c int) (int, kyber.Scalar) { //line 27
+				return id, suite.suite.G1().Scalar().SetInt64(int64(c))
+			})
+			for c := 0; c < len(valid) && checkForNotNil(verifier[id].CrowProofs) < 2*d_2+2; c++ { //line 20
+				if valid[c] {
+					verifier[id].CrowProofs[c] = verifier[id].colProofs[c] //line 19
 				}
-				c++
 			}
+		}
+		if valid := checkForNotNil(verifier[id].CrowProofs); valid < d_1+1 {
+			return fmt.Errorf("vss: verifier %d holds %d valid column points, %d are needed to recover its row", id, valid, d_1+1)
+		}
 
-			a_x, a_xi := InterpolateRows(verifier[id].CrowProofs, setup, d_1+1)
-			verifier[id].polynomial = *poly.NewPriPoly(setup.ReturnSuite(), d_2, a_x, a_xi, setup.ReturnSuite().RandomStream())
-
+		a_x, a_xi, err := InterpolateRows(verifier[id].CrowProofs, setup, d_1+1)
+		if err != nil {
+			return fmt.Errorf("vss: verifier %d cannot recover its row: %w", id, err)
 		}
+		verifier[id].polynomial = *poly.NewPriPoly(setup.ReturnSuite(), d_2, a_x, a_xi, setup.ReturnSuite().RandomStream())
 	}
 
+	return nil
 }
 
-func InterpolateRows(proofs []kzg.Proof, set *kzg.KzgShareSetup, d_1 int) ([]kyber.Scalar, []kyber.Scalar) {
+func InterpolateRows(proofs []kzg.Proof, set *kzg.KzgShareSetup, d_1 int) ([]kyber.Scalar, []kyber.Scalar, error) {
 	y_i := make([]kyber.Scalar, d_1+1)
 	y_j := make([]kyber.Scalar, d_1+1)
 	x_i := make([]kyber.Scalar, d_1+1)
@@ -256,11 +327,37 @@ func InterpolateRows(proofs []kzg.Proof, set *kzg.KzgShareSetup, d_1 int) ([]kyb
 		}
 	}
 
-	a_x := poly.RecoverVandermondeGivenX(set.ReturnSuite(), x_i, y_i, d_1)
+	a_x, err := poly.RecoverVandermondeGivenX(set.ReturnSuite(), x_i, y_i, d_1)
+	if err != nil {
+		return nil, nil, err
+	}
 
-	a_xj := poly.RecoverVandermondeGivenX(set.ReturnSuite(), x_i, y_j, d_1)
+	a_xj, err := poly.RecoverVandermondeGivenX(set.ReturnSuite(), x_i, y_j, d_1)
+	if err != nil {
+		return nil, nil, err
+	}
 
-	return a_x, a_xj
+	return a_x, a_xj, nil
+}
+
+// verifyProofs checks all non-empty proofs in one batch. at gives the commitment index and the
+// evaluation point of the proof at position c.
+func verifyProofs(set *kzg.KzgShareSetup, cm []kyber.Point, proofs []kzg.Proof, at func(c int) (int, kyber.Scalar)) []bool {
+	var pos []int
+	var openings []kzg.Opening
+	for c, p := range proofs {
+		if p.ReturnP() != nil {
+			i, z := at(c)
+			pos = append(pos, c)
+			openings = append(openings, kzg.Opening{Index: i, Z: z, Y_1: p.ReturnY_1(), Y_2: p.ReturnY_2(), Proof: p.ReturnP()})
+		}
+	}
+
+	valid := make([]bool, len(proofs))
+	for k, ok := range kzg.KZGVerifyEach(set, cm, openings) {
+		valid[pos[k]] = ok
+	}
+	return valid
 }
 
 func checkForNotNil(proof []kzg.Proof) int {
@@ -299,28 +396,178 @@ func (v *Verifier) ShareStatus(msg string, i int) {
 // 	}
 // }
 
-func BingoReconstruct(verifiers []Verifier, ver int, set *kzg.KzgShareSetup, k int, d_2 int, cm []kyber.Point) kyber.Scalar {
+/*
+This function reconstructs the secret with index k from the rows of the verifiers. It returns an
+error when fewer than d_2+2 verifiers hold a row that matches their commitment.
+*/
+func BingoReconstruct(verifiers []Verifier, ver int, set *kzg.KzgShareSetup, k int, d_2 int, cm []kyber.Point) (kyber.Scalar, error) {
 	//line 1: shares_i_k = null set
 	shares := make([]kzg.Proof, d_2+2)
 	shares_l := make([]kyber.Scalar, d_2+2)
+	shares_x := make([]kyber.Scalar, d_2+2)
+
+	neg_k := set.ReturnSuite().G1().Scalar().Neg(set.ReturnSuite().G1().Scalar().SetInt64(int64(k)))
+
+	// Open every row at -k, then check all the openings in one batch
+	evals := make([]kzg.Proof, len(verifiers))
+	for i := 0; i < len(verifiers) && i < len(cm); i++ {
+		if len(verifiers[i].polynomial.Coefficients()) == 0 {
+			continue
+		}
+		p, a_i, a_j_i, err := kzg.KZGEval(set, verifiers[i].polynomial.Coefficients(), verifiers[i].polynomial.Coefficients_2(), neg_k)
+		if err != nil {
+			continue
+		}
+		evals[i] = *kzg.NewProof(i, p, a_i, a_j_i)
+	}
+	valid := verifyProofs(set, cm, evals, func(i int) (int, kyber.Scalar) { return i, neg_k })
 
 	n := 0
-	i := 0
-	for checkForNotNil(shares) < d_2+2 {
-		neg_k := set.ReturnSuite().G1().Scalar().Neg(set.ReturnSuite().G1().Scalar().SetInt64(int64(k)))
-		p, a_i, a_j_i, _ := kzg.KZGEval(set, verifiers[i].polynomial.Coefficients(), verifiers[i].polynomial.Coefficients_2(), neg_k)
-
-		if kzg.KZGVerify(set, cm, i, p, neg_k, a_i, a_j_i) {
-			shares[n] = *kzg.NewProof(i, p, a_i, a_j_i, nil)
-			shares_l[n] = a_i
+	for i := 0; i < len(evals) && n < d_2+2; i++ {
+		if valid[i] {
+			shares[n] = evals[i]
+			shares_l[n] = evals[i].ReturnY_1()
+			shares_x[n] = set.ReturnSuite().G1().Scalar().SetInt64(int64(i))
 			n++
 		}
-		i++
+	}
+	if n < d_2+2 {
+		return nil, fmt.Errorf("vss: %d valid shares of secret %d, %d are needed", n, k, d_2+2)
+	}
+
+	// The rows that were skipped leave gaps, so interpolate at the indices of the shares
+	v, err := poly.RecoverVandermondeGivenX(set.ReturnSuite(), shares_x, shares_l, d_2+2)
+	if err != nil {
+		return nil, fmt.Errorf("vss: secret %d: %w", k, err)
 	}
 
-	zero := set.ReturnSuite().G1().Scalar().SetInt64(int64(0))
-	v_k := poly.LagrangeInterpolation(set.ReturnSuite(), shares_l, zero)
+	return v[0], nil
 
-	return v_k
+}
+
+// Reconstruction holds the secrets recovered from a dealing by BingoReconstructAll.
+type Reconstruction struct {
+	Secrets  map[int]kyber.Scalar // φ(-k, 0) for every packed secret k
+	Verified []int                // the verifiers whose row matches their commitment
+	Used     []int                // the verified rows the secrets were interpolated from
+}
 
+/*
+This function reconstructs the m packed secrets of a dealing at once. The row of every verifier
+is checked against its commitment a single time, the row φ(X, 0) is interpolated from d_2+1
+verified rows and evaluated at every -k. It returns an error when fewer than d_2+1 rows match
+their commitment.
+*/
+func BingoReconstructAll(verifiers []Verifier, set *kzg.KzgShareSetup, m int, d_2 int, cm []kyber.Point) (*Reconstruction, error) {
+	r := &Reconstruction{}
+	var rows [][]kyber.Scalar
+	for i := 0; i < len(verifiers) && i < len(cm); i++ {
+		p := verifiers[i].polynomial
+		if len(p.Coefficients()) == 0 || len(p.Coefficients_2()) == 0 {
+			continue
+		}
+		if !kzg.KZGCommits(set, p.Coefficients(), p.Coefficients_2()).Equal(cm[i]) {
+			continue
+		}
+		r.Verified = append(r.Verified, i)
+		if len(rows) < d_2+1 {
+			r.Used = append(r.Used, i)
+			rows = append(rows, p.Coefficients())
+		}
+	}
+	if len(rows) < d_2+1 {
+		return nil, fmt.Errorf("vss: %d valid rows, %d are needed", len(rows), d_2+1)
+	}
+
+	secrets, err := RecoverSecrets(set.ReturnSuite(), r.Used, rows, m)
+	if err != nil {
+		return nil, err
+	}
+	r.Secrets = make(map[int]kyber.Scalar, m)
+	for k, s := range secrets {
+		r.Secrets[k] = s
+	}
+	return r, nil
+}
+
+/*
+This function recovers the secrets φ(-k, 0), k = 0..m-1, from the rows φ(X, i) of the
+participants ids. The rows are combined into φ(X, 0) with the Lagrange coefficients at Y = 0,
+which is then evaluated at every -k. The rows are not checked against the commitments.
+*/
+func RecoverSecrets(g pairing.Suite, ids []int, rows [][]kyber.Scalar, m int) ([]kyber.Scalar, error) {
+	if len(ids) != len(rows) || len(ids) == 0 {
+		return nil, fmt.Errorf("vss: %d rows for %d participants", len(rows), len(ids))
+	}
+
+	y := make([]kyber.Scalar, len(ids))
+	for j, i := range ids {
+		y[j] = g.G1().Scalar().SetInt64(int64(i))
+	}
+	l, err := polynomial.NewLagrange(g, y)
+	if err != nil {
+		return nil, err
+	}
+
+	// φ(X, 0) = Σ_j λ_j(0) φ(X, ids[j])
+	var row0 []kyber.Scalar
+	t := g.G1().Scalar()
+	for j, lambda := range l.At(g.G1().Scalar().Zero()) {
+		for c, v := range rows[j] {
+			if c == len(row0) {
+				row0 = append(row0, g.G1().Scalar().Zero())
+			}
+			row0[c].Add(row0[c], t.Mul(lambda, v))
+		}
+	}
+
+	out := make([]kyber.Scalar, m)
+	for k := range out {
+		out[k] = polynomial.Evaluate(g, row0, g.G1().Scalar().Neg(g.G1().Scalar().SetInt64(int64(k))))
+	}
+	return out, nil
+}
+
+/*
+This function recovers the secrets φ(-k, 0), k = 0..m-1, from the rows φ(X, i) of the
+participants ids when up to (len(ids)-d_2-1)/2 of the rows hold arbitrary values, with no
+commitments to check them against: f corrupt rows out of 3f+1. The shares φ(-k, i) of every
+secret are decoded as a Reed–Solomon codeword of degree d_2. It also returns the participants
+whose row gave a wrong share of some secret.
+*/
+func RecoverSecretsRobust(g pairing.Suite, ids []int, rows [][]kyber.Scalar, m, d_2 int) ([]kyber.Scalar, []int, error) {
+	if len(ids) != len(rows) {
+		return nil, nil, fmt.Errorf("vss: %d rows for %d participants", len(rows), len(ids))
+	}
+
+	y := make([]kyber.Scalar, len(ids))
+	for j, i := range ids {
+		y[j] = g.G1().Scalar().SetInt64(int64(i))
+	}
+
+	out := make([]kyber.Scalar, m)
+	bad := make([]bool, len(ids))
+	shares := make([]kyber.Scalar, len(ids))
+	for k := range out {
+		neg_k := g.G1().Scalar().Neg(g.G1().Scalar().SetInt64(int64(k)))
+		for j := range rows {
+			shares[j] = polynomial.Evaluate(g, rows[j], neg_k)
+		}
+		p, errs, err := polynomial.Decode(g, y, shares, d_2+1)
+		if err != nil {
+			return nil, nil, fmt.Errorf("vss: secret %d: %w", k, err)
+		}
+		out[k] = p[0]
+		for _, j := range errs {
+			bad[j] = true
+		}
+	}
+
+	var corrupt []int
+	for j, b := range bad {
+		if b {
+			corrupt = append(corrupt, ids[j])
+		}
+	}
+	return out, corrupt, nil
 }
@@ -1,6 +1,7 @@
 package vss
 
 import (
+	poly "BingoVSS/Internal/BivPoly"
 	kzg "BingoVSS/Internal/Biv_KZG"
 	"fmt"
 	"os"
@@ -19,9 +20,37 @@ import (
 
 // }
 
+// forEachCurve runs the test once on every supported pairing curve.
+func forEachCurve(t *testing.T, test func(t *testing.T, g *Suite)) {
+	for _, curve := range Curves() {
+		g, err := NewSuiteForCurve(curve)
+		require.NoError(t, err)
+		t.Run(curve, func(t *testing.T) {
+			test(t, g)
+		})
+	}
+}
+
+func TestNewSuiteForCurve(t *testing.T) {
+	require.Equal(t, DefaultCurve, NewSuite().ReturnCurve())
+
+	for _, curve := range Curves() {
+		g, err := NewSuiteForCurve(curve)
+		require.NoError(t, err)
+		require.Equal(t, curve, g.ReturnCurve())
+	}
+
+	_, err := NewSuiteForCurve("p256")
+	require.Error(t, err)
+}
+
 func TestBingoDeal(t *testing.T) {
+	forEachCurve(t, testBingoDeal)
+}
+
+func testBingoDeal(t *testing.T, g *Suite) {
 
-	dealer := NewDealer()
+	dealer := NewDealerWithSuite(g)
 	require.True(t, dealer.id == 0)
 
 	//Create a Random number of secrets m
@@ -39,13 +68,17 @@ func TestBingoDeal(t *testing.T) {
 
 	setup, _ := kzg.NewKzgSetup(d_1+1, dealer.suite.suite)
 
-	dealer.BingoDeal(secrets, d_1, d_2, n, setup)
+	require.NoError(t, dealer.BingoDeal(secrets, d_1, d_2, n, setup))
 	require.True(t, len(dealer.sharePolys) == n+1)
 }
 
 func TestKZG(t *testing.T) {
+	forEachCurve(t, testKZG)
+}
 
-	dealer := NewDealer()
+func testKZG(t *testing.T, g *Suite) {
+
+	dealer := NewDealerWithSuite(g)
 	require.True(t, dealer.id == 0)
 
 	//Create a Random number of secrets m
@@ -67,16 +100,17 @@ func TestKZG(t *testing.T) {
 	}
 
 	trap, _ := kzg.NewKzgSetup(d_1+1, dealer.suite.suite)
-	sh_setup := kzg.NewShareSetup(trap.ReturnT_1(), trap.ReturnT_2(), trap.ReturnT_u(), dealer.suite.suite, trap.ReturnG_u(), trap.ReturnG_1(), trap.ReturnVal())
+	sh_setup := kzg.NewShareSetup(trap.ReturnT_1(), trap.ReturnT_2(), trap.ReturnT_u(), dealer.suite.suite, trap.ReturnG_u(), trap.ReturnG_1())
 
-	CM, coem, verifiers := BingoShareDealer(secrets, d_1, d_2, n, 0, dealer.ReturnSuite(), trap)
-	cm := kzg.PartialEval(trap, CM, coem, vn)
+	CM, verifiers, err := BingoShareDealer(secrets, d_1, d_2, n, 0, dealer.ReturnSuite(), trap)
+	require.NoError(t, err)
+	cm := kzg.PartialEval(trap, CM, vn)
 
 	//Step_5 : evaluate the polynomial at a specific point (for example I would evaluate it here at a=2)
 	a := dealer.ReturnSuite().suite.G1().Scalar().SetInt64(0)
 
 	// Step_6 : create an evaluation proof
-	proof, y_1, y_2, _, _ := kzg.KZGEvaluationProof(trap, verifiers[0].polynomial.Coefficients(), verifiers[0].polynomial.Coefficients_2(), a)
+	proof, y_1, y_2, _ := kzg.KZGEvaluationProof(trap, verifiers[0].polynomial.Coefficients(), verifiers[0].polynomial.Coefficients_2(), a)
 
 	// //Step_7: verify the proof
 	v := kzg.KZGVerify(sh_setup, cm, 0, proof, dealer.suite.suite.G1().Scalar().SetInt64(0), y_1, y_2) //this should return false
@@ -104,7 +138,7 @@ func BingoShareNotHonestCase(f int) {
 	}
 
 	setup, _ := kzg.NewKzgSetup(d_1+1, g.suite)
-	sh_setup := kzg.NewShareSetup(setup.ReturnT_1(), setup.ReturnT_2(), setup.ReturnT_u(), g.suite, setup.ReturnG_u(), setup.ReturnG_1(), setup.ReturnVal())
+	sh_setup := kzg.NewShareSetup(setup.ReturnT_1(), setup.ReturnT_2(), setup.ReturnT_u(), g.suite, setup.ReturnG_u(), setup.ReturnG_1())
 
 	verifiers := make([]Verifier, n+1)
 
@@ -112,9 +146,9 @@ func BingoShareNotHonestCase(f int) {
 
 	for i := 0; i <= n+1; i++ {
 		if i == 0 {
-			CM, coem, ver := BingoShareDealer(secrets, d_1, d_2, n, 0, *g, setup)
+			CM, ver, _ := BingoShareDealer(secrets, d_1, d_2, n, 0, *g, setup)
 			verifiers = ver
-			cm = kzg.PartialEval(setup, CM, coem, vn)
+			cm = kzg.PartialEval(setup, CM, vn)
 		} else {
 			if i < 2*f+1 {
 				verifiers[i-1].UpdateStatus("not correct polynomials")
@@ -139,7 +173,7 @@ func BingoShareNotHonestCase(f int) {
 	}
 
 	for i := 0; i < len(secrets); i++ {
-		BingoReconstruct(verifiers, 0, sh_setup, i, d_2, cm)
+		_, _ = BingoReconstruct(verifiers, 0, sh_setup, i, d_2, cm)
 		// require.True(t, secrets[i].s.Equal(secret))
 
 	}
@@ -213,7 +247,7 @@ func BingoShareHonestCase(f int) {
 	}
 
 	setup, _ := kzg.NewKzgSetup(d_1+1, g.suite)
-	sh_setup := kzg.NewShareSetup(setup.ReturnT_1(), setup.ReturnT_2(), setup.ReturnT_u(), g.suite, setup.ReturnG_u(), setup.ReturnG_1(), setup.ReturnVal())
+	sh_setup := kzg.NewShareSetup(setup.ReturnT_1(), setup.ReturnT_2(), setup.ReturnT_u(), g.suite, setup.ReturnG_u(), setup.ReturnG_1())
 
 	verifiers := make([]Verifier, n+1)
 
@@ -225,9 +259,9 @@ func BingoShareHonestCase(f int) {
 			bingo, _ := os.OpenFile("test_BingoShare64.txt", os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
 			start := time.Now()
 
-			CM, coem, ver := BingoShareDealer(secrets, d_1, d_2, n, 0, *g, setup)
+			CM, ver, _ := BingoShareDealer(secrets, d_1, d_2, n, 0, *g, setup)
 			verifiers = ver
-			cm = kzg.PartialEval(setup, CM, coem, vn)
+			cm = kzg.PartialEval(setup, CM, vn)
 			elapsed := time.Since(start)
 			_, _ = bingo.WriteString(fmt.Sprintf("BingoShare of %d took %v to execute\n", f, elapsed))
 
@@ -255,8 +289,11 @@ func BingoShareHonestCase(f int) {
 }
 
 func TestBingoShareNotHonestCase(t *testing.T) {
+	forEachCurve(t, testBingoShareNotHonestCase)
+}
+
+func testBingoShareNotHonestCase(t *testing.T, g *Suite) {
 
-	g := NewSuite()
 	f := 2
 	m := f + 1
 	secrets := make([]Secret, m+1)
@@ -274,7 +311,7 @@ func TestBingoShareNotHonestCase(t *testing.T) {
 	}
 
 	setup, _ := kzg.NewKzgSetup(d_1+1, g.suite)
-	sh_setup := kzg.NewShareSetup(setup.ReturnT_1(), setup.ReturnT_2(), setup.ReturnT_u(), g.suite, setup.ReturnG_u(), setup.ReturnG_1(), setup.ReturnVal())
+	sh_setup := kzg.NewShareSetup(setup.ReturnT_1(), setup.ReturnT_2(), setup.ReturnT_u(), g.suite, setup.ReturnG_u(), setup.ReturnG_1())
 
 	verifiers := make([]Verifier, n+1)
 
@@ -282,9 +319,10 @@ func TestBingoShareNotHonestCase(t *testing.T) {
 
 	for i := 0; i <= n+1; i++ {
 		if i == 0 {
-			CM, coem, ver := BingoShareDealer(secrets, d_1, d_2, n, 0, *g, setup)
+			CM, ver, err := BingoShareDealer(secrets, d_1, d_2, n, 0, *g, setup)
+			require.NoError(t, err)
 			verifiers = ver
-			cm = kzg.PartialEval(setup, CM, coem, vn)
+			cm = kzg.PartialEval(setup, CM, vn)
 		} else {
 			if i < 2*f+1 {
 				verifiers[i-1].UpdateStatus("not correct polynomials")
@@ -309,7 +347,7 @@ func TestBingoShareNotHonestCase(t *testing.T) {
 	}
 
 	for i := 0; i < len(secrets); i++ {
-		BingoReconstruct(verifiers, 0, sh_setup, i, d_2, cm)
+		_, _ = BingoReconstruct(verifiers, 0, sh_setup, i, d_2, cm)
 		// require.True(t, secrets[i].s.Equal(secret))
 
 	}
@@ -317,7 +355,10 @@ func TestBingoShareNotHonestCase(t *testing.T) {
 }
 
 func TestBingoShareHonestCase(t *testing.T) {
-	g := NewSuite()
+	forEachCurve(t, testBingoShareHonestCase)
+}
+
+func testBingoShareHonestCase(t *testing.T, g *Suite) {
 
 	f := 2
 
@@ -338,7 +379,7 @@ func TestBingoShareHonestCase(t *testing.T) {
 	}
 
 	setup, _ := kzg.NewKzgSetup(d_1+1, g.suite)
-	sh_setup := kzg.NewShareSetup(setup.ReturnT_1(), setup.ReturnT_2(), setup.ReturnT_u(), g.suite, setup.ReturnG_u(), setup.ReturnG_1(), setup.ReturnVal())
+	sh_setup := kzg.NewShareSetup(setup.ReturnT_1(), setup.ReturnT_2(), setup.ReturnT_u(), g.suite, setup.ReturnG_u(), setup.ReturnG_1())
 
 	verifiers := make([]Verifier, n+1)
 
@@ -347,9 +388,10 @@ func TestBingoShareHonestCase(t *testing.T) {
 	for i := 0; i <= n+1; i++ {
 		if i == 0 {
 
-			CM, coem, ver := BingoShareDealer(secrets, d_1, d_2, n, 0, *g, setup)
+			CM, ver, err := BingoShareDealer(secrets, d_1, d_2, n, 0, *g, setup)
+			require.NoError(t, err)
 			verifiers = ver
-			cm = kzg.PartialEval(setup, CM, coem, vn)
+			cm = kzg.PartialEval(setup, CM, vn)
 
 		} else {
 			BingoShare(verifiers, d_1, d_2, n, i-1, cm, *g, sh_setup, setup)
@@ -366,7 +408,182 @@ func TestBingoShareHonestCase(t *testing.T) {
 	//now is reconstruct time
 
 	for i := 0; i < len(secrets); i++ {
-		BingoReconstruct(verifiers, 0, sh_setup, i, d_2, cm)
+		secret, err := BingoReconstruct(verifiers, 0, sh_setup, i, d_2, cm)
+		require.NoError(t, err)
+		require.True(t, secrets[i].s.Equal(secret))
+	}
+
+}
+
+func TestBingoShareMissingProofs(t *testing.T) {
+	g := NewSuite()
+	f := 1
+	d_1, d_2, n := 2*f+1, f, 3*f+1
+
+	setup, err := kzg.NewKzgSetup(d_1+1, g.suite)
+	require.NoError(t, err)
+	sh_setup := kzg.NewShareSetup(setup.ReturnT_1(), setup.ReturnT_2(), setup.ReturnT_u(), g.suite, setup.ReturnG_u(), setup.ReturnG_1())
+
+	vn := make([]kyber.Scalar, n+1)
+	for i := range vn {
+		vn[i] = g.suite.G1().Scalar().SetInt64(int64(i))
+	}
+	CM, verifiers, err := BingoShareDealer([]Secret{*NewSecret(1, *g)}, d_1, d_2, n, 0, *g, setup)
+	require.NoError(t, err)
+	cm := kzg.PartialEval(setup, CM, vn)
+
+	// No row points and no column points arrived yet: report it instead of waiting
+	verifiers[1].UpdateStatus("has sent rows")
+	require.Error(t, BingoShare(verifiers, d_1, d_2, n, 1, cm, *g, sh_setup, setup))
+	verifiers[2].UpdateStatus("missing polynomial")
+	require.Error(t, BingoShare(verifiers, d_1, d_2, n, 2, cm, *g, sh_setup, setup))
+	require.Equal(t, "missing polynomial", verifiers[2].SendStatus())
+
+	require.Error(t, BingoShare(verifiers, d_1, d_2, n, n+1, cm, *g, sh_setup, setup))
+
+	// Too few rows to reconstruct from
+	_, err = BingoReconstruct(verifiers[:d_2+1], 0, sh_setup, 0, d_2, cm)
+	require.Error(t, err)
+
+	// Column points that claim the same index give an error instead of a row
+	dup := make([]kzg.Proof, d_1+1)
+	for c := range dup {
+		dup[c] = *kzg.NewProof(1, g.suite.G1().Point().Base(), g.suite.G1().Scalar().SetInt64(int64(c)), g.suite.G1().Scalar().One())
+	}
+	_, _, err = InterpolateRows(dup, sh_setup, d_1+1)
+	require.Error(t, err)
+}
+
+func TestBingoReconstructAll(t *testing.T) {
+	forEachCurve(t, testBingoReconstructAll)
+}
+
+func testBingoReconstructAll(t *testing.T, g *Suite) {
+	f := 2
+	d_2 := f
+	setup, err := kzg.NewKzgSetup(2*f+2, g.suite)
+	require.NoError(t, err)
+	sh_setup := kzg.NewShareSetup(setup.ReturnT_1(), setup.ReturnT_2(), setup.ReturnT_u(), g.suite, setup.ReturnG_u(), setup.ReturnG_1())
+
+	secrets := make([]Secret, f+1)
+	for i := range secrets {
+		secrets[i] = *NewSecret(i, *g)
+	}
+	verifiers, cm := shareHonestly(t, g, secrets, f, setup, sh_setup)
+
+	r, err := BingoReconstructAll(verifiers, sh_setup, len(secrets), d_2, cm)
+	require.NoError(t, err)
+	require.Len(t, r.Secrets, len(secrets))
+	require.Equal(t, []int{0, 1, 2, 3, 4, 5, 6, 7}, r.Verified)
+	require.Equal(t, []int{0, 1, 2}, r.Used)
+	for k := range secrets {
+		require.True(t, secrets[k].s.Equal(r.Secrets[k]), "secret %d", k)
+		one, err := BingoReconstruct(verifiers, 0, sh_setup, k, d_2, cm)
+		require.NoError(t, err)
+		require.True(t, one.Equal(r.Secrets[k]))
+	}
+
+	// A forged row is left out of the verified set
+	forged := *verifiers[1].SendPolynomials()
+	row := append([]kyber.Scalar{}, forged.Coefficients()...)
+	row[0] = g.suite.G1().Scalar().Add(row[0], g.suite.G1().Scalar().One())
+	verifiers[1].polynomial = *poly.NewPriPoly(g.suite, f, row, forged.Coefficients_2(), nil)
+	r, err = BingoReconstructAll(verifiers, sh_setup, len(secrets), d_2, cm)
+	require.NoError(t, err)
+	require.Equal(t, []int{0, 2, 3, 4, 5, 6, 7}, r.Verified)
+	require.Equal(t, []int{0, 2, 3}, r.Used)
+	for k := range secrets {
+		require.True(t, secrets[k].s.Equal(r.Secrets[k]), "secret %d", k)
+	}
+
+	// Too few valid rows
+	_, err = BingoReconstructAll(verifiers[:d_2+1], sh_setup, len(secrets), d_2, cm)
+	require.ErrorContains(t, err, "2 valid rows, 3 are needed")
+
+	_, err = RecoverSecrets(g.suite, []int{1, 2}, [][]kyber.Scalar{row}, 1)
+	require.Error(t, err)
+}
+
+func BenchmarkReconstruct(b *testing.B) {
+	g := NewSuite()
+	f := 5
+	d_2 := f
+	setup, _ := kzg.NewKzgSetup(2*f+2, g.suite)
+	sh_setup := kzg.NewShareSetup(setup.ReturnT_1(), setup.ReturnT_2(), setup.ReturnT_u(), g.suite, setup.ReturnG_u(), setup.ReturnG_1())
+
+	secrets := make([]Secret, f+1)
+	for i := range secrets {
+		secrets[i] = *NewSecret(i, *g)
 	}
+	verifiers, cm := shareHonestly(&testing.T{}, g, secrets, f, setup, sh_setup)
+
+	b.Run("per index", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			for k := range secrets {
+				if _, err := BingoReconstruct(verifiers, 0, sh_setup, k, d_2, cm); err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+	})
+	b.Run("all at once", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if _, err := BingoReconstructAll(verifiers, sh_setup, len(secrets), d_2, cm); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func TestRecoverSecretsRobust(t *testing.T) {
+	forEachCurve(t, testRecoverSecretsRobust)
+}
+
+func testRecoverSecretsRobust(t *testing.T, g *Suite) {
+	f := 2
+	d_2 := f
+	setup, err := kzg.NewKzgSetup(2*f+2, g.suite)
+	require.NoError(t, err)
+	sh_setup := kzg.NewShareSetup(setup.ReturnT_1(), setup.ReturnT_2(), setup.ReturnT_u(), g.suite, setup.ReturnG_u(), setup.ReturnG_1())
+
+	secrets := make([]Secret, f+1)
+	for i := range secrets {
+		secrets[i] = *NewSecret(i, *g)
+	}
+	verifiers, _ := shareHonestly(t, g, secrets, f, setup, sh_setup)
+
+	// the rows of participants 1..3f+1, as restored from backups without commitments
+	ids := make([]int, 3*f+1)
+	rows := make([][]kyber.Scalar, len(ids))
+	for j := range ids {
+		ids[j] = j + 1
+		rows[j] = append([]kyber.Scalar{}, verifiers[j+1].polynomial.Coefficients()...)
+	}
+
+	got, corrupt, err := RecoverSecretsRobust(g.suite, ids, rows, len(secrets), d_2)
+	require.NoError(t, err)
+	require.Empty(t, corrupt)
+	for k := range secrets {
+		require.True(t, secrets[k].s.Equal(got[k]), "secret %d", k)
+	}
+
+	// f rows are garbage
+	for c := range rows[2] {
+		rows[2][c] = g.suite.G1().Scalar().Pick(g.suite.RandomStream())
+	}
+	rows[5][1] = g.suite.G1().Scalar().Zero()
+	got, corrupt, err = RecoverSecretsRobust(g.suite, ids, rows, len(secrets), d_2)
+	require.NoError(t, err)
+	require.Equal(t, []int{3, 6}, corrupt)
+	for k := range secrets {
+		require.True(t, secrets[k].s.Equal(got[k]), "secret %d", k)
+	}
+
+	// f+1 are too many
+	rows[0][0] = g.suite.G1().Scalar().One()
+	_, _, err = RecoverSecretsRobust(g.suite, ids, rows, len(secrets), d_2)
+	require.ErrorContains(t, err, "too many errors")
 
+	_, _, err = RecoverSecretsRobust(g.suite, ids[1:], rows, len(secrets), d_2)
+	require.Error(t, err)
 }
@@ -1,75 +1,106 @@
 package main
 
 import (
+	vss "BingoVSS/Bingo"
+	kzg "BingoVSS/Internal/Biv_KZG"
+	transport "BingoVSS/Internal/Transport"
 	"bufio"
 	"bytes"
+	"encoding/binary"
+	"errors"
 	"flag"
 	"fmt"
 	"log"
 	"os"
 	"strings"
 
+	"github.com/drand/kyber"
 	"github.com/gorilla/websocket"
 )
 
 var addr = flag.String("addr", "localhost:8080", "http service address")
-var clientID = flag.String("id", "0", "client ID")
+var keyPath = flag.String("key", "keys/client-0.json", "identity of this client, as generated by the server with -genkeys")
+var directoryPath = flag.String("directory", "keys/directory.json", "public directory of all parties")
+var curve = flag.String("curve", vss.DefaultCurve, "pairing curve the server runs the protocol on (bn256 or bls12-381)")
+var srsPath = flag.String("srs", "", "SRS file to verify against instead of the one sent by the server")
+
+// The kinds of payload the server sends, see the server for their format.
+const (
+	frameText byte = iota
+	frameSetup
+	frameMessage
+)
+
+// share is what this client learned so far; every point is verified before it is counted.
+type share struct {
+	g         *vss.Suite
+	id        int // our index among the clients, which is also our row
+	n         int
+	setup     *kzg.KzgSetup
+	sh        *kzg.KzgShareSetup
+	cm        []kyber.Point // the row commitments cm[0..n]
+	d_1       int
+	d_2       int
+	rowPoints map[int]bool // the clients whose row point we verified
+	colPoints map[int]bool // the clients whose column point we verified
+}
 
 func main() {
 	flag.Parse()
 	log.SetFlags(0)
 
+	g, err := vss.NewSuiteForCurve(*curve)
+	if err != nil {
+		log.Fatal(err)
+	}
+	identity, err := transport.LoadIdentity(*keyPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	directory, err := transport.LoadDirectory(*directoryPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	dealer, ok := directory[0]
+	if !ok {
+		log.Fatal("the directory has no dealer")
+	}
+
+	st := &share{g: g, id: identity.ReturnID() - 1, n: len(directory) - 1, rowPoints: map[int]bool{}, colPoints: map[int]bool{}}
+	if *srsPath != "" {
+		if st.setup, err = kzg.LoadSetup(*srsPath, g.ReturnSuite()); err != nil {
+			log.Fatal(err)
+		}
+		st.sh = shareSetup(st.setup, g)
+	}
+
 	conn, _, err := websocket.DefaultDialer.Dial("ws://"+*addr, nil)
 	if err != nil {
 		log.Fatal("Dial:", err)
 	}
 	defer conn.Close()
 
-	// Sending the initial message (the client's ID)
-	err = conn.WriteMessage(websocket.TextMessage, []byte(*clientID))
+	// The handshake proves our identity to the dealer and checks that we talk to the dealer
+	sc, err := transport.Dial(transport.NewWebSocketStream(conn), identity, dealer)
 	if err != nil {
-		log.Println("Write:", err)
+		log.Println("Handshake:", err)
 		conn.Close()
 		return
 	}
+	fmt.Printf("Connected as client %d\n", st.id)
 
-	rowCount := 0 // Counter for "row"
-	colCount := 0 // Counter for "column"
-
-	// Read messages from the server and print to stdout
+	// Read messages from the server and verify every share before using it
 	go func() {
 		for {
-			messageType, message, err := conn.ReadMessage()
+			frame, err := sc.Receive()
 			if err != nil {
 				log.Println("Read:", err)
 				conn.Close()
 				return
 			}
 
-			if messageType == websocket.TextMessage {
-				fmt.Printf("%s\n", message)
-
-				// Count the occurrence of "row"
-				rowCount += bytes.Count(message, []byte("row"))
-				colCount += bytes.Count(message, []byte("column"))
-
-				// Do something when "row" appears a certain number of times
-				if rowCount >= 2 {
-					fmt.Println("=============================================")
-					fmt.Println("I have enough shares to reconstruct my column")
-					fmt.Println("=============================================")
-
-					// Perform some action here
-					rowCount = 0 // Reset counter
-				}
-				if colCount >= 4+1 {
-					fmt.Println("=============================================")
-					fmt.Println("I have enough shares to reconstruct my column")
-					fmt.Println("=============================================")
-
-					// Perform some action here
-					colCount = 0 // Reset counter
-				}
+			if err := st.handle(frame); err != nil {
+				fmt.Println("Rejected:", err)
 			}
 		}
 	}()
@@ -83,7 +114,7 @@ func main() {
 			break
 		}
 
-		err = conn.WriteMessage(websocket.TextMessage, []byte(text))
+		err = sc.Send([]byte(text))
 		if err != nil {
 			log.Println("Write:", err)
 			conn.Close()
@@ -97,3 +128,133 @@ func main() {
 		return
 	}
 }
+
+func shareSetup(setup *kzg.KzgSetup, g *vss.Suite) *kzg.KzgShareSetup {
+	return kzg.NewShareSetup(setup.ReturnT_1(), setup.ReturnT_2(), setup.ReturnT_u(), g.ReturnSuite(), setup.ReturnG_u(), setup.ReturnG_1())
+}
+
+// handle processes one payload received from the server.
+func (st *share) handle(frame []byte) error {
+	if len(frame) == 0 {
+		return errors.New("empty frame")
+	}
+
+	switch frame[0] {
+	case frameText:
+		fmt.Printf("%s\n", frame[1:])
+		return nil
+	case frameSetup:
+		if st.setup != nil {
+			return nil
+		}
+		setup, err := kzg.ReadSetup(bytes.NewReader(frame[1:]), st.g.ReturnSuite())
+		if err != nil {
+			return err
+		}
+		fmt.Println("Using the SRS sent by the dealer, pass -srs to verify against a shared one")
+		st.setup = setup
+		st.sh = shareSetup(setup, st.g)
+		return nil
+	case frameMessage:
+		r := bytes.NewReader(frame[1:])
+		var from int32
+		if err := binary.Read(r, binary.BigEndian, &from); err != nil {
+			return err
+		}
+		m, err := vss.ReadMessage(r, st.g)
+		if err != nil {
+			return err
+		}
+		if r.Len() != 0 {
+			return fmt.Errorf("%d trailing bytes after %s message", r.Len(), m.Type())
+		}
+		return st.handleMessage(int(from), m)
+	}
+	return fmt.Errorf("unknown frame kind %d", frame[0])
+}
+
+func (st *share) handleMessage(from int, m vss.Message) error {
+	if st.setup == nil {
+		return fmt.Errorf("%s message before the SRS", m.Type())
+	}
+	if _, ok := m.(*vss.CommitmentMessage); !ok && st.cm == nil {
+		return fmt.Errorf("%s message before the commitment", m.Type())
+	}
+
+	switch m := m.(type) {
+	case *vss.CommitmentMessage:
+		if from != -1 || st.cm != nil {
+			return errors.New("unexpected commitment")
+		}
+		if len(st.setup.ReturnT_1()) < 2*(len(m.Commitments)-1)+1 {
+			return fmt.Errorf("commitment of length %d exceeds the SRS", len(m.Commitments))
+		}
+		vn := make([]kyber.Scalar, st.n+1)
+		for i := range vn {
+			vn[i] = st.g.ReturnSuite().G1().Scalar().SetInt64(int64(i))
+		}
+		// The demo uses degree f in Y and 2f in X
+		st.d_2 = len(m.Commitments) - 1
+		st.d_1 = 2 * st.d_2
+		st.cm = kzg.PartialEval(st.setup, m.Commitments, vn)
+		fmt.Printf("Received the commitment to a polynomial of degree %d in Y\n", st.d_2)
+
+	case *vss.PolynomialMessage:
+		if from != -1 {
+			return fmt.Errorf("row polynomial from client %d", from)
+		}
+		if !kzg.KZGCommits(st.sh, m.Row, m.RowHiding).Equal(st.cm[st.id]) {
+			return errors.New("the row polynomial does not match the commitment")
+		}
+		fmt.Println("=============================================")
+		fmt.Println("My row polynomial matches the commitment")
+		fmt.Println("=============================================")
+
+	case *vss.RowPointMessage:
+		// φ(i, j) is the row of the sender evaluated at our index
+		if err := st.checkSender(from); err != nil {
+			return err
+		}
+		if !kzg.KZGVerify(st.sh, st.cm, from, m.Proof, st.scalar(st.id), m.Y_1, m.Y_2) {
+			return fmt.Errorf("invalid row point from client %d", from)
+		}
+		st.rowPoints[from] = true
+		fmt.Printf("Verified the <<row>> point of client %d\n", from)
+		if len(st.rowPoints) == st.d_2+1 {
+			fmt.Println("=============================================")
+			fmt.Println("I have enough shares to reconstruct my column")
+			fmt.Println("=============================================")
+		}
+
+	case *vss.ColumnPointMessage:
+		// φ(j, i) is our own row evaluated at the index of the sender
+		if err := st.checkSender(from); err != nil {
+			return err
+		}
+		if !kzg.KZGVerify(st.sh, st.cm, st.id, m.Proof, st.scalar(from), m.Y_1, m.Y_2) {
+			return fmt.Errorf("invalid column point from client %d", from)
+		}
+		st.colPoints[from] = true
+		fmt.Printf("Verified the <<column>> point of client %d\n", from)
+		if len(st.colPoints) == st.d_1+1 {
+			fmt.Println("=============================================")
+			fmt.Println("I have enough shares to reconstruct my row")
+			fmt.Println("=============================================")
+		}
+
+	default:
+		return fmt.Errorf("unexpected %s message", m.Type())
+	}
+	return nil
+}
+
+func (st *share) checkSender(from int) error {
+	if from < 0 || from >= st.n {
+		return fmt.Errorf("message from unknown client %d", from)
+	}
+	return nil
+}
+
+func (st *share) scalar(i int) kyber.Scalar {
+	return st.g.ReturnSuite().G1().Scalar().SetInt64(int64(i))
+}
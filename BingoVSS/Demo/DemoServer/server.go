@@ -3,12 +3,21 @@ package main
 import (
 	vss "BingoVSS/Bingo"
 	kzg "BingoVSS/Internal/Biv_KZG"
+	transport "BingoVSS/Internal/Transport"
+	store "BingoVSS/Store"
+	"bytes"
+	"crypto/rand"
+	"encoding/binary"
 	"encoding/hex"
+	"errors"
+	"flag"
 	"fmt"
+	"io/fs"
 	"log"
 	"net/http"
+	"os"
+	"path/filepath"
 	"strconv"
-	"strings"
 	"sync"
 	"time"
 
@@ -16,8 +25,14 @@ import (
 	"github.com/gorilla/websocket"
 )
 
+// client is an authenticated participant; id is its index in the protocol, proven during the handshake.
+type client struct {
+	id   string
+	conn *transport.Conn
+}
+
 var (
-	clients  = make(map[*websocket.Conn]string) // client connection and client ID
+	clients  = make(map[*websocket.Conn]client) // client connection and client ID
 	mu       sync.Mutex
 	upgrader = websocket.Upgrader{
 		ReadBufferSize:  1024,
@@ -27,34 +42,140 @@ var (
 	maxClientCount   = 4 // Maximum number of clients
 	reconstructCount = 0
 	consistent       = 0
+	srsPath          = flag.String("srs", "", "SRS file to load instead of running a local setup")
+	curve            = flag.String("curve", vss.DefaultCurve, "pairing curve to run the protocol on (bn256 or bls12-381)")
+	keysDir          = flag.String("keys", "keys", "directory with the dealer identity and the directory of all parties")
+	genKeys          = flag.Bool("genkeys", false, "generate identities for the dealer and every client in -keys and exit")
+	storeDir         = flag.String("store", "", "directory to keep the encrypted shares of the clients in, under the passphrase in "+passphraseEnv)
+	identity         *transport.Identity
+	directory        transport.Directory
+	shares           *store.Store
+	setup            *kzg.KzgSetup
+	restored         []vss.Verifier // the verifiers of the dealing kept in -store, nil if there is none
+	restoredCM       []kyber.Point  // the row commitments of that dealing
+)
+
+// passphraseEnv names the environment variable holding the passphrase of the -store directory.
+const passphraseEnv = "BINGO_STORE_PASSPHRASE"
+
+// srsFile is the file in the -store directory that keeps a local setup, so that the stored shares still verify after a restart.
+const srsFile = "srs.bin"
+
+// The degrees of the dealing in X and in Y.
+const (
+	d_1 = 4
+	d_2 = 2
 )
 
 func main() {
+	flag.Parse()
+	if _, err := vss.NewSuiteForCurve(*curve); err != nil {
+		log.Fatal(err)
+	}
+
+	if *genKeys {
+		if err := generateKeys(*keysDir, maxClientCount); err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("Generated the identities of the dealer and %d clients in %s\n", maxClientCount, *keysDir)
+		return
+	}
+
+	var err error
+	if identity, err = transport.LoadIdentity(filepath.Join(*keysDir, "dealer.json")); err != nil {
+		log.Fatal(err)
+	}
+	if directory, err = transport.LoadDirectory(filepath.Join(*keysDir, "directory.json")); err != nil {
+		log.Fatal(err)
+	}
+
+	if *storeDir != "" {
+		if shares, err = store.Open(*storeDir, []byte(os.Getenv(passphraseEnv)), store.DefaultKDF); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	// The stored shares are checked against the setup before any client connects
+	g, _ := vss.NewSuiteForCurve(*curve)
+	if setup, err = loadSetup(d_1+1, g); err != nil {
+		log.Fatal(err)
+	}
+	if restored, restoredCM, err = loadShares(g); err != nil {
+		log.Fatal(err)
+	}
+	if restored != nil {
+		fmt.Printf("Restored and verified the shares of %d clients from %s\n", maxClientCount, *storeDir)
+	}
+
 	http.HandleFunc("/", handleConnection)
-	err := http.ListenAndServe(":8080", nil)
+	err = http.ListenAndServe(":8080", nil)
 	if err != nil {
 		log.Println(err)
 		return
 	}
 }
 
+/*
+Every payload sent to a client starts with one byte telling what follows:
+
+	frameText     a line of text for the operator of the client
+	frameSetup    the SRS in the format of kzg.WriteSetup
+	frameMessage  int32 index of the client the message comes from, then a BingoShare message
+	              in the format of vss.WriteMessage
+
+The dealer relays the row and column points of every client, so the sender of a message is
+carried in the frame.
+*/
+const (
+	frameText byte = iota
+	frameSetup
+	frameMessage
+)
+
 func broadcast(message string) {
+	broadcastFrame(textFrame(message))
+}
+
+func broadcastFrame(frame []byte) {
 	mu.Lock()
 	defer mu.Unlock()
-	for conn := range clients {
-		if err := conn.WriteMessage(websocket.TextMessage, []byte(message)); err != nil {
+	for _, c := range clients {
+		if err := c.conn.Send(frame); err != nil {
 			log.Printf("Error broadcasting message to client: %v", err)
 		}
 	}
 }
 
+func textFrame(message string) []byte {
+	return append([]byte{frameText}, message...)
+}
+
+func setupFrame(setup *kzg.KzgSetup) ([]byte, error) {
+	buf := bytes.NewBuffer([]byte{frameSetup})
+	if err := kzg.WriteSetup(buf, setup); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// messageFrame encodes a message that client from sends to another client through the dealer.
+// The dealer itself sends as client -1.
+func messageFrame(from int, m vss.Message) ([]byte, error) {
+	buf := bytes.NewBuffer([]byte{frameMessage})
+	_ = binary.Write(buf, binary.BigEndian, int32(from))
+	if err := vss.WriteMessage(buf, m); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
 func closeAllClients() {
 	mu.Lock()
 	defer mu.Unlock()
 	for conn := range clients {
 		conn.Close()
 	}
-	clients = make(map[*websocket.Conn]string)
+	clients = make(map[*websocket.Conn]client)
 	clientCount = 0
 }
 
@@ -81,34 +202,48 @@ func handleConnection(w http.ResponseWriter, r *http.Request) {
 		log.Println(err)
 		return
 	}
-	defer closeAllClients()
 
-	_, msg, err := conn.ReadMessage()
+	// The client proves its identity with its long-term key instead of announcing an ID
+	sc, err := transport.Accept(transport.NewWebSocketStream(conn), identity, directory)
 	if err != nil {
 		log.Println(err)
+		rejectClient(conn)
 		return
 	}
 
-	clientID := string(msg)
+	// Transport index 0 is the dealer, so client i of the demo holds the identity i+1
+	clientID := strconv.Itoa(sc.Peer() - 1)
 	mu.Lock()
-	clients[conn] = clientID
+	for _, c := range clients {
+		if c.id == clientID {
+			mu.Unlock()
+			log.Printf("Client ID %s is already connected", clientID)
+			rejectClient(conn)
+			return
+		}
+	}
+	clients[conn] = client{id: clientID, conn: sc}
 	mu.Unlock()
+	defer closeAllClients()
 
 	// Display all connected client IDs
 	mu.Lock()
 	fmt.Println("Pariticipants:")
-	for _, id := range clients {
-		fmt.Println("We have a new participant = ", id)
+	for _, c := range clients {
+		fmt.Println("We have a new participant = ", c.id)
 	}
 	mu.Unlock()
 
 	// If maxClientCount reached, broadcast the message
-	if clientCount == maxClientCount {
+	if clientCount == maxClientCount && restored != nil {
+		broadcast("Maximum client limit reached")
+		resumeDealing(reconstructionChannel)
+	} else if clientCount == maxClientCount {
 		broadcast("Maximum client limit reached")
 		broadcast("-----------------------------------------------------------")
 		broadcast("We will now begin the Bingo secret sharing! Are you excited?")
 		broadcast("-----------------------------------------------------------")
-		g := vss.NewSuite()
+		g, _ := vss.NewSuiteForCurve(*curve)
 		//Create a Random number of secrets m
 		m := 9
 		secrets := make([]vss.Secret, m)
@@ -117,8 +252,6 @@ func handleConnection(w http.ResponseWriter, r *http.Request) {
 		}
 		broadcast("I have create some secrets, specifically 9 secrets.")
 
-		d_1 := 4
-		d_2 := 2
 		n := maxClientCount
 
 		vn := make([]kyber.Scalar, n+1)
@@ -126,8 +259,15 @@ func handleConnection(w http.ResponseWriter, r *http.Request) {
 			vn[i] = g.ReturnSuite().G1().Scalar().SetInt64(int64(i))
 		}
 
-		setup, _ := kzg.NewKzgSetup(d_1+1, g.ReturnSuite())
-		sh_setup := kzg.NewShareSetup(setup.ReturnT_1(), setup.ReturnT_2(), setup.ReturnT_u(), g.ReturnSuite(), setup.ReturnG_u(), setup.ReturnG_1(), setup.ReturnVal())
+		sh_setup := kzg.NewShareSetup(setup.ReturnT_1(), setup.ReturnT_2(), setup.ReturnT_u(), g.ReturnSuite(), setup.ReturnG_u(), setup.ReturnG_1())
+
+		// The clients verify everything they receive against the commitment under this SRS
+		frame, err := setupFrame(setup)
+		if err != nil {
+			log.Println(err)
+			return
+		}
+		broadcastFrame(frame)
 
 		verifiers := make([]vss.Verifier, maxClientCount+1)
 
@@ -135,11 +275,15 @@ func handleConnection(w http.ResponseWriter, r *http.Request) {
 
 		for i := 0; i <= maxClientCount+1; i++ {
 			if i == 0 {
-				CM, coem, ver := vss.BingoShareDealer(secrets, d_1, d_2, n, 0, *g, setup)
+				CM, ver, err := vss.BingoShareDealer(secrets, d_1, d_2, n, 0, *g, setup)
+				if err != nil {
+					log.Println(err)
+					return
+				}
 				verifiers = ver
 				broadcast("The commitments are the following: ")
 				BroadcastCommitments(CM)
-				cm = kzg.PartialEval(setup, CM, coem, vn)
+				cm = kzg.PartialEval(setup, CM, vn)
 				broadcast("-----------------------------------------------------------")
 				// Wait for 5 seconds
 				time.Sleep(20 * time.Second)
@@ -148,7 +292,10 @@ func handleConnection(w http.ResponseWriter, r *http.Request) {
 				sendPolynomials(verifiers, maxClientCount)
 
 			} else {
-				vss.BingoShare(verifiers, d_1, d_2, n, i-1, cm, *g, sh_setup, setup)
+				if err := vss.BingoShare(verifiers, d_1, d_2, n, i-1, cm, *g, sh_setup, setup); err != nil {
+					sendToSpecificClient(strconv.Itoa(i-1), "I cannot check the polynomials given: "+err.Error())
+					continue
+				}
 
 				if verifiers[i-1].SendStatus() == ("correct polynomial") {
 
@@ -174,7 +321,10 @@ func handleConnection(w http.ResponseWriter, r *http.Request) {
 		}
 
 		for i := 0; i <= n-1; i++ {
-			vss.BingoShare(verifiers, d_1, d_2, n, i, cm, *g, sh_setup, setup)
+			if err := vss.BingoShare(verifiers, d_1, d_2, n, i, cm, *g, sh_setup, setup); err != nil {
+				sendToSpecificClient(strconv.Itoa(i), "I cannot send my columns: "+err.Error())
+				continue
+			}
 			handleSendingCol(verifiers, i, d_1, d_2, n, i-1)
 			verifiers[i].UpdateStatus("Done")
 		}
@@ -186,7 +336,10 @@ func handleConnection(w http.ResponseWriter, r *http.Request) {
 				sender := strconv.Itoa(i)
 				sendToSpecificClient(sender, "I am attempting to reconstruct my polynomial.\n"+"-----------------------------------------------------------\n")
 
-				vss.BingoShare(verifiers, d_1, d_2, n, i, cm, *g, sh_setup, setup)
+				if err := vss.BingoShare(verifiers, d_1, d_2, n, i, cm, *g, sh_setup, setup); err != nil {
+					sendToSpecificClient(sender, "I cannot reconstruct my polynomial: "+err.Error())
+					continue
+				}
 
 				verifiers[i].UpdateStatus("Done")
 				consistent++
@@ -194,6 +347,11 @@ func handleConnection(w http.ResponseWriter, r *http.Request) {
 
 		}
 
+		// The shares outlive the connections, which closeAllClients drops
+		if err := saveShares(verifiers, cm, g); err != nil {
+			log.Println(err)
+		}
+
 		if consistent == maxClientCount+1 {
 			broadcast("All the shares are correct. Therefore, the secret sharing has been completed. You can reconstruct the secrets if you want now. Just sent -Reconstruct-")
 
@@ -203,7 +361,11 @@ func handleConnection(w http.ResponseWriter, r *http.Request) {
 			<-reconstructionChannel
 
 			str := strconv.Itoa(0)
-			x := vss.BingoReconstruct(verifiers, 0, sh_setup, 0, d_2, cm)
+			x, err := vss.BingoReconstruct(verifiers, 0, sh_setup, 0, d_2, cm)
+			if err != nil {
+				broadcast("The secret cannot be reconstructed: " + err.Error())
+				return
+			}
 
 			xBytes, err := x.MarshalBinary()
 			if err != nil {
@@ -229,15 +391,13 @@ func handleConnection(w http.ResponseWriter, r *http.Request) {
 	}
 
 	for {
-		messageType, msg, err := conn.ReadMessage()
+		msg, err := sc.Receive()
 		if err != nil {
 			log.Printf("Removed client ID: %s and closing all connections\n", clientID)
 			return
 		}
 
-		if messageType == websocket.TextMessage {
-			log.Printf("Received message from client ID %s: %s\n", clientID, string(msg))
-		}
+		log.Printf("Received message from client ID %s: %s\n", clientID, string(msg))
 		receivedMessage := string(msg)
 
 		if receivedMessage == "Rec" {
@@ -256,124 +416,246 @@ func handleConnection(w http.ResponseWriter, r *http.Request) {
 func handleSendingCol(verifiers []vss.Verifier, x, d_1, d_2, n, i2 int) {
 
 	for i := 0; i < len(verifiers)-1; i++ {
-		if verifiers[i].SendProofsCol()[x].ReturnY_1() != nil {
-			a_x := verifiers[i].SendProofsCol()[x].ReturnY_1()
-			a_xi := verifiers[i].SendProofsCol()[x].ReturnY_2()
-			p := verifiers[i].SendProofsCol()[x].ReturnP()
-
+		p := verifiers[i].SendProofsCol()[x]
+		if p.ReturnY_1() != nil {
 			sender := strconv.Itoa(x)
 			receiver := strconv.Itoa(i)
 
-			pointBytes, _ := a_x.MarshalBinary()
-			a_x_s := hex.EncodeToString(pointBytes)
-
-			pointBytes, _ = a_xi.MarshalBinary()
-			a_x_si := hex.EncodeToString(pointBytes)
-
-			pointBytes, _ = p.MarshalBinary()
-			p_i := hex.EncodeToString(pointBytes)
-
-			str := "a_" + sender + " = " + a_x_s + "\n a'_" + sender + " = " + a_x_si + "\n p_" + sender + " = " + p_i
+			msg := &vss.ColumnPointMessage{Y_1: p.ReturnY_1(), Y_2: p.ReturnY_2(), Proof: p.ReturnP()}
 
 			sendToSpecificClient(sender, "sending <<column>> to "+receiver+"\n"+"-----------------------------------------------------------\n")
 
-			sendToSpecificClient(receiver, "<<column>> from "+sender+"\n"+str+"-----------------------------------------------------------\n")
-
+			sendMessageToClient(receiver, x, msg)
 		}
 	}
 }
 
-func arrayToString(scalars []kyber.Scalar) string {
-	var elements []string
-	for _, scalar := range scalars {
-		scalarBytes, err := scalar.MarshalBinary()
-		if err != nil {
-			panic(err)
-		}
-
-		// Convert the individual scalar's bytes to hex string
-		scalarHex := hex.EncodeToString(scalarBytes)
-
-		// Append this hex string to the list of elements
-		elements = append(elements, scalarHex)
-	}
-
-	// Combine all the hex strings separated by a space
-	combinedString := strings.Join(elements, " ")
-
-	return combinedString
-}
-
 func sendPolynomials(verifiers []vss.Verifier, maxClientCount int) {
 	for i := 0; i < maxClientCount; i++ {
 		str := strconv.Itoa(i)
-		a_x := arrayToString(verifiers[i].SendPolynomials().Coefficients())
-		a_xj := arrayToString(verifiers[i].SendPolynomials().Coefficients_2())
+		msg := &vss.PolynomialMessage{
+			Row:       verifiers[i].SendPolynomials().Coefficients(),
+			RowHiding: verifiers[i].SendPolynomials().Coefficients_2(),
+		}
 
-		str_all := " rows \n" + "a(x)= " + a_x + " \n" + "a'(x)= " + a_xj
 		sendToSpecificClient(str, "-----------------------------------------------------------")
-		sendToSpecificClient(str, str_all)
+		sendMessageToClient(str, -1, msg)
 		sendToSpecificClient(str, "-----------------------------------------------------------")
 	}
 }
 
 func BroadcastCommitments(CM []kyber.Point) {
-
-	for i := 0; i < len(CM); i++ {
-		pointBytes, err := CM[i].MarshalBinary()
-		if err != nil {
-			panic(err)
-		}
-
-		// Convert the byte slice to a hex string
-		pointHex := hex.EncodeToString(pointBytes)
-		str := strconv.Itoa(i)
-
-		broadcast("<commits> " + str + " =" + pointHex)
-		broadcast("-----------------------------------------------------------")
-
+	frame, err := messageFrame(-1, &vss.CommitmentMessage{Commitments: CM})
+	if err != nil {
+		log.Println(err)
+		return
 	}
+
+	broadcastFrame(frame)
+	broadcast("-----------------------------------------------------------")
 }
 
 func handleSending(verifiers []vss.Verifier, x, d_1, d_2, n, i2 int) {
 
 	for i := 0; i < len(verifiers)-1; i++ {
-		if verifiers[i].SendProofsRow()[x].ReturnY_1() != nil {
-			a_x := verifiers[i].SendProofsRow()[x].ReturnY_1()
-			a_xi := verifiers[i].SendProofsRow()[x].ReturnY_2()
-			p := verifiers[i].SendProofsRow()[x].ReturnP()
-
+		p := verifiers[i].SendProofsRow()[x]
+		if p.ReturnY_1() != nil {
 			sender := strconv.Itoa(x)
 			receiver := strconv.Itoa(i)
 
-			pointBytes, _ := a_x.MarshalBinary()
-			a_x_s := hex.EncodeToString(pointBytes)
-
-			pointBytes, _ = a_xi.MarshalBinary()
-			a_x_si := hex.EncodeToString(pointBytes)
-
-			pointBytes, _ = p.MarshalBinary()
-			p_i := hex.EncodeToString(pointBytes)
-
-			str := "a_" + sender + " = " + a_x_s + "\n a'_" + sender + " = " + a_x_si + "\n p_" + sender + " = " + p_i
+			msg := &vss.RowPointMessage{Y_1: p.ReturnY_1(), Y_2: p.ReturnY_2(), Proof: p.ReturnP()}
 
 			sendToSpecificClient(sender, "sending <<row>> to "+receiver+"\n"+"-----------------------------------------------------------\n")
 
-			sendToSpecificClient(receiver, "<<row>> from "+sender+" \n"+str+"\n"+"-----------------------------------------------------------\n")
+			sendMessageToClient(receiver, x, msg)
 		}
 	}
 }
 
 func sendToSpecificClient(clientID string, message string) {
+	sendFrame(clientID, textFrame(message))
+}
+
+// sendMessageToClient sends a BingoShare message from client from, or from the dealer if from is -1.
+func sendMessageToClient(clientID string, from int, m vss.Message) {
+	frame, err := messageFrame(from, m)
+	if err != nil {
+		log.Printf("Error encoding %s message for client %s: %v", m.Type(), clientID, err)
+		return
+	}
+	sendFrame(clientID, frame)
+}
+
+func sendFrame(clientID string, frame []byte) {
 	mu.Lock()
 	defer mu.Unlock()
 
-	for conn, id := range clients {
-		if id == clientID {
-			if err := conn.WriteMessage(websocket.TextMessage, []byte(message)); err != nil {
+	for _, c := range clients {
+		if c.id == clientID {
+			if err := c.conn.Send(frame); err != nil {
 				log.Printf("Error sending message to client %s: %v", clientID, err)
 			}
 			break
 		}
 	}
 }
+
+// loadSetup reads the shared SRS given with -srs, or runs a local setup of l powers when no file is given.
+// With -store the local setup is kept in the store directory and read from there on the next start.
+func loadSetup(l int, g *vss.Suite) (*kzg.KzgSetup, error) {
+	path := *srsPath
+	if path == "" && shares != nil {
+		path = filepath.Join(shares.ReturnDir(), srsFile)
+		if _, err := os.Stat(path); errors.Is(err, fs.ErrNotExist) {
+			setup, err := kzg.NewKzgSetup(l, g.ReturnSuite())
+			if err != nil {
+				return nil, err
+			}
+			return setup, kzg.SaveSetup(path, setup)
+		}
+	}
+	if path == "" {
+		return kzg.NewKzgSetup(l, g.ReturnSuite())
+	}
+
+	setup, err := kzg.LoadSetup(path, g.ReturnSuite())
+	if err != nil {
+		return nil, err
+	}
+	if len(setup.ReturnT_1()) < l {
+		return nil, fmt.Errorf("the SRS in %s has %d powers, %d are needed", path, len(setup.ReturnT_1()), l)
+	}
+	return setup, nil
+}
+
+// saveShares stores the state of every client in the -store directory, if one was given.
+func saveShares(verifiers []vss.Verifier, cm []kyber.Point, g *vss.Suite) error {
+	if shares == nil {
+		return nil
+	}
+	for i := 0; i < maxClientCount; i++ {
+		rec := &store.Record{Curve: g.ReturnCurve(), Verifier: verifiers[i].State(), Commitments: cm}
+		if err := shares.Save(fmt.Sprintf("client-%d", i), rec); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+/*
+This function loads the shares that saveShares kept in the -store directory. Store.Load checks
+every record against the setup, and the records have to belong to the same dealing, one per
+client. It returns nil when the store holds no shares.
+*/
+func loadShares(g *vss.Suite) ([]vss.Verifier, []kyber.Point, error) {
+	if shares == nil {
+		return nil, nil, nil
+	}
+	names, err := shares.List()
+	if err != nil || len(names) == 0 {
+		return nil, nil, err
+	}
+
+	sh_setup := kzg.NewShareSetup(setup.ReturnT_1(), setup.ReturnT_2(), setup.ReturnT_u(), g.ReturnSuite(), setup.ReturnG_u(), setup.ReturnG_1())
+	verifiers := make([]vss.Verifier, maxClientCount+1)
+	var cm []kyber.Point
+	for i := 0; i < maxClientCount; i++ {
+		name := fmt.Sprintf("client-%d", i)
+		rec, err := shares.Load(name, sh_setup)
+		if err != nil {
+			return nil, nil, err
+		}
+		if rec.Verifier.ID != i+1 {
+			return nil, nil, fmt.Errorf("%s holds the share of verifier %d", name, rec.Verifier.ID)
+		}
+		if cm == nil {
+			cm = rec.Commitments
+		} else if !sameCommitments(cm, rec.Commitments) {
+			return nil, nil, fmt.Errorf("%s belongs to another dealing than client-0", name)
+		}
+
+		v, err := vss.RestoreVerifier(g, rec.Verifier)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s: %w", name, err)
+		}
+		verifiers[i] = *v
+	}
+	return verifiers, cm, nil
+}
+
+func sameCommitments(a, b []kyber.Point) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !a[i].Equal(b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// resumeDealing serves the dealing restored from the store instead of dealing a new one. The clients
+// get the SRS again and can ask for the reconstruction.
+func resumeDealing(reconstructionChannel chan bool) {
+	g, _ := vss.NewSuiteForCurve(*curve)
+	sh_setup := kzg.NewShareSetup(setup.ReturnT_1(), setup.ReturnT_2(), setup.ReturnT_u(), g.ReturnSuite(), setup.ReturnG_u(), setup.ReturnG_1())
+
+	frame, err := setupFrame(setup)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+	broadcastFrame(frame)
+	broadcast("The shares of the previous dealing were restored from the store. You can reconstruct the secrets if you want now. Just sent -Reconstruct-")
+
+	go func() {
+		<-reconstructionChannel
+
+		x, err := vss.BingoReconstruct(restored, 0, sh_setup, 0, d_2, restoredCM)
+		if err != nil {
+			broadcast("The secret cannot be reconstructed: " + err.Error())
+			return
+		}
+		xBytes, err := x.MarshalBinary()
+		if err != nil {
+			panic(err)
+		}
+		broadcast("Secret at place 0 equals with " + hex.EncodeToString(xBytes))
+	}()
+}
+
+// rejectClient closes a connection that never became an authenticated client.
+func rejectClient(conn *websocket.Conn) {
+	conn.Close()
+	mu.Lock()
+	clientCount--
+	mu.Unlock()
+}
+
+// generateKeys creates the identity of the dealer (index 0) and of n clients (indices 1..n) in dir,
+// together with the public directory that every party needs.
+func generateKeys(dir string, n int) error {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+
+	pub := transport.NewDirectory()
+	for i := 0; i <= n; i++ {
+		id, err := transport.NewIdentity(i, rand.Reader)
+		if err != nil {
+			return err
+		}
+
+		name := "dealer.json"
+		if i > 0 {
+			name = fmt.Sprintf("client-%d.json", i-1)
+		}
+		if err := transport.SaveIdentity(filepath.Join(dir, name), id); err != nil {
+			return err
+		}
+		pub[i] = id.Public()
+	}
+
+	return transport.SaveDirectory(filepath.Join(dir, "directory.json"), pub)
+}
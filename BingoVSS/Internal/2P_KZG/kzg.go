@@ -1,10 +1,11 @@
 package polytwo_kzg
 
 import (
+	"BingoVSS/Internal/MSM"
 	"fmt"
 
 	"github.com/drand/kyber"
-	"github.com/drand/kyber/pairing/bn256"
+	"github.com/drand/kyber/pairing"
 )
 
 // kzgSetup is used to set down the bilinear pairing setups
@@ -12,14 +13,14 @@ type kzgSetup struct {
 	t_1  []kyber.Point
 	t_2  []kyber.Point
 	t_Up []kyber.Point
-	g    *bn256.Suite
+	g    pairing.Suite
 	gUp  kyber.Point
 }
 
 /*
-This function is being utilized to represent the setup of the bilinear pairings used by the KZG commitments. More specifically it gets the generators both from G1 and G2 of bn256 eliptic curve and creates the g^t^i
+This function is being utilized to represent the setup of the bilinear pairings used by the KZG commitments. More specifically it gets the generators both from G1 and G2 of the pairing eliptic curve and creates the g^t^i
 */
-func NewKzgSetup(l int, pairing *bn256.Suite) (*kzgSetup, error) {
+func NewKzgSetup(l int, pairing pairing.Suite) (*kzgSetup, error) {
 
 	// First Step: Compute the random value of τ, which we called trapdoor
 	t := pairing.G1().Scalar().SetInt64(2)
@@ -52,39 +53,15 @@ func KZGCommits(ts *kzgSetup, f_1 []kyber.Scalar, f_2 []kyber.Scalar) kyber.Poin
 // evaluatePolyTrap evaluates a polynomial q at specific trapdoor values provided in the kzgSetup structure ts.
 // It returns the result of the evaluation as a point c.
 func evaluatePolyTrap_f1(ts *kzgSetup, q []kyber.Scalar) kyber.Point {
-	// Initialize the result c by multiplying the first coefficient of the polynomial q with the first trapdoor value in ts.t_1
-	c := ts.t_1[0].Clone().Mul(q[0], ts.t_1[0])
-
-	// Iterate through the remaining coefficients of the polynomial q
-	for i := 1; i < len(q); i++ {
-		// Multiply the current coefficient q[i] with the corresponding trapdoor value ts.t_1[i]
-		sp := ts.t_1[i].Clone().Mul(q[i], ts.t_1[i])
-
-		// Add the result to the current value of c
-		c = c.Add(c, sp)
-	}
-
-	// Return the result of the evaluation
-	return c
+	// Σ_i q_i·t_1[i] with the bucket method
+	return msm.MultiExp(ts.g.G1(), ts.t_1, q)
 }
 
 // evaluatePolyTrap evaluates a polynomial q at specific trapdoor values provided in the kzgSetup structure ts.
 // It returns the result of the evaluation as a point c.
 func evaluatePolyTrap_f2(ts *kzgSetup, q []kyber.Scalar) kyber.Point {
-	// Initialize the result c by multiplying the first coefficient of the polynomial q with the first trapdoor value in ts.t_1
-	c := ts.t_Up[0].Clone().Mul(q[0], ts.t_Up[0])
-
-	// Iterate through the remaining coefficients of the polynomial q
-	for i := 1; i < len(q); i++ {
-		// Multiply the current coefficient q[i] with the corresponding trapdoor value ts.t_1[i]
-		sp := ts.t_Up[i].Clone().Mul(q[i], ts.t_Up[i])
-
-		// Add the result to the current value of c
-		c = c.Add(c, sp)
-	}
-
-	// Return the result of the evaluation
-	return c
+	// Σ_i q_i·t_Up[i] with the bucket method
+	return msm.MultiExp(ts.g.G1(), ts.t_Up, q)
 }
 
 /* This function represents the KZG evaluation proof. It computes the evaluation proof π for a given polynomial ϕ(X) at a point a, with the result y. */
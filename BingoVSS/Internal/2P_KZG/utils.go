@@ -5,14 +5,14 @@ import (
 	"math/big"
 
 	"github.com/drand/kyber"
-	"github.com/drand/kyber/pairing/bn256"
+	"github.com/drand/kyber/pairing"
 )
 
 const debug = 0
 
 // calculateTrapdoorValues calculates trapdoor values for a given point g_i within a specific group.
 // pairing is the pairing suite, g_i is the base point, group is the group identifier (1 or 2), l is the length of the trapdoor, and t is a scalar value.
-func calculateTrapdoorValues(pairing *bn256.Suite, g_i kyber.Point, group, l int, t kyber.Scalar) ([]kyber.Point, error) {
+func calculateTrapdoorValues(pairing pairing.Suite, g_i kyber.Point, group, l int, t kyber.Scalar) ([]kyber.Point, error) {
 	// Initialize a slice gTrapdoor to store the trapdoor values, with length l
 	gTrapdoor := make([]kyber.Point, l)
 
@@ -45,7 +45,7 @@ func calculateTrapdoorValues(pairing *bn256.Suite, g_i kyber.Point, group, l int
 
 // Pow computes the power of a given base raised to a specified exponent within a specific group.
 // exp is the exponent, base is the base value, and group represents the eliptic curve working with.
-func Pow(exp int, base kyber.Scalar, e kyber.Scalar, group *bn256.Suite) kyber.Scalar {
+func Pow(exp int, base kyber.Scalar, e kyber.Scalar, group pairing.Suite) kyber.Scalar {
 	// Initialize a variable power with the value of 1 in the group
 	power := group.G1().Scalar().One()
 
@@ -68,7 +68,7 @@ func Pow(exp int, base kyber.Scalar, e kyber.Scalar, group *bn256.Suite) kyber.S
 }
 
 // subPoly performs the subtraction of a scalar value y from the constant term of a given polynomial f.
-func subPoly(f []kyber.Scalar, y kyber.Scalar, group *bn256.Suite) []kyber.Scalar {
+func subPoly(f []kyber.Scalar, y kyber.Scalar, group pairing.Suite) []kyber.Scalar {
 	// Create a new slice q with the same length as f, to store the result of the subtraction
 	q := make([]kyber.Scalar, len(f))
 	for i := 0; i < len(f); i++ {
@@ -96,7 +96,7 @@ func subPoly(f []kyber.Scalar, y kyber.Scalar, group *bn256.Suite) []kyber.Scala
 
 // DivPoly performs polynomial division of two polynomials n (numerator) and d (denominator).
 // It returns the quotient q and remainder rem of the division.
-func DivPoly(n, d []kyber.Scalar, group *bn256.Suite) ([]kyber.Scalar, []kyber.Scalar) {
+func DivPoly(n, d []kyber.Scalar, group pairing.Suite) ([]kyber.Scalar, []kyber.Scalar) {
 	// Initialize the quotient q with the appropriate length
 	q := make([]kyber.Scalar, len(n)-len(d)+1)
 	for i := range q {
@@ -137,9 +137,9 @@ func DivPoly(n, d []kyber.Scalar, group *bn256.Suite) ([]kyber.Scalar, []kyber.S
 // EvaluatePolynomial evaluates a polynomial p at a given value x.
 // It takes a slice of kyber.Scalar values representing the coefficients of the polynomial p,
 // a kyber.Scalar value x representing the point at which to evaluate the polynomial,
-// and a bn256.Suite object suite for performing arithmetic operations.
+// and a pairing.Suite object suite for performing arithmetic operations.
 // It returns the result of the evaluation as a kyber.Scalar value.
-func evaluatePolynomial(p []kyber.Scalar, x kyber.Scalar, suite *bn256.Suite) kyber.Scalar {
+func evaluatePolynomial(p []kyber.Scalar, x kyber.Scalar, suite pairing.Suite) kyber.Scalar {
 	// Initialize the result r to zero
 	r := suite.G1().Scalar().Zero()
 
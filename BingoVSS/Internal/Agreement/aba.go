@@ -0,0 +1,417 @@
+package agreement
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Kind is the step of the agreement a message belongs to.
+type Kind uint8
+
+const (
+	KindBVal Kind = iota + 1 // a value proposed in a round
+	KindAux                  // a value a party accepted in a round
+	KindTerm                 // the value a party decided
+	KindCoin                 // a share of the coin of a round
+)
+
+func (k Kind) String() string {
+	switch k {
+	case KindBVal:
+		return "bval"
+	case KindAux:
+		return "aux"
+	case KindTerm:
+		return "term"
+	case KindCoin:
+		return "coin"
+	}
+	return "unknown"
+}
+
+// Message is a single step of an agreement instance. Round is ignored for KindTerm, Value for
+// KindCoin and Share for all kinds but KindCoin.
+type Message struct {
+	Kind  Kind
+	Round int
+	Value bool
+	Share []byte
+}
+
+// Envelope carries a message between two parties. From is set by the authenticated channel it
+// arrived on, never by the message itself.
+type Envelope struct {
+	From int
+	To   int
+	Msg  Message
+}
+
+// roundsAhead is how many rounds past its current one a party keeps messages for. An honest
+// party that falls further behind decides from the TERM messages of the others.
+const roundsAhead = 8
+
+// round holds what a party received in one round.
+type round struct {
+	bvalFrom  [2]map[int]bool
+	bvalSent  [2]bool
+	binValues [2]bool
+	auxFrom   map[int]bool
+	aux       [2]int
+	auxSent   bool
+	coinFrom  map[int][]byte // the verified coin shares, until the coin is known
+	coinSent  bool
+	coinKnown bool
+	coin      bool
+}
+
+func newRound() *round {
+	return &round{bvalFrom: [2]map[int]bool{{}, {}}, auxFrom: make(map[int]bool), coinFrom: make(map[int][]byte)}
+}
+
+/*
+Instance is one party in a binary Byzantine agreement among n >= 3f+1 parties, following
+Mostéfaoui, Moumen and Raynal. In every round r with estimate est:
+
+ 1. broadcast BVAL(r, est); on BVAL(r, b) from f+1 parties broadcast it too, and on BVAL(r, b)
+    from 2f+1 parties add b to bin_values
+ 2. once bin_values is not empty, broadcast AUX(r, w) for the first w in it
+ 3. on AUX(r, ·) from n-f parties with values in bin_values, let vals be those values and s the
+    coin of round r, which the party only releases its share of at this point: if vals = {b}, set
+    est = b and decide b if b = s; otherwise set est = s
+ 4. continue with round r+1
+
+A party that decides broadcasts TERM(b). On TERM(b) from f+1 parties it decides b as well, and on
+TERM(b) from 2f+1 parties it stops, since every honest party is then bound to decide b. All honest
+parties decide the same value, and if they all have the same input they decide it.
+*/
+type Instance struct {
+	self    int
+	parties map[int]bool
+	order   []int
+	f       int
+	coin    Coin
+
+	started bool
+	current int
+	est     bool
+	rounds  map[int]*round
+
+	decided  bool
+	value    bool
+	termSent bool
+	termFrom map[int]bool
+	terms    [2]int
+	halted   bool
+}
+
+/* This function constructs the instance of party self in an agreement among the given parties */
+func NewInstance(self int, parties []int, f int, coin Coin) (*Instance, error) {
+	set := make(map[int]bool, len(parties))
+	order := append([]int{}, parties...)
+	sort.Ints(order)
+	for _, p := range order {
+		if set[p] {
+			return nil, fmt.Errorf("agreement: party %d listed twice", p)
+		}
+		set[p] = true
+	}
+	if f < 0 || len(set) < 3*f+1 {
+		return nil, fmt.Errorf("agreement: need n >= 3f+1, got n = %d and f = %d", len(set), f)
+	}
+	if !set[self] {
+		return nil, fmt.Errorf("agreement: %d is not a party", self)
+	}
+	if coin == nil {
+		return nil, fmt.Errorf("agreement: no coin")
+	}
+	if coin.Threshold() < f+1 || coin.Threshold() > len(set)-f {
+		// f shares must not determine the coin, and n-f must
+		return nil, fmt.Errorf("agreement: coin threshold %d outside %d..%d", coin.Threshold(), f+1, len(set)-f)
+	}
+
+	return &Instance{
+		self:     self,
+		parties:  set,
+		order:    order,
+		f:        f,
+		coin:     coin,
+		rounds:   make(map[int]*round),
+		termFrom: make(map[int]bool),
+	}, nil
+}
+
+// Input starts the agreement with the value v and returns the messages to send.
+func (a *Instance) Input(v bool) ([]Envelope, error) {
+	if a.started {
+		return nil, fmt.Errorf("agreement: %d already has an input", a.self)
+	}
+	if a.halted {
+		return nil, nil
+	}
+	a.started = true
+	a.est = v
+	return a.startRound()
+}
+
+// Handle processes one message from the party from and returns the messages to send in response.
+// Messages of a party to itself are handled internally and never returned.
+func (a *Instance) Handle(from int, m Message) ([]Envelope, error) {
+	if !a.parties[from] {
+		return nil, fmt.Errorf("agreement: %s from unknown party %d", m.Kind, from)
+	}
+	if a.halted {
+		return nil, nil
+	}
+
+	switch m.Kind {
+	case KindBVal, KindAux, KindCoin:
+		if m.Round < 0 {
+			return nil, fmt.Errorf("agreement: %s for round %d from %d", m.Kind, m.Round, from)
+		}
+		if m.Round > a.current+roundsAhead {
+			// Keeping it would let a faulty party allocate rounds without end
+			return nil, nil
+		}
+		// Past rounds are still counted and relayed, slower parties may need them. Their state
+		// was created when the party went through them, so it is bounded by the current round.
+		r := a.round(m.Round)
+		b := index(m.Value)
+
+		if m.Kind == KindCoin {
+			if r.coinKnown || r.coinFrom[from] != nil {
+				return nil, nil
+			}
+			// Our own share comes straight from the key
+			if from != a.self {
+				if err := a.coin.Verify(from, m.Round, m.Share); err != nil {
+					return nil, fmt.Errorf("agreement: invalid coin share for round %d from %d: %v", m.Round, from, err)
+				}
+			}
+			r.coinFrom[from] = m.Share
+			if len(r.coinFrom) >= a.coin.Threshold() {
+				v, err := a.coin.Value(m.Round, r.coinFrom)
+				if err != nil {
+					return nil, err
+				}
+				r.coinKnown, r.coin, r.coinFrom = true, v, nil
+			}
+			return a.progress()
+		}
+
+		if m.Kind == KindBVal {
+			if r.bvalFrom[b][from] {
+				return nil, nil
+			}
+			r.bvalFrom[b][from] = true
+			var out []Envelope
+			if len(r.bvalFrom[b]) >= a.f+1 && !r.bvalSent[b] {
+				r.bvalSent[b] = true
+				next, err := a.sendToAll(Message{Kind: KindBVal, Round: m.Round, Value: m.Value})
+				if err != nil {
+					return nil, err
+				}
+				out = append(out, next...)
+			}
+			if len(r.bvalFrom[b]) >= 2*a.f+1 {
+				r.binValues[b] = true
+			}
+			next, err := a.progress()
+			if err != nil {
+				return nil, err
+			}
+			return append(out, next...), nil
+		}
+
+		if r.auxFrom[from] {
+			return nil, nil
+		}
+		r.auxFrom[from] = true
+		r.aux[b]++
+		return a.progress()
+
+	case KindTerm:
+		if a.termFrom[from] {
+			return nil, nil
+		}
+		a.termFrom[from] = true
+		b := index(m.Value)
+		a.terms[b]++
+
+		var out []Envelope
+		if a.terms[b] >= a.f+1 {
+			if !a.decided {
+				a.decided = true
+				a.value = m.Value
+			}
+			next, err := a.term()
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, next...)
+		}
+		if a.terms[b] >= 2*a.f+1 {
+			a.halted = true
+		}
+		return out, nil
+	}
+
+	return nil, fmt.Errorf("agreement: unknown message kind %d from %d", m.Kind, from)
+}
+
+// Decided returns the decided value, if any.
+func (a *Instance) Decided() (bool, bool) {
+	return a.value, a.decided
+}
+
+// Halted tells whether the party stopped taking part: it decided and knows that every honest
+// party will decide the same.
+func (a *Instance) Halted() bool {
+	return a.halted
+}
+
+// ReturnRound returns the current round.
+func (a *Instance) ReturnRound() int {
+	return a.current
+}
+
+func (a *Instance) round(r int) *round {
+	if a.rounds[r] == nil {
+		a.rounds[r] = newRound()
+	}
+	return a.rounds[r]
+}
+
+// startRound broadcasts the estimate in the current round and moves on as far as the messages
+// already received allow.
+func (a *Instance) startRound() ([]Envelope, error) {
+	r := a.round(a.current)
+	var out []Envelope
+	if !r.bvalSent[index(a.est)] {
+		r.bvalSent[index(a.est)] = true
+		next, err := a.sendToAll(Message{Kind: KindBVal, Round: a.current, Value: a.est})
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, next...)
+	}
+	next, err := a.progress()
+	if err != nil {
+		return nil, err
+	}
+	return append(out, next...), nil
+}
+
+// progress runs steps 2 and 3 of the current round.
+func (a *Instance) progress() ([]Envelope, error) {
+	if !a.started || a.halted {
+		return nil, nil
+	}
+	cur := a.current
+	r := a.round(cur)
+
+	var out []Envelope
+	if !r.auxSent && (r.binValues[0] || r.binValues[1]) {
+		r.auxSent = true
+		w := r.binValues[1]
+		if r.binValues[0] && r.binValues[1] {
+			// both arrived in the same message handling, pick the estimate
+			w = a.est
+		}
+		next, err := a.sendToAll(Message{Kind: KindAux, Round: a.current, Value: w})
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, next...)
+		// sendToAll may have finished the round already
+		if a.current != cur {
+			return out, nil
+		}
+	}
+	if !r.auxSent {
+		return out, nil
+	}
+
+	var vals [2]bool
+	count := 0
+	for b := range vals {
+		if r.binValues[b] && r.aux[b] > 0 {
+			vals[b] = true
+			count += r.aux[b]
+		}
+	}
+	if count < len(a.parties)-a.f {
+		return out, nil
+	}
+
+	if !r.coinSent {
+		r.coinSent = true
+		share, err := a.coin.Share(cur)
+		if err != nil {
+			return nil, err
+		}
+		next, err := a.sendToAll(Message{Kind: KindCoin, Round: cur, Share: share})
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, next...)
+		if a.current != cur {
+			return out, nil
+		}
+	}
+	if !r.coinKnown {
+		return out, nil
+	}
+
+	s := r.coin
+	if vals[0] != vals[1] {
+		a.est = vals[1]
+		if a.est == s && !a.decided {
+			a.decided = true
+			a.value = s
+			next, err := a.term()
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, next...)
+		}
+	} else {
+		a.est = s
+	}
+
+	a.current++
+	next, err := a.startRound()
+	if err != nil {
+		return nil, err
+	}
+	return append(out, next...), nil
+}
+
+func (a *Instance) term() ([]Envelope, error) {
+	if a.termSent {
+		return nil, nil
+	}
+	a.termSent = true
+	return a.sendToAll(Message{Kind: KindTerm, Value: a.value})
+}
+
+// sendToAll returns m for every other party and handles our own copy right away.
+func (a *Instance) sendToAll(m Message) ([]Envelope, error) {
+	out := make([]Envelope, 0, len(a.parties))
+	for _, p := range a.order {
+		if p != a.self {
+			out = append(out, Envelope{From: a.self, To: p, Msg: m})
+		}
+	}
+	next, err := a.Handle(a.self, m)
+	if err != nil {
+		return nil, err
+	}
+	return append(out, next...), nil
+}
+
+func index(v bool) int {
+	if v {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,357 @@
+package agreement
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"github.com/drand/kyber/pairing/bn256"
+	"github.com/stretchr/testify/require"
+)
+
+// network delivers the queued messages to the honest instances in a random order. Byzantine
+// parties answer every message they receive with the given function.
+type network struct {
+	nodes     map[int]*Instance
+	byzantine map[int]func(env Envelope) []Envelope
+	queue     []Envelope
+	rnd       *rand.Rand
+}
+
+func parties(n int) []int {
+	p := make([]int, n)
+	for i := range p {
+		p[i] = i + 1
+	}
+	return p
+}
+
+// coins deals the coin keys of the parties 1..n with threshold f+1.
+func coins(t *testing.T, n, f int) map[int]*CoinKey {
+	g := bn256.NewSuite()
+	keys, err := DealCoinKeys(g, parties(n), f+1, g.RandomStream())
+	require.NoError(t, err)
+	return keys
+}
+
+func newNetwork(t *testing.T, n, f int, byzantine map[int]func(env Envelope) []Envelope, seed int64) *network {
+	net := &network{nodes: make(map[int]*Instance), byzantine: byzantine, rnd: rand.New(rand.NewSource(seed))}
+	keys := coins(t, n, f)
+	for _, p := range parties(n) {
+		if _, ok := byzantine[p]; ok {
+			continue
+		}
+		a, err := NewInstance(p, parties(n), f, keys[p].Coin("test"))
+		require.NoError(t, err)
+		net.nodes[p] = a
+	}
+	return net
+}
+
+func (net *network) input(t *testing.T, inputs map[int]bool) {
+	for p, v := range inputs {
+		if a, ok := net.nodes[p]; ok {
+			out, err := a.Input(v)
+			require.NoError(t, err)
+			net.queue = append(net.queue, out...)
+		}
+	}
+}
+
+func (net *network) run(t *testing.T) {
+	for steps := 0; len(net.queue) > 0; steps++ {
+		require.Less(t, steps, 1<<20, "the agreement does not terminate")
+		k := net.rnd.Intn(len(net.queue))
+		env := net.queue[k]
+		net.queue = append(net.queue[:k], net.queue[k+1:]...)
+
+		if reply, ok := net.byzantine[env.To]; ok {
+			net.queue = append(net.queue, reply(env)...)
+			continue
+		}
+		out, err := net.nodes[env.To].Handle(env.From, env.Msg)
+		require.NoError(t, err)
+		net.queue = append(net.queue, out...)
+	}
+}
+
+// decided checks agreement and termination and returns the decided value.
+func (net *network) decided(t *testing.T) bool {
+	var value bool
+	first := true
+	for p, a := range net.nodes {
+		v, ok := a.Decided()
+		require.True(t, ok, "party %d did not decide", p)
+		require.True(t, a.Halted(), "party %d did not halt", p)
+		if !first {
+			require.Equal(t, value, v, "honest parties decided differently")
+		}
+		value, first = v, false
+	}
+	return value
+}
+
+func TestUnanimousInput(t *testing.T) {
+	for _, v := range []bool{false, true} {
+		for seed := int64(0); seed < 20; seed++ {
+			net := newNetwork(t, 4, 1, nil, seed)
+			net.input(t, map[int]bool{1: v, 2: v, 3: v, 4: v})
+			net.run(t)
+			require.Equal(t, v, net.decided(t), "seed %d", seed)
+		}
+	}
+}
+
+func TestMixedInput(t *testing.T) {
+	for seed := int64(0); seed < 30; seed++ {
+		net := newNetwork(t, 7, 2, nil, seed)
+		inputs := make(map[int]bool)
+		for _, p := range parties(7) {
+			inputs[p] = net.rnd.Intn(2) == 1
+		}
+		net.input(t, inputs)
+		net.run(t)
+		net.decided(t)
+	}
+}
+
+func TestLateInput(t *testing.T) {
+	// Some parties only get their input once messages are flowing
+	for seed := int64(0); seed < 20; seed++ {
+		net := newNetwork(t, 4, 1, nil, seed)
+		net.input(t, map[int]bool{1: true, 2: true})
+		for i := 0; i < 5 && len(net.queue) > 0; i++ {
+			env := net.queue[0]
+			net.queue = net.queue[1:]
+			out, err := net.nodes[env.To].Handle(env.From, env.Msg)
+			require.NoError(t, err)
+			net.queue = append(net.queue, out...)
+		}
+		net.input(t, map[int]bool{3: false, 4: true})
+		net.run(t)
+		net.decided(t)
+	}
+}
+
+func TestCrashedParties(t *testing.T) {
+	silent := func(Envelope) []Envelope { return nil }
+	for seed := int64(0); seed < 20; seed++ {
+		net := newNetwork(t, 7, 2, map[int]func(Envelope) []Envelope{3: silent, 6: silent}, seed)
+		net.input(t, map[int]bool{1: true, 2: false, 4: true, 5: false, 7: true})
+		net.run(t)
+		net.decided(t)
+	}
+}
+
+func TestByzantineParties(t *testing.T) {
+	// Byzantine parties answer every message with the opposite value to everyone, in every kind,
+	// and never release their coin shares
+	lie := func(self int) func(Envelope) []Envelope {
+		type step struct {
+			kind  Kind
+			round int
+			value bool
+		}
+		sent := make(map[step]bool)
+		return func(env Envelope) []Envelope {
+			m := Message{Kind: env.Msg.Kind, Round: env.Msg.Round, Value: !env.Msg.Value}
+			if m.Kind == KindCoin || sent[step{m.Kind, m.Round, m.Value}] {
+				return nil
+			}
+			sent[step{m.Kind, m.Round, m.Value}] = true
+			var out []Envelope
+			for _, p := range parties(7) {
+				if p != self {
+					out = append(out, Envelope{From: self, To: p, Msg: m})
+				}
+			}
+			return out
+		}
+	}
+	for _, v := range []bool{false, true} {
+		for seed := int64(0); seed < 20; seed++ {
+			net := newNetwork(t, 7, 2, map[int]func(Envelope) []Envelope{1: lie(1), 5: lie(5)}, seed)
+			net.input(t, map[int]bool{2: v, 3: v, 4: v, 6: v, 7: v})
+			net.run(t)
+			require.Equal(t, v, net.decided(t), "validity, seed %d", seed)
+		}
+	}
+}
+
+func TestInvalidMessages(t *testing.T) {
+	keys := coins(t, 4, 1)
+	_, err := NewInstance(1, parties(3), 1, keys[1].Coin("x"))
+	require.Error(t, err)
+	_, err = NewInstance(5, parties(4), 1, keys[1].Coin("x"))
+	require.Error(t, err)
+	_, err = NewInstance(1, []int{1, 2, 3, 3}, 0, keys[1].Coin("x"))
+	require.Error(t, err)
+	_, err = NewInstance(1, parties(4), 1, nil)
+	require.Error(t, err)
+	// f shares must not determine the coin
+	_, err = NewInstance(1, parties(4), 1, coins(t, 4, 0)[1].Coin("x"))
+	require.Error(t, err)
+
+	a, err := NewInstance(1, parties(4), 1, keys[1].Coin("x"))
+	require.NoError(t, err)
+	_, err = a.Handle(9, Message{Kind: KindBVal})
+	require.Error(t, err)
+	_, err = a.Handle(2, Message{Kind: KindAux, Round: -1})
+	require.Error(t, err)
+	_, err = a.Handle(2, Message{Kind: 9})
+	require.Error(t, err)
+
+	// Rounds far ahead of the current one are dropped without keeping any state
+	for r := roundsAhead + 1; r < roundsAhead+100; r++ {
+		out, err := a.Handle(2, Message{Kind: KindBVal, Round: r, Value: true})
+		require.NoError(t, err)
+		require.Empty(t, out)
+	}
+	require.Len(t, a.rounds, 0)
+	_, err = a.Handle(2, Message{Kind: KindBVal, Round: roundsAhead, Value: true})
+	require.NoError(t, err)
+	require.Len(t, a.rounds, 1)
+
+	// A coin share must be the one of the sender for this session and round
+	share, err := keys[3].Coin("x").Share(0)
+	require.NoError(t, err)
+	_, err = a.Handle(2, Message{Kind: KindCoin, Round: 0, Share: share})
+	require.Error(t, err)
+	_, err = a.Handle(3, Message{Kind: KindCoin, Round: 1, Share: share})
+	require.Error(t, err)
+	other, err := keys[3].Coin("y").Share(0)
+	require.NoError(t, err)
+	_, err = a.Handle(3, Message{Kind: KindCoin, Round: 0, Share: other})
+	require.Error(t, err)
+	_, err = a.Handle(3, Message{Kind: KindCoin, Round: 0, Share: share})
+	require.NoError(t, err)
+
+	_, err = a.Input(true)
+	require.NoError(t, err)
+	_, err = a.Input(false)
+	require.Error(t, err)
+}
+
+func TestThresholdCoin(t *testing.T) {
+	keys := coins(t, 4, 1)
+	heads := 0
+	for r := 0; r < 60; r++ {
+		shares := make(map[int][]byte)
+		for _, p := range parties(4) {
+			share, err := keys[p].Coin("tag").Share(r)
+			require.NoError(t, err)
+			require.NoError(t, keys[1].Coin("tag").Verify(p, r, share))
+			shares[p] = share
+		}
+
+		// Every two shares give the same coin, one share is not enough
+		v, err := keys[1].Coin("tag").Value(r, map[int][]byte{1: shares[1], 2: shares[2]})
+		require.NoError(t, err)
+		w, err := keys[4].Coin("tag").Value(r, map[int][]byte{3: shares[3], 4: shares[4]})
+		require.NoError(t, err)
+		require.Equal(t, v, w)
+		_, err = keys[1].Coin("tag").Value(r, map[int][]byte{1: shares[1]})
+		require.Error(t, err)
+		if v {
+			heads++
+		}
+	}
+	require.InDelta(t, 30, heads, 15)
+
+	_, err := DealCoinKeys(bn256.NewSuite(), parties(4), 5, bn256.NewSuite().RandomStream())
+	require.Error(t, err)
+	_, err = NewCoinKey(bn256.NewSuite(), 1, keys[2].share, keys[1].public, 2)
+	require.Error(t, err)
+}
+
+func TestSubset(t *testing.T) {
+	// Every party accepts five proposals, 3 is accepted by a single party and 6 by nobody
+	for seed := int64(0); seed < 10; seed++ {
+		rnd := rand.New(rand.NewSource(seed))
+		keys := coins(t, 7, 2)
+		subsets := make(map[int]*Subset)
+		var queue []SubsetEnvelope
+		for _, p := range parties(7) {
+			s, err := NewSubset(p, parties(7), 2, func(proposer int) Coin { return keys[p].Coin(fmt.Sprint(proposer)) })
+			require.NoError(t, err)
+			subsets[p] = s
+		}
+		accept := func(p, proposer int) {
+			out, err := subsets[p].Accept(proposer)
+			require.NoError(t, err)
+			queue = append(queue, out...)
+		}
+		for _, p := range parties(7) {
+			for _, proposer := range []int{1, 2, 4, 5, 7} {
+				accept(p, proposer)
+			}
+		}
+		accept(2, 3)
+
+		for len(queue) > 0 {
+			k := rnd.Intn(len(queue))
+			env := queue[k]
+			queue = append(queue[:k], queue[k+1:]...)
+			out, err := subsets[env.To].Handle(env.From, env.Proposer, env.Msg)
+			require.NoError(t, err)
+			queue = append(queue, out...)
+		}
+
+		set := subsets[1].Set()
+		require.GreaterOrEqual(t, len(set), 5, "seed %d", seed)
+		require.Subset(t, []int{1, 2, 3, 4, 5, 7}, set)
+		for _, p := range parties(7) {
+			require.Equal(t, set, subsets[p].Set())
+			require.Equal(t, 7, subsets[p].Decisions())
+		}
+	}
+
+	s, err := NewSubset(1, parties(4), 1, func(int) Coin { return coins(t, 4, 1)[1].Coin("x") })
+	require.NoError(t, err)
+	require.Nil(t, s.Set())
+	_, err = s.Accept(5)
+	require.Error(t, err)
+	_, err = s.Handle(2, 0, Message{Kind: KindBVal})
+	require.Error(t, err)
+	_, err = NewSubset(1, parties(4), 1, nil)
+	require.Error(t, err)
+}
+
+func TestSubsetFor(t *testing.T) {
+	// Four parties agree on proposals of seven others, five of which every party accepts
+	rnd := rand.New(rand.NewSource(1))
+	keys := coins(t, 4, 1)
+	subsets := make(map[int]*Subset)
+	var queue []SubsetEnvelope
+	for _, p := range parties(4) {
+		s, err := NewSubsetFor(p, parties(4), 1, parties(7), 5, func(proposer int) Coin { return keys[p].Coin(fmt.Sprint(proposer)) })
+		require.NoError(t, err)
+		subsets[p] = s
+		for _, proposer := range []int{2, 3, 4, 6, 7} {
+			out, err := s.Accept(proposer)
+			require.NoError(t, err)
+			queue = append(queue, out...)
+		}
+	}
+
+	for len(queue) > 0 {
+		k := rnd.Intn(len(queue))
+		env := queue[k]
+		queue = append(queue[:k], queue[k+1:]...)
+		out, err := subsets[env.To].Handle(env.From, env.Proposer, env.Msg)
+		require.NoError(t, err)
+		queue = append(queue, out...)
+	}
+
+	set := subsets[1].Set()
+	require.GreaterOrEqual(t, len(set), 5)
+	require.Subset(t, []int{2, 3, 4, 6, 7}, set)
+	for _, p := range parties(4) {
+		require.Equal(t, set, subsets[p].Set())
+	}
+
+	_, err := NewSubsetFor(1, parties(4), 1, parties(7), 8, func(int) Coin { return keys[1].Coin("x") })
+	require.Error(t, err)
+	_, err = NewSubsetFor(1, parties(4), 1, parties(7), 0, func(int) Coin { return keys[1].Coin("x") })
+	require.Error(t, err)
+}
@@ -0,0 +1,173 @@
+package agreement
+
+import (
+	"BingoVSS/Internal/MSM"
+	"BingoVSS/Internal/Polynomial"
+	"crypto/cipher"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"sort"
+
+	"github.com/drand/kyber"
+	"github.com/drand/kyber/pairing"
+	"github.com/drand/kyber/sign/bls"
+)
+
+// Coin is a common coin. Every party releases a share of the coin of a round, and Threshold valid
+// shares of distinct parties determine its value, which is the same for every party.
+type Coin interface {
+	Share(round int) ([]byte, error)
+	Verify(from, round int, share []byte) error
+	Value(round int, shares map[int][]byte) (bool, error)
+	Threshold() int
+}
+
+/*
+CoinKey is the share of party Self in a threshold BLS key x, after Cachin, Kursawe and Shoup. The
+coin of a round is the last bit of SHA-256 of the unique BLS signature on the session and the
+round. Any t signature shares x_j·H(m) determine that signature, and fewer reveal nothing about
+it, so with f < t <= n-f the coin is unpredictable until an honest party released its share and
+every round still gets its coin.
+*/
+type CoinKey struct {
+	g      pairing.Suite
+	self   int
+	share  kyber.Scalar        // x_self
+	public map[int]kyber.Point // x_j·g_2 of every party j
+	t      int
+}
+
+/* This function constructs the coin key of party self from its share of x, the public key shares x_j·g_2 of all parties and the number t of shares that determine a coin */
+func NewCoinKey(g pairing.Suite, self int, share kyber.Scalar, public map[int]kyber.Point, t int) (*CoinKey, error) {
+	if t < 1 || t > len(public) {
+		return nil, fmt.Errorf("agreement: coin threshold %d for %d parties", t, len(public))
+	}
+	if share == nil || public[self] == nil || !g.G2().Point().Mul(share, nil).Equal(public[self]) {
+		return nil, fmt.Errorf("agreement: the coin key share of %d does not match its public key share", self)
+	}
+	for j, X := range public {
+		if X == nil {
+			return nil, fmt.Errorf("agreement: no public coin key share for %d", j)
+		}
+	}
+
+	copied := make(map[int]kyber.Point, len(public))
+	for j, X := range public {
+		copied[j] = X.Clone()
+	}
+	return &CoinKey{g: g, self: self, share: share.Clone(), public: copied, t: t}, nil
+}
+
+/*
+This function deals the coin keys of the given parties with a random polynomial of degree t-1, as
+a trusted dealer. Like the SRS, this is a one-time setup; the keys can also come from a distributed
+key generation through NewCoinKey.
+*/
+func DealCoinKeys(g pairing.Suite, parties []int, t int, rand cipher.Stream) (map[int]*CoinKey, error) {
+	if t < 1 || t > len(parties) {
+		return nil, fmt.Errorf("agreement: coin threshold %d for %d parties", t, len(parties))
+	}
+	coeffs := make([]kyber.Scalar, t)
+	for i := range coeffs {
+		coeffs[i] = g.G1().Scalar().Pick(rand)
+	}
+
+	shares := make(map[int]kyber.Scalar, len(parties))
+	public := make(map[int]kyber.Point, len(parties))
+	for _, j := range parties {
+		if j < 1 {
+			return nil, fmt.Errorf("agreement: party %d cannot hold a coin key share", j)
+		}
+		if shares[j] != nil {
+			return nil, fmt.Errorf("agreement: party %d listed twice", j)
+		}
+		shares[j] = polynomial.Evaluate(g, coeffs, g.G1().Scalar().SetInt64(int64(j)))
+		public[j] = g.G2().Point().Mul(shares[j], nil)
+	}
+
+	keys := make(map[int]*CoinKey, len(parties))
+	for _, j := range parties {
+		k, err := NewCoinKey(g, j, shares[j], public, t)
+		if err != nil {
+			return nil, err
+		}
+		keys[j] = k
+	}
+	return keys, nil
+}
+
+// ReturnSelf returns the party that holds the key share.
+func (k *CoinKey) ReturnSelf() int {
+	return k.self
+}
+
+// Coin returns the coin of the session. Every instance that uses the key needs its own session.
+func (k *CoinKey) Coin(session string) Coin {
+	return &thresholdCoin{key: k, session: session}
+}
+
+type thresholdCoin struct {
+	key     *CoinKey
+	session string
+}
+
+// message returns the message whose signature is the coin of the round.
+func (c *thresholdCoin) message(round int) []byte {
+	m := []byte("BingoVSS/coin")
+	m = binary.BigEndian.AppendUint32(m, uint32(len(c.session)))
+	m = append(m, c.session...)
+	return binary.BigEndian.AppendUint64(m, uint64(round))
+}
+
+func (c *thresholdCoin) Share(round int) ([]byte, error) {
+	return bls.NewSchemeOnG1(c.key.g).Sign(c.key.share, c.message(round))
+}
+
+func (c *thresholdCoin) Verify(from, round int, share []byte) error {
+	X := c.key.public[from]
+	if X == nil {
+		return fmt.Errorf("agreement: %d holds no coin key share", from)
+	}
+	return bls.NewSchemeOnG1(c.key.g).Verify(X, c.message(round), share)
+}
+
+// Value interpolates the signature from t verified shares and hashes it.
+func (c *thresholdCoin) Value(round int, shares map[int][]byte) (bool, error) {
+	g := c.key.g
+	if len(shares) < c.key.t {
+		return false, fmt.Errorf("agreement: %d coin shares, %d are needed", len(shares), c.key.t)
+	}
+
+	ids := make([]int, 0, len(shares))
+	for j := range shares {
+		ids = append(ids, j)
+	}
+	sort.Ints(ids)
+	ids = ids[:c.key.t]
+
+	x := make([]kyber.Scalar, len(ids))
+	sigs := make([]kyber.Point, len(ids))
+	for k, j := range ids {
+		x[k] = g.G1().Scalar().SetInt64(int64(j))
+		sigs[k] = g.G1().Point()
+		if err := sigs[k].UnmarshalBinary(shares[j]); err != nil {
+			return false, fmt.Errorf("agreement: invalid coin share of %d: %v", j, err)
+		}
+	}
+	l, err := polynomial.NewLagrange(g, x)
+	if err != nil {
+		return false, err
+	}
+
+	sig, err := msm.MultiExp(g.G1(), sigs, l.At(g.G1().Scalar().Zero())).MarshalBinary()
+	if err != nil {
+		return false, err
+	}
+	d := sha256.Sum256(sig)
+	return d[len(d)-1]&1 == 1, nil
+}
+
+func (c *thresholdCoin) Threshold() int {
+	return c.key.t
+}
@@ -0,0 +1,166 @@
+package agreement
+
+import (
+	"fmt"
+	"sort"
+)
+
+// SubsetEnvelope carries a message of the agreement on the proposal of Proposer.
+type SubsetEnvelope struct {
+	Proposer int
+	Envelope
+}
+
+/*
+Subset agrees on a subset of the proposals of n >= 3f+1 parties, after Ben-Or, Kelmer and Rabin:
+one binary agreement runs per proposer. A party inputs 1 to the agreement of every proposal it
+accepted, and once n-f agreements decided 1, it inputs 0 to the rest. All honest parties output
+the same subset of at least n-f proposers, and an agreement only decides 1 for a proposal that
+some honest party accepted. With NewSubsetFor, the proposers need not be the parties that agree.
+*/
+type Subset struct {
+	self     int
+	order    []int // the proposers
+	quorum   int   // the number of proposals decided 1 after which a party inputs 0 to the rest
+	aba      map[int]*Instance
+	accepted map[int]bool
+	input    map[int]bool
+	set      []int
+}
+
+/* This function constructs the part of party self in a subset agreement; coin returns the coin of the agreement on each proposer */
+func NewSubset(self int, parties []int, f int, coin func(proposer int) Coin) (*Subset, error) {
+	return NewSubsetFor(self, parties, f, parties, len(parties)-f, coin)
+}
+
+/*
+This function constructs the part of party self in an agreement of the parties on a subset of the
+proposals of others, for example of the members of a previous committee. Once quorum agreements
+decided 1, a party inputs 0 to the rest, so quorum must be at most the number of proposers that
+are honest.
+*/
+func NewSubsetFor(self int, parties []int, f int, proposers []int, quorum int, coin func(proposer int) Coin) (*Subset, error) {
+	if coin == nil {
+		return nil, fmt.Errorf("agreement: no coin")
+	}
+	if quorum < 1 || quorum > len(proposers) {
+		return nil, fmt.Errorf("agreement: quorum %d for %d proposers", quorum, len(proposers))
+	}
+	s := &Subset{
+		self:     self,
+		order:    append([]int{}, proposers...),
+		quorum:   quorum,
+		aba:      make(map[int]*Instance, len(proposers)),
+		accepted: make(map[int]bool),
+		input:    make(map[int]bool),
+	}
+	sort.Ints(s.order)
+	for _, p := range s.order {
+		a, err := NewInstance(self, parties, f, coin(p))
+		if err != nil {
+			return nil, err
+		}
+		s.aba[p] = a
+	}
+	return s, nil
+}
+
+// Accept records that the proposal of proposer is valid and available, and returns the messages to send.
+func (s *Subset) Accept(proposer int) ([]SubsetEnvelope, error) {
+	if s.aba[proposer] == nil {
+		return nil, fmt.Errorf("agreement: unknown proposer %d", proposer)
+	}
+	s.accepted[proposer] = true
+	return s.progress()
+}
+
+// Handle processes one message of the agreement on proposer from the party from.
+func (s *Subset) Handle(from, proposer int, m Message) ([]SubsetEnvelope, error) {
+	a := s.aba[proposer]
+	if a == nil {
+		return nil, fmt.Errorf("agreement: agreement on unknown proposer %d from %d", proposer, from)
+	}
+	out, err := a.Handle(from, m)
+	if err != nil {
+		return nil, err
+	}
+	next, err := s.progress()
+	if err != nil {
+		return nil, err
+	}
+	return append(wrap(proposer, out), next...), nil
+}
+
+// Set returns the agreed proposers in increasing order, or nil while some agreement is undecided.
+func (s *Subset) Set() []int {
+	return s.set
+}
+
+// Decisions returns the number of agreements that decided.
+func (s *Subset) Decisions() int {
+	decided := 0
+	for _, a := range s.aba {
+		if _, ok := a.Decided(); ok {
+			decided++
+		}
+	}
+	return decided
+}
+
+// progress gives the agreements their inputs, which may make more of them decide.
+func (s *Subset) progress() ([]SubsetEnvelope, error) {
+	var out []SubsetEnvelope
+	for changed := true; changed; {
+		changed = false
+		ones := 0
+		for _, a := range s.aba {
+			if v, ok := a.Decided(); ok && v {
+				ones++
+			}
+		}
+		for _, p := range s.order {
+			if s.input[p] {
+				continue
+			}
+			var v bool
+			switch {
+			case ones >= s.quorum:
+				v = false
+			case s.accepted[p]:
+				v = true
+			default:
+				continue
+			}
+			s.input[p] = true
+			next, err := s.aba[p].Input(v)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, wrap(p, next)...)
+			changed = true
+		}
+	}
+
+	if s.set == nil {
+		set := []int{}
+		for _, p := range s.order {
+			v, ok := s.aba[p].Decided()
+			if !ok {
+				return out, nil
+			}
+			if v {
+				set = append(set, p)
+			}
+		}
+		s.set = set
+	}
+	return out, nil
+}
+
+func wrap(proposer int, msgs []Envelope) []SubsetEnvelope {
+	out := make([]SubsetEnvelope, len(msgs))
+	for k, env := range msgs {
+		out[k] = SubsetEnvelope{Proposer: proposer, Envelope: env}
+	}
+	return out
+}
@@ -1,6 +1,7 @@
 package bivpoly
 
 import (
+	"BingoVSS/Internal/Polynomial"
 	"crypto/cipher"
 	"crypto/subtle"
 	"errors"
@@ -8,7 +9,7 @@ import (
 	"sort"
 
 	"github.com/drand/kyber"
-	"github.com/drand/kyber/pairing/bn256"
+	"github.com/drand/kyber/pairing"
 )
 
 // PriShare represents a private share.
@@ -23,49 +24,49 @@ func (p *PriShare) String() string {
 
 // PriPoly represents a secret sharing polynomial.
 type PriPoly struct {
-	g     *bn256.Suite   // Cryptographic group
+	g     pairing.Suite  // Cryptographic group
 	f_x   []kyber.Scalar // Coefficients of the polynomial
 	f_h_x []kyber.Scalar
 }
 
 // BivPoly represents a bivariate polynomial
 type BivPoly struct {
-	g      *bn256.Suite     // Cryptographic group
-	coeffs [][]kyber.Scalar // Coefficients of the polynomial
-	d_1    int              //degree in x
-	d_2    int              //degree in y
+	g      pairing.Suite    // Cryptographic group
+	coeffs [][]kyber.Scalar // Coefficients of the polynomial, coeffs[i][j] for X^i Y^j
 }
 
 // PrivBivPoly represents the secret sharing bivariate polynomial
 type PrivBivPoly struct {
-	g      *bn256.Suite     // Cryptographic group
-	coeffs [][]kyber.Scalar // Coefficients of the polynomial
-	d_1    int
-	d_2    int
+	BivPoly
 }
 
 // NewPrivBivPoly creates a new secret sharing bivariate polynomial. (as defined in the paper's algo)
-func NewPrivBivPoly(g *bn256.Suite, poly *BivPoly, d_1, d_2 int, secrets []kyber.Scalar) *PrivBivPoly {
+// The degrees are the ones of poly.
+func NewPrivBivPoly(g pairing.Suite, poly *BivPoly, secrets []kyber.Scalar) (*PrivBivPoly, error) {
+	d_1, d_2 := poly.DegreeX()+1, poly.DegreeY()+1
 
 	//First Step perform Vandermonde to satisfy that φ(-κ,0) = S_k. This will eventually return coefficients
 	// that when evaluated to the specific points (-κ) will give back the secrets
-	secret_coefficients := recoverVandermonde(g, secrets, d_1)
+	secret_coefficients, err := recoverVandermonde(g, secrets, d_1)
+	if err != nil {
+		return nil, err
+	}
 
 	//Second Step: As soon as we received the new coeffs need to adjust them to the given random polynom
 	poly.adjustCoefficients(d_2, secret_coefficients, g)
 
 	//Third_Step: Create Projection Polynomials
-	uni_f := CreateProjectionPolynomials(g, poly.coeffs, d_1, d_2, d_2)
+	uni_f := CreateProjectionPolynomials(poly, d_2)
 
 	//Fourth_Step: Interpolate the polynomial to become again a bivariate one.
 	final := interpolatePolynomial(poly.coeffs, uni_f, d_1, d_2)
 
 	//Final_Step create the new bivariate polynomial and return it.
-	return &PrivBivPoly{g: g, coeffs: final, d_1: d_1 - 1, d_2: d_2 - 1}
+	return &PrivBivPoly{BivPoly{g: g, coeffs: final}}, nil
 
 }
 
-func findNegShares(poly []kyber.Scalar, n int, g *bn256.Suite) []kyber.Scalar {
+func findNegShares(poly []kyber.Scalar, n int, g pairing.Suite) []kyber.Scalar {
 	shares := make([]kyber.Scalar, n)
 
 	for i := 0; i < n; i++ {
@@ -87,25 +88,20 @@ func interpolatePolynomial(f_1 [][]kyber.Scalar, a_f1_x [][]kyber.Scalar, d_1, d
 	return f_1
 }
 
-// Threshold returns the secret sharing threshold.
+// ReturnCoefficients returns the coefficients, coeffs[i][j] for X^i Y^j.
 func (p BivPoly) ReturnCoefficients() [][]kyber.Scalar {
 	return p.coeffs
 }
 
 // Threshold returns the secret sharing threshold.
-func (p PrivBivPoly) ReturnCoefficients() [][]kyber.Scalar {
-	return p.coeffs
-}
-
-// Threshold returns the secret sharing threshold.
-func (p *BivPoly) adjustCoefficients(d_2 int, coeff []kyber.Scalar, g *bn256.Suite) {
+func (p *BivPoly) adjustCoefficients(d_2 int, coeff []kyber.Scalar, g pairing.Suite) {
 
 	for i := 0; i < len(coeff); i++ {
 		p.coeffs[i][0] = coeff[i]
 	}
 }
 
-func recoverVandermonde(g *bn256.Suite, s []kyber.Scalar, d_1 int) []kyber.Scalar {
+func recoverVandermonde(g pairing.Suite, s []kyber.Scalar, d_1 int) ([]kyber.Scalar, error) {
 
 	//set_data_points
 	//set_data_points_for_x
@@ -130,20 +126,11 @@ func recoverVandermonde(g *bn256.Suite, s []kyber.Scalar, d_1 int) []kyber.Scala
 
 	}
 
-	// perform vandermonde on this
-	degree := len(x) - 1
-
-	// Generate Vandermonde matrix
-	vMatrix := vandermonde(x, degree)
-
-	// Solve the system of linear equations
-	coeffs := solveLinearSystem(vMatrix, y)
-
-	return coeffs
+	return polynomial.Interpolate(g, x, y)
 
 }
 
-func RecoverVandermondePos(g *bn256.Suite, s []kyber.Scalar, d_1 int) []kyber.Scalar {
+func RecoverVandermondePos(g pairing.Suite, s []kyber.Scalar, d_1 int) ([]kyber.Scalar, error) {
 
 	//set_data_points
 	//set_data_points_for_x
@@ -168,20 +155,11 @@ func RecoverVandermondePos(g *bn256.Suite, s []kyber.Scalar, d_1 int) []kyber.Sc
 
 	}
 
-	// perform vandermonde on this
-	degree := len(x) - 1
-
-	// Generate Vandermonde matrix
-	vMatrix := vandermonde(x, degree)
-
-	// Solve the system of linear equations
-	coeffs := solveLinearSystem(vMatrix, y)
-
-	return coeffs
+	return polynomial.Interpolate(g, x, y)
 
 }
 
-func RecoverVandermondeGivenX(g *bn256.Suite, xg []kyber.Scalar, s []kyber.Scalar, d_1 int) []kyber.Scalar {
+func RecoverVandermondeGivenX(g pairing.Suite, xg []kyber.Scalar, s []kyber.Scalar, d_1 int) ([]kyber.Scalar, error) {
 
 	//set_data_points
 	//set_data_points_for_x
@@ -191,7 +169,6 @@ func RecoverVandermondeGivenX(g *bn256.Suite, xg []kyber.Scalar, s []kyber.Scala
 		if j < len(xg) {
 			x[j] = xg[j]
 		} else {
-			fmt.Println("elia")
 			x[j] = g.G1().Scalar().SetInt64(int64(j))
 		}
 
@@ -209,22 +186,13 @@ func RecoverVandermondeGivenX(g *bn256.Suite, xg []kyber.Scalar, s []kyber.Scala
 
 	}
 
-	// perform vandermonde on this
-	degree := len(x) - 1
-
-	// Generate Vandermonde matrix
-	vMatrix := vandermonde(x, degree)
-
-	// Solve the system of linear equations
-	coeffs := solveLinearSystem(vMatrix, y)
-
-	return coeffs
+	return polynomial.Interpolate(g, x, y)
 
 }
 
-// NewBivPoly creates a new bivariate polynomial using the provided
-// cryptographic group, the degree in x (d_1) and the degree in y (d_2)
-func NewBivPolyRandom(g *bn256.Suite, d_1, d_2 int, rand cipher.Stream) *BivPoly {
+// NewBivPolyRandom creates a random bivariate polynomial using the provided
+// cryptographic group, with d_1 coefficients in x and d_2 coefficients in y
+func NewBivPolyRandom(g pairing.Suite, d_1, d_2 int, rand cipher.Stream) *BivPoly {
 
 	coeffs := make([][]kyber.Scalar, d_1)
 
@@ -235,11 +203,11 @@ func NewBivPolyRandom(g *bn256.Suite, d_1, d_2 int, rand cipher.Stream) *BivPoly
 		}
 	}
 
-	return &BivPoly{g: g, coeffs: coeffs, d_1: d_1 - 1, d_2: d_2 - 1}
+	return &BivPoly{g: g, coeffs: coeffs}
 }
 
 // NewPriPoly creates a new secret sharing polynomial that the dealer shares with the participants.
-func NewPriPoly(group *bn256.Suite, f int, coeffs, coeffs_2 []kyber.Scalar, rand cipher.Stream) *PriPoly {
+func NewPriPoly(group pairing.Suite, f int, coeffs, coeffs_2 []kyber.Scalar, rand cipher.Stream) *PriPoly {
 
 	return &PriPoly{g: group, f_x: coeffs, f_h_x: coeffs_2}
 }
@@ -249,42 +217,15 @@ func NewPriShare(i int, val kyber.Scalar) *PriShare {
 	return &PriShare{I: i, V: val}
 }
 
-// NewPriPoly creates a new secret sharing polynomial using the provided
-// cryptographic group, the secret sharing threshold t, and the secret to be
-// shared s. If s is nil, a new s is chosen using the provided randomness
-// stream rand.
-func NewBivPoly(g *bn256.Suite, f int, s kyber.Scalar, rand cipher.Stream) *BivPoly {
-	d_1 := 2*f + 1
-	d_2 := f + 1
-
-	coeffs := make([][]kyber.Scalar, d_1) //this represents the Φ(Χ)
-	coeffs[0] = make([]kyber.Scalar, d_2)
-	coeffs[1] = make([]kyber.Scalar, d_2)
-	coeffs[2] = make([]kyber.Scalar, d_2)
-	coeffs[3] = make([]kyber.Scalar, d_2)
-	coeffs[4] = make([]kyber.Scalar, d_2)
-
-	coeffs[0][0] = g.G1().Scalar().SetInt64(2)
-	coeffs[0][1] = g.G1().Scalar().SetInt64(2)
-	coeffs[0][2] = g.G1().Scalar().SetInt64(2)
-
-	coeffs[1][0] = g.G1().Scalar().SetInt64(0)
-	coeffs[1][1] = g.G1().Scalar().SetInt64(1)
-	coeffs[1][2] = g.G1().Scalar().SetInt64(2)
-
-	coeffs[2][0] = g.G1().Scalar().SetInt64(0)
-	coeffs[2][1] = g.G1().Scalar().SetInt64(1)
-	coeffs[2][2] = g.G1().Scalar().SetInt64(1)
-
-	coeffs[3][0] = g.G1().Scalar().SetInt64(0)
-	coeffs[3][1] = g.G1().Scalar().SetInt64(1)
-	coeffs[3][2] = g.G1().Scalar().SetInt64(0)
-
-	coeffs[4][0] = g.G1().Scalar().SetInt64(0)
-	coeffs[4][1] = g.G1().Scalar().SetInt64(1)
-	coeffs[4][2] = g.G1().Scalar().SetInt64(0)
-
-	return &BivPoly{g: g, coeffs: coeffs, d_1: 2*f + 1, d_2: f + 1}
+// NewBivPoly creates a random bivariate polynomial of degree 2f in X and f in Y, the degrees
+// BingoShare uses, with φ(0, 0) = s. If s is nil, a new s is chosen using the provided
+// randomness stream rand.
+func NewBivPoly(g pairing.Suite, f int, s kyber.Scalar, rand cipher.Stream) *BivPoly {
+	p := NewBivPolyRandom(g, 2*f+1, f+1, rand)
+	if s != nil {
+		p.coeffs[0][0] = g.G1().Scalar().Set(s)
+	}
+	return p
 }
 
 // Coefficients return the list of coefficients representing p.
@@ -298,7 +239,7 @@ func (p *PriPoly) Coefficients_2() []kyber.Scalar {
 
 // RecoverSecret reconstructs the shared secret p(0) from a list of private
 // shares using Lagrange interpolation.
-func RecoverSecret(g *bn256.Suite, shares []*PriShare, t, n int) (kyber.Scalar, error) {
+func RecoverSecret(g pairing.Suite, shares []*PriShare, t, n int) (kyber.Scalar, error) {
 	x, y := xyScalar(g, shares, t, n)
 	if len(x) < t {
 		return nil, errors.New("share: not enough shares to recover secret")
@@ -326,6 +267,38 @@ func RecoverSecret(g *bn256.Suite, shares []*PriShare, t, n int) (kyber.Scalar,
 	return acc, nil
 }
 
+/*
+RecoverSecretRobust reconstructs the shared secret p(0) of a polynomial with t coefficients from
+all the given shares, when up to (len(shares)-t)/2 of them may hold arbitrary values. It decodes
+the shares as a Reed–Solomon codeword and returns the indices of the corrupt shares as well.
+*/
+func RecoverSecretRobust(g pairing.Suite, shares []*PriShare, t int) (kyber.Scalar, []int, error) {
+	sorted := make([]*PriShare, 0, len(shares))
+	for _, share := range shares {
+		if share != nil && share.V != nil && share.I >= 0 {
+			sorted = append(sorted, share)
+		}
+	}
+	sort.Sort(byIndexScalar(sorted))
+
+	x := make([]kyber.Scalar, len(sorted))
+	y := make([]kyber.Scalar, len(sorted))
+	for i, s := range sorted {
+		x[i] = g.G1().Scalar().SetInt64(int64(s.I + 1))
+		y[i] = s.V
+	}
+	p, errs, err := polynomial.Decode(g, x, y, t)
+	if err != nil {
+		return nil, nil, fmt.Errorf("share: %w", err)
+	}
+
+	corrupt := make([]int, len(errs))
+	for i, e := range errs {
+		corrupt[i] = sorted[e].I
+	}
+	return p[0], corrupt, nil
+}
+
 type byIndexScalar []*PriShare
 
 func (s byIndexScalar) Len() int           { return len(s) }
@@ -335,7 +308,7 @@ func (s byIndexScalar) Less(i, j int) bool { return s[i].I < s[j].I }
 // xyScalar returns the list of (x_i, y_i) pairs indexed. The first map returned
 // is the list of x_i and the second map is the list of y_i, both indexed in
 // their respective map at index i.
-func xyScalar(g *bn256.Suite, shares []*PriShare, t, n int) (map[int]kyber.Scalar, map[int]kyber.Scalar) {
+func xyScalar(g pairing.Suite, shares []*PriShare, t, n int) (map[int]kyber.Scalar, map[int]kyber.Scalar) {
 	// we are sorting first the shares since the shares may be unrelated for
 	// some applications. In this case, all participants needs to interpolate on
 	// the exact same order shares.
@@ -371,13 +344,13 @@ type PubShare struct {
 
 // PubPoly represents a public commitment polynomial to a secret sharing polynomial.
 type PubPoly struct {
-	g       *bn256.Suite  // Cryptographic group
+	g       pairing.Suite // Cryptographic group
 	b       kyber.Point   // Base point, nil for standard base
 	commits []kyber.Point // Commitments to coefficients of the secret sharing polynomial
 }
 
 // NewPubPoly creates a new public commitment polynomial.
-func NewPubPoly(g *bn256.Suite, b kyber.Point, commits []kyber.Point) *PubPoly {
+func NewPubPoly(g pairing.Suite, b kyber.Point, commits []kyber.Point) *PubPoly {
 	return &PubPoly{g, b, commits}
 }
 
@@ -399,7 +372,7 @@ func (p *PubPoly) Commit() kyber.Point {
 // Eval computes the public share v = p(i).
 func (p *PubPoly) Eval(i int) *PubShare {
 	xi := p.g.G1().Scalar().SetInt64(1 + int64(i)) // x-coordinate of this share
-	v := p.g.Point().Null()
+	v := p.g.G1().Point().Null()
 	for j := p.Threshold() - 1; j >= 0; j-- {
 		v.Mul(xi, v)
 		v.Add(v, p.commits[j])
@@ -412,7 +385,7 @@ func (p *PubPoly) Eval(i int) *PubShare {
 // this routine returns in variable time. Otherwise it runs in constant time
 // regardless of whether it eventually returns true or false.
 func (p *PubPoly) Equal(q *PubPoly) bool {
-	if p.g.String() != q.g.String() {
+	if p.g.G1().String() != q.g.G1().String() {
 		return false
 	}
 	b := 1
@@ -424,7 +397,7 @@ func (p *PubPoly) Equal(q *PubPoly) bool {
 	return b == 1
 }
 
-func LagrangeInterpolation(suite *bn256.Suite, points []kyber.Scalar, x kyber.Scalar) kyber.Scalar {
+func LagrangeInterpolation(suite pairing.Suite, points []kyber.Scalar, x kyber.Scalar) kyber.Scalar {
 	n := len(points)
 	result := suite.G1().Scalar().Zero()
 
@@ -38,7 +38,8 @@ func TestCreationSharingRandom(test *testing.T) {
 		secrets[i] = g.G1().Scalar().Pick(g.RandomStream())
 	}
 
-	poly_s := NewPrivBivPoly(g, poly, d_1, d_2, secrets)
+	poly_s, err := NewPrivBivPoly(g, poly, secrets)
+	require.NoError(test, err)
 
 	require.Len(test, poly_s.coeffs, d_1+1)
 
@@ -61,9 +62,10 @@ func TestReconstructionOfSecretsRandom(test *testing.T) {
 		secrets[i] = g.G1().Scalar().Pick(g.RandomStream())
 	}
 
-	poly_s := NewPrivBivPoly(g, poly, d_1+1, d_2+1, secrets)
+	poly_s, err := NewPrivBivPoly(g, poly, secrets)
+	require.NoError(test, err)
 
-	f_p := CreateProjectionPolynomials(g, poly_s.coeffs, d_1+1, d_2+1, d_2+1)
+	f_p := CreateProjectionPolynomials(&poly_s.BivPoly, d_2+1)
 
 	// //Create the shares for each
 	shares := make([][]kyber.Scalar, len(f_p))
@@ -82,7 +84,8 @@ func TestReconstructionOfSecretsRandom(test *testing.T) {
 		}
 
 		//reconstruct the polynomial
-		poly_rec := RecoverVandermondePos(g, poly_sk, len(shares))
+		poly_rec, err := RecoverVandermondePos(g, poly_sk, len(shares))
+		require.NoError(test, err)
 		// evaluate it at 0
 		x := g.G1().Scalar().SetInt64(0)
 		reco := EvaluatePolynomial(poly_rec, x, g)
@@ -110,11 +113,12 @@ func TestReconstructionOfSecretsRandomLangrange(test *testing.T) {
 		secrets[i] = g.G1().Scalar().Pick(g.RandomStream())
 	}
 
-	poly_s := NewPrivBivPoly(g, poly, d_1+1, d_2+1, secrets)
+	poly_s, err := NewPrivBivPoly(g, poly, secrets)
+	require.NoError(test, err)
 
 	//we are creating again the univariate polynomials
 
-	f_p := CreateProjectionPolynomials(g, poly_s.coeffs, d_1+1, d_2+1, d_2+1)
+	f_p := CreateProjectionPolynomials(&poly_s.BivPoly, d_2+1)
 
 	// //Create the shares for each
 	shares := make([][]kyber.Scalar, len(f_p))
@@ -140,3 +144,196 @@ func TestReconstructionOfSecretsRandomLangrange(test *testing.T) {
 
 	}
 }
+
+func TestRecoverVandermondeDuplicatePoints(test *testing.T) {
+	g := bn256.NewSuite()
+
+	one := g.G1().Scalar().SetInt64(1)
+	y := []kyber.Scalar{g.G1().Scalar().SetInt64(5), g.G1().Scalar().SetInt64(7)}
+
+	// Two values for the same x cannot be interpolated
+	_, err := RecoverVandermondeGivenX(g, []kyber.Scalar{one, one}, y, 2)
+	require.Error(test, err)
+
+	// Neither can a given x that collides with a padded one
+	_, err = RecoverVandermondeGivenX(g, []kyber.Scalar{one}, y, 2)
+	require.Error(test, err)
+
+	coeffs, err := RecoverVandermondeGivenX(g, []kyber.Scalar{one, g.G1().Scalar().SetInt64(2)}, y, 2)
+	require.NoError(test, err)
+	require.True(test, EvaluatePolynomial(coeffs, one, g).Equal(y[0]))
+}
+
+func TestBivPolyAlgebra(test *testing.T) {
+	g := bn256.NewSuite()
+	p := NewBivPolyRandom(g, 5, 3, g.RandomStream())
+	q := NewBivPolyRandom(g, 3, 4, g.RandomStream())
+	require.Equal(test, 4, p.DegreeX())
+	require.Equal(test, 2, p.DegreeY())
+
+	x := g.G1().Scalar().Pick(g.RandomStream())
+	y := g.G1().Scalar().Pick(g.RandomStream())
+
+	// φ(x, y) = Σ c[i][j] x^i y^j
+	want := g.G1().Scalar().Zero()
+	for i, row := range p.ReturnCoefficients() {
+		for j, c := range row {
+			term := g.G1().Scalar().Mul(c, Pow(i, x, nil, g))
+			want.Add(want, term.Mul(term, Pow(j, y, nil, g)))
+		}
+	}
+	require.True(test, p.Evaluate(x, y).Equal(want))
+
+	// rows and columns are restrictions
+	require.True(test, EvaluatePolynomial(p.Row(y), x, g).Equal(want))
+	require.True(test, EvaluatePolynomial(p.Column(x), y, g).Equal(want))
+	require.Len(test, p.Row(y), 5)
+	require.Len(test, p.Column(x), 3)
+
+	sum := p.Add(q)
+	require.Equal(test, 4, sum.DegreeX())
+	require.Equal(test, 3, sum.DegreeY())
+	require.True(test, sum.Evaluate(x, y).Equal(g.G1().Scalar().Add(want, q.Evaluate(x, y))))
+
+	s := g.G1().Scalar().Pick(g.RandomStream())
+	require.True(test, p.Mul(s).Evaluate(x, y).Equal(g.G1().Scalar().Mul(s, want)))
+
+	require.True(test, sum.Add(q.Mul(g.G1().Scalar().SetInt64(-1))).Equal(p))
+	require.False(test, sum.Equal(p))
+
+	// the rows at 0..n match the projections the dealer used before
+	rows := p.Rows(6)
+	projections := CreateProjectionPolynomials(p, 7)
+	for j := range rows {
+		for i := range rows[j] {
+			require.True(test, rows[j][i].Equal(projections[j][i]))
+		}
+	}
+}
+
+func TestInterpolateBivPoly(test *testing.T) {
+	g := bn256.NewSuite()
+	p := NewBivPoly(g, 2, g.G1().Scalar().SetInt64(42), g.RandomStream())
+	require.Equal(test, 4, p.DegreeX())
+	require.Equal(test, 2, p.DegreeY())
+	require.True(test, p.Evaluate(g.G1().Scalar().Zero(), g.G1().Scalar().Zero()).Equal(g.G1().Scalar().SetInt64(42)))
+
+	x := make([]kyber.Scalar, p.DegreeX()+1)
+	y := make([]kyber.Scalar, p.DegreeY()+1)
+	for a := range x {
+		x[a] = g.G1().Scalar().SetInt64(int64(a + 1))
+	}
+	for b := range y {
+		y[b] = g.G1().Scalar().Neg(g.G1().Scalar().SetInt64(int64(b)))
+	}
+	values := make([][]kyber.Scalar, len(x))
+	for a := range x {
+		values[a] = make([]kyber.Scalar, len(y))
+		for b := range y {
+			values[a][b] = p.Evaluate(x[a], y[b])
+		}
+	}
+
+	q, err := InterpolateBivPoly(g, x, y, values)
+	require.NoError(test, err)
+	require.True(test, q.Equal(p))
+
+	_, err = InterpolateBivPoly(g, x, y, values[1:])
+	require.Error(test, err)
+	values[2] = values[2][1:]
+	_, err = InterpolateBivPoly(g, x, y, values)
+	require.Error(test, err)
+	_, err = InterpolateBivPoly(g, []kyber.Scalar{x[0], x[0]}, y, values[:2])
+	require.Error(test, err)
+}
+
+func TestNewBivPolyFromCoefficients(test *testing.T) {
+	g := bn256.NewSuite()
+	p := NewBivPolyRandom(g, 3, 2, g.RandomStream())
+
+	q, err := NewBivPolyFromCoefficients(g, p.ReturnCoefficients())
+	require.NoError(test, err)
+	require.True(test, q.Equal(p))
+
+	// the coefficients are copied
+	q.ReturnCoefficients()[0][0].Add(q.ReturnCoefficients()[0][0], g.G1().Scalar().One())
+	require.False(test, q.Equal(p))
+
+	_, err = NewBivPolyFromCoefficients(g, nil)
+	require.Error(test, err)
+	_, err = NewBivPolyFromCoefficients(g, [][]kyber.Scalar{{g.G1().Scalar().One()}, {}})
+	require.Error(test, err)
+	_, err = NewBivPolyFromCoefficients(g, [][]kyber.Scalar{{nil}})
+	require.Error(test, err)
+}
+
+func TestRecoverSecretRobust(test *testing.T) {
+	g := bn256.NewSuite()
+	f := 3
+	p := NewBivPolyRandom(g, f+1, 1, g.RandomStream()).Row(g.G1().Scalar().Zero())
+	require.Len(test, p, f+1)
+
+	shares := make([]*PriShare, 3*f+1)
+	for i := range shares {
+		shares[i] = NewPriShare(i, EvaluatePolynomial(p, g.G1().Scalar().SetInt64(int64(i+1)), g))
+	}
+
+	secret, err := RecoverSecret(g, shares, f+1, len(shares))
+	require.NoError(test, err)
+	require.True(test, secret.Equal(p[0]))
+
+	// f garbage shares, in any order
+	shares[2].V = g.G1().Scalar().Pick(g.RandomStream())
+	shares[5].V = g.G1().Scalar().Pick(g.RandomStream())
+	shares[9].V = g.G1().Scalar().Zero()
+	shares[0], shares[9] = shares[9], shares[0]
+	secret, corrupt, err := RecoverSecretRobust(g, shares, f+1)
+	require.NoError(test, err)
+	require.True(test, secret.Equal(p[0]))
+	require.Equal(test, []int{2, 5, 9}, corrupt)
+
+	// one more is beyond what can be corrected
+	shares[4].V = g.G1().Scalar().One()
+	_, _, err = RecoverSecretRobust(g, shares, f+1)
+	require.Error(test, err)
+
+	// with a missing share only (3f-f)/2 errors can be corrected
+	shares[4] = nil
+	_, _, err = RecoverSecretRobust(g, shares, f+1)
+	require.Error(test, err)
+}
+
+func TestNewBivPolyZeroRow(test *testing.T) {
+	g := bn256.NewSuite()
+	p := NewBivPolyZeroRow(g, 4, 2, g.RandomStream())
+	require.Equal(test, 4, p.DegreeX())
+	require.Equal(test, 2, p.DegreeY())
+
+	zero := g.G1().Scalar().Zero()
+	for _, c := range p.Row(zero) {
+		require.True(test, c.Equal(zero))
+	}
+	require.False(test, p.Row(g.G1().Scalar().One())[0].Equal(zero))
+}
+
+func TestNewBivPolyWithRow(test *testing.T) {
+	g := bn256.NewSuite()
+	row := make([]kyber.Scalar, 5)
+	for i := range row {
+		row[i] = g.G1().Scalar().Pick(g.RandomStream())
+	}
+	p, err := NewBivPolyWithRow(g, 2, row, g.RandomStream())
+	require.NoError(test, err)
+	require.Equal(test, 4, p.DegreeX())
+	require.Equal(test, 2, p.DegreeY())
+
+	for i, c := range p.Row(g.G1().Scalar().Zero()) {
+		require.True(test, c.Equal(row[i]))
+	}
+	require.False(test, p.Row(g.G1().Scalar().One())[0].Equal(row[0]))
+
+	_, err = NewBivPolyWithRow(g, 2, nil, g.RandomStream())
+	require.Error(test, err)
+	_, err = NewBivPolyWithRow(g, 2, make([]kyber.Scalar, 3), g.RandomStream())
+	require.Error(test, err)
+}
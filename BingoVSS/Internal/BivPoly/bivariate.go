@@ -0,0 +1,224 @@
+package bivpoly
+
+import (
+	"BingoVSS/Internal/Polynomial"
+	"crypto/cipher"
+	"errors"
+	"fmt"
+
+	"github.com/drand/kyber"
+	"github.com/drand/kyber/pairing"
+)
+
+// A BivPoly φ(X, Y) = Σ_i Σ_j c[i][j] X^i Y^j holds c[i][j] at coeffs[i][j], for i up to the
+// degree bound in X and j up to the degree bound in Y.
+
+/* This function constructs a bivariate polynomial from its coefficients, coeffs[i][j] being the one of X^i Y^j */
+func NewBivPolyFromCoefficients(g pairing.Suite, coeffs [][]kyber.Scalar) (*BivPoly, error) {
+	if len(coeffs) == 0 || len(coeffs[0]) == 0 {
+		return nil, errors.New("bivpoly: no coefficients")
+	}
+	c := make([][]kyber.Scalar, len(coeffs))
+	for i := range coeffs {
+		if len(coeffs[i]) != len(coeffs[0]) {
+			return nil, fmt.Errorf("bivpoly: row %d of the coefficients has %d entries instead of %d", i, len(coeffs[i]), len(coeffs[0]))
+		}
+		c[i] = make([]kyber.Scalar, len(coeffs[i]))
+		for j, s := range coeffs[i] {
+			if s == nil {
+				return nil, fmt.Errorf("bivpoly: missing coefficient %d, %d", i, j)
+			}
+			c[i][j] = g.G1().Scalar().Set(s)
+		}
+	}
+	return &BivPoly{g: g, coeffs: c}, nil
+}
+
+func newZeroBivPoly(g pairing.Suite, d_1, d_2 int) *BivPoly {
+	c := make([][]kyber.Scalar, d_1+1)
+	for i := range c {
+		c[i] = make([]kyber.Scalar, d_2+1)
+		for j := range c[i] {
+			c[i][j] = g.G1().Scalar().Zero()
+		}
+	}
+	return &BivPoly{g: g, coeffs: c}
+}
+
+/*
+This function constructs a random bivariate polynomial of degrees d_1 and d_2 with φ(X, 0) = 0,
+so that adding it to another polynomial leaves the row at Y = 0, and with it every packed
+secret, unchanged.
+*/
+func NewBivPolyZeroRow(g pairing.Suite, d_1, d_2 int, rand cipher.Stream) *BivPoly {
+	p := newZeroBivPoly(g, d_1, d_2)
+	for i := range p.coeffs {
+		for j := 1; j < len(p.coeffs[i]); j++ {
+			p.coeffs[i][j] = g.G1().Scalar().Pick(rand)
+		}
+	}
+	return p
+}
+
+/*
+This function constructs a random bivariate polynomial of degree d_2 in Y whose row at Y = 0 is
+the given one, φ(X, 0) = row, so its degree in X is len(row)-1.
+*/
+func NewBivPolyWithRow(g pairing.Suite, d_2 int, row []kyber.Scalar, rand cipher.Stream) (*BivPoly, error) {
+	if len(row) == 0 {
+		return nil, errors.New("bivpoly: empty row")
+	}
+	p := NewBivPolyZeroRow(g, len(row)-1, d_2, rand)
+	for i, c := range row {
+		if c == nil {
+			return nil, fmt.Errorf("bivpoly: missing coefficient %d of the row", i)
+		}
+		p.coeffs[i][0].Set(c)
+	}
+	return p, nil
+}
+
+// DegreeX returns the degree bound of φ in X.
+func (p *BivPoly) DegreeX() int {
+	return len(p.coeffs) - 1
+}
+
+// DegreeY returns the degree bound of φ in Y.
+func (p *BivPoly) DegreeY() int {
+	if len(p.coeffs) == 0 {
+		return -1
+	}
+	return len(p.coeffs[0]) - 1
+}
+
+func (p *BivPoly) ReturnSuite() pairing.Suite {
+	return p.g
+}
+
+// Evaluate returns φ(x, y).
+func (p *BivPoly) Evaluate(x, y kyber.Scalar) kyber.Scalar {
+	return polynomial.Evaluate(p.g, p.Row(y), x)
+}
+
+// Row returns the coefficients of the univariate polynomial φ(X, y).
+func (p *BivPoly) Row(y kyber.Scalar) []kyber.Scalar {
+	row := make([]kyber.Scalar, len(p.coeffs))
+	for i, c := range p.coeffs {
+		row[i] = polynomial.Evaluate(p.g, c, y)
+	}
+	return row
+}
+
+// Column returns the coefficients of the univariate polynomial φ(x, Y).
+func (p *BivPoly) Column(x kyber.Scalar) []kyber.Scalar {
+	column := make([]kyber.Scalar, p.DegreeY()+1)
+	c := make([]kyber.Scalar, len(p.coeffs))
+	for j := range column {
+		for i := range p.coeffs {
+			c[i] = p.coeffs[i][j]
+		}
+		column[j] = polynomial.Evaluate(p.g, c, x)
+	}
+	return column
+}
+
+// Rows returns the rows φ(X, 0), ..., φ(X, n).
+func (p *BivPoly) Rows(n int) [][]kyber.Scalar {
+	rows := make([][]kyber.Scalar, n+1)
+	for j := range rows {
+		rows[j] = p.Row(p.g.G1().Scalar().SetInt64(int64(j)))
+	}
+	return rows
+}
+
+// Add returns φ + q. The degree bounds of the sum are the larger ones of the two.
+func (p *BivPoly) Add(q *BivPoly) *BivPoly {
+	d_1, d_2 := p.DegreeX(), p.DegreeY()
+	if q.DegreeX() > d_1 {
+		d_1 = q.DegreeX()
+	}
+	if q.DegreeY() > d_2 {
+		d_2 = q.DegreeY()
+	}
+
+	sum := newZeroBivPoly(p.g, d_1, d_2)
+	for _, term := range []*BivPoly{p, q} {
+		for i := range term.coeffs {
+			for j, c := range term.coeffs[i] {
+				sum.coeffs[i][j].Add(sum.coeffs[i][j], c)
+			}
+		}
+	}
+	return sum
+}
+
+// Mul returns s·φ.
+func (p *BivPoly) Mul(s kyber.Scalar) *BivPoly {
+	out := newZeroBivPoly(p.g, p.DegreeX(), p.DegreeY())
+	for i := range p.coeffs {
+		for j, c := range p.coeffs[i] {
+			out.coeffs[i][j].Mul(c, s)
+		}
+	}
+	return out
+}
+
+// Equal tells whether φ and q have the same coefficients, ignoring zero coefficients beyond the
+// degree bound of the other.
+func (p *BivPoly) Equal(q *BivPoly) bool {
+	diff := p.Add(q.Mul(p.g.G1().Scalar().Neg(p.g.G1().Scalar().One())))
+	for i := range diff.coeffs {
+		for _, c := range diff.coeffs[i] {
+			if !c.Equal(p.g.G1().Scalar().Zero()) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+/*
+This function interpolates the bivariate polynomial with degree bounds len(x)-1 in X and
+len(y)-1 in Y from its values on a grid, values[a][b] = φ(x[a], y[b]). It interpolates the rows
+φ(X, y[b]) first and then every coefficient of X along Y.
+*/
+func InterpolateBivPoly(g pairing.Suite, x, y []kyber.Scalar, values [][]kyber.Scalar) (*BivPoly, error) {
+	if len(values) != len(x) {
+		return nil, fmt.Errorf("bivpoly: %d rows of values for %d points in X", len(values), len(x))
+	}
+	lx, err := polynomial.NewLagrange(g, x)
+	if err != nil {
+		return nil, err
+	}
+	ly, err := polynomial.NewLagrange(g, y)
+	if err != nil {
+		return nil, err
+	}
+
+	// rows[b] = φ(X, y[b])
+	rows := make([][]kyber.Scalar, len(y))
+	column := make([]kyber.Scalar, len(x))
+	for b := range y {
+		for a := range x {
+			if len(values[a]) != len(y) {
+				return nil, fmt.Errorf("bivpoly: %d values at x[%d] for %d points in Y", len(values[a]), a, len(y))
+			}
+			column[a] = values[a][b]
+		}
+		if rows[b], err = lx.Interpolate(column); err != nil {
+			return nil, err
+		}
+	}
+
+	p := newZeroBivPoly(g, len(x)-1, len(y)-1)
+	along := make([]kyber.Scalar, len(y))
+	for i := range x {
+		for b := range y {
+			along[b] = rows[b][i]
+		}
+		if p.coeffs[i], err = ly.Interpolate(along); err != nil {
+			return nil, err
+		}
+	}
+	return p, nil
+}
@@ -0,0 +1,114 @@
+package biv_kzg
+
+import (
+	"BingoVSS/Internal/MSM"
+
+	"github.com/drand/kyber"
+)
+
+// Opening is a claimed evaluation y_1, y_2 at Z of the polynomial committed in A[Index],
+// together with its evaluation proof.
+type Opening struct {
+	Index int
+	Z     kyber.Scalar
+	Y_1   kyber.Scalar
+	Y_2   kyber.Scalar
+	Proof kyber.Point
+}
+
+/*
+This function verifies many openings with a single pairing check. Every opening satisfies
+e(A[i] - [y_1]₁ - y_2·gUp + z·π, H) = e(π, [τ]₂), which is KZGVerify with z moved to the left.
+Summing the equations with random coefficients r_k gives
+
+	e(Σ r_k (A[i_k] + z_k π_k) - [Σ r_k y_1k]₁ - (Σ r_k y_2k)·gUp, H) = e(Σ r_k π_k, [τ]₂)
+
+which holds for invalid openings only with negligible probability. The cost is two pairings and
+two multi-scalar multiplications, whatever the number of openings.
+*/
+func KZGBatchVerify(ts *KzgShareSetup, A []kyber.Point, openings []Opening) bool {
+	for _, o := range openings {
+		if !wellFormed(A, o) {
+			return false
+		}
+	}
+	if len(openings) == 0 {
+		return true
+	}
+
+	g1 := ts.g.G1()
+	n := len(openings)
+
+	// the left side has the commitments and the proofs as bases
+	bases := make([]kyber.Point, 0, 2*n)
+	scalars := make([]kyber.Scalar, 0, 2*n)
+	proofs := make([]kyber.Point, n)
+	r := make([]kyber.Scalar, n)
+
+	y_1 := g1.Scalar().Zero()
+	y_2 := g1.Scalar().Zero()
+	for k, o := range openings {
+		r[k] = g1.Scalar().Pick(ts.g.RandomStream())
+		proofs[k] = o.Proof
+
+		bases = append(bases, A[o.Index], o.Proof)
+		scalars = append(scalars, r[k], g1.Scalar().Mul(r[k], o.Z))
+
+		y_1.Add(y_1, g1.Scalar().Mul(r[k], o.Y_1))
+		y_2.Add(y_2, g1.Scalar().Mul(r[k], o.Y_2))
+	}
+
+	left := msm.MultiExp(g1, bases, scalars)
+	left.Sub(left, g1.Point().Mul(y_1, nil))
+	left.Sub(left, g1.Point().Mul(y_2, ts.gUp))
+	right := msm.MultiExp(g1, proofs, r)
+
+	return ts.g.Pair(left, ts.g.G2().Point().Base()).Equal(ts.g.Pair(right, ts.t_2[1]))
+}
+
+/*
+This function tells for every opening whether it is valid. All openings are first checked in one
+batch; when the batch fails it is split in halves until the invalid openings are isolated, so t
+invalid openings out of n cost O(t log n) batch checks.
+*/
+func KZGVerifyEach(ts *KzgShareSetup, A []kyber.Point, openings []Opening) []bool {
+	valid := make([]bool, len(openings))
+
+	// Malformed openings would fail every batch they are part of, so leave them out right away
+	candidates := make([]int, 0, len(openings))
+	for k, o := range openings {
+		if wellFormed(A, o) {
+			candidates = append(candidates, k)
+		}
+	}
+
+	var bisect func(idx []int)
+	bisect = func(idx []int) {
+		if len(idx) == 0 {
+			return
+		}
+		batch := make([]Opening, len(idx))
+		for k, i := range idx {
+			batch[k] = openings[i]
+		}
+		if KZGBatchVerify(ts, A, batch) {
+			for _, i := range idx {
+				valid[i] = true
+			}
+			return
+		}
+		if len(idx) == 1 {
+			return
+		}
+		bisect(idx[:len(idx)/2])
+		bisect(idx[len(idx)/2:])
+	}
+	bisect(candidates)
+
+	return valid
+}
+
+func wellFormed(A []kyber.Point, o Opening) bool {
+	return o.Index >= 0 && o.Index < len(A) && A[o.Index] != nil &&
+		o.Z != nil && o.Y_1 != nil && o.Y_2 != nil && o.Proof != nil
+}
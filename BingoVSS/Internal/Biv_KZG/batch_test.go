@@ -0,0 +1,123 @@
+package biv_kzg
+
+import (
+	"testing"
+
+	"github.com/drand/kyber"
+	"github.com/drand/kyber/pairing/bn256"
+	"github.com/stretchr/testify/require"
+)
+
+// batchFixture commits to a random bivariate polynomial and opens every row 0..n at every index 0..n.
+func batchFixture(t testing.TB, f int) (*KzgShareSetup, []kyber.Point, []Opening) {
+	pairing := bn256.NewSuite()
+	d_1, d_2, n := 2*f, f, 3*f+1
+
+	trap, err := NewKzgSetup(d_1+1, pairing)
+	require.NoError(t, err)
+	sh_setup := NewShareSetup(trap.ReturnT_1(), trap.ReturnT_2(), trap.ReturnT_u(), pairing, trap.ReturnG_u(), trap.ReturnG_1())
+
+	f_1 := make([][]kyber.Scalar, d_1+1)
+	f_2 := make([][]kyber.Scalar, d_1+1)
+	for i := 0; i <= d_1; i++ {
+		f_1[i] = make([]kyber.Scalar, d_2+1)
+		f_2[i] = make([]kyber.Scalar, d_2+1)
+		for j := 0; j <= d_2; j++ {
+			f_1[i][j] = pairing.G1().Scalar().Pick(pairing.RandomStream())
+			f_2[i][j] = pairing.G1().Scalar().Pick(pairing.RandomStream())
+		}
+	}
+
+	vn := make([]kyber.Scalar, n+1)
+	for i := 0; i <= n; i++ {
+		vn[i] = pairing.G1().Scalar().SetInt64(int64(i))
+	}
+	CM, err := Commits(trap, bivPoly(t, pairing, f_1), bivPoly(t, pairing, f_2))
+	require.NoError(t, err)
+	cm := PartialEval(trap, CM, vn)
+
+	f_1_x := createProjectionPolynomials(pairing, f_1, d_1+1, d_2+1, n+1)
+	f_2_x := createProjectionPolynomials(pairing, f_2, d_1+1, d_2+1, n+1)
+
+	var openings []Opening
+	for row := 0; row <= n; row++ {
+		for x := 0; x <= n; x++ {
+			p, y_1, y_2, err := KZGEvaluationProof(trap, f_1_x[row], f_2_x[row], vn[x])
+			require.NoError(t, err)
+			openings = append(openings, Opening{Index: row, Z: vn[x], Y_1: y_1, Y_2: y_2, Proof: p})
+		}
+	}
+	return sh_setup, cm, openings
+}
+
+func TestKZGBatchVerify(t *testing.T) {
+	sh_setup, cm, openings := batchFixture(t, 1)
+	g := sh_setup.ReturnSuite().G1()
+
+	require.True(t, KZGBatchVerify(sh_setup, cm, openings))
+	require.True(t, KZGBatchVerify(sh_setup, cm, nil))
+	require.True(t, KZGBatchVerify(sh_setup, cm, openings[3:4]))
+
+	// every single way to break an opening breaks the batch
+	breaks := []func(o *Opening){
+		func(o *Opening) { o.Y_1 = g.Scalar().Add(o.Y_1, g.Scalar().One()) },
+		func(o *Opening) { o.Y_2 = g.Scalar().Add(o.Y_2, g.Scalar().One()) },
+		func(o *Opening) { o.Z = g.Scalar().Add(o.Z, g.Scalar().One()) },
+		func(o *Opening) { o.Index = (o.Index + 1) % len(cm) },
+		func(o *Opening) { o.Proof = g.Point().Add(o.Proof, g.Point().Base()) },
+		func(o *Opening) { o.Index = len(cm) },
+		func(o *Opening) { o.Proof = nil },
+	}
+	for k, b := range breaks {
+		bad := append([]Opening{}, openings...)
+		b(&bad[7])
+		require.False(t, KZGBatchVerify(sh_setup, cm, bad), "break %d", k)
+	}
+
+	// two wrong openings whose errors cancel in a plain sum must not cancel with random coefficients
+	bad := append([]Opening{}, openings...)
+	bad[1].Y_1 = g.Scalar().Add(bad[1].Y_1, g.Scalar().One())
+	bad[2].Y_1 = g.Scalar().Sub(bad[2].Y_1, g.Scalar().One())
+	require.False(t, KZGBatchVerify(sh_setup, cm, bad))
+}
+
+func TestKZGVerifyEach(t *testing.T) {
+	sh_setup, cm, openings := batchFixture(t, 1)
+	g := sh_setup.ReturnSuite().G1()
+
+	for _, v := range KZGVerifyEach(sh_setup, cm, openings) {
+		require.True(t, v)
+	}
+
+	bad := append([]Opening{}, openings...)
+	invalid := map[int]bool{0: true, 5: true, 6: true, len(bad) - 1: true}
+	for k := range invalid {
+		bad[k].Y_2 = g.Scalar().Add(bad[k].Y_2, g.Scalar().One())
+	}
+	bad[9].Proof = nil
+	invalid[9] = true
+
+	valid := KZGVerifyEach(sh_setup, cm, bad)
+	require.Len(t, valid, len(bad))
+	for k := range bad {
+		require.Equal(t, !invalid[k], valid[k], "opening %d", k)
+		require.Equal(t, valid[k], wellFormed(cm, bad[k]) && KZGVerify(sh_setup, cm, bad[k].Index, bad[k].Proof, bad[k].Z, bad[k].Y_1, bad[k].Y_2))
+	}
+}
+
+func BenchmarkVerify(b *testing.B) {
+	sh_setup, cm, openings := batchFixture(b, 3)
+
+	b.Run("one by one", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			for _, o := range openings {
+				KZGVerify(sh_setup, cm, o.Index, o.Proof, o.Z, o.Y_1, o.Y_2)
+			}
+		}
+	})
+	b.Run("batch", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			KZGBatchVerify(sh_setup, cm, openings)
+		}
+	})
+}
@@ -0,0 +1,265 @@
+package biv_kzg
+
+import (
+	"BingoVSS/Internal/Polynomial"
+	"fmt"
+	"math/bits"
+
+	"github.com/drand/kyber"
+)
+
+/*
+The proof for f at z commits to the quotient q_z(X) = (f(X) - f(z)) / (X - z), and
+
+	[q_z(τ)] = Σ_{i<d} z^i h_i   with   h_i = Σ_{k>i} f_k [τ^(k-i-1)]
+
+so the proofs of all points are the evaluations of one polynomial of degree d-1 whose
+coefficients h are group elements. h is a Toeplitz matrix times the powers of τ, which
+Feist and Khovratovich compute with FFTs; the proofs at a domain of roots of unity are then one
+more FFT, O(n log n) in total instead of a division and a commitment per point.
+*/
+
+/*
+This function returns the evaluations and the proofs of f_1, f_2 at every element of the domain,
+with the Feist-Khovratovich method. The polynomials can be of any length; the domain must be
+supported by the field, which on bn256 means at most 32 elements.
+*/
+func KZGOpenDomain(ts *KzgShareSetup, f_1, f_2 []kyber.Scalar, d *polynomial.Domain) ([]kyber.Point, []kyber.Scalar, []kyber.Scalar, error) {
+	h, err := quotientCoefficients(ts, f_1, f_2)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	// ω^n = 1, so coefficients beyond the size of the domain wrap around
+	g1 := ts.g.G1()
+	n := d.Size()
+	folded := make([]kyber.Point, n)
+	for i := range folded {
+		folded[i] = g1.Point().Null()
+	}
+	for i, p := range h {
+		folded[i%n] = folded[i%n].Add(folded[i%n], p)
+	}
+
+	proofs, err := d.FFTPoints(g1, folded)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	y_1, err := d.FFT(foldScalars(ts, f_1, n))
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	y_2, err := d.FFT(foldScalars(ts, f_2, n))
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	return proofs, y_1, y_2, nil
+}
+
+// quotientCoefficients returns h for f_1 committed with t_1 plus f_2 committed with t_Up.
+func quotientCoefficients(ts *KzgShareSetup, f_1, f_2 []kyber.Scalar) ([]kyber.Point, error) {
+	g1 := ts.g.G1()
+	m := len(f_1)
+	if len(f_2) > m {
+		m = len(f_2)
+	}
+	m-- // h has one coefficient less than f
+	if m > len(ts.t_1) || m > len(ts.t_Up) {
+		return nil, fmt.Errorf("Error: the setup supports degree %d, not %d", len(ts.t_1)-1, m)
+	}
+	if m <= 0 {
+		return nil, nil
+	}
+
+	/*
+		h_i = Σ_{u=i}^{m-1} f_{u+1} s_{u-i} is entry m-1+i of the linear convolution of
+		F_u = f_{u+1} with the reversed powers S_v = s_{m-1-v}. The convolution has 2m-1 terms,
+		so a cyclic one of that size computes it without wrapping around.
+
+		The field may not have a domain that large: bn256 has 2^5 as the largest power of two
+		dividing r-1. F and S are then cut into blocks of b terms whose products fit a domain of
+		2b, and the product of the blocks a and c lands at offset (a+c)·b of the convolution.
+	*/
+	field, err := polynomial.FieldOf(ts.g)
+	if err != nil {
+		return nil, err
+	}
+	b := m
+	if 2*b-1 > field.MaxDomain() {
+		b = field.MaxDomain() / 2
+	}
+	blocks := (m + b - 1) / b
+	conv, err := polynomial.NewDomain(ts.g, 2*b-1)
+	if err != nil {
+		return nil, err
+	}
+
+	// sum[k] accumulates, in the evaluation form, the block products at offset k·b
+	sum := make([][]kyber.Point, 2*blocks-1)
+	for k := range sum {
+		sum[k] = make([]kyber.Point, conv.Size())
+		for i := range sum[k] {
+			sum[k][i] = g1.Point().Null()
+		}
+	}
+	for _, part := range []struct {
+		f   []kyber.Scalar
+		srs []kyber.Point
+	}{{f_1, ts.t_1}, {f_2, ts.t_Up}} {
+		fF := make([][]kyber.Scalar, blocks)
+		fS := make([][]kyber.Point, blocks)
+		for a := 0; a < blocks; a++ {
+			F := make([]kyber.Scalar, b)
+			S := make([]kyber.Point, b)
+			for j := 0; j < b; j++ {
+				u := a*b + j
+				F[j] = g1.Scalar().Zero()
+				S[j] = g1.Point().Null()
+				if u < m {
+					if u+1 < len(part.f) {
+						F[j] = part.f[u+1]
+					}
+					S[j] = part.srs[m-1-u]
+				}
+			}
+
+			if fF[a], err = conv.FFT(F); err != nil {
+				return nil, err
+			}
+			if fS[a], err = conv.FFTPoints(g1, S); err != nil {
+				return nil, err
+			}
+		}
+
+		for a := 0; a < blocks; a++ {
+			for c := 0; c < blocks; c++ {
+				if (a+c+2)*b-2 < m-1 {
+					continue // lands entirely before h
+				}
+				for i := range sum[a+c] {
+					sum[a+c][i] = sum[a+c][i].Add(sum[a+c][i], g1.Point().Mul(fF[a][i], fS[c][i]))
+				}
+			}
+		}
+	}
+
+	h := make([]kyber.Point, m)
+	for i := range h {
+		h[i] = g1.Point().Null()
+	}
+	for k := range sum {
+		if (k+2)*b-2 < m-1 {
+			continue
+		}
+		c, err := conv.IFFTPoints(g1, sum[k])
+		if err != nil {
+			return nil, err
+		}
+		for j, p := range c {
+			if i := k*b + j - (m - 1); i >= 0 && i < m {
+				h[i] = h[i].Add(h[i], p)
+			}
+		}
+	}
+	return h, nil
+}
+
+/*
+This function returns the evaluations and the proofs of f_1, f_2 at X = 0, 1, ..., n. The proofs
+are the values at z of P(z) = Σ_i z^i h_i, with h from the Feist-Khovratovich Toeplitz product.
+P has degree d-1, so only its first d values are computed with Horner's rule, where z is a small
+integer and costs a few point additions; the rest follow from forward differences with d point
+additions each.
+*/
+func KZGEvalAll(ts *KzgShareSetup, f_1, f_2 []kyber.Scalar, n int) ([]kyber.Point, []kyber.Scalar, []kyber.Scalar, error) {
+	g1 := ts.g.G1()
+	proofs := make([]kyber.Point, n+1)
+	y_1 := make([]kyber.Scalar, n+1)
+	y_2 := make([]kyber.Scalar, n+1)
+
+	for z := 0; z <= n; z++ {
+		x := g1.Scalar().SetInt64(int64(z))
+		y_1[z] = evaluatePolynomial(f_1, x, ts.g)
+		y_2[z] = evaluatePolynomial(f_2, x, ts.g)
+	}
+
+	h, err := quotientCoefficients(ts, f_1, f_2)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	d := len(h)
+	if d == 0 {
+		// Constant polynomials have a zero quotient everywhere
+		for z := range proofs {
+			proofs[z] = g1.Point().Null()
+		}
+		return proofs, y_1, y_2, nil
+	}
+
+	// diff[k] = Δ^k P(z), starting from the first d values
+	first := d
+	if first > n+1 {
+		first = n + 1
+	}
+	for z := 0; z < first; z++ {
+		p := h[d-1].Clone()
+		for i := d - 2; i >= 0; i-- {
+			p = mulSmall(g1, p, z)
+			p = p.Add(p, h[i])
+		}
+		proofs[z] = p
+	}
+	if first == n+1 {
+		return proofs, y_1, y_2, nil
+	}
+
+	diff := make([]kyber.Point, d)
+	for k := range diff {
+		diff[k] = proofs[k].Clone()
+	}
+	for k := 1; k < d; k++ {
+		for j := d - 1; j >= k; j-- {
+			diff[j] = diff[j].Sub(diff[j], diff[j-1])
+		}
+	}
+	// diff now holds Δ^k P(0); step it forward to z = d-1
+	for z := 0; z < d-1; z++ {
+		for k := 0; k < d-1; k++ {
+			diff[k] = diff[k].Add(diff[k], diff[k+1])
+		}
+	}
+
+	for z := d; z <= n; z++ {
+		for k := 0; k < d-1; k++ {
+			diff[k] = diff[k].Add(diff[k], diff[k+1])
+		}
+		proofs[z] = diff[0].Clone()
+	}
+
+	return proofs, y_1, y_2, nil
+}
+
+// mulSmall returns k·p for a small k ≥ 0 with double-and-add, which is much cheaper than a
+// multiplication by a full scalar.
+func mulSmall(g kyber.Group, p kyber.Point, k int) kyber.Point {
+	r := g.Point().Null()
+	for bit := bits.Len(uint(k)) - 1; bit >= 0; bit-- {
+		r = r.Add(r, r)
+		if k>>bit&1 == 1 {
+			r = r.Add(r, p)
+		}
+	}
+	return r
+}
+
+func foldScalars(ts *KzgShareSetup, f []kyber.Scalar, n int) []kyber.Scalar {
+	out := make([]kyber.Scalar, n)
+	for i := range out {
+		out[i] = ts.g.G1().Scalar().Zero()
+	}
+	for i, c := range f {
+		out[i%n] = ts.g.G1().Scalar().Add(out[i%n], c)
+	}
+	return out
+}
@@ -0,0 +1,127 @@
+package biv_kzg
+
+import (
+	"BingoVSS/Internal/Polynomial"
+	"fmt"
+	"testing"
+
+	"github.com/drand/kyber"
+	bls "github.com/drand/kyber-bls12381"
+	"github.com/drand/kyber/pairing"
+	"github.com/drand/kyber/pairing/bn256"
+	"github.com/stretchr/testify/require"
+)
+
+func fkFixture(t testing.TB, pairing pairing.Suite, l int) (*KzgShareSetup, []kyber.Scalar, []kyber.Scalar) {
+	trap, err := NewKzgSetup(l, pairing)
+	require.NoError(t, err)
+	sh_setup := NewShareSetup(trap.ReturnT_1(), trap.ReturnT_2(), trap.ReturnT_u(), pairing, trap.ReturnG_u(), trap.ReturnG_1())
+
+	f_1 := make([]kyber.Scalar, l)
+	f_2 := make([]kyber.Scalar, l)
+	for i := range f_1 {
+		f_1[i] = pairing.G1().Scalar().Pick(pairing.RandomStream())
+		f_2[i] = pairing.G1().Scalar().Pick(pairing.RandomStream())
+	}
+	return sh_setup, f_1, f_2
+}
+
+func TestKZGOpenDomain(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		pairing pairing.Suite
+		l, n    int
+	}{
+		{"bn256", bn256.NewSuite(), 5, 8},
+		{"bn256 folded", bn256.NewSuite(), 9, 4},
+		{"bn256 blocked", bn256.NewSuite(), 21, 32},
+		{"bn256 blocked uneven", bn256.NewSuite(), 50, 16},
+		{"bls12381", bls.NewBLS12381Suite(), 20, 32},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			sh_setup, f_1, f_2 := fkFixture(t, tc.pairing, tc.l)
+			cm := []kyber.Point{KZGCommits(sh_setup, f_1, f_2)}
+
+			d, err := polynomial.NewDomain(tc.pairing, tc.n)
+			require.NoError(t, err)
+			proofs, y_1, y_2, err := KZGOpenDomain(sh_setup, f_1, f_2, d)
+			require.NoError(t, err)
+			require.Len(t, proofs, d.Size())
+
+			for i := 0; i < d.Size(); i++ {
+				p, v_1, v_2, err := KZGEval(sh_setup, f_1, f_2, d.Element(i))
+				require.NoError(t, err)
+				require.True(t, proofs[i].Equal(p), "element %d", i)
+				require.True(t, y_1[i].Equal(v_1))
+				require.True(t, y_2[i].Equal(v_2))
+				require.True(t, KZGVerify(sh_setup, cm, 0, proofs[i], d.Element(i), y_1[i], y_2[i]))
+			}
+		})
+	}
+}
+
+func TestKZGEvalAll(t *testing.T) {
+	pairing := bn256.NewSuite()
+	for _, l := range []int{1, 2, 5, 40} {
+		sh_setup, f_1, f_2 := fkFixture(t, pairing, l)
+		for _, n := range []int{0, 3, 13, 60} {
+			proofs, y_1, y_2, err := KZGEvalAll(sh_setup, f_1, f_2, n)
+			require.NoError(t, err)
+			require.Len(t, proofs, n+1)
+
+			for z := 0; z <= n; z++ {
+				p, v_1, v_2, err := KZGEval(sh_setup, f_1, f_2, pairing.G1().Scalar().SetInt64(int64(z)))
+				require.NoError(t, err)
+				require.True(t, proofs[z].Equal(p), "length %d, n %d, z %d", l, n, z)
+				require.True(t, y_1[z].Equal(v_1))
+				require.True(t, y_2[z].Equal(v_2))
+			}
+		}
+	}
+}
+
+func BenchmarkOpenAll(b *testing.B) {
+	pairing := bls.NewBLS12381Suite()
+	sh_setup, f_1, f_2 := fkFixture(b, pairing, 16)
+	d, err := polynomial.NewDomain(pairing, 64)
+	require.NoError(b, err)
+
+	b.Run("KZGEval", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			for j := 0; j < d.Size(); j++ {
+				_, _, _, _ = KZGEval(sh_setup, f_1, f_2, d.Element(j))
+			}
+		}
+	})
+	b.Run("KZGOpenDomain", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			_, _, _, _ = KZGOpenDomain(sh_setup, f_1, f_2, d)
+		}
+	})
+	b.Run("KZGEvalAll", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			_, _, _, _ = KZGEvalAll(sh_setup, f_1, f_2, d.Size()-1)
+		}
+	})
+}
+
+// BenchmarkEvalAllNode compares the openings a bn256 node computes for its row and column with
+// one KZGEval per point.
+func BenchmarkEvalAllNode(b *testing.B) {
+	pairing := bn256.NewSuite()
+	for _, tc := range []struct{ l, n int }{{11, 31}, {34, 100}} {
+		sh_setup, f_1, f_2 := fkFixture(b, pairing, tc.l)
+		b.Run(fmt.Sprintf("KZGEval/%d/%d", tc.l, tc.n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				for z := 0; z <= tc.n; z++ {
+					_, _, _, _ = KZGEval(sh_setup, f_1, f_2, pairing.G1().Scalar().SetInt64(int64(z)))
+				}
+			}
+		})
+		b.Run(fmt.Sprintf("KZGEvalAll/%d/%d", tc.l, tc.n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				_, _, _, _ = KZGEvalAll(sh_setup, f_1, f_2, tc.n)
+			}
+		})
+	}
+}
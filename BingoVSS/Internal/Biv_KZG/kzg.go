@@ -2,10 +2,12 @@ package biv_kzg
 
 import (
 	poly "BingoVSS/Internal/BivPoly"
+	"BingoVSS/Internal/MSM"
+	"BingoVSS/Internal/Polynomial"
 	"fmt"
 
 	"github.com/drand/kyber"
-	"github.com/drand/kyber/pairing/bn256"
+	"github.com/drand/kyber/pairing"
 )
 
 type Proof struct {
@@ -13,7 +15,6 @@ type Proof struct {
 	p       kyber.Point
 	y_1     kyber.Scalar
 	y_2     kyber.Scalar
-	c       kyber.Scalar
 }
 
 func (d Proof) ReturnP() kyber.Point {
@@ -33,31 +34,30 @@ func (d Proof) ReturnID() int {
 }
 
 /* This function constructs a new dealer */
-func NewProof(id int, p kyber.Point, y_1, y_2, c kyber.Scalar) *Proof {
+func NewProof(id int, p kyber.Point, y_1, y_2 kyber.Scalar) *Proof {
 
-	return &Proof{id, p, y_1, y_2, c}
+	return &Proof{id, p, y_1, y_2}
 }
 
-// KzgSetup is used to set down the bilinear pairing setups
+// KzgSetup is used to set down the bilinear pairing setups. It only holds the
+// public group elements g^τ^i, h^τ^i and gUp^τ^i; the trapdoor τ and the exponent
+// of gUp are discarded as soon as the setup has been computed.
 type KzgSetup struct {
-	t_1      []kyber.Point
-	t_2      []kyber.Point
-	t_Up     []kyber.Point
-	g        *bn256.Suite
-	x        kyber.Scalar
-	gUp      kyber.Point
-	g1       kyber.Point
-	trap_val []kyber.Scalar
+	t_1  []kyber.Point
+	t_2  []kyber.Point
+	t_Up []kyber.Point
+	g    pairing.Suite
+	gUp  kyber.Point
+	g1   kyber.Point
 }
 
 type KzgShareSetup struct {
-	t_1      []kyber.Point
-	t_2      []kyber.Point
-	t_Up     []kyber.Point
-	g        *bn256.Suite
-	gUp      kyber.Point
-	g1       kyber.Point
-	trap_val []kyber.Scalar
+	t_1  []kyber.Point
+	t_2  []kyber.Point
+	t_Up []kyber.Point
+	g    pairing.Suite
+	gUp  kyber.Point
+	g1   kyber.Point
 }
 
 func (k *KzgSetup) ReturnT_1() []kyber.Point {
@@ -76,11 +76,7 @@ func (k *KzgSetup) ReturnG_1() kyber.Point {
 	return k.g1
 }
 
-func (k *KzgSetup) ReturnVal() []kyber.Scalar {
-	return k.trap_val
-}
-
-func (k *KzgShareSetup) ReturnSuite() *bn256.Suite {
+func (k *KzgShareSetup) ReturnSuite() pairing.Suite {
 	return k.g
 }
 
@@ -88,21 +84,19 @@ func (k *KzgSetup) ReturnG_u() kyber.Point {
 	return k.gUp
 }
 
-func NewShareSetup(t_1, t_2, t_up []kyber.Point, g *bn256.Suite, gUp, g1 kyber.Point, trap_val []kyber.Scalar) *KzgShareSetup {
-	return &KzgShareSetup{t_1, t_2, t_up, g, gUp, g1, trap_val}
+func NewShareSetup(t_1, t_2, t_up []kyber.Point, g pairing.Suite, gUp, g1 kyber.Point) *KzgShareSetup {
+	return &KzgShareSetup{t_1, t_2, t_up, g, gUp, g1}
 }
 
 /*
-This function is being utilized to represent the setup of the bilinear pairings used by the KZG commitments. More specifically it gets the generators both from G1 and G2 of bn256 eliptic curve and creates the g^t^i
+This function is being utilized to represent the setup of the bilinear pairings used by the KZG commitments. More specifically it gets the generators both from G1 and G2 of the pairing eliptic curve and creates the g^t^i
 */
-func NewKzgSetup(l int, pairing *bn256.Suite) (*KzgSetup, error) {
+func NewKzgSetup(l int, pairing pairing.Suite) (*KzgSetup, error) {
 
 	// First Step: Compute the random value of τ, which we called trapdoor
 	t := pairing.G1().Scalar().Pick(pairing.RandomStream()) //choose random for beginning
 	x := pairing.G1().Scalar().Pick(pairing.RandomStream()) //choose random for next
 
-	trap := calculateT(pairing, t, l)
-
 	g1 := pairing.G1().Point().Base() //generate the base generator of Group_1
 	g2 := pairing.G2().Point().Base() //generate the base generator of Group_2
 	gUp := pairing.G1().Point().Mul(x, g1)
@@ -111,60 +105,63 @@ func NewKzgSetup(l int, pairing *bn256.Suite) (*KzgSetup, error) {
 	gTrapdoorG2, err_2 := calculateTrapdoorValues(pairing, g2, 2, l, t)
 	gTrapdoorGup, err_3 := calculateTrapdoorValues(pairing, gUp, 1, l, t)
 
+	// The toxic waste must never leave the setup, so overwrite it before returning
+	t.Zero()
+	x.Zero()
+
 	if err != nil || err_2 != nil || err_3 != nil {
 		return nil, fmt.Errorf("Wrong computations")
 	}
 
-	return &KzgSetup{gTrapdoorG1, gTrapdoorG2, gTrapdoorGup, pairing, x, gUp, g1, trap}, nil
+	return &KzgSetup{gTrapdoorG1, gTrapdoorG2, gTrapdoorGup, pairing, gUp, g1}, nil
 }
 
-/* The purpose of the calculateT function is to compute a list (slice) of trapdoor values
-based on a given value t and length l using a particular cryptographic pairing suite. */
-
-func calculateT(pairing *bn256.Suite, t kyber.Scalar, l int) []kyber.Scalar {
-	// Initialize the trapdoor slice with the specified length l.
-	trapdoor := make([]kyber.Scalar, l)
-
-	// Set the first trapdoor value to the scalar representation of 1.
-	trapdoor[0] = pairing.G1().Scalar().SetInt64(int64(1))
-
-	// For the remaining trapdoor values, compute t raised to the power of the current index.
-	for i := 1; i < l; i++ {
-		// Get the scalar representation of the current index.
-		index_s := pairing.G1().Scalar().SetInt64(int64(i))
+/*
+This function builds the setup from already computed public powers, for example the output of a
+powers-of-tau ceremony. t_1 and t_Up must be the powers in G1 and t_2 the powers in G2, all of length l.
+*/
+func NewKzgSetupFromPowers(t_1, t_2, t_Up []kyber.Point, pairing pairing.Suite) (*KzgSetup, error) {
 
-		// Raise t to the power of the current index and store the result.
-		t_in_power := Pow(i, t, index_s, pairing)
+	if len(t_1) < 2 || len(t_1) != len(t_2) || len(t_1) != len(t_Up) {
+		return nil, fmt.Errorf("Wrong number of powers: %d, %d, %d", len(t_1), len(t_2), len(t_Up))
+	}
 
-		// Set the computed value as the next trapdoor entry.
-		trapdoor[i] = t_in_power
+	if !t_1[0].Equal(pairing.G1().Point().Base()) || !t_2[0].Equal(pairing.G2().Point().Base()) {
+		return nil, fmt.Errorf("The powers do not start from the base generators")
 	}
 
-	// Return the computed trapdoor values.
-	return trapdoor
+	return &KzgSetup{t_1, t_2, t_Up, pairing, t_Up[0], t_1[0]}, nil
 }
 
-/* This function is being utilized to do the commitment of the polynomial */
-func Commits(ts *KzgSetup, f_1 [][]kyber.Scalar, f_2 [][]kyber.Scalar, d_1 int, d_2 int) ([]kyber.Point, []kyber.Scalar) {
+/*
+This function is being utilized to do the commitment of the polynomial. f_1 and f_2 must have the
+same degrees, which the setup must support in X.
+*/
+func Commits(ts *KzgSetup, f_1, f_2 *poly.BivPoly) ([]kyber.Point, error) {
+	d_1, d_2 := f_1.DegreeX()+1, f_1.DegreeY()+1
+	if f_2.DegreeX()+1 != d_1 || f_2.DegreeY()+1 != d_2 {
+		return nil, fmt.Errorf("Error: the polynomials have degrees %d, %d and %d, %d", d_1-1, d_2-1, f_2.DegreeX(), f_2.DegreeY())
+	}
+	if d_1 > len(ts.t_1) {
+		return nil, fmt.Errorf("Error: the setup supports degree %d in X, not %d", len(ts.t_1)-1, d_1-1)
+	}
 
-	f_1_x := createUnivariatePolynomials(ts, f_1, d_1, d_2) //f
-	f_2_x := createUnivariatePolynomials(ts, f_2, d_1, d_2) //f^
+	f_1_x := createUnivariatePolynomials(ts, f_1.ReturnCoefficients(), d_1, d_2) //f
+	f_2_x := createUnivariatePolynomials(ts, f_2.ReturnCoefficients(), d_1, d_2) //f^
 
 	c := make([]kyber.Point, d_2)
-	coeffs := make([]kyber.Scalar, d_2)
 
 	for i := 0; i < d_2; i++ {
 
-		c_1, s_1 := evaluatePolyTrap_f1(ts, f_1_x[i]) // evaluate polynomial at a specific point of the trapdoor
+		c_1 := evaluatePolyTrap_f1(ts, f_1_x[i]) // evaluate polynomial at a specific point of the trapdoor
 
-		c_2, s_2 := evaluatePolyTrap_f2(ts, f_2_x[i]) // evaluate polynomial at a specific point of the trapdoor
+		c_2 := evaluatePolyTrap_f2(ts, f_2_x[i]) // evaluate polynomial at a specific point of the trapdoor
 
 		c[i] = ts.g.G1().Point().Add(c_1, c_2) //produce the commitment
-		coeffs[i] = ts.g.G1().Scalar().Add(s_1, s_2)
 
 	}
 
-	return c, coeffs
+	return c, nil
 }
 
 /* This function is being utilized to do the commitment of the polynomial */
@@ -178,92 +175,34 @@ func KZGCommits(ts *KzgShareSetup, f_1 []kyber.Scalar, f_2 []kyber.Scalar) kyber
 
 // evaluatePolyTrap evaluates a polynomial q at specific trapdoor values provided in the KzgSetup structure ts.
 // It returns the result of the evaluation as a point c.
-func evaluatePolyTrap_f1(ts *KzgSetup, q []kyber.Scalar) (kyber.Point, kyber.Scalar) {
-
-	// Initialize the result c by multiplying the first coefficient of the polynomial q with the first trapdoor value in ts.t_1
-	s := ts.trap_val[0].Clone().Mul(q[0], ts.trap_val[0])
-	c := ts.t_1[0].Clone().Mul(q[0], ts.t_1[0])
-
-	// Iterate through the remaining coefficients of the polynomial q
-	for i := 1; i < len(q); i++ {
-		// Multiply the current coefficient q[i] with the corresponding trapdoor value ts.t_1[i]
-		sp := ts.t_1[i].Clone().Mul(q[i], ts.t_1[i])
-		s_n := ts.trap_val[i].Clone().Mul(q[i], ts.trap_val[i])
-
-		// Add the result to the current value of c
-		c = c.Add(c, sp)
-		s = s.Add(s, s_n) //Comment this in terms of the paper
-	}
-
-	// Return the result of the evaluation
-	return c, s
+func evaluatePolyTrap_f1(ts *KzgSetup, q []kyber.Scalar) kyber.Point {
+	// Σ_i q_i·t_1[i] with the bucket method
+	return msm.MultiExp(ts.g.G1(), ts.t_1, q)
 }
 
 // evaluatePolyTrap evaluates a polynomial q at specific trapdoor values provided in the KzgSetup structure ts.
 // It returns the result of the evaluation as a point c.
 func evaluatePolyTrap_f1_sh(ts *KzgShareSetup, q []kyber.Scalar) kyber.Point {
-
-	// Initialize the result c by multiplying the first coefficient of the polynomial q with the first trapdoor value in ts.t_1
-	c := ts.t_1[0].Clone().Mul(q[0], ts.t_1[0])
-	// Iterate through the remaining coefficients of the polynomial q
-	for i := 1; i < len(q); i++ {
-		// Multiply the current coefficient q[i] with the corresponding trapdoor value ts.t_1[i]
-		sp := ts.t_1[i].Clone().Mul(q[i], ts.t_1[i])
-
-		// Add the result to the current value of c
-		c = c.Add(c, sp)
-
-	}
-
-	// Return the result of the evaluation
-	return c
+	// Σ_i q_i·t_1[i] with the bucket method
+	return msm.MultiExp(ts.g.G1(), ts.t_1, q)
 }
 
 // evaluatePolyTrap evaluates a polynomial q at specific trapdoor values provided in the KzgSetup structure ts.
 // It returns the result of the evaluation as a point c.
-func evaluatePolyTrap_f2(ts *KzgSetup, q []kyber.Scalar) (kyber.Point, kyber.Scalar) {
-	// Initialize the result c by multiplying the first coefficient of the polynomial q with the first trapdoor value in ts.t_1
-	c := ts.t_Up[0].Clone().Mul(q[0], ts.t_Up[0])
-	s := ts.trap_val[0].Clone().Mul(q[0], ts.trap_val[0])
-
-	// Iterate through the remaining coefficients of the polynomial q
-	for i := 1; i < len(q); i++ {
-		// Multiply the current coefficient q[i] with the corresponding trapdoor value ts.t_1[i]
-		sp := ts.t_Up[i].Clone().Mul(q[i], ts.t_Up[i])
-		sn := ts.trap_val[i].Clone().Mul(q[i], ts.trap_val[i])
-
-		// Add the result to the current value of c
-		c = c.Add(c, sp)
-		s = s.Add(s, sn)
-	}
-
-	s = s.Mul(ts.x, s)
-	// Return the result of the evaluation
-	return c, s
+func evaluatePolyTrap_f2(ts *KzgSetup, q []kyber.Scalar) kyber.Point {
+	// Σ_i q_i·t_Up[i] with the bucket method
+	return msm.MultiExp(ts.g.G1(), ts.t_Up, q)
 }
 
 // evaluatePolyTrap evaluates a polynomial q at specific trapdoor values provided in the KzgSetup structure ts.
 // It returns the result of the evaluation as a point c.
 func evaluatePolyTrap_f2_sh(ts *KzgShareSetup, q []kyber.Scalar) kyber.Point {
-	// Initialize the result c by multiplying the first coefficient of the polynomial q with the first trapdoor value in ts.t_1
-	c := ts.t_Up[0].Clone().Mul(q[0], ts.t_Up[0])
-
-	// Iterate through the remaining coefficients of the polynomial q
-	for i := 1; i < len(q); i++ {
-		// Multiply the current coefficient q[i] with the corresponding trapdoor value ts.t_1[i]
-		sp := ts.t_Up[i].Clone().Mul(q[i], ts.t_Up[i])
-
-		// Add the result to the current value of c
-		c = c.Add(c, sp)
-
-	}
-
-	// Return the result of the evaluation
-	return c
+	// Σ_i q_i·t_Up[i] with the bucket method
+	return msm.MultiExp(ts.g.G1(), ts.t_Up, q)
 }
 
 /* This function represents the KZG evaluation proof. It computes the evaluation proof π for a given polynomial ϕ(X) at a point a, with the result y. */
-func KZGEvaluationProof(ts *KzgSetup, f_1, f_2 []kyber.Scalar, z kyber.Scalar) (kyber.Point, kyber.Scalar, kyber.Scalar, kyber.Scalar, error) {
+func KZGEvaluationProof(ts *KzgSetup, f_1, f_2 []kyber.Scalar, z kyber.Scalar) (kyber.Point, kyber.Scalar, kyber.Scalar, error) {
 	y_1 := evaluatePolynomial(f_1, z, ts.g)
 	y_2 := evaluatePolynomial(f_2, z, ts.g)
 
@@ -286,25 +225,24 @@ func KZGEvaluationProof(ts *KzgSetup, f_1, f_2 []kyber.Scalar, z kyber.Scalar) (
 	// This ensures that the division was exact, and there is no remainder, as per the polynomial remainder theorem
 	for _, v := range rem_1 {
 		if !v.Equal(ts.g.G1().Scalar().Zero()) {
-			return nil, nil, nil, nil, fmt.Errorf("Error: the remainder should be 0 not %v", rem_1)
+			return nil, nil, nil, fmt.Errorf("Error: the remainder should be 0 not %v", rem_1)
 		}
 	}
 
 	for _, v := range rem_2 {
 		if !v.Equal(ts.g.G1().Scalar().Zero()) {
-			return nil, nil, nil, nil, fmt.Errorf("Error: the remainder should be 0 not %v", rem_2)
+			return nil, nil, nil, fmt.Errorf("Error: the remainder should be 0 not %v", rem_2)
 		}
 	}
 
 	// Compute the proof: e
 	// This is the final evaluation proof π by evaluating the quotient polynomial q(X) at a specific point τ
-	e_1, s_1 := evaluatePolyTrap_f1(ts, q_1)
-	e_2, s_2 := evaluatePolyTrap_f2(ts, q_2)
+	e_1 := evaluatePolyTrap_f1(ts, q_1)
+	e_2 := evaluatePolyTrap_f2(ts, q_2)
 
 	e := ts.g.G1().Point().Add(e_1, e_2)
-	c := ts.g.G1().Scalar().Add(s_1, s_2)
 
-	return e, y_1, y_2, c, nil
+	return e, y_1, y_2, nil
 }
 
 /* This function represents the KZG evaluation proof. It computes the evaluation proof π for a given polynomial ϕ(X) at a point a, with the result y. */
@@ -383,12 +321,36 @@ func KZGVerify(ts *KzgShareSetup, A []kyber.Point, i int, proof kyber.Point, z,
 	return e1.Equal(e2)
 }
 
+/*
+KZGVerifyCommitted verifies an evaluation proof of the commitment c at z when the values are not
+revealed, only their commitment v = y_1·g + y_2·gUp. It checks e(c - v, h) = e(π, [τ - z]₂).
+*/
+func KZGVerifyCommitted(ts *KzgShareSetup, c kyber.Point, proof kyber.Point, z kyber.Scalar, v kyber.Point) bool {
+	sz := ts.g.G2().Point().Sub(ts.t_2[1], ts.g.G2().Point().Mul(z, nil))
+	cv := ts.g.G1().Point().Sub(c, v)
+	return ts.g.Pair(cv, ts.g.G2().Point().Base()).Equal(ts.g.Pair(proof, sz))
+}
+
+// ValueCommitment returns v = y_1·g + y_2·gUp, the commitment to the values of an opening.
+func ValueCommitment(ts *KzgShareSetup, y_1, y_2 kyber.Scalar) kyber.Point {
+	return ts.g.G1().Point().Add(ts.g.G1().Point().Mul(y_1, nil), ts.g.G1().Point().Mul(y_2, ts.gUp))
+}
+
+func (k *KzgShareSetup) ReturnG_u() kyber.Point {
+	return k.gUp
+}
+
+func (k *KzgShareSetup) ReturnT_2() []kyber.Point {
+	return k.t_2
+}
+
 /*
 	The goal of the function is to evaluate the polynomial commitments at the points (partial points)
-	given an array of distinct points.
+	given an array of distinct points. The commitment to the row φ(X, i) is computed in the group as
+	∏_j c_j^(i^j), so no knowledge of the exponents behind the commitments is required.
 */
 
-func PartialEval(trap *KzgSetup, c []kyber.Point, co, vn []kyber.Scalar) []kyber.Point {
+func PartialEval(trap *KzgSetup, c []kyber.Point, vn []kyber.Scalar) []kyber.Point {
 
 	// Initialize a slice to store the results of polynomial evaluations.
 	results := make([]kyber.Point, len(vn))
@@ -398,53 +360,60 @@ func PartialEval(trap *KzgSetup, c []kyber.Point, co, vn []kyber.Scalar) []kyber
 		// Get the scalar representation of integer i.
 		x := trap.g.G1().Scalar().SetInt64(int64(i))
 
-		// Evaluate the polynomial at point x.
-		y := evaluatePolynomialAt(co, x)
-
-		// Multiply the evaluation result with a point on the curve to get the commitment
-		// and store it in the results slice.
-		results[i] = trap.g1.Clone().Mul(y, trap.g1)
+		// Evaluate the committed polynomial at point x directly on the commitments.
+		results[i] = evaluatePointsAt(trap.g, c, x)
 	}
 
 	// Return the results of polynomial evaluations.
 	return results
 }
 
+/*
+	GetProofs computes the column points and their evaluation proofs for every point in vn.
+	The evaluations are interpolated over the scalars, while the proofs are interpolated
+	in the exponent using the Lagrange coefficients of the verified row proofs.
+*/
+
 func GetProofs(proofs []Proof, vn []kyber.Scalar, setup *KzgSetup, d_2 int) ([]kyber.Point, []kyber.Scalar, []kyber.Scalar) {
 
 	//β(X) ← Interpolate {(wi, yi)}i∈[d1+1]
-	y_i := make([]kyber.Scalar, d_2+1)
-	y_j := make([]kyber.Scalar, d_2+1)
-	x_i := make([]kyber.Scalar, d_2+1)
-	c_i := make([]kyber.Scalar, d_2+1)
-	n := 0
+	y_i := make([]kyber.Scalar, 0, d_2+1)
+	y_j := make([]kyber.Scalar, 0, d_2+1)
+	x_i := make([]kyber.Scalar, 0, d_2+1)
+	p_i := make([]kyber.Point, 0, d_2+1)
 
 	for i := 0; i < len(proofs); i++ {
-		if proofs[i].p != nil && n < d_2+1 {
-			y_i[n] = proofs[i].y_1
-			y_j[n] = proofs[i].y_2
-			x_i[n] = setup.g.G1().Scalar().SetInt64(int64(proofs[i].id_from))
-			c_i[n] = proofs[i].c
-			n++
+		if proofs[i].p != nil && len(x_i) < d_2+1 {
+			y_i = append(y_i, proofs[i].y_1)
+			y_j = append(y_j, proofs[i].y_2)
+			x_i = append(x_i, setup.g.G1().Scalar().SetInt64(int64(proofs[i].id_from)))
+			p_i = append(p_i, proofs[i].p)
 		}
 	}
 
-	b_x := poly.RecoverVandermondeGivenX(setup.g, x_i, y_i, d_2)
-
-	b_xj := poly.RecoverVandermondeGivenX(setup.g, x_i, y_j, d_2)
-
-	c_r := poly.RecoverVandermondeGivenX(setup.g, x_i, c_i, d_2)
-
 	pr := make([]kyber.Point, len(vn))
 	y_1 := make([]kyber.Scalar, len(vn))
 	y_2 := make([]kyber.Scalar, len(vn))
 
+	// The barycentric weights of x_i are shared by every point of vn
+	lagrange, err := polynomial.NewLagrange(setup.g, x_i)
+
 	for i := 0; i < len(vn); i++ {
 
-		y_1[i] = evaluatePolynomialAt(b_x, vn[i])
-		y_2[i] = evaluatePolynomialAt(b_xj, vn[i])
-		c := evaluatePolynomialAt(c_r, vn[i])
-		pr[i] = setup.g1.Clone().Mul(c, setup.g1)
+		var lambda []kyber.Scalar
+		if err == nil {
+			lambda = lagrange.At(vn[i])
+		}
+
+		y_1[i] = setup.g.G1().Scalar().Zero()
+		y_2[i] = setup.g.G1().Scalar().Zero()
+		pr[i] = setup.g.G1().Point().Null()
+
+		for k := 0; k < len(lambda); k++ {
+			y_1[i] = y_1[i].Add(y_1[i], setup.g.G1().Scalar().Mul(lambda[k], y_i[k]))
+			y_2[i] = y_2[i].Add(y_2[i], setup.g.G1().Scalar().Mul(lambda[k], y_j[k]))
+		}
+		pr[i] = msm.MultiExp(setup.g.G1(), p_i, lambda)
 
 	}
 
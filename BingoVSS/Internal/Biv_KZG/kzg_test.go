@@ -1,11 +1,14 @@
 package biv_kzg
 
 import (
+	poly "BingoVSS/Internal/BivPoly"
+	"BingoVSS/Internal/Polynomial"
 	"fmt"
 	"math/big"
 	"testing"
 
 	"github.com/drand/kyber"
+	"github.com/drand/kyber/pairing"
 	"github.com/drand/kyber/pairing/bn256"
 	"github.com/stretchr/testify/require"
 )
@@ -20,7 +23,7 @@ func TestPartialEval(t *testing.T) {
 	d_2 := 2 // Degree in Y
 
 	trap, _ := NewKzgSetup(d_1+1, pairing) //this should eventually return an srs
-	sh_setup := NewShareSetup(trap.ReturnT_1(), trap.ReturnT_2(), trap.ReturnT_u(), pairing, trap.ReturnG_u(), trap.ReturnG_1(), trap.ReturnVal())
+	sh_setup := NewShareSetup(trap.ReturnT_1(), trap.ReturnT_2(), trap.ReturnT_u(), pairing, trap.ReturnG_u(), trap.ReturnG_1())
 
 	f_1 := make([][]kyber.Scalar, d_1+1) //this represents the Φ(Χ)
 	f_2 := make([][]kyber.Scalar, d_1+1) //this represents the Φ'(Χ)
@@ -55,10 +58,10 @@ func TestPartialEval(t *testing.T) {
 	degree := len(x) - 1
 
 	// Generate Vandermonde matrix
-	vMatrix := vandermonde(x, degree)
+	vMatrix := vandermonde(pairing, x, degree)
 
 	// Solve the system of linear equations
-	coeffs := solveLinearSystem(vMatrix, y)
+	coeffs := solveLinearSystem(pairing, vMatrix, y)
 
 	f_1 = adjustBivariateCoefficients(f_1, d_2+1, coeffs, pairing)
 
@@ -70,7 +73,8 @@ func TestPartialEval(t *testing.T) {
 
 	f_p_2 := createProjectionPolynomials(pairing, f_2, d_1+1, d_2+1, d_2+1)
 
-	CM, co := Commits(trap, f_final, f_2, d_1+1, d_2+1)
+	CM, err := Commits(trap, bivPoly(t, pairing, f_final), bivPoly(t, pairing, f_2))
+	require.NoError(t, err)
 
 	vn := make([]kyber.Scalar, d_2+1)
 	vn[0] = pairing.G1().Scalar().SetInt64(0)
@@ -78,7 +82,7 @@ func TestPartialEval(t *testing.T) {
 	vn[2] = pairing.G1().Scalar().SetInt64(2)
 
 	//first part done
-	cm := PartialEval(trap, CM, co, vn)
+	cm := PartialEval(trap, CM, vn)
 
 	s := KZGCommits(sh_setup, f_p[2], f_p_2[2])
 
@@ -87,6 +91,61 @@ func TestPartialEval(t *testing.T) {
 
 }
 
+func TestGetProofs(t *testing.T) {
+
+	// Initialize the pairing suite for cryptographic operations
+	pairing := bn256.NewSuite()
+
+	f := 2
+	d_1 := 2 * f // Degree in X
+	d_2 := f     // Degree in Y
+	n := 3*f + 1
+	id := 3 // the participant computing its column
+
+	trap, _ := NewKzgSetup(d_1+1, pairing)
+	sh_setup := NewShareSetup(trap.ReturnT_1(), trap.ReturnT_2(), trap.ReturnT_u(), pairing, trap.ReturnG_u(), trap.ReturnG_1())
+
+	f_1 := make([][]kyber.Scalar, d_1+1)
+	f_2 := make([][]kyber.Scalar, d_1+1)
+	for i := 0; i <= d_1; i++ {
+		f_1[i] = make([]kyber.Scalar, d_2+1)
+		f_2[i] = make([]kyber.Scalar, d_2+1)
+		for j := 0; j <= d_2; j++ {
+			f_1[i][j] = pairing.G1().Scalar().Pick(pairing.RandomStream())
+			f_2[i][j] = pairing.G1().Scalar().Pick(pairing.RandomStream())
+		}
+	}
+
+	CM, err := Commits(trap, bivPoly(t, pairing, f_1), bivPoly(t, pairing, f_2))
+	require.NoError(t, err)
+
+	vn := make([]kyber.Scalar, n+1)
+	for i := 0; i <= n; i++ {
+		vn[i] = pairing.G1().Scalar().SetInt64(int64(i))
+	}
+	cm := PartialEval(trap, CM, vn)
+
+	f_1_x := createProjectionPolynomials(pairing, f_1, d_1+1, d_2+1, n+1)
+	f_2_x := createProjectionPolynomials(pairing, f_2, d_1+1, d_2+1, n+1)
+
+	// The participant receives the points of d_2+1 rows at its own index
+	a := pairing.G1().Scalar().SetInt64(int64(id))
+	proofs := make([]Proof, n+1)
+	for c := 1; c <= d_2+1; c++ {
+		p, y_1, y_2, err := KZGEvaluationProof(trap, f_1_x[c], f_2_x[c], a)
+		require.NoError(t, err)
+		require.True(t, KZGVerify(sh_setup, cm, c, p, a, y_1, y_2))
+		proofs[c] = *NewProof(c, p, y_1, y_2)
+	}
+
+	// The column points must verify against every row commitment without knowing any exponent
+	pr, y_1, y_2 := GetProofs(proofs, vn, trap, d_2+1)
+	for j := 0; j <= n; j++ {
+		require.True(t, KZGVerify(sh_setup, cm, j, pr[j], a, y_1[j], y_2[j]))
+		require.True(t, y_1[j].Equal(evaluatePolynomial(f_1_x[j], a, pairing)))
+	}
+}
+
 func TestSimple(t *testing.T) {
 
 	//Step_0 : set the suite
@@ -146,14 +205,15 @@ func TestSimple(t *testing.T) {
 	//Step_3: call the trusted setup, to setup the bilinear pairings in this setup we have an srs return
 	// I am considering that the polynomials are from the same degree
 	trap, err := NewKzgSetup(d_1+1, pairing) //this should eventually return an srs
-	sh_setup := NewShareSetup(trap.ReturnT_1(), trap.ReturnT_2(), trap.ReturnT_u(), pairing, trap.ReturnG_u(), trap.ReturnG_1(), trap.ReturnVal())
+	sh_setup := NewShareSetup(trap.ReturnT_1(), trap.ReturnT_2(), trap.ReturnT_u(), pairing, trap.ReturnG_u(), trap.ReturnG_1())
 
 	if err != nil {
 		fmt.Println(trap)
 	}
 
 	//Step_4 : Use the setup (trusted setup) to commit the polynomial φ(x)
-	com, co := Commits(trap, f_1, f_2, d_1+1, d_2+1)
+	com, err := Commits(trap, bivPoly(t, pairing, f_1), bivPoly(t, pairing, f_2))
+	require.NoError(t, err)
 
 	vn := make([]kyber.Scalar, d_2+1)
 	vn[0] = pairing.G1().Scalar().SetInt64(1)
@@ -164,13 +224,13 @@ func TestSimple(t *testing.T) {
 
 	f_2_x := createProjectionPolynomials(trap.g, f_2, d_1+1, d_2+1, d_2+1)
 
-	alpha := PartialEval(trap, com, co, vn)
+	alpha := PartialEval(trap, com, vn)
 
 	//Step_5 : evaluate the polynomial at a specific point (for example I would evaluate it here at a=2)
 	a := pairing.G1().Scalar().SetInt64(2)
 
 	// Step_6 : create an evaluation proof
-	proof, y_1, y_2, _, _ := KZGEvaluationProof(trap, f_1_x[1], f_2_x[1], a)
+	proof, y_1, y_2, _ := KZGEvaluationProof(trap, f_1_x[1], f_2_x[1], a)
 
 	// //Step_7: verify the proof
 	v := KZGVerify(sh_setup, alpha, 1, proof, pairing.G1().Scalar().SetInt64(2), y_1, y_2) //this should return false
@@ -222,7 +282,7 @@ func TestSimple(t *testing.T) {
 // 	setup, err := os.OpenFile("test_setup.txt", os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
 // 	start := time.Now()
 // 	trap, err := NewKzgSetup(d_1, pairing) //this should eventually return an srs
-// 	sh_setup := NewShareSetup(trap.ReturnT_1(), trap.ReturnT_2(), trap.ReturnT_u(), pairing, trap.ReturnG_u(), trap.ReturnG_1(), trap.ReturnVal())
+// 	sh_setup := NewShareSetup(trap.ReturnT_1(), trap.ReturnT_2(), trap.ReturnT_u(), pairing, trap.ReturnG_u(), trap.ReturnG_1())
 // 	elapsed := time.Since(start)
 // 	setup.WriteString(fmt.Sprintf("Setup took %v to execute\n", elapsed))
 
@@ -233,7 +293,7 @@ func TestSimple(t *testing.T) {
 // 	commit, err := os.OpenFile("test_commit.txt", os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
 // 	//Step_4 : Use the setup (trusted setup) to commit the polynomial φ(x)
 // 	start = time.Now()
-// 	com, co := Commits(trap, f_1, f_2, d_1, d_2)
+// 	com := Commits(trap, f_1, f_2, d_1, d_2)
 // 	elapsed = time.Since(start)
 
 // 	commit.WriteString(fmt.Sprintf("Commit took %v to execute\n", elapsed))
@@ -250,7 +310,7 @@ func TestSimple(t *testing.T) {
 // 	partial, err := os.OpenFile("test_partial.txt", os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
 // 	//Step_4 : Use the setup (trusted setup) to commit the polynomial φ(x)
 // 	start = time.Now()
-// 	cm := PartialEval(trap, com, co, vn)
+// 	cm := PartialEval(trap, com, vn)
 // 	elapsed = time.Since(start)
 
 // 	partial.WriteString(fmt.Sprintf("Partial took %v to execute\n", elapsed))
@@ -277,7 +337,7 @@ func TestSimple(t *testing.T) {
 // 	proof_t, err := os.OpenFile("test_proof.txt", os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
 // 	start = time.Now()
 // 	// Step_6 : create an evaluation proof
-// 	proof, _, _, _, _ := KZGEvaluationProof(trap, f_1_x[1], f_2_x[1], a)
+// 	proof, _, _, _ := KZGEvaluationProof(trap, f_1_x[1], f_2_x[1], a)
 // 	elapsed = time.Since(start)
 
 // 	proof_t.WriteString(fmt.Sprintf("Proof took %v to execute\n", elapsed))
@@ -312,8 +372,8 @@ func TestRandom(t *testing.T) {
 		}
 	}
 
-	trap, err := NewKzgSetup(d_1, pairing) //this should eventually return an srs
-	sh_setup := NewShareSetup(trap.ReturnT_1(), trap.ReturnT_2(), trap.ReturnT_u(), pairing, trap.ReturnG_u(), trap.ReturnG_1(), trap.ReturnVal())
+	trap, err := NewKzgSetup(d_1+1, pairing) //this should eventually return an srs
+	sh_setup := NewShareSetup(trap.ReturnT_1(), trap.ReturnT_2(), trap.ReturnT_u(), pairing, trap.ReturnG_u(), trap.ReturnG_1())
 
 	if err != nil {
 		fmt.Println(trap)
@@ -321,7 +381,8 @@ func TestRandom(t *testing.T) {
 
 	//Step_4 : Use the setup (trusted setup) to commit the polynomial φ(x)
 
-	com, co := Commits(trap, f_1, f_2, d_1, d_2)
+	com, err := Commits(trap, bivPoly(t, pairing, f_1), bivPoly(t, pairing, f_2))
+	require.NoError(t, err)
 
 	vn := make([]kyber.Scalar, d_2+1)
 	for i := 0; i < d_2+1; i++ {
@@ -334,7 +395,7 @@ func TestRandom(t *testing.T) {
 
 	//Step_4 : Use the setup (trusted setup) to commit the polynomial φ(x)
 
-	cm := PartialEval(trap, com, co, vn)
+	cm := PartialEval(trap, com, vn)
 
 	//Step_5 : evaluate the polynomial at a specific point (for example I would evaluate it here at a=2)
 	a := pairing.G1().Scalar().SetInt64(2)
@@ -356,8 +417,99 @@ func TestRandom(t *testing.T) {
 	}
 
 	// Step_6 : create an evaluation proof
-	proof, _, _, _, _ := KZGEvaluationProof(trap, f_1_x[1], f_2_x[1], a)
+	proof, _, _, _ := KZGEvaluationProof(trap, f_1_x[1], f_2_x[1], a)
 
 	KZGVerify(sh_setup, cm, 1, proof, a, y_1, y_2) //this should return false
 
 }
+
+// Compare with BenchmarkCommit in Internal/MSM for the sum of products before the bucket method.
+func BenchmarkKZGCommits(b *testing.B) {
+	pairing := bn256.NewSuite()
+
+	for _, l := range []int{16, 64, 256} {
+		trap, err := NewKzgSetup(l, pairing)
+		require.NoError(b, err)
+		sh_setup := NewShareSetup(trap.ReturnT_1(), trap.ReturnT_2(), trap.ReturnT_u(), pairing, trap.ReturnG_u(), trap.ReturnG_1())
+
+		f_1 := make([]kyber.Scalar, l)
+		f_2 := make([]kyber.Scalar, l)
+		for i := range f_1 {
+			f_1[i] = pairing.G1().Scalar().Pick(pairing.RandomStream())
+			f_2[i] = pairing.G1().Scalar().Pick(pairing.RandomStream())
+		}
+
+		b.Run(fmt.Sprintf("l=%d", l), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				KZGCommits(sh_setup, f_1, f_2)
+			}
+		})
+	}
+}
+
+func TestKZGVerifyCommitted(t *testing.T) {
+	g := bn256.NewSuite()
+	setup, err := NewKzgSetup(5, g)
+	require.NoError(t, err)
+	ts := NewShareSetup(setup.ReturnT_1(), setup.ReturnT_2(), setup.ReturnT_u(), g, setup.ReturnG_u(), setup.ReturnG_1())
+
+	f_1, f_2 := make([]kyber.Scalar, 5), make([]kyber.Scalar, 5)
+	for i := range f_1 {
+		f_1[i] = g.G1().Scalar().Pick(g.RandomStream())
+		f_2[i] = g.G1().Scalar().Pick(g.RandomStream())
+	}
+	c := KZGCommits(ts, f_1, f_2)
+	z := g.G1().Scalar().SetInt64(0)
+	proof, y_1, y_2, err := KZGEval(ts, f_1, f_2, z)
+	require.NoError(t, err)
+
+	v := ValueCommitment(ts, y_1, y_2)
+	require.True(t, KZGVerifyCommitted(ts, c, proof, z, v))
+	require.True(t, ts.ReturnG_u().Equal(setup.ReturnG_u()))
+
+	// another value, another point or another proof
+	require.False(t, KZGVerifyCommitted(ts, c, proof, z, ValueCommitment(ts, y_2, y_1)))
+	require.False(t, KZGVerifyCommitted(ts, c, proof, g.G1().Scalar().One(), v))
+	require.False(t, KZGVerifyCommitted(ts, c, g.G1().Point().Add(proof, g.G1().Point().Base()), z, v))
+}
+
+func TestKZGVanishing(t *testing.T) {
+	g := bn256.NewSuite()
+	setup, err := NewKzgSetup(6, g)
+	require.NoError(t, err)
+	ts := NewShareSetup(setup.ReturnT_1(), setup.ReturnT_2(), setup.ReturnT_u(), g, setup.ReturnG_u(), setup.ReturnG_1())
+
+	// f_1 = (X + 1)(X - 2)·a(X) and f_2 = (X + 1)(X - 2)·b(X) for random a, b of degree 3
+	z := []kyber.Scalar{g.G1().Scalar().SetInt64(-1), g.G1().Scalar().SetInt64(2)}
+	Z := vanishing(g, z)
+	a, b := make([]kyber.Scalar, 4), make([]kyber.Scalar, 4)
+	for i := range a {
+		a[i] = g.G1().Scalar().Pick(g.RandomStream())
+		b[i] = g.G1().Scalar().Pick(g.RandomStream())
+	}
+	f_1, f_2 := polynomial.Mul(g, Z, a), polynomial.Mul(g, Z, b)
+	c := KZGCommits(ts, f_1, f_2)
+
+	proof, err := KZGVanishingProof(ts, f_1, f_2, z)
+	require.NoError(t, err)
+	require.True(t, KZGVerifyVanishing(ts, c, proof, z))
+
+	// another commitment, another set of points or another proof
+	require.False(t, KZGVerifyVanishing(ts, g.G1().Point().Add(c, g.G1().Point().Base()), proof, z))
+	require.False(t, KZGVerifyVanishing(ts, c, proof, z[:1]))
+	require.False(t, KZGVerifyVanishing(ts, c, g.G1().Point().Add(proof, g.G1().Point().Base()), z))
+
+	// a polynomial that does not vanish on z has no proof
+	f_1[0] = g.G1().Scalar().Add(f_1[0], g.G1().Scalar().One())
+	_, err = KZGVanishingProof(ts, f_1, f_2, z)
+	require.Error(t, err)
+	_, err = KZGVanishingProof(ts, f_1, f_2, append(z, z[0], z[1], z[0], z[1]))
+	require.Error(t, err)
+}
+
+// bivPoly wraps the coefficients of a test polynomial, c[i][j] for X^i Y^j.
+func bivPoly(t testing.TB, pairing pairing.Suite, c [][]kyber.Scalar) *poly.BivPoly {
+	p, err := poly.NewBivPolyFromCoefficients(pairing, c)
+	require.NoError(t, err)
+	return p
+}
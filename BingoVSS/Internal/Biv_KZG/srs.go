@@ -0,0 +1,284 @@
+package biv_kzg
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/drand/kyber"
+	"github.com/drand/kyber/pairing"
+)
+
+/*
+The structured reference string is stored in the following binary format (all integers big-endian):
+
+	magic      4 bytes  "BSRS"
+	version    uint16   srsVersion
+	curve      uint8 length followed by the name of the pairing suite
+	degree     uint32   number of powers l (the degree bound in X plus one)
+	hash       32 bytes SHA-256 of the body
+	body       t_1[0..l), t_2[0..l), t_Up[0..l) as marshalled points
+
+Only public group elements are written, so the file can be shared with every node.
+*/
+
+var srsMagic = []byte("BSRS")
+
+const srsVersion uint16 = 1
+
+// maxSRSPowers bounds the degree read from an untrusted file.
+const maxSRSPowers = 1 << 24
+
+// WriteSetup streams the public parameters of ts to w in the versioned SRS format.
+func WriteSetup(w io.Writer, ts *KzgSetup) error {
+	return writeSRS(w, ts.g, ts.t_1, ts.t_2, ts.t_Up)
+}
+
+// WriteShareSetup streams the public parameters of ts to w in the versioned SRS format.
+func WriteShareSetup(w io.Writer, ts *KzgShareSetup) error {
+	return writeSRS(w, ts.g, ts.t_1, ts.t_2, ts.t_Up)
+}
+
+// ReadSetup reads an SRS written by WriteSetup and checks with pairings that the powers are consistent.
+func ReadSetup(r io.Reader, pairing pairing.Suite) (*KzgSetup, error) {
+	t_1, t_2, t_Up, err := readSRS(r, pairing)
+	if err != nil {
+		return nil, err
+	}
+	return NewKzgSetupFromPowers(t_1, t_2, t_Up, pairing)
+}
+
+// ReadShareSetup reads an SRS written by WriteSetup or WriteShareSetup and checks that the powers are consistent.
+func ReadShareSetup(r io.Reader, pairing pairing.Suite) (*KzgShareSetup, error) {
+	ts, err := ReadSetup(r, pairing)
+	if err != nil {
+		return nil, err
+	}
+	return NewShareSetup(ts.t_1, ts.t_2, ts.t_Up, pairing, ts.gUp, ts.g1), nil
+}
+
+// SaveSetup writes the SRS of ts to the file at path.
+func SaveSetup(path string, ts *KzgSetup) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	if err := WriteSetup(f, ts); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+// LoadSetup reads and validates the SRS stored in the file at path.
+func LoadSetup(path string, pairing pairing.Suite) (*KzgSetup, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return ReadSetup(f, pairing)
+}
+
+func writeSRS(w io.Writer, g pairing.Suite, t_1, t_2, t_Up []kyber.Point) error {
+	curve := curveName(g)
+	if len(curve) > 255 {
+		return fmt.Errorf("srs: curve name too long")
+	}
+
+	// First pass: hash the body so the header can carry it
+	digest := sha256.New()
+	if err := writePoints(digest, t_1, t_2, t_Up); err != nil {
+		return err
+	}
+
+	bw := bufio.NewWriter(w)
+
+	header := make([]byte, 0, 4+2+1+len(curve)+4+sha256.Size)
+	header = append(header, srsMagic...)
+	header = binary.BigEndian.AppendUint16(header, srsVersion)
+	header = append(header, byte(len(curve)))
+	header = append(header, curve...)
+	header = binary.BigEndian.AppendUint32(header, uint32(len(t_1)))
+	header = append(header, digest.Sum(nil)...)
+
+	if _, err := bw.Write(header); err != nil {
+		return err
+	}
+
+	// Second pass: stream the body itself
+	if err := writePoints(bw, t_1, t_2, t_Up); err != nil {
+		return err
+	}
+
+	return bw.Flush()
+}
+
+func writePoints(w io.Writer, groups ...[]kyber.Point) error {
+	for _, points := range groups {
+		for _, p := range points {
+			if _, err := p.MarshalTo(w); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func readSRS(r io.Reader, pairing pairing.Suite) ([]kyber.Point, []kyber.Point, []kyber.Point, error) {
+	br := bufio.NewReader(r)
+
+	magic := make([]byte, len(srsMagic))
+	if _, err := io.ReadFull(br, magic); err != nil || !bytes.Equal(magic, srsMagic) {
+		return nil, nil, nil, errors.New("srs: not an SRS file")
+	}
+
+	var version uint16
+	if err := binary.Read(br, binary.BigEndian, &version); err != nil {
+		return nil, nil, nil, err
+	}
+	if version != srsVersion {
+		return nil, nil, nil, fmt.Errorf("srs: unsupported version %d", version)
+	}
+
+	curveLen, err := br.ReadByte()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	curve := make([]byte, curveLen)
+	if _, err := io.ReadFull(br, curve); err != nil {
+		return nil, nil, nil, err
+	}
+	if string(curve) != curveName(pairing) {
+		return nil, nil, nil, fmt.Errorf("srs: file is for curve %q, expected %q", curve, curveName(pairing))
+	}
+
+	var l uint32
+	if err := binary.Read(br, binary.BigEndian, &l); err != nil {
+		return nil, nil, nil, err
+	}
+	if l < 2 || l > maxSRSPowers {
+		return nil, nil, nil, fmt.Errorf("srs: invalid degree bound %d", l)
+	}
+
+	expected := make([]byte, sha256.Size)
+	if _, err := io.ReadFull(br, expected); err != nil {
+		return nil, nil, nil, err
+	}
+
+	// Everything read from the body goes through the hash
+	digest := sha256.New()
+	body := io.TeeReader(br, digest)
+
+	t_1, err := readPowers(body, pairing.G1(), int(l))
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	t_2, err := readPowers(body, pairing.G2(), int(l))
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	t_Up, err := readPowers(body, pairing.G1(), int(l))
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	if !bytes.Equal(digest.Sum(nil), expected) {
+		return nil, nil, nil, errors.New("srs: hash of the body does not match the header")
+	}
+	if _, err := br.ReadByte(); err != io.EOF {
+		return nil, nil, nil, errors.New("srs: trailing data after the body")
+	}
+
+	if err := VerifyPowers(pairing, t_1, t_2, t_Up); err != nil {
+		return nil, nil, nil, fmt.Errorf("srs: %w", err)
+	}
+
+	return t_1, t_2, t_Up, nil
+}
+
+func readPowers(r io.Reader, group kyber.Group, l int) ([]kyber.Point, error) {
+	// The capacity is bounded since l comes from the file
+	capacity := l
+	if capacity > 1024 {
+		capacity = 1024
+	}
+
+	points := make([]kyber.Point, 0, capacity)
+	for i := 0; i < l; i++ {
+		p := group.Point()
+		if _, err := p.UnmarshalFrom(r); err != nil {
+			return nil, fmt.Errorf("srs: reading power %d: %w", i, err)
+		}
+		points = append(points, p)
+	}
+	return points, nil
+}
+
+// VerifyPowers checks with pairings that t_1, t_2 and t_Up are consecutive powers of the same τ.
+// The individual checks e(t[i], h) = e(t[i-1], τ·h) are combined with random coefficients ρ_i,
+// so the cost is a constant number of pairings.
+func VerifyPowers(g pairing.Suite, t_1, t_2, t_Up []kyber.Point) error {
+
+	l := len(t_1)
+	if l < 2 || len(t_2) != l || len(t_Up) != l {
+		return fmt.Errorf("wrong number of powers: %d, %d, %d", len(t_1), len(t_2), len(t_Up))
+	}
+
+	// With τ = 0 or gUp = 0 every pairing check below holds trivially and the trapdoor is public
+	if t_1[1].Equal(g.G1().Point().Null()) || t_2[1].Equal(g.G2().Point().Null()) {
+		return fmt.Errorf("τ is zero")
+	}
+	if t_Up[0].Equal(g.G1().Point().Null()) {
+		return fmt.Errorf("gUp is the identity")
+	}
+
+	h := g.G2().Point().Base()
+	g1 := g.G1().Point().Base()
+
+	// Σ ρ_i t_1[i] against Σ ρ_i t_1[i-1], and the same for t_Up and t_2
+	cur_1 := g.G1().Point().Null()
+	prev_1 := g.G1().Point().Null()
+	cur_up := g.G1().Point().Null()
+	prev_up := g.G1().Point().Null()
+	cur_2 := g.G2().Point().Null()
+	prev_2 := g.G2().Point().Null()
+
+	for i := 1; i < l; i++ {
+		rho := g.G1().Scalar().Pick(g.RandomStream())
+
+		cur_1 = cur_1.Add(cur_1, g.G1().Point().Mul(rho, t_1[i]))
+		prev_1 = prev_1.Add(prev_1, g.G1().Point().Mul(rho, t_1[i-1]))
+		cur_up = cur_up.Add(cur_up, g.G1().Point().Mul(rho, t_Up[i]))
+		prev_up = prev_up.Add(prev_up, g.G1().Point().Mul(rho, t_Up[i-1]))
+		cur_2 = cur_2.Add(cur_2, g.G2().Point().Mul(rho, t_2[i]))
+		prev_2 = prev_2.Add(prev_2, g.G2().Point().Mul(rho, t_2[i-1]))
+	}
+
+	if !g.Pair(cur_1, h).Equal(g.Pair(prev_1, t_2[1])) {
+		return fmt.Errorf("t_1 are not consecutive powers of τ")
+	}
+	if !g.Pair(cur_up, h).Equal(g.Pair(prev_up, t_2[1])) {
+		return fmt.Errorf("t_Up are not consecutive powers of τ")
+	}
+	if !g.Pair(t_1[1], prev_2).Equal(g.Pair(g1, cur_2)) {
+		return fmt.Errorf("t_2 are not consecutive powers of τ")
+	}
+
+	return nil
+}
+
+// curveName returns the name recorded in the file for the curve of the suite, e.g. "bn256" or "bls12-381".
+// Not every pairing suite implements String, so it is derived from the name of G1.
+func curveName(g pairing.Suite) string {
+	return strings.TrimSuffix(g.G1().String(), ".G1")
+}
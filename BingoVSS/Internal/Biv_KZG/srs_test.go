@@ -0,0 +1,147 @@
+package biv_kzg
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+
+	"github.com/drand/kyber"
+	bls "github.com/drand/kyber-bls12381"
+	"github.com/drand/kyber/pairing/bn256"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSRSRoundTrip(t *testing.T) {
+	pairing := bn256.NewSuite()
+
+	trap, err := NewKzgSetup(5, pairing)
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteSetup(&buf, trap))
+
+	loaded, err := ReadSetup(bytes.NewReader(buf.Bytes()), pairing)
+	require.NoError(t, err)
+
+	for i := range trap.t_1 {
+		require.True(t, trap.t_1[i].Equal(loaded.t_1[i]))
+		require.True(t, trap.t_2[i].Equal(loaded.t_2[i]))
+		require.True(t, trap.t_Up[i].Equal(loaded.t_Up[i]))
+	}
+	require.True(t, trap.gUp.Equal(loaded.ReturnG_u()))
+
+	// A share setup read from the same bytes commits exactly like the original
+	sh_setup, err := ReadShareSetup(bytes.NewReader(buf.Bytes()), pairing)
+	require.NoError(t, err)
+
+	f_1 := []kyber.Scalar{pairing.G1().Scalar().SetInt64(3), pairing.G1().Scalar().SetInt64(1)}
+	f_2 := []kyber.Scalar{pairing.G1().Scalar().SetInt64(7), pairing.G1().Scalar().SetInt64(2)}
+	original := NewShareSetup(trap.t_1, trap.t_2, trap.t_Up, pairing, trap.gUp, trap.g1)
+	require.True(t, KZGCommits(sh_setup, f_1, f_2).Equal(KZGCommits(original, f_1, f_2)))
+
+	// The same through a file on disk
+	path := filepath.Join(t.TempDir(), "srs.bin")
+	require.NoError(t, SaveSetup(path, trap))
+	fromFile, err := LoadSetup(path, pairing)
+	require.NoError(t, err)
+	require.True(t, fromFile.t_2[4].Equal(trap.t_2[4]))
+}
+
+func TestSRSRejectsInvalidFiles(t *testing.T) {
+	pairing := bn256.NewSuite()
+
+	trap, _ := NewKzgSetup(4, pairing)
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteSetup(&buf, trap))
+	data := buf.Bytes()
+
+	// Truncated body
+	_, err := ReadSetup(bytes.NewReader(data[:len(data)-10]), pairing)
+	require.Error(t, err)
+
+	// Trailing data
+	_, err = ReadSetup(bytes.NewReader(append(append([]byte{}, data...), 1)), pairing)
+	require.Error(t, err)
+
+	// Wrong magic and version
+	bad := append([]byte{}, data...)
+	bad[0] = 'X'
+	_, err = ReadSetup(bytes.NewReader(bad), pairing)
+	require.Error(t, err)
+
+	bad = append([]byte{}, data...)
+	bad[5] = 9
+	_, err = ReadSetup(bytes.NewReader(bad), pairing)
+	require.Error(t, err)
+
+	// A body that does not match the hash of the header
+	bad = append([]byte{}, data...)
+	bad[len(bad)-1] ^= 1
+	_, err = ReadSetup(bytes.NewReader(bad), pairing)
+	require.Error(t, err)
+
+	// A well formed file whose powers are not consecutive powers of τ
+	t_1 := append([]kyber.Point{}, trap.t_1...)
+	t_1[2] = pairing.G1().Point().Pick(pairing.RandomStream())
+	forged, err := NewKzgSetupFromPowers(t_1, trap.t_2, trap.t_Up, pairing)
+	require.NoError(t, err)
+
+	buf.Reset()
+	require.NoError(t, WriteSetup(&buf, forged))
+	_, err = ReadSetup(bytes.NewReader(buf.Bytes()), pairing)
+	require.Error(t, err)
+}
+
+func TestSRSCurveMismatch(t *testing.T) {
+	blsPairing := bls.NewBLS12381Suite()
+
+	trap, err := NewKzgSetup(3, blsPairing)
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteSetup(&buf, trap))
+
+	_, err = ReadSetup(bytes.NewReader(buf.Bytes()), blsPairing)
+	require.NoError(t, err)
+
+	// A BLS12-381 file must not be loaded as a bn256 one
+	_, err = ReadSetup(bytes.NewReader(buf.Bytes()), bn256.NewSuite())
+	require.Error(t, err)
+}
+
+func TestSRSRejectsZeroTrapdoor(t *testing.T) {
+	pairing := bn256.NewSuite()
+	l := 4
+
+	// τ = 0 turns every power after the first into the identity and passes the pairing checks
+	t_1 := make([]kyber.Point, l)
+	t_2 := make([]kyber.Point, l)
+	t_Up := make([]kyber.Point, l)
+	for i := 0; i < l; i++ {
+		t_1[i] = pairing.G1().Point().Null()
+		t_2[i] = pairing.G2().Point().Null()
+		t_Up[i] = pairing.G1().Point().Null()
+	}
+	t_1[0] = pairing.G1().Point().Base()
+	t_2[0] = pairing.G2().Point().Base()
+	t_Up[0] = pairing.G1().Point().Pick(pairing.RandomStream())
+
+	require.Error(t, VerifyPowers(pairing, t_1, t_2, t_Up))
+
+	forged, err := NewKzgSetupFromPowers(t_1, t_2, t_Up, pairing)
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteSetup(&buf, forged))
+	_, err = ReadSetup(bytes.NewReader(buf.Bytes()), pairing)
+	require.Error(t, err)
+
+	// The same with an identity gUp
+	trap, _ := NewKzgSetup(l, pairing)
+	up := make([]kyber.Point, l)
+	for i := range up {
+		up[i] = pairing.G1().Point().Null()
+	}
+	require.Error(t, VerifyPowers(pairing, trap.t_1, trap.t_2, up))
+}
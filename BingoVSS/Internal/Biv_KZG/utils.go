@@ -1,18 +1,20 @@
 package biv_kzg
 
 import (
+	"BingoVSS/Internal/MSM"
+	"BingoVSS/Internal/Polynomial"
 	"fmt"
 	"math/big"
 
 	"github.com/drand/kyber"
-	"github.com/drand/kyber/pairing/bn256"
+	"github.com/drand/kyber/pairing"
 )
 
 const debug = 0
 
 // calculateTrapdoorValues calculates trapdoor values for a given point g_i within a specific group.
 // pairing is the pairing suite, g_i is the base point, group is the group identifier (1 or 2), l is the length of the trapdoor, and t is a scalar value.
-func calculateTrapdoorValues(pairing *bn256.Suite, g_i kyber.Point, group, l int, t kyber.Scalar) ([]kyber.Point, error) {
+func calculateTrapdoorValues(pairing pairing.Suite, g_i kyber.Point, group, l int, t kyber.Scalar) ([]kyber.Point, error) {
 	// Initialize a slice gTrapdoor to store the trapdoor values, with length l
 	gTrapdoor := make([]kyber.Point, l)
 
@@ -45,7 +47,7 @@ func calculateTrapdoorValues(pairing *bn256.Suite, g_i kyber.Point, group, l int
 
 // Pow computes the power of a given base raised to a specified exponent within a specific group.
 // exp is the exponent, base is the base value, and group represents the eliptic curve working with.
-func Pow(exp int, base kyber.Scalar, e kyber.Scalar, group *bn256.Suite) kyber.Scalar {
+func Pow(exp int, base kyber.Scalar, e kyber.Scalar, group pairing.Suite) kyber.Scalar {
 	// Initialize a variable power with the value of 1 in the group
 	power := group.G1().Scalar().One()
 
@@ -68,7 +70,7 @@ func Pow(exp int, base kyber.Scalar, e kyber.Scalar, group *bn256.Suite) kyber.S
 }
 
 // subPoly performs the subtraction of a scalar value y from the constant term of a given polynomial f.
-func subPoly(f []kyber.Scalar, y kyber.Scalar, group *bn256.Suite) []kyber.Scalar {
+func subPoly(f []kyber.Scalar, y kyber.Scalar, group pairing.Suite) []kyber.Scalar {
 	// Create a new slice q with the same length as f, to store the result of the subtraction
 	q := make([]kyber.Scalar, len(f))
 	for i := 0; i < len(f); i++ {
@@ -96,7 +98,7 @@ func subPoly(f []kyber.Scalar, y kyber.Scalar, group *bn256.Suite) []kyber.Scala
 
 // DivPoly performs polynomial division of two polynomials n (numerator) and d (denominator).
 // It returns the quotient q and remainder rem of the division.
-func DivPoly(n, d []kyber.Scalar, group *bn256.Suite) ([]kyber.Scalar, []kyber.Scalar) {
+func DivPoly(n, d []kyber.Scalar, group pairing.Suite) ([]kyber.Scalar, []kyber.Scalar) {
 	// Initialize the quotient q with the appropriate length
 	q := make([]kyber.Scalar, len(n)-len(d)+1)
 	for i := range q {
@@ -137,29 +139,11 @@ func DivPoly(n, d []kyber.Scalar, group *bn256.Suite) ([]kyber.Scalar, []kyber.S
 // EvaluatePolynomial evaluates a polynomial p at a given value x.
 // It takes a slice of kyber.Scalar values representing the coefficients of the polynomial p,
 // a kyber.Scalar value x representing the point at which to evaluate the polynomial,
-// and a bn256.Suite object suite for performing arithmetic operations.
+// and a pairing.Suite object suite for performing arithmetic operations.
 // It returns the result of the evaluation as a kyber.Scalar value.
-func evaluatePolynomial(p []kyber.Scalar, x kyber.Scalar, suite *bn256.Suite) kyber.Scalar {
-	// Initialize the result r to zero
-	r := suite.G1().Scalar().Zero()
-
-	// Initialize a temporary variable tmp to one, to be used for calculating the power of x
-	tmp := suite.G1().Scalar().One()
-
-	// Iterate through the coefficients of the polynomial p
-	for _, coeff := range p {
-		// Multiply the current coefficient by the current power of x (stored in tmp)
-		term := suite.G1().Scalar().Mul(tmp, coeff)
-
-		// Add the result to the current value of r
-		r = suite.G1().Scalar().Add(r, term)
-
-		// Multiply tmp by x, increasing the power of x for the next iteration
-		tmp = suite.G1().Scalar().Mul(tmp, x)
-	}
-
-	// Return the result of the evaluation
-	return r
+func evaluatePolynomial(p []kyber.Scalar, x kyber.Scalar, suite pairing.Suite) kyber.Scalar {
+	// Horner's rule, one multiplication per coefficient
+	return polynomial.Evaluate(suite, p, x)
 }
 
 func createUnivariatePolynomials(ts *KzgSetup, f_x [][]kyber.Scalar, d_1 int, d_2 int) [][]kyber.Scalar {
@@ -185,7 +169,7 @@ func createUnivariatePolynomials(ts *KzgSetup, f_x [][]kyber.Scalar, d_1 int, d_
 	return uni_f
 }
 
-// func evaluatePolynomialPoint(scalar, a kyber.Scalar, suite *bn256.Suite) kyber.Scalar {
+// func evaluatePolynomialPoint(scalar, a kyber.Scalar, suite pairing.Suite) kyber.Scalar {
 // 	// Initialize the result r to zero
 // 	r := suite.G1().Scalar().Zero()
 
@@ -217,8 +201,7 @@ func interpolatePolynomial(f_1 [][]kyber.Scalar, a_f1_x [][]kyber.Scalar, d_1, d
 	return f_1
 }
 
-func vandermonde(x []kyber.Scalar, degree int) [][]kyber.Scalar {
-	suite := bn256.NewSuite()
+func vandermonde(suite pairing.Suite, x []kyber.Scalar, degree int) [][]kyber.Scalar {
 	rows := len(x)
 	cols := degree + 1
 	vMatrix := make([][]kyber.Scalar, rows)
@@ -233,8 +216,7 @@ func vandermonde(x []kyber.Scalar, degree int) [][]kyber.Scalar {
 	return vMatrix
 }
 
-func solveLinearSystem(vMatrix [][]kyber.Scalar, y []kyber.Scalar) []kyber.Scalar {
-	suite := bn256.NewSuite()
+func solveLinearSystem(suite pairing.Suite, vMatrix [][]kyber.Scalar, y []kyber.Scalar) []kyber.Scalar {
 	rows := len(vMatrix)
 	cols := len(vMatrix[0])
 
@@ -272,7 +254,7 @@ func solveLinearSystem(vMatrix [][]kyber.Scalar, y []kyber.Scalar) []kyber.Scala
 	return coeffs
 }
 
-func adjustBivariateCoefficients(f_1 [][]kyber.Scalar, d_2 int, co []kyber.Scalar, group *bn256.Suite) [][]kyber.Scalar {
+func adjustBivariateCoefficients(f_1 [][]kyber.Scalar, d_2 int, co []kyber.Scalar, group pairing.Suite) [][]kyber.Scalar {
 
 	for i := 0; i < len(co); i++ {
 		f_1[i][0] = co[i]
@@ -281,7 +263,7 @@ func adjustBivariateCoefficients(f_1 [][]kyber.Scalar, d_2 int, co []kyber.Scala
 	return f_1
 }
 
-func createProjectionPolynomials(g *bn256.Suite, f_x [][]kyber.Scalar, d_1 int, d_2 int, n int) [][]kyber.Scalar {
+func createProjectionPolynomials(g pairing.Suite, f_x [][]kyber.Scalar, d_1 int, d_2 int, n int) [][]kyber.Scalar {
 
 	// Create a 2D array to store the univariate polynomials
 	uni_f := make([][]kyber.Scalar, n)
@@ -313,14 +295,16 @@ func createProjectionPolynomials(g *bn256.Suite, f_x [][]kyber.Scalar, d_1 int,
 	return uni_f
 }
 
-func evaluatePolynomialAt(phi []kyber.Scalar, value kyber.Scalar) kyber.Scalar {
-	result := phi[0].Clone().Zero()
-
-	currentValue := value.Clone().One()
-	for _, coeff := range phi {
-		result.Add(result, coeff.Clone().Mul(coeff, currentValue))
-		currentValue.Mul(currentValue, value)
+// evaluatePointsAt evaluates a polynomial whose coefficients are group elements c
+// at the scalar value x, returning Σ_j x^j c_j.
+func evaluatePointsAt(suite pairing.Suite, c []kyber.Point, x kyber.Scalar) kyber.Point {
+	// The powers of x are the scalars of a multi-scalar multiplication
+	powers := make([]kyber.Scalar, len(c))
+	tmp := suite.G1().Scalar().One()
+	for j := range c {
+		powers[j] = suite.G1().Scalar().Set(tmp)
+		tmp = tmp.Mul(tmp, x)
 	}
 
-	return result
+	return msm.MultiExp(suite.G1(), c, powers)
 }
@@ -0,0 +1,54 @@
+package biv_kzg
+
+import (
+	"BingoVSS/Internal/Polynomial"
+	"fmt"
+
+	"github.com/drand/kyber"
+	"github.com/drand/kyber/pairing"
+)
+
+/*
+This function proves that the committed pair f_1, f_2 vanishes at every point of z without
+revealing anything else: the proof commits to q_1 = f_1 / Z and q_2 = f_2 / Z, with
+Z(X) = Π_k (X - z_k). It returns an error when f_1 or f_2 does not vanish on z.
+*/
+func KZGVanishingProof(ts *KzgShareSetup, f_1, f_2, z []kyber.Scalar) (kyber.Point, error) {
+	Z := vanishing(ts.g, z)
+	if len(Z) > len(ts.t_2) {
+		return nil, fmt.Errorf("Error: the setup supports %d points, not %d", len(ts.t_2)-1, len(z))
+	}
+	q_1, r_1 := polynomial.Div(ts.g, f_1, Z)
+	q_2, r_2 := polynomial.Div(ts.g, f_2, Z)
+	for _, r := range append(r_1, r_2...) {
+		if !r.Equal(ts.g.G1().Scalar().Zero()) {
+			return nil, fmt.Errorf("Error: the remainder should be 0 not %v", r)
+		}
+	}
+	return KZGCommits(ts, q_1, q_2), nil
+}
+
+/*
+KZGVerifyVanishing checks a proof of KZGVanishingProof for the commitment c:
+e(c, h) = e(π, [Z(τ)]₂).
+*/
+func KZGVerifyVanishing(ts *KzgShareSetup, c, proof kyber.Point, z []kyber.Scalar) bool {
+	Z := vanishing(ts.g, z)
+	if len(Z) > len(ts.t_2) || c == nil || proof == nil {
+		return false
+	}
+	zG2 := ts.g.G2().Point().Null()
+	for i, a := range Z {
+		zG2.Add(zG2, ts.g.G2().Point().Mul(a, ts.t_2[i]))
+	}
+	return ts.g.Pair(c, ts.g.G2().Point().Base()).Equal(ts.g.Pair(proof, zG2))
+}
+
+// vanishing returns the coefficients of Z(X) = Π_k (X - z_k).
+func vanishing(g pairing.Suite, z []kyber.Scalar) []kyber.Scalar {
+	Z := []kyber.Scalar{g.G1().Scalar().One()}
+	for _, p := range z {
+		Z = polynomial.Mul(g, Z, []kyber.Scalar{g.G1().Scalar().Neg(p), g.G1().Scalar().One()})
+	}
+	return Z
+}
@@ -0,0 +1,262 @@
+package broadcast
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// Kind is the step of Bracha's protocol a message belongs to.
+type Kind uint8
+
+const (
+	KindSend  Kind = iota + 1 // the value as sent by the sender
+	KindEcho                  // a party saw the value from the sender
+	KindReady                 // a party is ready to deliver the value
+)
+
+func (k Kind) String() string {
+	switch k {
+	case KindSend:
+		return "send"
+	case KindEcho:
+		return "echo"
+	case KindReady:
+		return "ready"
+	}
+	return "unknown"
+}
+
+// Message is a single step of a broadcast instance.
+type Message struct {
+	Kind  Kind
+	Value []byte
+}
+
+// Envelope carries a message between two parties. From is set by the authenticated channel it
+// arrived on, never by the message itself.
+type Envelope struct {
+	From int
+	To   int
+	Msg  Message
+}
+
+/*
+Instance is one party in a single run of Bracha's reliable broadcast. The sender may or may not
+be one of the parties; among the n parties at most f may be faulty, with n >= 3f+1. The steps are:
+
+ 1. on SEND(v) from the sender, send ECHO(v) to everyone
+ 2. on ECHO(v) from ⌈(n+f+1)/2⌉ parties, or READY(v) from f+1 parties, send READY(v) to everyone
+ 3. on READY(v) from 2f+1 parties, deliver v
+
+Only the first message of every kind from a party counts, so a faulty party cannot vote twice.
+If one honest party delivers v, every honest party eventually delivers v and none delivers
+anything else, even when the sender sends different values to different parties.
+*/
+type Instance struct {
+	self    int
+	sender  int
+	parties map[int]bool
+	order   []int // the parties in increasing order, so the output is deterministic
+	f       int
+
+	echoSent  bool
+	readySent bool
+
+	echoFrom  map[int]bool
+	readyFrom map[int]bool
+	echoes    map[[32]byte]int
+	readies   map[[32]byte]int
+	values    map[[32]byte][]byte
+
+	delivered []byte
+	done      bool
+}
+
+/* This function constructs the instance of party self in a broadcast from sender to the given parties */
+func NewInstance(self, sender int, parties []int, f int) (*Instance, error) {
+	set := make(map[int]bool, len(parties))
+	order := append([]int{}, parties...)
+	sort.Ints(order)
+	for _, p := range order {
+		if set[p] {
+			return nil, fmt.Errorf("broadcast: party %d listed twice", p)
+		}
+		set[p] = true
+	}
+	if f < 0 || len(set) < 3*f+1 {
+		return nil, fmt.Errorf("broadcast: need n >= 3f+1, got n = %d and f = %d", len(set), f)
+	}
+	if !set[self] && self != sender {
+		return nil, fmt.Errorf("broadcast: %d is neither a party nor the sender", self)
+	}
+
+	return &Instance{
+		self:      self,
+		sender:    sender,
+		parties:   set,
+		order:     order,
+		f:         f,
+		echoFrom:  make(map[int]bool),
+		readyFrom: make(map[int]bool),
+		echoes:    make(map[[32]byte]int),
+		readies:   make(map[[32]byte]int),
+		values:    make(map[[32]byte][]byte),
+	}, nil
+}
+
+// Broadcast starts the instance at the sender and returns the SEND messages to all parties.
+func (b *Instance) Broadcast(value []byte) ([]Envelope, error) {
+	if b.self != b.sender {
+		return nil, fmt.Errorf("broadcast: %d is not the sender", b.self)
+	}
+
+	out := b.toAll(Message{Kind: KindSend, Value: value})
+	if b.parties[b.self] {
+		next, err := b.Handle(b.self, Message{Kind: KindSend, Value: value})
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, next...)
+	}
+	return out, nil
+}
+
+// Handle processes one message from the party from and returns the messages to send in response.
+// Messages of a party to itself are handled internally and never returned.
+func (b *Instance) Handle(from int, m Message) ([]Envelope, error) {
+	if !b.parties[b.self] {
+		return nil, nil
+	}
+
+	switch m.Kind {
+	case KindSend:
+		if from != b.sender {
+			return nil, fmt.Errorf("broadcast: send from %d instead of the sender %d", from, b.sender)
+		}
+		if b.echoSent {
+			return nil, nil
+		}
+		b.echoSent = true
+		return b.sendToAll(Message{Kind: KindEcho, Value: m.Value})
+
+	case KindEcho:
+		if !b.parties[from] {
+			return nil, fmt.Errorf("broadcast: echo from unknown party %d", from)
+		}
+		if b.echoFrom[from] {
+			return nil, nil
+		}
+		b.echoFrom[from] = true
+		d := b.record(m.Value)
+		b.echoes[d]++
+		if b.echoes[d] >= b.echoThreshold() {
+			return b.ready(d)
+		}
+		return nil, nil
+
+	case KindReady:
+		if !b.parties[from] {
+			return nil, fmt.Errorf("broadcast: ready from unknown party %d", from)
+		}
+		if b.readyFrom[from] {
+			return nil, nil
+		}
+		b.readyFrom[from] = true
+		d := b.record(m.Value)
+		b.readies[d]++
+		if b.readies[d] >= 2*b.f+1 && !b.done {
+			b.delivered = b.values[d]
+			b.done = true
+		}
+		if b.readies[d] >= b.f+1 {
+			return b.ready(d)
+		}
+		return nil, nil
+	}
+
+	return nil, fmt.Errorf("broadcast: unknown message kind %d from %d", m.Kind, from)
+}
+
+/*
+This function restarts the instance of a party that crashed after sending ECHO(echo) and
+READY(ready), nil for a step it had not taken. The fresh instance counts both steps as taken, so it
+never echoes or readies another value after the restart, and returns them for everyone again;
+the other parties count them only once. The votes the party had received before the crash are
+lost and have to be sent again.
+*/
+func (b *Instance) Resume(echo, ready []byte) ([]Envelope, error) {
+	if b.echoSent || b.readySent || len(b.echoFrom) > 0 || len(b.readyFrom) > 0 {
+		return nil, errors.New("broadcast: only a fresh instance can resume")
+	}
+	if !b.parties[b.self] {
+		return nil, nil
+	}
+
+	b.echoSent = echo != nil
+	b.readySent = ready != nil
+	var out []Envelope
+	if echo != nil {
+		next, err := b.sendToAll(Message{Kind: KindEcho, Value: echo})
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, next...)
+	}
+	if ready != nil {
+		out = append(out, b.toAll(Message{Kind: KindReady, Value: ready})...)
+		next, err := b.Handle(b.self, Message{Kind: KindReady, Value: ready})
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, next...)
+	}
+	return out, nil
+}
+
+// Delivered returns the delivered value, if any.
+func (b *Instance) Delivered() ([]byte, bool) {
+	return b.delivered, b.done
+}
+
+func (b *Instance) ready(d [32]byte) ([]Envelope, error) {
+	if b.readySent {
+		return nil, nil
+	}
+	b.readySent = true
+	return b.sendToAll(Message{Kind: KindReady, Value: b.values[d]})
+}
+
+// sendToAll returns m for every other party and handles our own copy right away.
+func (b *Instance) sendToAll(m Message) ([]Envelope, error) {
+	out := b.toAll(m)
+	next, err := b.Handle(b.self, m)
+	if err != nil {
+		return nil, err
+	}
+	return append(out, next...), nil
+}
+
+func (b *Instance) toAll(m Message) []Envelope {
+	out := make([]Envelope, 0, len(b.parties))
+	for _, p := range b.order {
+		if p != b.self {
+			out = append(out, Envelope{From: b.self, To: p, Msg: m})
+		}
+	}
+	return out
+}
+
+// record remembers a value under its digest, so that votes are counted per value.
+func (b *Instance) record(value []byte) [32]byte {
+	d := sha256.Sum256(value)
+	if _, ok := b.values[d]; !ok {
+		b.values[d] = append([]byte{}, value...)
+	}
+	return d
+}
+
+func (b *Instance) echoThreshold() int {
+	return (len(b.parties)+b.f)/2 + 1
+}
@@ -0,0 +1,247 @@
+package broadcast
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const sender = 0
+
+// network delivers the queued messages to the honest instances in a random order. Faulty parties
+// are not simulated, their messages are put in the queue by the test.
+type network struct {
+	nodes map[int]*Instance
+	queue []Envelope
+	rnd   *rand.Rand
+}
+
+func newNetwork(t *testing.T, n, f int, faulty map[int]bool, seed int64) *network {
+	parties := make([]int, n)
+	for i := range parties {
+		parties[i] = i + 1
+	}
+
+	net := &network{nodes: make(map[int]*Instance), rnd: rand.New(rand.NewSource(seed))}
+	for _, p := range parties {
+		if faulty[p] {
+			continue
+		}
+		b, err := NewInstance(p, sender, parties, f)
+		require.NoError(t, err)
+		net.nodes[p] = b
+	}
+	return net
+}
+
+func (net *network) run(t *testing.T) {
+	for len(net.queue) > 0 {
+		k := net.rnd.Intn(len(net.queue))
+		env := net.queue[k]
+		net.queue = append(net.queue[:k], net.queue[k+1:]...)
+
+		b, ok := net.nodes[env.To]
+		if !ok {
+			continue
+		}
+		out, err := b.Handle(env.From, env.Msg)
+		require.NoError(t, err)
+		net.queue = append(net.queue, out...)
+	}
+}
+
+// delivered checks agreement and totality: either every honest party delivered the same value, or none did.
+func (net *network) delivered(t *testing.T) ([]byte, bool) {
+	var value []byte
+	count := 0
+	for _, b := range net.nodes {
+		v, ok := b.Delivered()
+		if !ok {
+			continue
+		}
+		if count > 0 {
+			require.True(t, bytes.Equal(value, v), "honest parties delivered different values")
+		}
+		value = v
+		count++
+	}
+	require.True(t, count == 0 || count == len(net.nodes), "only %d of %d honest parties delivered", count, len(net.nodes))
+	return value, count > 0
+}
+
+func send(to int, v []byte) Envelope {
+	return Envelope{From: sender, To: to, Msg: Message{Kind: KindSend, Value: v}}
+}
+
+func TestHonestSender(t *testing.T) {
+	for seed := int64(0); seed < 20; seed++ {
+		net := newNetwork(t, 4, 1, nil, seed)
+
+		s, err := NewInstance(sender, sender, []int{1, 2, 3, 4}, 1)
+		require.NoError(t, err)
+		out, err := s.Broadcast([]byte("commitment"))
+		require.NoError(t, err)
+		require.Len(t, out, 4)
+
+		net.queue = out
+		net.run(t)
+
+		v, ok := net.delivered(t)
+		require.True(t, ok)
+		require.Equal(t, "commitment", string(v))
+	}
+}
+
+func TestSenderAmongParties(t *testing.T) {
+	parties := []int{0, 1, 2, 3}
+	nodes := make(map[int]*Instance)
+	for _, p := range parties {
+		b, err := NewInstance(p, sender, parties, 1)
+		require.NoError(t, err)
+		nodes[p] = b
+	}
+	net := &network{nodes: nodes, rnd: rand.New(rand.NewSource(1))}
+
+	out, err := nodes[sender].Broadcast([]byte("v"))
+	require.NoError(t, err)
+	net.queue = out
+	net.run(t)
+
+	v, ok := net.delivered(t)
+	require.True(t, ok)
+	require.Equal(t, "v", string(v))
+}
+
+func TestEquivocatingSender(t *testing.T) {
+	a, b := []byte("A"), []byte("B")
+
+	for seed := int64(0); seed < 50; seed++ {
+		// Split evenly: neither value gets enough echoes, so nobody delivers
+		net := newNetwork(t, 4, 1, nil, seed)
+		net.queue = []Envelope{send(1, a), send(2, a), send(3, b), send(4, b)}
+		net.run(t)
+		_, ok := net.delivered(t)
+		require.False(t, ok)
+
+		// Three out of four see A, so every honest party delivers A and never B
+		net = newNetwork(t, 4, 1, nil, seed)
+		net.queue = []Envelope{send(1, a), send(2, a), send(3, a), send(4, b)}
+		net.run(t)
+		v, ok := net.delivered(t)
+		require.True(t, ok)
+		require.Equal(t, a, v)
+	}
+}
+
+func TestEquivocatingSenderWithFaultyParties(t *testing.T) {
+	a, b := []byte("A"), []byte("B")
+	n, f := 7, 2
+	faulty := map[int]bool{6: true, 7: true}
+
+	for seed := int64(0); seed < 50; seed++ {
+		net := newNetwork(t, n, f, faulty, seed)
+
+		// The sender splits the honest parties, and the faulty parties echo and ready
+		// A to some of them and B to the others, several times
+		for p := 1; p <= n-f; p++ {
+			v := a
+			if p > 3 {
+				v = b
+			}
+			net.queue = append(net.queue, send(p, v))
+		}
+		for _, bad := range []int{6, 7} {
+			for p := 1; p <= n-f; p++ {
+				for _, v := range [][]byte{a, b} {
+					if p%2 == 0 {
+						v = b
+					}
+					net.queue = append(net.queue,
+						Envelope{From: bad, To: p, Msg: Message{Kind: KindEcho, Value: v}},
+						Envelope{From: bad, To: p, Msg: Message{Kind: KindReady, Value: v}})
+				}
+			}
+		}
+
+		net.run(t)
+		if v, ok := net.delivered(t); ok {
+			require.True(t, bytes.Equal(v, a) || bytes.Equal(v, b))
+		}
+	}
+}
+
+func TestFaultyPartiesCannotForceDelivery(t *testing.T) {
+	n, f := 4, 1
+	net := newNetwork(t, n, f, map[int]bool{4: true}, 0)
+
+	// The faulty party repeats its vote, which only counts once
+	for i := 0; i < 5; i++ {
+		for p := 1; p <= 3; p++ {
+			net.queue = append(net.queue, Envelope{From: 4, To: p, Msg: Message{Kind: KindReady, Value: []byte("X")}})
+		}
+	}
+	net.run(t)
+
+	_, ok := net.delivered(t)
+	require.False(t, ok)
+}
+
+func TestInvalidMessages(t *testing.T) {
+	b, err := NewInstance(1, sender, []int{1, 2, 3, 4}, 1)
+	require.NoError(t, err)
+
+	_, err = b.Handle(2, Message{Kind: KindSend, Value: []byte("v")})
+	require.Error(t, err)
+	_, err = b.Handle(9, Message{Kind: KindEcho, Value: []byte("v")})
+	require.Error(t, err)
+	_, err = b.Handle(sender, Message{Kind: KindReady, Value: []byte("v")})
+	require.Error(t, err)
+	_, err = b.Handle(2, Message{Kind: 9})
+	require.Error(t, err)
+	_, err = b.Broadcast([]byte("v"))
+	require.Error(t, err)
+
+	_, err = NewInstance(1, sender, []int{1, 2, 3}, 1)
+	require.Error(t, err)
+	_, err = NewInstance(1, sender, []int{1, 1, 2, 3}, 0)
+	require.Error(t, err)
+	_, err = NewInstance(5, sender, []int{1, 2, 3, 4}, 1)
+	require.Error(t, err)
+}
+
+func TestResumeAfterCrash(t *testing.T) {
+	a, b := []byte("A"), []byte("B")
+
+	for seed := int64(0); seed < 20; seed++ {
+		net := newNetwork(t, 4, 1, nil, seed)
+
+		// Party 1 echoes A and crashes; its echo went out before the crash
+		out, err := net.nodes[1].Handle(sender, send(1, a).Msg)
+		require.NoError(t, err)
+		require.Len(t, out, 3)
+
+		restarted, err := NewInstance(1, sender, []int{1, 2, 3, 4}, 1)
+		require.NoError(t, err)
+		again, err := restarted.Resume(a, nil)
+		require.NoError(t, err)
+		require.Equal(t, out, again)
+		net.nodes[1] = restarted
+
+		// The equivocating sender tries B on the restarted party, which does not echo twice
+		next, err := restarted.Handle(sender, send(1, b).Msg)
+		require.NoError(t, err)
+		require.Empty(t, next)
+
+		net.queue = append(append(out, again...), send(2, a), send(3, a), send(4, b))
+		net.run(t)
+		v, ok := net.delivered(t)
+		require.True(t, ok)
+		require.Equal(t, a, v)
+
+		// Only a fresh instance resumes
+		_, err = restarted.Resume(a, a)
+		require.Error(t, err)
+	}
+}
@@ -0,0 +1,255 @@
+package ceremony
+
+import (
+	kzg "BingoVSS/Internal/Biv_KZG"
+	"crypto/cipher"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/drand/kyber"
+	"github.com/drand/kyber/pairing"
+)
+
+// PoK is a Schnorr proof of knowledge of the discrete logarithm of a point in G1.
+type PoK struct {
+	k kyber.Point  // commitment k·g1
+	z kyber.Scalar // response k + c·r
+}
+
+// Contribution records what a single participant added to the transcript. The contributor
+// multiplied every power of τ with r^i and the gUp powers additionally with s.
+type Contribution struct {
+	tauG1  kyber.Point // r·g1
+	tauG2  kyber.Point // r·h
+	upG1   kyber.Point // s·g1
+	upG2   kyber.Point // s·h
+	tau_1  kyber.Point // t_1[1] after the contribution
+	up_1   kyber.Point // t_Up[0] after the contribution
+	pokTau PoK
+	pokUp  PoK
+}
+
+// Transcript is the chain of contributions together with the current powers t_1, t_2 and t_Up.
+type Transcript struct {
+	g             pairing.Suite
+	t_1           []kyber.Point
+	t_2           []kyber.Point
+	t_Up          []kyber.Point
+	contributions []Contribution
+}
+
+// NewTranscript creates the starting transcript of length l, that is the powers of τ = 1 with gUp = g1.
+// It carries no secret at all, so the resulting SRS is only trustworthy after at least one honest contribution.
+func NewTranscript(l int, pairing pairing.Suite) (*Transcript, error) {
+
+	if l < 2 {
+		return nil, fmt.Errorf("ceremony: the transcript needs at least 2 powers, got %d", l)
+	}
+
+	t_1 := make([]kyber.Point, l)
+	t_2 := make([]kyber.Point, l)
+	t_Up := make([]kyber.Point, l)
+
+	for i := 0; i < l; i++ {
+		t_1[i] = pairing.G1().Point().Base()
+		t_2[i] = pairing.G2().Point().Base()
+		t_Up[i] = pairing.G1().Point().Base()
+	}
+
+	return &Transcript{g: pairing, t_1: t_1, t_2: t_2, t_Up: t_Up}, nil
+}
+
+// ReturnContributions returns the number of contributions in the transcript.
+func (tr *Transcript) ReturnContributions() int {
+	return len(tr.contributions)
+}
+
+// Setup returns the KZG setup built from the current powers of the transcript.
+func (tr *Transcript) Setup() (*kzg.KzgSetup, error) {
+	return kzg.NewKzgSetupFromPowers(tr.t_1, tr.t_2, tr.t_Up, tr.g)
+}
+
+// Contribute re-randomizes the transcript with fresh secrets r and s taken from rand.
+// Every power t_1[i], t_2[i] is multiplied by r^i and t_Up[i] by s·r^i, after which both secrets are discarded.
+func (tr *Transcript) Contribute(rand cipher.Stream) error {
+
+	r := pickNonZero(tr.g, rand)
+	s := pickNonZero(tr.g, rand)
+	defer r.Zero()
+	defer s.Zero()
+
+	prevTau := tr.t_1[1]
+	prevUp := tr.t_Up[0]
+
+	// Raise every power to the new secret, r_i holds r^i and u_i holds s·r^i
+	r_i := tr.g.G1().Scalar().One()
+	u_i := tr.g.G1().Scalar().Set(s)
+	for i := 0; i < len(tr.t_1); i++ {
+		tr.t_1[i] = tr.g.G1().Point().Mul(r_i, tr.t_1[i])
+		tr.t_2[i] = tr.g.G2().Point().Mul(r_i, tr.t_2[i])
+		tr.t_Up[i] = tr.g.G1().Point().Mul(u_i, tr.t_Up[i])
+
+		r_i = r_i.Mul(r_i, r)
+		u_i = u_i.Mul(u_i, r)
+	}
+	r_i.Zero()
+	u_i.Zero()
+
+	c := Contribution{
+		tauG1: tr.g.G1().Point().Mul(r, nil),
+		tauG2: tr.g.G2().Point().Mul(r, nil),
+		upG1:  tr.g.G1().Point().Mul(s, nil),
+		upG2:  tr.g.G2().Point().Mul(s, nil),
+		tau_1: tr.t_1[1],
+		up_1:  tr.t_Up[0],
+	}
+
+	var err error
+	index := len(tr.contributions)
+	if c.pokTau, err = prove(tr.g, r, c.tauG1, challengeContext(tr.g, index, 0, prevTau), rand); err != nil {
+		return err
+	}
+	if c.pokUp, err = prove(tr.g, s, c.upG1, challengeContext(tr.g, index, 1, prevUp), rand); err != nil {
+		return err
+	}
+
+	tr.contributions = append(tr.contributions, c)
+
+	return nil
+}
+
+// Verify checks the whole chain of contributions with pairings and checks that the final
+// powers have the structure g^τ^i, h^τ^i and gUp^τ^i. It returns nil if the transcript is valid.
+func (tr *Transcript) Verify() error {
+
+	g := tr.g
+	g1 := g.G1().Point().Base()
+	h := g.G2().Point().Base()
+
+	if len(tr.contributions) == 0 {
+		return fmt.Errorf("ceremony: the transcript has no contributions")
+	}
+
+	// First Step: follow the chain, starting from τ = 1 and gUp = g1
+	prevTau := g1
+	prevUp := g1
+	for i, c := range tr.contributions {
+		// A zero secret would make τ = 0 or gUp = 0 public, and every check below would hold trivially
+		for _, p := range []kyber.Point{c.tauG1, c.tauG2, c.upG1, c.upG2, c.tau_1, c.up_1} {
+			if p == nil || isIdentity(p) {
+				return fmt.Errorf("ceremony: contribution %d has a zero secret", i)
+			}
+		}
+
+		if !verifyPoK(g, c.tauG1, c.pokTau, challengeContext(g, i, 0, prevTau)) {
+			return fmt.Errorf("ceremony: contribution %d has an invalid proof of knowledge for τ", i)
+		}
+		if !verifyPoK(g, c.upG1, c.pokUp, challengeContext(g, i, 1, prevUp)) {
+			return fmt.Errorf("ceremony: contribution %d has an invalid proof of knowledge for gUp", i)
+		}
+
+		// The secret in G1 and in G2 must be the same: e(r·g1, h) = e(g1, r·h)
+		if !g.Pair(c.tauG1, h).Equal(g.Pair(g1, c.tauG2)) || !g.Pair(c.upG1, h).Equal(g.Pair(g1, c.upG2)) {
+			return fmt.Errorf("ceremony: contribution %d has inconsistent secrets in G1 and G2", i)
+		}
+
+		// The contribution must build on the previous one: e(τ'·g1, h) = e(τ·g1, r·h)
+		if !g.Pair(c.tau_1, h).Equal(g.Pair(prevTau, c.tauG2)) {
+			return fmt.Errorf("ceremony: contribution %d does not extend the previous τ", i)
+		}
+		if !g.Pair(c.up_1, h).Equal(g.Pair(prevUp, c.upG2)) {
+			return fmt.Errorf("ceremony: contribution %d does not extend the previous gUp", i)
+		}
+
+		prevTau = c.tau_1
+		prevUp = c.up_1
+	}
+
+	// Second Step: the current powers must be the ones produced by the last contribution
+	if !tr.t_1[0].Equal(g1) || !tr.t_2[0].Equal(h) {
+		return fmt.Errorf("ceremony: the powers do not start from the base generators")
+	}
+	if !tr.t_1[1].Equal(prevTau) || !tr.t_Up[0].Equal(prevUp) {
+		return fmt.Errorf("ceremony: the powers do not match the last contribution")
+	}
+
+	return kzg.VerifyPowers(g, tr.t_1, tr.t_2, tr.t_Up)
+}
+
+// pickNonZero picks a random non-zero scalar from rand.
+func pickNonZero(g pairing.Suite, rand cipher.Stream) kyber.Scalar {
+	zero := g.G1().Scalar().Zero()
+	for {
+		r := g.G1().Scalar().Pick(rand)
+		if !r.Equal(zero) {
+			return r
+		}
+	}
+}
+
+// isIdentity reports whether p is the neutral element of its group.
+func isIdentity(p kyber.Point) bool {
+	return p.Equal(p.Clone().Null())
+}
+
+// prove creates a Schnorr proof of knowledge of r such that R = r·g1, bound to the context ctx.
+func prove(g pairing.Suite, r kyber.Scalar, R kyber.Point, ctx []byte, rand cipher.Stream) (PoK, error) {
+	k := g.G1().Scalar().Pick(rand)
+	defer k.Zero()
+
+	K := g.G1().Point().Mul(k, nil)
+	c, err := challenge(g, ctx, R, K)
+	if err != nil {
+		return PoK{}, err
+	}
+
+	z := g.G1().Scalar().Mul(c, r)
+	z = z.Add(z, k)
+
+	return PoK{k: K, z: z}, nil
+}
+
+// verifyPoK checks a Schnorr proof of knowledge, that is z·g1 = K + c·R.
+func verifyPoK(g pairing.Suite, R kyber.Point, p PoK, ctx []byte) bool {
+	if p.k == nil || p.z == nil {
+		return false
+	}
+
+	c, err := challenge(g, ctx, R, p.k)
+	if err != nil {
+		return false
+	}
+
+	left := g.G1().Point().Mul(p.z, nil)
+	right := g.G1().Point().Add(p.k, g.G1().Point().Mul(c, R))
+
+	return left.Equal(right)
+}
+
+// challenge derives the Fiat-Shamir challenge of a proof of knowledge.
+func challenge(g pairing.Suite, ctx []byte, R, K kyber.Point) (kyber.Scalar, error) {
+	h := sha256.New()
+	h.Write(ctx)
+
+	for _, p := range []kyber.Point{R, K} {
+		b, err := p.MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		h.Write(b)
+	}
+
+	return g.G1().Scalar().SetBytes(h.Sum(nil)), nil
+}
+
+// challengeContext binds a proof to its position in the chain and to the previous value it extends,
+// so a proof cannot be replayed in another transcript or for another secret.
+func challengeContext(g pairing.Suite, index, secret int, prev kyber.Point) []byte {
+	ctx := []byte("BingoVSS-ceremony")
+	ctx = binary.BigEndian.AppendUint32(ctx, uint32(index))
+	ctx = append(ctx, byte(secret))
+
+	b, _ := prev.MarshalBinary()
+	return append(ctx, b...)
+}
@@ -0,0 +1,149 @@
+package ceremony
+
+import (
+	kzg "BingoVSS/Internal/Biv_KZG"
+	"testing"
+
+	"github.com/drand/kyber"
+	"github.com/drand/kyber/pairing/bn256"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCeremonyChain(t *testing.T) {
+	pairing := bn256.NewSuite()
+	l := 6
+
+	tr, err := NewTranscript(l, pairing)
+	require.NoError(t, err)
+
+	// The starting transcript has no contributions and must not be accepted
+	require.Error(t, tr.Verify())
+
+	for i := 0; i < 3; i++ {
+		require.NoError(t, tr.Contribute(pairing.RandomStream()))
+		require.NoError(t, tr.Verify())
+	}
+	require.Equal(t, 3, tr.ReturnContributions())
+
+	// The resulting powers are usable as a KZG setup
+	setup, err := tr.Setup()
+	require.NoError(t, err)
+
+	sh_setup := kzg.NewShareSetup(setup.ReturnT_1(), setup.ReturnT_2(), setup.ReturnT_u(), pairing, setup.ReturnG_u(), setup.ReturnG_1())
+
+	f_1 := make([]kyber.Scalar, l)
+	f_2 := make([]kyber.Scalar, l)
+	for i := 0; i < l; i++ {
+		f_1[i] = pairing.G1().Scalar().Pick(pairing.RandomStream())
+		f_2[i] = pairing.G1().Scalar().Pick(pairing.RandomStream())
+	}
+
+	c := kzg.KZGCommits(sh_setup, f_1, f_2)
+	a := pairing.G1().Scalar().SetInt64(3)
+	proof, y_1, y_2, err := kzg.KZGEval(sh_setup, f_1, f_2, a)
+	require.NoError(t, err)
+	require.True(t, kzg.KZGVerify(sh_setup, []kyber.Point{c}, 0, proof, a, y_1, y_2))
+}
+
+func TestCeremonyTampering(t *testing.T) {
+	pairing := bn256.NewSuite()
+	l := 5
+
+	tr, _ := NewTranscript(l, pairing)
+	require.NoError(t, tr.Contribute(pairing.RandomStream()))
+	require.NoError(t, tr.Contribute(pairing.RandomStream()))
+
+	// A power that does not follow the chain
+	saved := tr.t_1[3]
+	tr.t_1[3] = pairing.G1().Point().Pick(pairing.RandomStream())
+	require.Error(t, tr.Verify())
+	tr.t_1[3] = saved
+
+	// A gUp power that does not follow the chain
+	saved = tr.t_Up[2]
+	tr.t_Up[2] = pairing.G1().Point().Pick(pairing.RandomStream())
+	require.Error(t, tr.Verify())
+	tr.t_Up[2] = saved
+
+	// A proof of knowledge that does not belong to the contribution
+	savedPoK := tr.contributions[1].pokTau
+	tr.contributions[1].pokTau = tr.contributions[0].pokTau
+	require.Error(t, tr.Verify())
+	tr.contributions[1].pokTau = savedPoK
+
+	// Removing a contribution breaks the chain
+	first := tr.contributions[0]
+	tr.contributions = tr.contributions[1:]
+	require.Error(t, tr.Verify())
+	tr.contributions = append([]Contribution{first}, tr.contributions...)
+
+	require.NoError(t, tr.Verify())
+}
+
+func TestTranscriptEncoding(t *testing.T) {
+	pairing := bn256.NewSuite()
+
+	tr, _ := NewTranscript(4, pairing)
+	require.NoError(t, tr.Contribute(pairing.RandomStream()))
+	require.NoError(t, tr.Contribute(pairing.RandomStream()))
+
+	data, err := tr.MarshalBinary()
+	require.NoError(t, err)
+
+	decoded, err := UnmarshalTranscript(data, pairing)
+	require.NoError(t, err)
+	require.NoError(t, decoded.Verify())
+	require.Equal(t, 2, decoded.ReturnContributions())
+
+	// Continue the ceremony from the decoded transcript
+	require.NoError(t, decoded.Contribute(pairing.RandomStream()))
+	require.NoError(t, decoded.Verify())
+
+	_, err = UnmarshalTranscript(data[:len(data)-1], pairing)
+	require.Error(t, err)
+
+	_, err = UnmarshalTranscript(append(data, 0), pairing)
+	require.Error(t, err)
+}
+
+func TestCeremonyRejectsZeroSecret(t *testing.T) {
+	pairing := bn256.NewSuite()
+	l := 4
+
+	tr, _ := NewTranscript(l, pairing)
+	require.NoError(t, tr.Contribute(pairing.RandomStream()))
+
+	// A malicious contributor with r = s = 0 sets every power after the first to the identity,
+	// its proofs of knowledge hold trivially since z·g1 = K + c·0
+	zero := pairing.G1().Scalar().Zero()
+	prevTau := tr.t_1[1]
+	prevUp := tr.t_Up[0]
+	for i := 0; i < l; i++ {
+		if i > 0 {
+			tr.t_1[i] = pairing.G1().Point().Null()
+			tr.t_2[i] = pairing.G2().Point().Null()
+		}
+		tr.t_Up[i] = pairing.G1().Point().Null()
+	}
+
+	c := Contribution{
+		tauG1: pairing.G1().Point().Null(),
+		tauG2: pairing.G2().Point().Null(),
+		upG1:  pairing.G1().Point().Null(),
+		upG2:  pairing.G2().Point().Null(),
+		tau_1: tr.t_1[1],
+		up_1:  tr.t_Up[0],
+	}
+	var err error
+	c.pokTau, err = prove(pairing, zero, c.tauG1, challengeContext(pairing, 1, 0, prevTau), pairing.RandomStream())
+	require.NoError(t, err)
+	c.pokUp, err = prove(pairing, zero, c.upG1, challengeContext(pairing, 1, 1, prevUp), pairing.RandomStream())
+	require.NoError(t, err)
+	require.True(t, verifyPoK(pairing, c.tauG1, c.pokTau, challengeContext(pairing, 1, 0, prevTau)))
+
+	tr.contributions = append(tr.contributions, c)
+	require.Error(t, tr.Verify())
+
+	// The powers themselves are rejected as well, so they cannot be loaded as an SRS
+	require.Error(t, kzg.VerifyPowers(pairing, tr.t_1, tr.t_2, tr.t_Up))
+}
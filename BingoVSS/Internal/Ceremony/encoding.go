@@ -0,0 +1,137 @@
+package ceremony
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/drand/kyber"
+	"github.com/drand/kyber/pairing"
+)
+
+// transcriptMagic identifies a ceremony transcript on disk.
+var transcriptMagic = []byte("BCER")
+
+// maxPowers bounds the length read from an untrusted transcript.
+const maxPowers = 1 << 20
+
+// MarshalBinary encodes the transcript as the magic, the number of powers and contributions,
+// followed by the powers t_1, t_2, t_Up and every contribution in order.
+func (tr *Transcript) MarshalBinary() ([]byte, error) {
+	var buf bytes.Buffer
+
+	buf.Write(transcriptMagic)
+	_ = binary.Write(&buf, binary.BigEndian, uint32(len(tr.t_1)))
+	_ = binary.Write(&buf, binary.BigEndian, uint32(len(tr.contributions)))
+
+	points := make([]kyber.Point, 0, 3*len(tr.t_1)+8*len(tr.contributions))
+	points = append(points, tr.t_1...)
+	points = append(points, tr.t_2...)
+	points = append(points, tr.t_Up...)
+
+	for _, p := range points {
+		if _, err := p.MarshalTo(&buf); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, c := range tr.contributions {
+		for _, p := range []kyber.Point{c.tauG1, c.tauG2, c.upG1, c.upG2, c.tau_1, c.up_1, c.pokTau.k, c.pokUp.k} {
+			if _, err := p.MarshalTo(&buf); err != nil {
+				return nil, err
+			}
+		}
+		for _, s := range []kyber.Scalar{c.pokTau.z, c.pokUp.z} {
+			if _, err := s.MarshalTo(&buf); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return buf.Bytes(), nil
+}
+
+// UnmarshalTranscript decodes a transcript produced by MarshalBinary. It does not verify it;
+// call Verify on the result before using the powers.
+func UnmarshalTranscript(data []byte, pairing pairing.Suite) (*Transcript, error) {
+	r := bytes.NewReader(data)
+
+	magic := make([]byte, len(transcriptMagic))
+	if _, err := io.ReadFull(r, magic); err != nil || !bytes.Equal(magic, transcriptMagic) {
+		return nil, errors.New("ceremony: not a transcript")
+	}
+
+	var l, n uint32
+	if err := binary.Read(r, binary.BigEndian, &l); err != nil {
+		return nil, err
+	}
+	if err := binary.Read(r, binary.BigEndian, &n); err != nil {
+		return nil, err
+	}
+	if l < 2 || l > maxPowers || n > maxPowers {
+		return nil, fmt.Errorf("ceremony: invalid transcript sizes %d and %d", l, n)
+	}
+
+	tr := &Transcript{g: pairing}
+	var err error
+	if tr.t_1, err = readPoints(r, pairing.G1(), int(l)); err != nil {
+		return nil, err
+	}
+	if tr.t_2, err = readPoints(r, pairing.G2(), int(l)); err != nil {
+		return nil, err
+	}
+	if tr.t_Up, err = readPoints(r, pairing.G1(), int(l)); err != nil {
+		return nil, err
+	}
+
+	groups := []kyber.Group{pairing.G1(), pairing.G2(), pairing.G1(), pairing.G2(), pairing.G1(), pairing.G1(), pairing.G1(), pairing.G1()}
+	for i := uint32(0); i < n; i++ {
+		p := make([]kyber.Point, len(groups))
+		for j, group := range groups {
+			p[j] = group.Point()
+			if _, err := p[j].UnmarshalFrom(r); err != nil {
+				return nil, err
+			}
+		}
+
+		z_1 := pairing.G1().Scalar()
+		z_2 := pairing.G1().Scalar()
+		if _, err := z_1.UnmarshalFrom(r); err != nil {
+			return nil, err
+		}
+		if _, err := z_2.UnmarshalFrom(r); err != nil {
+			return nil, err
+		}
+
+		tr.contributions = append(tr.contributions, Contribution{
+			tauG1:  p[0],
+			tauG2:  p[1],
+			upG1:   p[2],
+			upG2:   p[3],
+			tau_1:  p[4],
+			up_1:   p[5],
+			pokTau: PoK{k: p[6], z: z_1},
+			pokUp:  PoK{k: p[7], z: z_2},
+		})
+	}
+
+	if r.Len() != 0 {
+		return nil, fmt.Errorf("ceremony: %d trailing bytes in transcript", r.Len())
+	}
+
+	return tr, nil
+}
+
+// readPoints reads l consecutive points of the given group.
+func readPoints(r io.Reader, group kyber.Group, l int) ([]kyber.Point, error) {
+	points := make([]kyber.Point, l)
+	for i := 0; i < l; i++ {
+		points[i] = group.Point()
+		if _, err := points[i].UnmarshalFrom(r); err != nil {
+			return nil, err
+		}
+	}
+	return points, nil
+}
@@ -4,7 +4,7 @@ import (
 	"math"
 
 	"github.com/drand/kyber"
-	"github.com/drand/kyber/pairing/bn256"
+	"github.com/drand/kyber/pairing"
 
 	"gonum.org/v1/gonum/mat"
 )
@@ -37,9 +37,7 @@ func createVandermonde(xValues []float64, d_1 int) *mat.Dense {
 
 // vandermonde constructs a Vandermonde matrix for a given vector of kyber.Scalar values x and a specified degree.
 // The resulting matrix will have a size of len(x) x (degree+1).
-func vandermonde(x []kyber.Scalar, degree int) [][]kyber.Scalar {
-	// Initializing the bn256 pairing suite.
-	suite := bn256.NewSuite()
+func vandermonde(suite pairing.Suite, x []kyber.Scalar, degree int) [][]kyber.Scalar {
 	rows := len(x)
 	cols := degree + 1
 
@@ -65,8 +63,7 @@ func vandermonde(x []kyber.Scalar, degree int) [][]kyber.Scalar {
 
 // solveLinearSystem solves a system of linear equations represented by the Vandermonde matrix vMatrix and result vector y.
 // It returns the vector of coefficients c that satisfy the equation V*c = y.
-func solveLinearSystem(vMatrix [][]kyber.Scalar, y []kyber.Scalar) []kyber.Scalar {
-	suite := bn256.NewSuite()
+func solveLinearSystem(suite pairing.Suite, vMatrix [][]kyber.Scalar, y []kyber.Scalar) []kyber.Scalar {
 	rows := len(vMatrix)
 	cols := len(vMatrix[0])
 
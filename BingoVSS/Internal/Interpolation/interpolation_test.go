@@ -106,10 +106,10 @@ func TestReconstructionRandom(t *testing.T) {
 	degree := len(x) - 1
 
 	// Generate Vandermonde matrix
-	vMatrix := vandermonde(x, degree)
+	vMatrix := vandermonde(pairing, x, degree)
 
 	// Solve the system of linear equations
-	coeffs := solveLinearSystem(vMatrix, y)
+	coeffs := solveLinearSystem(pairing, vMatrix, y)
 
 	f_1 = adjustBivariateCoefficients(f_1, d_2+1, coeffs, pairing)
 
@@ -145,10 +145,10 @@ func TestReconstructionRandom(t *testing.T) {
 	degree = len(x) - 1
 
 	// Generate Vandermonde matrix
-	vMatrix = vandermonde(x, degree)
+	vMatrix = vandermonde(pairing, x, degree)
 
 	// Solve the system of linear equations
-	coeffs = solveLinearSystem(vMatrix, y)
+	coeffs = solveLinearSystem(pairing, vMatrix, y)
 
 	xy := pairing.G1().Scalar().SetInt64(0)
 
@@ -216,10 +216,10 @@ func TestReconstruction(t *testing.T) {
 	degree := len(x) - 1
 
 	// Generate Vandermonde matrix
-	vMatrix := vandermonde(x, degree)
+	vMatrix := vandermonde(pairing, x, degree)
 
 	// Solve the system of linear equations
-	coeffs := solveLinearSystem(vMatrix, y)
+	coeffs := solveLinearSystem(pairing, vMatrix, y)
 
 	f_1 = adjustBivariateCoefficients(f_1, d_2+1, coeffs, pairing)
 
@@ -255,10 +255,10 @@ func TestReconstruction(t *testing.T) {
 	degree = len(x) - 1
 
 	// Generate Vandermonde matrix
-	vMatrix = vandermonde(x, degree)
+	vMatrix = vandermonde(pairing, x, degree)
 
 	// Solve the system of linear equations
-	coeffs = solveLinearSystem(vMatrix, y)
+	coeffs = solveLinearSystem(pairing, vMatrix, y)
 
 	xy := pairing.G1().Scalar().SetInt64(0)
 
@@ -290,10 +290,10 @@ func TestVandermondeScalar(t *testing.T) {
 	degree := len(x) - 1
 
 	// Generate Vandermonde matrix
-	vMatrix := vandermonde(x, degree)
+	vMatrix := vandermonde(suite, x, degree)
 
 	// Solve the system of linear equations
-	coeffs := solveLinearSystem(vMatrix, y)
+	coeffs := solveLinearSystem(suite, vMatrix, y)
 
 	xy := suite.G1().Scalar().SetInt64(5)
 	evaluatePolynomial(coeffs, xy, suite)
@@ -325,10 +325,10 @@ func TestVandermondeScalarRandom(t *testing.T) {
 	degree := len(x) - 1
 
 	// Generate Vandermonde matrix
-	vMatrix := vandermonde(x, degree)
+	vMatrix := vandermonde(suite, x, degree)
 
 	// Solve the system of linear equations
-	coeffs := solveLinearSystem(vMatrix, y)
+	coeffs := solveLinearSystem(suite, vMatrix, y)
 
 	d_1 := 4
 	d_2 := 2
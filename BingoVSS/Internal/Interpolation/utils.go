@@ -5,7 +5,7 @@ import (
 	"math/big"
 
 	"github.com/drand/kyber"
-	"github.com/drand/kyber/pairing/bn256"
+	"github.com/drand/kyber/pairing"
 )
 
 // interpolatePolynomial takes in two 2D slices (f_1 and a_f1_x) and their dimensions (d_1 and d_2).
@@ -49,7 +49,7 @@ func convert_to_float(s_0 kyber.Scalar) float64 {
 // adjustBivariateCoefficients updates the first column of a bivariate polynomial (that means
 // the coefficients of the fist polynomial φ(Χ,0) with the given coefficients
 // occured through interpolation
-func adjustBivariateCoefficients(f_1 [][]kyber.Scalar, d_2 int, co []kyber.Scalar, group *bn256.Suite) [][]kyber.Scalar {
+func adjustBivariateCoefficients(f_1 [][]kyber.Scalar, d_2 int, co []kyber.Scalar, group pairing.Suite) [][]kyber.Scalar {
 
 	for i := 0; i < len(co); i++ {
 		f_1[i][0] = co[i]
@@ -61,9 +61,9 @@ func adjustBivariateCoefficients(f_1 [][]kyber.Scalar, d_2 int, co []kyber.Scala
 // EvaluatePolynomial evaluates a polynomial p at a given value x.
 // It takes a slice of kyber.Scalar values representing the coefficients of the polynomial p,
 // a kyber.Scalar value x representing the point at which to evaluate the polynomial,
-// and a bn256.Suite object suite for performing arithmetic operations.
+// and a pairing.Suite object suite for performing arithmetic operations.
 // It returns the result of the evaluation as a kyber.Scalar value.
-func evaluatePolynomial(p []kyber.Scalar, x kyber.Scalar, suite *bn256.Suite) kyber.Scalar {
+func evaluatePolynomial(p []kyber.Scalar, x kyber.Scalar, suite pairing.Suite) kyber.Scalar {
 	// Initialize the result r to zero
 	r := suite.G1().Scalar().Zero()
 
@@ -102,7 +102,7 @@ func (s ScalarSlice) Less(i, j int) bool {
 	return bytes.Compare(bytes1, bytes2) < 0
 }
 
-func createProjectionPolynomials(g *bn256.Suite, f_x [][]kyber.Scalar, d_1 int, d_2 int, n int) [][]kyber.Scalar {
+func createProjectionPolynomials(g pairing.Suite, f_x [][]kyber.Scalar, d_1 int, d_2 int, n int) [][]kyber.Scalar {
 
 	// Create a 2D array to store the univariate polynomials
 	uni_f := make([][]kyber.Scalar, n)
@@ -136,7 +136,7 @@ func createProjectionPolynomials(g *bn256.Suite, f_x [][]kyber.Scalar, d_1 int,
 
 // Pow computes the power of a given base raised to a specified exponent within a specific group.
 // exp is the exponent, base is the base value, and group represents the eliptic curve working with.
-func Pow(exp int, base kyber.Scalar, e kyber.Scalar, group *bn256.Suite) kyber.Scalar {
+func Pow(exp int, base kyber.Scalar, e kyber.Scalar, group pairing.Suite) kyber.Scalar {
 	// Initialize a variable power with the value of 1 in the group
 	power := group.G1().Scalar().One()
 
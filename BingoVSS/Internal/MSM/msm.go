@@ -0,0 +1,167 @@
+package msm
+
+import (
+	"math/bits"
+	"runtime"
+	"sync"
+
+	"github.com/drand/kyber"
+)
+
+const (
+	// naiveThreshold is the number of terms below which the plain sum of products is faster
+	naiveThreshold = 8
+	// parallelThreshold is the number of terms from which MultiExp spreads the windows over all CPUs
+	parallelThreshold = 256
+)
+
+/*
+This function computes Σ_i s_i·P_i for the first len(scalars) points with Pippenger's bucket
+method. Large inputs are processed on all CPUs.
+*/
+func MultiExp(g kyber.Group, points []kyber.Point, scalars []kyber.Scalar) kyber.Point {
+	workers := 1
+	if len(scalars) >= parallelThreshold {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	return MultiExpParallel(g, points, scalars, workers)
+}
+
+/*
+This function computes Σ_i s_i·P_i like MultiExp with the windows of the scalars split over the
+given number of goroutines. One worker or less runs sequentially.
+*/
+func MultiExpParallel(g kyber.Group, points []kyber.Point, scalars []kyber.Scalar, workers int) kyber.Point {
+	n := len(scalars)
+	if n < naiveThreshold {
+		return naive(g, points, scalars)
+	}
+
+	little := littleEndian(g)
+	digits := make([][]byte, n)
+	for i, s := range scalars {
+		digits[i] = bigEndian(s, little)
+	}
+
+	c := window(n)
+	windows := (8*g.ScalarLen() + c - 1) / c
+	sums := make([]kyber.Point, windows)
+
+	if workers <= 1 {
+		for w := range sums {
+			sums[w] = windowSum(g, points[:n], digits, w*c, c)
+		}
+	} else {
+		next := make(chan int, windows)
+		for w := range sums {
+			next <- w
+		}
+		close(next)
+
+		var wg sync.WaitGroup
+		for k := 0; k < workers && k < windows; k++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for w := range next {
+					sums[w] = windowSum(g, points[:n], digits, w*c, c)
+				}
+			}()
+		}
+		wg.Wait()
+	}
+
+	// Horner over the windows, most significant first
+	acc := g.Point().Null()
+	for w := windows - 1; w >= 0; w-- {
+		for k := 0; k < c; k++ {
+			acc = g.Point().Add(acc, acc)
+		}
+		acc = acc.Add(acc, sums[w])
+	}
+	return acc
+}
+
+// windowSum returns Σ_i d_i·P_i where d_i are the c bits of the i-th scalar starting at bit.
+func windowSum(g kyber.Group, points []kyber.Point, digits [][]byte, bit, c int) kyber.Point {
+	// bucket d-1 collects the points whose digit is d
+	buckets := make([]kyber.Point, 1<<c-1)
+	for i, b := range digits {
+		d := digit(b, bit, c)
+		if d == 0 {
+			continue
+		}
+		if buckets[d-1] == nil {
+			buckets[d-1] = points[i].Clone()
+		} else {
+			buckets[d-1] = buckets[d-1].Add(buckets[d-1], points[i])
+		}
+	}
+
+	// Σ_d d·B_d as a sum of running sums: B_max is added max times, B_1 once
+	running := g.Point().Null()
+	sum := g.Point().Null()
+	for d := len(buckets) - 1; d >= 0; d-- {
+		if buckets[d] != nil {
+			running = running.Add(running, buckets[d])
+		}
+		sum = sum.Add(sum, running)
+	}
+	return sum
+}
+
+// digit reads c bits of the big-endian integer b, starting at bit counted from the least
+// significant one.
+func digit(b []byte, bit, c int) int {
+	d := 0
+	for j := 0; j < c; j++ {
+		k := bit + j
+		if k >= 8*len(b) {
+			break
+		}
+		if b[len(b)-1-k/8]>>(k%8)&1 == 1 {
+			d |= 1 << j
+		}
+	}
+	return d
+}
+
+// window picks the number of bits per window, about log2 of the number of terms.
+func window(n int) int {
+	c := bits.Len(uint(n)) - 2
+	if c < 2 {
+		return 2
+	}
+	if c > 16 {
+		return 16
+	}
+	return c
+}
+
+func naive(g kyber.Group, points []kyber.Point, scalars []kyber.Scalar) kyber.Point {
+	acc := g.Point().Null()
+	for i, s := range scalars {
+		acc = acc.Add(acc, g.Point().Mul(s, points[i]))
+	}
+	return acc
+}
+
+// littleEndian tells whether the group marshals its scalars least significant byte first.
+func littleEndian(g kyber.Group) bool {
+	one, err := g.Scalar().One().MarshalBinary()
+	return err == nil && len(one) > 1 && one[0] == 1
+}
+
+// bigEndian returns the scalar as a big-endian integer.
+func bigEndian(s kyber.Scalar, little bool) []byte {
+	b, err := s.MarshalBinary()
+	if err != nil {
+		panic(err)
+	}
+	if little {
+		for i, j := 0, len(b)-1; i < j; i, j = i+1, j-1 {
+			b[i], b[j] = b[j], b[i]
+		}
+	}
+	return b
+}
@@ -0,0 +1,80 @@
+package msm
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/drand/kyber"
+	bls "github.com/drand/kyber-bls12381"
+	"github.com/drand/kyber/pairing"
+	"github.com/drand/kyber/pairing/bn256"
+	"github.com/stretchr/testify/require"
+)
+
+func randomInput(g pairing.Suite, n int) ([]kyber.Point, []kyber.Scalar) {
+	points := make([]kyber.Point, n)
+	scalars := make([]kyber.Scalar, n)
+	for i := range points {
+		points[i] = g.G1().Point().Pick(g.RandomStream())
+		scalars[i] = g.G1().Scalar().Pick(g.RandomStream())
+	}
+	return points, scalars
+}
+
+func TestMultiExp(t *testing.T) {
+	suites := map[string]pairing.Suite{"bn256": bn256.NewSuite(), "bls12381": bls.NewBLS12381Suite()}
+	for name, g := range suites {
+		t.Run(name, func(t *testing.T) {
+			for _, n := range []int{0, 1, 7, 8, 33, 300} {
+				points, scalars := randomInput(g, n)
+
+				// scalars with few bits set hit the empty buckets
+				if n > 3 {
+					scalars[0] = g.G1().Scalar().Zero()
+					scalars[1] = g.G1().Scalar().One()
+					scalars[2] = g.G1().Scalar().Neg(g.G1().Scalar().One())
+					points[3] = points[2].Clone()
+				}
+
+				want := naive(g.G1(), points, scalars)
+				require.True(t, MultiExp(g.G1(), points, scalars).Equal(want), "n = %d", n)
+				require.True(t, MultiExpParallel(g.G1(), points, scalars, 4).Equal(want), "n = %d", n)
+			}
+		})
+	}
+}
+
+func TestMultiExpUsesFirstPoints(t *testing.T) {
+	g := bn256.NewSuite()
+	points, scalars := randomInput(g, 20)
+	require.True(t, MultiExp(g.G1(), points, scalars[:10]).Equal(naive(g.G1(), points[:10], scalars[:10])))
+}
+
+func TestDigit(t *testing.T) {
+	b := []byte{0b1011_0110, 0b0101_1100}
+	require.Equal(t, 0b1100, digit(b, 0, 4))
+	require.Equal(t, 0b0110_0101, digit(b, 4, 8))
+	require.Equal(t, 0b10, digit(b, 14, 4))
+}
+
+func BenchmarkCommit(b *testing.B) {
+	g := bn256.NewSuite()
+	for _, n := range []int{16, 64, 256} {
+		points, scalars := randomInput(g, n)
+		b.Run(fmt.Sprintf("naive/n=%d", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				naive(g.G1(), points, scalars)
+			}
+		})
+		b.Run(fmt.Sprintf("pippenger/n=%d", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				MultiExpParallel(g.G1(), points, scalars, 1)
+			}
+		})
+		b.Run(fmt.Sprintf("parallel/n=%d", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				MultiExpParallel(g.G1(), points, scalars, 4)
+			}
+		})
+	}
+}
@@ -0,0 +1,158 @@
+package polynomial
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/drand/kyber"
+	"github.com/drand/kyber/pairing"
+)
+
+/*
+Decode returns the polynomial p with k coefficients that takes the values y at all but at most
+(len(x)-k)/2 of the distinct points x, and the positions where it does not. This is the unique
+decoding of a Reed–Solomon code with the Berlekamp–Welch algorithm: it solves
+
+	Q(x_i) = y_i · E(x_i)
+
+for an error locator E, monic of degree e = (n-k)/2, and Q of degree less than e+k, then
+divides p = Q / E. It returns an error when more than e values are wrong.
+*/
+func Decode(suite pairing.Suite, x, y []kyber.Scalar, k int) ([]kyber.Scalar, []int, error) {
+	n := len(x)
+	if len(y) != n {
+		return nil, nil, fmt.Errorf("polynomial: %d values for %d points", len(y), n)
+	}
+	if k < 1 || n < k {
+		return nil, nil, fmt.Errorf("polynomial: %d points cannot determine %d coefficients", n, k)
+	}
+	if _, err := NewLagrange(suite, x); err != nil {
+		return nil, nil, err
+	}
+	g := suite.G1()
+	e := (n - k) / 2
+
+	// Unknowns: q_0..q_{e+k-1}, then E_0..E_{e-1}. Row i reads
+	// Σ_j q_j x_i^j - y_i Σ_j E_j x_i^j = y_i x_i^e.
+	cols := 2*e + k
+	m := make([][]kyber.Scalar, n)
+	for i := range m {
+		m[i] = make([]kyber.Scalar, cols+1)
+		pow := g.Scalar().One()
+		for j := 0; j < e+k; j++ {
+			m[i][j] = g.Scalar().Set(pow)
+			if j < e {
+				m[i][e+k+j] = g.Scalar().Neg(g.Scalar().Mul(y[i], pow))
+			}
+			if j == e {
+				m[i][cols] = g.Scalar().Mul(y[i], pow)
+			}
+			pow.Mul(pow, x[i])
+		}
+	}
+	sol, ok := solve(suite, m, cols)
+	if !ok {
+		return nil, nil, errors.New("polynomial: too many errors to decode")
+	}
+
+	q := sol[:e+k]
+	E := make([]kyber.Scalar, e+1)
+	copy(E, sol[e+k:])
+	E[e] = g.Scalar().One()
+
+	p, r := Div(suite, q, E)
+	for _, c := range r {
+		if !c.Equal(g.Scalar().Zero()) {
+			return nil, nil, errors.New("polynomial: too many errors to decode")
+		}
+	}
+
+	var errs []int
+	for i := range x {
+		if !Evaluate(suite, p, x[i]).Equal(y[i]) {
+			errs = append(errs, i)
+		}
+	}
+	if len(errs) > e {
+		return nil, nil, errors.New("polynomial: too many errors to decode")
+	}
+	return p, errs, nil
+}
+
+// solve returns a solution of the linear system with augmented matrix m and cols unknowns, with
+// the free unknowns set to zero, or false when the system has none. m is overwritten.
+func solve(suite pairing.Suite, m [][]kyber.Scalar, cols int) ([]kyber.Scalar, bool) {
+	g := suite.G1()
+	zero := g.Scalar().Zero()
+	t := g.Scalar()
+
+	var pivots []int
+	row := 0
+	for c := 0; c < cols && row < len(m); c++ {
+		p := -1
+		for i := row; i < len(m); i++ {
+			if !m[i][c].Equal(zero) {
+				p = i
+				break
+			}
+		}
+		if p < 0 {
+			continue
+		}
+		m[row], m[p] = m[p], m[row]
+
+		inv := g.Scalar().Inv(m[row][c])
+		for j := c; j <= cols; j++ {
+			m[row][j].Mul(m[row][j], inv)
+		}
+		for i := range m {
+			if i == row || m[i][c].Equal(zero) {
+				continue
+			}
+			factor := g.Scalar().Set(m[i][c])
+			for j := c; j <= cols; j++ {
+				m[i][j].Sub(m[i][j], t.Mul(factor, m[row][j]))
+			}
+		}
+		pivots = append(pivots, c)
+		row++
+	}
+	for i := row; i < len(m); i++ {
+		if !m[i][cols].Equal(zero) {
+			return nil, false
+		}
+	}
+
+	sol := make([]kyber.Scalar, cols)
+	for j := range sol {
+		sol[j] = g.Scalar().Zero()
+	}
+	for i, c := range pivots {
+		sol[c].Set(m[i][cols])
+	}
+	return sol, true
+}
+
+// Div returns the quotient and the remainder of a divided by b. The leading coefficient of b must
+// not be zero.
+func Div(suite pairing.Suite, a, b []kyber.Scalar) ([]kyber.Scalar, []kyber.Scalar) {
+	g := suite.G1()
+	r := make([]kyber.Scalar, len(a))
+	for i := range a {
+		r[i] = g.Scalar().Set(a[i])
+	}
+	if len(a) < len(b) {
+		return nil, r
+	}
+
+	q := make([]kyber.Scalar, len(a)-len(b)+1)
+	lead := g.Scalar().Inv(b[len(b)-1])
+	t := g.Scalar()
+	for i := len(q) - 1; i >= 0; i-- {
+		q[i] = g.Scalar().Mul(r[i+len(b)-1], lead)
+		for j := range b {
+			r[i+j].Sub(r[i+j], t.Mul(q[i], b[j]))
+		}
+	}
+	return q, r[:len(b)-1]
+}
@@ -0,0 +1,212 @@
+package polynomial
+
+import (
+	"fmt"
+
+	"github.com/drand/kyber"
+	"github.com/drand/kyber/pairing"
+)
+
+// Domain is the multiplicative subgroup {1, ω, ω², ..., ω^(n-1)} of the n-th roots of unity,
+// for n a power of two. Evaluation and interpolation on it take O(n log n) with the FFT.
+type Domain struct {
+	g       kyber.Group
+	size    int
+	omega   kyber.Scalar
+	sizeInv kyber.Scalar
+	powers  []kyber.Scalar // ω^0 .. ω^(n/2), the twiddle factors
+}
+
+/* This function constructs the smallest domain with at least n elements */
+func NewDomain(suite pairing.Suite, n int) (*Domain, error) {
+	f, err := FieldOf(suite)
+	if err != nil {
+		return nil, err
+	}
+	if n < 1 {
+		return nil, fmt.Errorf("polynomial: invalid domain size %d", n)
+	}
+	size := 1
+	for size < n {
+		size <<= 1
+	}
+
+	omega, err := f.RootOfUnity(size)
+	if err != nil {
+		return nil, err
+	}
+
+	g := suite.G1()
+	d := &Domain{
+		g:       g,
+		size:    size,
+		omega:   omega,
+		sizeInv: g.Scalar().Inv(g.Scalar().SetInt64(int64(size))),
+		powers:  make([]kyber.Scalar, size/2+1),
+	}
+	d.powers[0] = g.Scalar().One()
+	for i := 1; i < len(d.powers); i++ {
+		d.powers[i] = g.Scalar().Mul(d.powers[i-1], omega)
+	}
+
+	return d, nil
+}
+
+func (d *Domain) Size() int {
+	return d.size
+}
+
+// Element returns ω^i.
+func (d *Domain) Element(i int) kyber.Scalar {
+	i %= d.size
+	if i < 0 {
+		i += d.size
+	}
+	if i < len(d.powers) {
+		return d.g.Scalar().Set(d.powers[i])
+	}
+	// ω^(n/2) = -1
+	return d.g.Scalar().Neg(d.powers[i-d.size/2])
+}
+
+// FFT evaluates the polynomial with the given coefficients, of degree less than the size of
+// the domain, at every element of the domain.
+func (d *Domain) FFT(coeffs []kyber.Scalar) ([]kyber.Scalar, error) {
+	if len(coeffs) > d.size {
+		return nil, fmt.Errorf("polynomial: %d coefficients do not fit a domain of size %d", len(coeffs), d.size)
+	}
+	a := d.padded(coeffs)
+	d.transform(a, false)
+	return a, nil
+}
+
+// IFFT returns the coefficients of the polynomial of degree less than the size of the domain
+// that takes the given values at the elements of the domain.
+func (d *Domain) IFFT(evals []kyber.Scalar) ([]kyber.Scalar, error) {
+	if len(evals) != d.size {
+		return nil, fmt.Errorf("polynomial: %d evaluations for a domain of size %d", len(evals), d.size)
+	}
+	a := d.padded(evals)
+	d.transform(a, true)
+	for i := range a {
+		a[i].Mul(a[i], d.sizeInv)
+	}
+	return a, nil
+}
+
+func (d *Domain) padded(v []kyber.Scalar) []kyber.Scalar {
+	a := make([]kyber.Scalar, d.size)
+	for i := range a {
+		if i < len(v) {
+			a[i] = d.g.Scalar().Set(v[i])
+		} else {
+			a[i] = d.g.Scalar().Zero()
+		}
+	}
+	return a
+}
+
+// transform is the iterative radix-2 Cooley-Tukey FFT, in place.
+func (d *Domain) transform(a []kyber.Scalar, inverse bool) {
+	n := len(a)
+
+	for i, j := 1, 0; i < n; i++ {
+		bit := n >> 1
+		for ; j&bit != 0; bit >>= 1 {
+			j ^= bit
+		}
+		j ^= bit
+		if i < j {
+			a[i], a[j] = a[j], a[i]
+		}
+	}
+
+	t := d.g.Scalar()
+	for length := 2; length <= n; length <<= 1 {
+		step := n / length
+		for start := 0; start < n; start += length {
+			for k := 0; k < length/2; k++ {
+				w := d.powers[k*step]
+				if inverse && k != 0 {
+					// ω^(-j) = -ω^(n/2-j)
+					w = d.g.Scalar().Neg(d.powers[n/2-k*step])
+				}
+				u := a[start+k]
+				t.Mul(a[start+k+length/2], w)
+				a[start+k+length/2] = d.g.Scalar().Sub(u, t)
+				a[start+k] = d.g.Scalar().Add(u, t)
+			}
+		}
+	}
+}
+
+// FFTPoints is FFT for a polynomial with coefficients in a group, as KZG uses for the proofs of
+// all elements of a domain at once.
+func (d *Domain) FFTPoints(g kyber.Group, coeffs []kyber.Point) ([]kyber.Point, error) {
+	if len(coeffs) > d.size {
+		return nil, fmt.Errorf("polynomial: %d coefficients do not fit a domain of size %d", len(coeffs), d.size)
+	}
+	a := d.paddedPoints(g, coeffs)
+	d.transformPoints(g, a, false)
+	return a, nil
+}
+
+// IFFTPoints is IFFT for evaluations in a group.
+func (d *Domain) IFFTPoints(g kyber.Group, evals []kyber.Point) ([]kyber.Point, error) {
+	if len(evals) != d.size {
+		return nil, fmt.Errorf("polynomial: %d evaluations for a domain of size %d", len(evals), d.size)
+	}
+	a := d.paddedPoints(g, evals)
+	d.transformPoints(g, a, true)
+	for i := range a {
+		a[i] = g.Point().Mul(d.sizeInv, a[i])
+	}
+	return a, nil
+}
+
+func (d *Domain) paddedPoints(g kyber.Group, v []kyber.Point) []kyber.Point {
+	a := make([]kyber.Point, d.size)
+	for i := range a {
+		if i < len(v) {
+			a[i] = v[i].Clone()
+		} else {
+			a[i] = g.Point().Null()
+		}
+	}
+	return a
+}
+
+// transformPoints is transform with group elements in place of scalars.
+func (d *Domain) transformPoints(g kyber.Group, a []kyber.Point, inverse bool) {
+	n := len(a)
+
+	for i, j := 1, 0; i < n; i++ {
+		bit := n >> 1
+		for ; j&bit != 0; bit >>= 1 {
+			j ^= bit
+		}
+		j ^= bit
+		if i < j {
+			a[i], a[j] = a[j], a[i]
+		}
+	}
+
+	for length := 2; length <= n; length <<= 1 {
+		step := n / length
+		for start := 0; start < n; start += length {
+			for k := 0; k < length/2; k++ {
+				w := d.powers[k*step]
+				if inverse && k != 0 {
+					w = d.g.Scalar().Neg(d.powers[n/2-k*step])
+				}
+				u := a[start+k]
+				t := a[start+k+length/2]
+				if k != 0 {
+					t = g.Point().Mul(w, t)
+				}
+				a[start+k+length/2] = g.Point().Sub(u, t)
+				a[start+k] = g.Point().Add(u, t)
+			}
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package polynomial
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/drand/kyber"
+	"github.com/drand/kyber/pairing"
+)
+
+// Field describes the scalar field of a pairing suite: its modulus r and the largest
+// power-of-two subgroup of its multiplicative group, where the roots of unity live.
+type Field struct {
+	g            kyber.Group
+	modulus      *big.Int
+	littleEndian bool
+	twoAdicity   int          // the largest s with 2^s | r-1
+	root         kyber.Scalar // a primitive 2^s-th root of unity
+}
+
+var fields sync.Map // name of G1 -> *Field
+
+/* This function returns the scalar field of the suite, computing its roots of unity on first use */
+func FieldOf(suite pairing.Suite) (*Field, error) {
+	name := suite.G1().String()
+	if f, ok := fields.Load(name); ok {
+		return f.(*Field), nil
+	}
+
+	f, err := newField(suite.G1())
+	if err != nil {
+		return nil, err
+	}
+	fields.Store(name, f)
+	return f, nil
+}
+
+func newField(g kyber.Group) (*Field, error) {
+	one, err := g.Scalar().One().MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	minusOne, err := g.Scalar().Neg(g.Scalar().One()).MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+
+	f := &Field{g: g, littleEndian: one[0] == 1 && len(one) > 1}
+	if f.littleEndian {
+		minusOne = reversed(minusOne)
+	}
+	f.modulus = new(big.Int).Add(new(big.Int).SetBytes(minusOne), big.NewInt(1))
+
+	rMinusOne := new(big.Int).Sub(f.modulus, big.NewInt(1))
+	for rMinusOne.Bit(f.twoAdicity) == 0 {
+		f.twoAdicity++
+	}
+	if f.twoAdicity == 0 {
+		return nil, errors.New("polynomial: the scalar field has no roots of unity")
+	}
+
+	// c^((r-1)/2^s) has order 2^s exactly when it is not a square root of one
+	exp := new(big.Int).Rsh(rMinusOne, uint(f.twoAdicity))
+	half := new(big.Int).Lsh(big.NewInt(1), uint(f.twoAdicity-1))
+	for c := int64(2); c < 1000; c++ {
+		w := new(big.Int).Exp(big.NewInt(c), exp, f.modulus)
+		if new(big.Int).Exp(w, half, f.modulus).Cmp(rMinusOne) == 0 {
+			f.root = f.scalar(w)
+			return f, nil
+		}
+	}
+	return nil, errors.New("polynomial: no primitive root of unity found")
+}
+
+// Modulus returns the order r of the scalar field.
+func (f *Field) Modulus() *big.Int {
+	return new(big.Int).Set(f.modulus)
+}
+
+// MaxDomain returns the size of the largest power-of-two domain of the field.
+func (f *Field) MaxDomain() int {
+	if f.twoAdicity >= 30 {
+		return 1 << 30
+	}
+	return 1 << f.twoAdicity
+}
+
+// RootOfUnity returns a primitive n-th root of unity, for n a power of two up to MaxDomain.
+func (f *Field) RootOfUnity(n int) (kyber.Scalar, error) {
+	if n <= 0 || n&(n-1) != 0 || n > f.MaxDomain() {
+		return nil, fmt.Errorf("polynomial: no domain of size %d, the field supports powers of two up to %d", n, f.MaxDomain())
+	}
+
+	w := f.g.Scalar().Set(f.root)
+	for size := 1 << f.twoAdicity; size > n; size >>= 1 {
+		w.Mul(w, w)
+	}
+	return w, nil
+}
+
+// SetInt returns the scalar with value v mod r.
+func (f *Field) SetInt(v *big.Int) kyber.Scalar {
+	return f.scalar(new(big.Int).Mod(v, f.modulus))
+}
+
+// Int returns the value of s as an integer in [0, r).
+func (f *Field) Int(s kyber.Scalar) *big.Int {
+	buf, err := s.MarshalBinary()
+	if err != nil {
+		panic(err)
+	}
+	if f.littleEndian {
+		buf = reversed(buf)
+	}
+	return new(big.Int).SetBytes(buf)
+}
+
+// scalar converts an integer in [0, r) into a scalar of the field.
+func (f *Field) scalar(v *big.Int) kyber.Scalar {
+	buf := v.FillBytes(make([]byte, f.g.ScalarLen()))
+	if f.littleEndian {
+		buf = reversed(buf)
+	}
+	s := f.g.Scalar()
+	if err := s.UnmarshalBinary(buf); err != nil {
+		panic(err)
+	}
+	return s
+}
+
+func reversed(b []byte) []byte {
+	out := make([]byte, len(b))
+	for i := range b {
+		out[len(b)-1-i] = b[i]
+	}
+	return out
+}
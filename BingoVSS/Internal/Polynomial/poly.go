@@ -0,0 +1,234 @@
+package polynomial
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/drand/kyber"
+	"github.com/drand/kyber/pairing"
+)
+
+// Polynomials are slices of coefficients, lowest degree first.
+
+// fftThreshold is the length from which Mul switches from the schoolbook method to the FFT.
+const fftThreshold = 64
+
+// Evaluate returns p(x) with Horner's rule.
+func Evaluate(suite pairing.Suite, p []kyber.Scalar, x kyber.Scalar) kyber.Scalar {
+	g := suite.G1()
+	r := g.Scalar().Zero()
+	for i := len(p) - 1; i >= 0; i-- {
+		r.Mul(r, x)
+		r.Add(r, p[i])
+	}
+	return r
+}
+
+// EvaluateMany returns p at every point of xs.
+func EvaluateMany(suite pairing.Suite, p []kyber.Scalar, xs []kyber.Scalar) []kyber.Scalar {
+	out := make([]kyber.Scalar, len(xs))
+	for i, x := range xs {
+		out[i] = Evaluate(suite, p, x)
+	}
+	return out
+}
+
+// Mul returns the product of a and b. Long operands are multiplied with the FFT when the field
+// has a large enough domain.
+func Mul(suite pairing.Suite, a, b []kyber.Scalar) []kyber.Scalar {
+	if len(a) == 0 || len(b) == 0 {
+		return nil
+	}
+	n := len(a) + len(b) - 1
+
+	if len(a) >= fftThreshold && len(b) >= fftThreshold {
+		if d, err := NewDomain(suite, n); err == nil {
+			fa, _ := d.FFT(a)
+			fb, _ := d.FFT(b)
+			for i := range fa {
+				fa[i].Mul(fa[i], fb[i])
+			}
+			c, _ := d.IFFT(fa)
+			return c[:n]
+		}
+	}
+
+	g := suite.G1()
+	c := make([]kyber.Scalar, n)
+	for i := range c {
+		c[i] = g.Scalar().Zero()
+	}
+	t := g.Scalar()
+	for i := range a {
+		for j := range b {
+			c[i+j].Add(c[i+j], t.Mul(a[i], b[j]))
+		}
+	}
+	return c
+}
+
+// BatchInvert returns the inverses of all xs with a single field inversion.
+func BatchInvert(suite pairing.Suite, xs []kyber.Scalar) ([]kyber.Scalar, error) {
+	g := suite.G1()
+	if len(xs) == 0 {
+		return nil, nil
+	}
+
+	prefix := make([]kyber.Scalar, len(xs))
+	acc := g.Scalar().One()
+	for i, x := range xs {
+		if x.Equal(g.Scalar().Zero()) {
+			return nil, errors.New("polynomial: inverse of zero")
+		}
+		prefix[i] = g.Scalar().Set(acc)
+		acc.Mul(acc, x)
+	}
+
+	inv := g.Scalar().Inv(acc)
+	out := make([]kyber.Scalar, len(xs))
+	for i := len(xs) - 1; i >= 0; i-- {
+		out[i] = g.Scalar().Mul(inv, prefix[i])
+		inv.Mul(inv, xs[i])
+	}
+	return out, nil
+}
+
+/*
+Lagrange holds the barycentric weights w_i = 1 / ∏_{j≠i} (x_i - x_j) of a set of distinct
+points. Computing them takes O(n²) once; after that the Lagrange coefficients at any point take
+O(n) and the interpolating polynomial O(n²). This is the fallback for points that are not a
+domain of roots of unity, such as the indices of the participants.
+*/
+type Lagrange struct {
+	g       kyber.Group
+	suite   pairing.Suite
+	x       []kyber.Scalar
+	weights []kyber.Scalar
+}
+
+/* This function computes the barycentric weights of the points x */
+func NewLagrange(suite pairing.Suite, x []kyber.Scalar) (*Lagrange, error) {
+	g := suite.G1()
+	if len(x) == 0 {
+		return nil, errors.New("polynomial: no points to interpolate")
+	}
+
+	den := make([]kyber.Scalar, len(x))
+	t := g.Scalar()
+	for i := range x {
+		den[i] = g.Scalar().One()
+		for j := range x {
+			if i != j {
+				den[i].Mul(den[i], t.Sub(x[i], x[j]))
+			}
+		}
+	}
+	weights, err := BatchInvert(suite, den)
+	if err != nil {
+		return nil, fmt.Errorf("polynomial: the points are not distinct")
+	}
+
+	return &Lagrange{g: g, suite: suite, x: x, weights: weights}, nil
+}
+
+// At returns the Lagrange coefficients λ_i(z) = ∏_{j≠i} (z - x_j) / (x_i - x_j).
+func (l *Lagrange) At(z kyber.Scalar) []kyber.Scalar {
+	lambda := make([]kyber.Scalar, len(l.x))
+
+	diff := make([]kyber.Scalar, len(l.x))
+	for i := range l.x {
+		diff[i] = l.g.Scalar().Sub(z, l.x[i])
+		if diff[i].Equal(l.g.Scalar().Zero()) {
+			// z is one of the points
+			for j := range lambda {
+				lambda[j] = l.g.Scalar().Zero()
+			}
+			lambda[i] = l.g.Scalar().One()
+			return lambda
+		}
+	}
+
+	// λ_i(z) = M(z) · w_i / (z - x_i) with M(z) = ∏ (z - x_j)
+	m := l.g.Scalar().One()
+	for _, d := range diff {
+		m.Mul(m, d)
+	}
+	inv, _ := BatchInvert(l.suite, diff)
+	for i := range lambda {
+		lambda[i] = l.g.Scalar().Mul(m, l.weights[i])
+		lambda[i].Mul(lambda[i], inv[i])
+	}
+	return lambda
+}
+
+// Interpolate returns the coefficients of the polynomial of degree less than n that takes the
+// values y at the points.
+func (l *Lagrange) Interpolate(y []kyber.Scalar) ([]kyber.Scalar, error) {
+	n := len(l.x)
+	if len(y) != n {
+		return nil, fmt.Errorf("polynomial: %d values for %d points", len(y), n)
+	}
+
+	// M(X) = ∏ (X - x_j), of degree n
+	m := make([]kyber.Scalar, n+1)
+	m[0] = l.g.Scalar().One()
+	for j := 1; j <= n; j++ {
+		m[j] = l.g.Scalar().Zero()
+	}
+	t := l.g.Scalar()
+	for j, xj := range l.x {
+		for k := j + 1; k > 0; k-- {
+			m[k] = l.g.Scalar().Sub(m[k-1], t.Mul(m[k], xj))
+		}
+		m[0] = l.g.Scalar().Neg(t.Mul(m[0], xj))
+	}
+
+	out := make([]kyber.Scalar, n)
+	for k := range out {
+		out[k] = l.g.Scalar().Zero()
+	}
+	q := make([]kyber.Scalar, n)
+	for i, xi := range l.x {
+		// q = M / (X - x_i) by synthetic division
+		q[n-1] = l.g.Scalar().Set(m[n])
+		for k := n - 1; k > 0; k-- {
+			q[k-1] = l.g.Scalar().Add(m[k], t.Mul(q[k], xi))
+		}
+
+		c := l.g.Scalar().Mul(y[i], l.weights[i])
+		for k := range out {
+			out[k].Add(out[k], t.Mul(q[k], c))
+		}
+	}
+	return out, nil
+}
+
+// Interpolate returns the coefficients of the polynomial of degree less than len(x) that takes
+// the values y at the distinct points x, in O(n²).
+func Interpolate(suite pairing.Suite, x, y []kyber.Scalar) ([]kyber.Scalar, error) {
+	l, err := NewLagrange(suite, x)
+	if err != nil {
+		return nil, err
+	}
+	return l.Interpolate(y)
+}
+
+// EvaluateAt returns the value at z of the polynomial through the points (x_i, y_i) without
+// computing its coefficients.
+func EvaluateAt(suite pairing.Suite, x, y []kyber.Scalar, z kyber.Scalar) (kyber.Scalar, error) {
+	if len(x) != len(y) {
+		return nil, fmt.Errorf("polynomial: %d values for %d points", len(y), len(x))
+	}
+	l, err := NewLagrange(suite, x)
+	if err != nil {
+		return nil, err
+	}
+
+	g := suite.G1()
+	r := g.Scalar().Zero()
+	t := g.Scalar()
+	for i, lambda := range l.At(z) {
+		r.Add(r, t.Mul(lambda, y[i]))
+	}
+	return r, nil
+}
@@ -0,0 +1,367 @@
+package polynomial
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+	"testing"
+
+	"github.com/drand/kyber"
+	bls "github.com/drand/kyber-bls12381"
+	"github.com/drand/kyber/pairing"
+	"github.com/drand/kyber/pairing/bn256"
+	"github.com/stretchr/testify/require"
+)
+
+func suites() map[string]pairing.Suite {
+	return map[string]pairing.Suite{"bn256": bn256.NewSuite(), "bls12381": bls.NewBLS12381Suite()}
+}
+
+func randomScalars(g pairing.Suite, n int) []kyber.Scalar {
+	s := make([]kyber.Scalar, n)
+	for i := range s {
+		s[i] = g.G1().Scalar().Pick(g.RandomStream())
+	}
+	return s
+}
+
+func naiveEvaluate(g pairing.Suite, p []kyber.Scalar, x kyber.Scalar) kyber.Scalar {
+	r := g.G1().Scalar().Zero()
+	pow := g.G1().Scalar().One()
+	for _, c := range p {
+		r.Add(r, g.G1().Scalar().Mul(c, pow))
+		pow.Mul(pow, x)
+	}
+	return r
+}
+
+func powInt(g pairing.Suite, x kyber.Scalar, e int) kyber.Scalar {
+	r := g.G1().Scalar().One()
+	for i := 0; i < e; i++ {
+		r.Mul(r, x)
+	}
+	return r
+}
+
+func requireEqualScalars(t *testing.T, a, b []kyber.Scalar) {
+	require.Len(t, a, len(b))
+	for i := range a {
+		require.True(t, a[i].Equal(b[i]), "index %d", i)
+	}
+}
+
+func TestRootsOfUnity(t *testing.T) {
+	for name, g := range suites() {
+		t.Run(name, func(t *testing.T) {
+			f, err := FieldOf(g)
+			require.NoError(t, err)
+
+			// r - 1 = -1 in the field
+			minusOne := f.scalar(new(big.Int).Sub(f.Modulus(), big.NewInt(1)))
+			require.True(t, minusOne.Equal(g.G1().Scalar().Neg(g.G1().Scalar().One())))
+
+			for n := 1; n <= 32; n <<= 1 {
+				w, err := f.RootOfUnity(n)
+				require.NoError(t, err)
+
+				// w has order exactly n
+				require.True(t, powInt(g, w, n).Equal(g.G1().Scalar().One()))
+				if n > 1 {
+					require.False(t, powInt(g, w, n/2).Equal(g.G1().Scalar().One()))
+				}
+			}
+
+			_, err = f.RootOfUnity(3)
+			require.Error(t, err)
+			_, err = f.RootOfUnity(2 * f.MaxDomain())
+			require.Error(t, err)
+		})
+	}
+
+	f, err := FieldOf(bn256.NewSuite())
+	require.NoError(t, err)
+	require.Equal(t, 32, f.MaxDomain())
+	f, err = FieldOf(bls.NewBLS12381Suite())
+	require.NoError(t, err)
+	require.Equal(t, 1<<30, f.MaxDomain())
+}
+
+func TestFFTMatchesEvaluation(t *testing.T) {
+	for name, g := range suites() {
+		t.Run(name, func(t *testing.T) {
+			for _, n := range []int{1, 2, 5, 16, 32} {
+				d, err := NewDomain(g, n)
+				require.NoError(t, err)
+
+				p := randomScalars(g, n)
+				evals, err := d.FFT(p)
+				require.NoError(t, err)
+				for i := 0; i < d.Size(); i++ {
+					require.True(t, evals[i].Equal(naiveEvaluate(g, p, d.Element(i))), "size %d, element %d", n, i)
+				}
+
+				coeffs, err := d.IFFT(evals)
+				require.NoError(t, err)
+				requireEqualScalars(t, coeffs[:n], p)
+				for _, c := range coeffs[n:] {
+					require.True(t, c.Equal(g.G1().Scalar().Zero()))
+				}
+			}
+		})
+	}
+}
+
+func TestFFTPoints(t *testing.T) {
+	g := bn256.NewSuite()
+	d, err := NewDomain(g, 8)
+	require.NoError(t, err)
+
+	// the coefficients are c_i·G, so the evaluations are p(ω^j)·G
+	p := randomScalars(g, 6)
+	points := make([]kyber.Point, len(p))
+	for i, c := range p {
+		points[i] = g.G1().Point().Mul(c, nil)
+	}
+
+	evals, err := d.FFTPoints(g.G1(), points)
+	require.NoError(t, err)
+	for j := 0; j < d.Size(); j++ {
+		require.True(t, evals[j].Equal(g.G1().Point().Mul(Evaluate(g, p, d.Element(j)), nil)))
+	}
+
+	back, err := d.IFFTPoints(g.G1(), evals)
+	require.NoError(t, err)
+	for i := range points {
+		require.True(t, back[i].Equal(points[i]))
+	}
+	require.True(t, back[7].Equal(g.G1().Point().Null()))
+}
+
+func TestLargeDomain(t *testing.T) {
+	g := bls.NewBLS12381Suite()
+	d, err := NewDomain(g, 1000)
+	require.NoError(t, err)
+	require.Equal(t, 1024, d.Size())
+	require.True(t, d.Element(512).Equal(g.G1().Scalar().Neg(g.G1().Scalar().One())))
+
+	p := randomScalars(g, 1000)
+	evals, err := d.FFT(p)
+	require.NoError(t, err)
+	require.True(t, evals[777].Equal(Evaluate(g, p, d.Element(777))))
+
+	coeffs, err := d.IFFT(evals)
+	require.NoError(t, err)
+	requireEqualScalars(t, coeffs[:1000], p)
+
+	_, err = NewDomain(bn256.NewSuite(), 64)
+	require.Error(t, err)
+	_, err = d.FFT(randomScalars(g, 1025))
+	require.Error(t, err)
+	_, err = d.IFFT(evals[:10])
+	require.Error(t, err)
+}
+
+func TestInterpolate(t *testing.T) {
+	for name, g := range suites() {
+		t.Run(name, func(t *testing.T) {
+			for _, n := range []int{1, 2, 7, 40} {
+				p := randomScalars(g, n)
+
+				// the points are -1, ..., -n like the secrets of the dealer
+				x := make([]kyber.Scalar, n)
+				for i := range x {
+					x[i] = g.G1().Scalar().Neg(g.G1().Scalar().SetInt64(int64(i + 1)))
+				}
+				coeffs, err := Interpolate(g, x, EvaluateMany(g, p, x))
+				require.NoError(t, err)
+				requireEqualScalars(t, coeffs, p)
+
+				// random points
+				x = randomScalars(g, n)
+				y := EvaluateMany(g, p, x)
+				coeffs, err = Interpolate(g, x, y)
+				require.NoError(t, err)
+				requireEqualScalars(t, coeffs, p)
+
+				z := g.G1().Scalar().Pick(g.RandomStream())
+				v, err := EvaluateAt(g, x, y, z)
+				require.NoError(t, err)
+				require.True(t, v.Equal(Evaluate(g, p, z)))
+
+				v, err = EvaluateAt(g, x, y, x[n-1])
+				require.NoError(t, err)
+				require.True(t, v.Equal(y[n-1]))
+			}
+		})
+	}
+}
+
+func TestInterpolateErrors(t *testing.T) {
+	g := bn256.NewSuite()
+	x := randomScalars(g, 3)
+
+	_, err := Interpolate(g, x, randomScalars(g, 2))
+	require.Error(t, err)
+	_, err = Interpolate(g, nil, nil)
+	require.Error(t, err)
+	_, err = Interpolate(g, []kyber.Scalar{x[0], x[1], x[0]}, randomScalars(g, 3))
+	require.ErrorContains(t, err, "not distinct")
+	_, err = EvaluateAt(g, x, randomScalars(g, 2), x[0])
+	require.Error(t, err)
+}
+
+func TestMul(t *testing.T) {
+	for name, g := range suites() {
+		t.Run(name, func(t *testing.T) {
+			// both below and above the FFT threshold
+			for _, n := range []int{3, fftThreshold + 10} {
+				a, b := randomScalars(g, n), randomScalars(g, n+1)
+				c := Mul(g, a, b)
+				require.Len(t, c, 2*n)
+
+				z := g.G1().Scalar().Pick(g.RandomStream())
+				want := g.G1().Scalar().Mul(Evaluate(g, a, z), Evaluate(g, b, z))
+				require.True(t, Evaluate(g, c, z).Equal(want), "length %d", n)
+			}
+		})
+	}
+	require.Nil(t, Mul(bn256.NewSuite(), nil, randomScalars(bn256.NewSuite(), 2)))
+}
+
+func TestBatchInvert(t *testing.T) {
+	g := bn256.NewSuite()
+	xs := randomScalars(g, 10)
+	inv, err := BatchInvert(g, xs)
+	require.NoError(t, err)
+	for i := range xs {
+		require.True(t, g.G1().Scalar().Mul(xs[i], inv[i]).Equal(g.G1().Scalar().One()))
+	}
+
+	xs[4] = g.G1().Scalar().Zero()
+	_, err = BatchInvert(g, xs)
+	require.Error(t, err)
+}
+
+func BenchmarkInterpolate(b *testing.B) {
+	g := bn256.NewSuite()
+	for _, n := range []int{64, 256} {
+		x, y := randomScalars(g, n), randomScalars(g, n)
+		b.Run(fmt.Sprintf("n=%d", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, err := Interpolate(g, x, y); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkFFT(b *testing.B) {
+	g := bls.NewBLS12381Suite()
+	for _, n := range []int{256, 1024} {
+		d, err := NewDomain(g, n)
+		if err != nil {
+			b.Fatal(err)
+		}
+		p := randomScalars(g, n)
+		b.Run(fmt.Sprintf("n=%d", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, err := d.FFT(p); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func TestFieldIntegers(t *testing.T) {
+	for name, g := range suites() {
+		t.Run(name, func(t *testing.T) {
+			f, err := FieldOf(g)
+			require.NoError(t, err)
+
+			v := big.NewInt(1234567)
+			s := f.SetInt(v)
+			require.True(t, s.Equal(g.G1().Scalar().SetInt64(1234567)))
+			require.Equal(t, 0, f.Int(s).Cmp(v))
+
+			// values are reduced mod r
+			require.True(t, f.SetInt(new(big.Int).Add(f.Modulus(), v)).Equal(s))
+			require.True(t, f.SetInt(big.NewInt(-1)).Equal(g.G1().Scalar().Neg(g.G1().Scalar().One())))
+			require.Equal(t, 0, f.Int(g.G1().Scalar().Neg(g.G1().Scalar().One())).Cmp(new(big.Int).Sub(f.Modulus(), big.NewInt(1))))
+		})
+	}
+}
+
+func TestDecode(t *testing.T) {
+	for name, g := range suites() {
+		t.Run(name, func(t *testing.T) {
+			// n = 3f+1 shares of a polynomial of degree f corrects f errors
+			for _, f := range []int{0, 1, 3, 5} {
+				n, k := 3*f+1, f+1
+				p := randomScalars(g, k)
+				x := make([]kyber.Scalar, n)
+				for i := range x {
+					x[i] = g.G1().Scalar().SetInt64(int64(i + 1))
+				}
+
+				for errs := 0; errs <= f; errs++ {
+					y := EvaluateMany(g, p, x)
+					var want []int
+					for j := 0; j < errs; j++ {
+						i := (3*j + 1) % n
+						y[i] = g.G1().Scalar().Pick(g.RandomStream())
+						want = append(want, i)
+					}
+					sort.Ints(want)
+
+					got, bad, err := Decode(g, x, y, k)
+					require.NoError(t, err, "f %d, %d errors", f, errs)
+					requireEqualScalars(t, got, p)
+					require.Equal(t, want, bad)
+				}
+			}
+		})
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	g := bn256.NewSuite()
+	f := 2
+	n, k := 3*f+1, f+1
+	p := randomScalars(g, k)
+	x := randomScalars(g, n)
+	y := EvaluateMany(g, p, x)
+
+	// f+1 errors are more than the code corrects
+	for i := 0; i <= f; i++ {
+		y[i] = g.G1().Scalar().Pick(g.RandomStream())
+	}
+	_, _, err := Decode(g, x, y, k)
+	require.ErrorContains(t, err, "too many errors")
+
+	_, _, err = Decode(g, x, y[:3], k)
+	require.Error(t, err)
+	_, _, err = Decode(g, x[:2], y[:2], k)
+	require.Error(t, err)
+	x[1] = x[0]
+	_, _, err = Decode(g, x, y, k)
+	require.Error(t, err)
+}
+
+func TestDiv(t *testing.T) {
+	g := bn256.NewSuite()
+	a, b := randomScalars(g, 7), randomScalars(g, 3)
+	q, r := Div(g, a, b)
+	require.Len(t, q, 5)
+	require.Len(t, r, 2)
+
+	// a = q·b + r
+	z := g.G1().Scalar().Pick(g.RandomStream())
+	want := g.G1().Scalar().Add(g.G1().Scalar().Mul(Evaluate(g, q, z), Evaluate(g, b, z)), Evaluate(g, r, z))
+	require.True(t, Evaluate(g, a, z).Equal(want))
+
+	q, r = Div(g, b, a)
+	require.Nil(t, q)
+	requireEqualScalars(t, r, b)
+}
@@ -0,0 +1,41 @@
+package proofs
+
+import (
+	"github.com/drand/kyber"
+	"github.com/drand/kyber/pairing"
+)
+
+/*
+DLEQProof is a Chaum–Pedersen proof that xG = x·G and xH = x·H hold for the same x, with G and H
+in the same group. Made non-interactive with Fiat–Shamir:
+
+	T_1 = a·G, T_2 = a·H, c = H(tag, i, G, H, xG, xH, T_1, T_2), Z = a + c·x
+*/
+type DLEQProof struct {
+	T_1 kyber.Point
+	T_2 kyber.Point
+	Z   kyber.Scalar
+}
+
+/* This function proves that xG = x·G and xH = x·H; tag and i bind the proof to its context and sender */
+func ProveDLEQ(g pairing.Suite, tag string, i int, x kyber.Scalar, G, H, xG, xH kyber.Point) DLEQProof {
+	a := g.G1().Scalar().Pick(g.RandomStream())
+	p := DLEQProof{T_1: G.Clone().Mul(a, G), T_2: H.Clone().Mul(a, H)}
+	c := Challenge(g, tag, i, G, H, xG, xH, p.T_1, p.T_2)
+	p.Z = g.G1().Scalar().Add(a, g.G1().Scalar().Mul(c, x))
+	return p
+}
+
+/* This function checks a proof made by ProveDLEQ with the same tag and sender i */
+func VerifyDLEQ(g pairing.Suite, tag string, i int, G, H, xG, xH kyber.Point, p DLEQProof) bool {
+	if xG == nil || xH == nil || p.T_1 == nil || p.T_2 == nil || p.Z == nil {
+		return false
+	}
+	c := Challenge(g, tag, i, G, H, xG, xH, p.T_1, p.T_2)
+
+	// Z·G = T_1 + c·xG and Z·H = T_2 + c·xH
+	if !G.Clone().Mul(p.Z, G).Equal(G.Clone().Add(p.T_1, G.Clone().Mul(c, xG))) {
+		return false
+	}
+	return H.Clone().Mul(p.Z, H).Equal(H.Clone().Add(p.T_2, H.Clone().Mul(c, xH)))
+}
@@ -0,0 +1,57 @@
+package proofs
+
+import (
+	"testing"
+
+	"github.com/drand/kyber"
+	"github.com/drand/kyber/pairing/bn256"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShareProof(t *testing.T) {
+	g := bn256.NewSuite()
+	h := g.G1().Point().Pick(g.RandomStream())
+	x := g.G1().Scalar().Pick(g.RandomStream())
+	r := g.G1().Scalar().Pick(g.RandomStream())
+	V := g.G1().Point().Add(g.G1().Point().Mul(x, nil), g.G1().Point().Mul(r, h))
+
+	for _, base := range []kyber.Point{g.G1().Point().Base(), g.G2().Point().Base()} {
+		X := base.Clone().Mul(x, base)
+
+		p := ProveShare(g, h, base, "test", 3, x, r, X, V)
+		require.True(t, VerifyShare(g, h, base, "test", 3, X, V, p))
+		require.False(t, VerifyShare(g, h, base, "test", 4, X, V, p))
+		require.False(t, VerifyShare(g, h, base, "other", 3, X, V, p))
+		require.False(t, VerifyShare(g, h, base, "test", 3, base.Clone().Add(X, X), V, p))
+		require.False(t, VerifyShare(g, h, base, "test", 3, X, V, ShareProof{}))
+
+		// A value that differs from the committed one cannot be proven
+		y := g.G1().Scalar().Pick(g.RandomStream())
+		Y := base.Clone().Mul(y, base)
+		bad := ProveShare(g, h, base, "test", 3, y, r, Y, V)
+		require.False(t, VerifyShare(g, h, base, "test", 3, Y, V, bad))
+	}
+}
+
+func TestDLEQProof(t *testing.T) {
+	g := bn256.NewSuite()
+	for _, group := range []kyber.Group{g.G1(), g.G2()} {
+		x := g.G1().Scalar().Pick(g.RandomStream())
+		G := group.Point().Base()
+		H := group.Point().Pick(g.RandomStream())
+		xG, xH := group.Point().Mul(x, G), group.Point().Mul(x, H)
+
+		p := ProveDLEQ(g, "test", 1, x, G, H, xG, xH)
+		require.True(t, VerifyDLEQ(g, "test", 1, G, H, xG, xH, p))
+		require.False(t, VerifyDLEQ(g, "test", 2, G, H, xG, xH, p))
+		require.False(t, VerifyDLEQ(g, "other", 1, G, H, xG, xH, p))
+		require.False(t, VerifyDLEQ(g, "test", 1, G, H, xG, group.Point().Add(xH, G), p))
+		require.False(t, VerifyDLEQ(g, "test", 1, G, H, xG, xH, DLEQProof{}))
+
+		// Different exponents cannot be proven equal
+		y := g.G1().Scalar().Pick(g.RandomStream())
+		yH := group.Point().Mul(y, H)
+		bad := ProveDLEQ(g, "test", 1, x, G, H, xG, yH)
+		require.False(t, VerifyDLEQ(g, "test", 1, G, H, xG, yH, bad))
+	}
+}
@@ -0,0 +1,84 @@
+package proofs
+
+import (
+	"BingoVSS/Internal/Polynomial"
+	"crypto/sha256"
+	"encoding/binary"
+	"math/big"
+
+	"github.com/drand/kyber"
+	"github.com/drand/kyber/pairing"
+)
+
+/*
+ShareProof shows knowledge of x and r with X = x·B and V = x·g + r·h, without revealing them.
+V is a Pedersen commitment in G1, such as the value of a KZG opening, and B is a base in G1 or G2
+of the same suite. It is a Schnorr proof for both equations with the same x, made non-interactive
+with Fiat–Shamir:
+
+	T_1 = a·g + b·h, T_2 = a·B, c = H(tag, i, B, X, V, T_1, T_2), Z_1 = a + c·x, Z_2 = b + c·r
+
+Since V binds x as long as the discrete logarithm of h is unknown, X holds the committed value.
+*/
+type ShareProof struct {
+	T_1 kyber.Point
+	T_2 kyber.Point
+	Z_1 kyber.Scalar
+	Z_2 kyber.Scalar
+}
+
+/* This function proves that X = x·base and V = x·g + r·h hold for the same x; tag and i bind the proof to its context and sender */
+func ProveShare(g pairing.Suite, h, base kyber.Point, tag string, i int, x, r kyber.Scalar, X, V kyber.Point) ShareProof {
+	a := g.G1().Scalar().Pick(g.RandomStream())
+	b := g.G1().Scalar().Pick(g.RandomStream())
+
+	p := ShareProof{
+		T_1: g.G1().Point().Add(g.G1().Point().Mul(a, nil), g.G1().Point().Mul(b, h)),
+		T_2: base.Clone().Mul(a, base),
+	}
+	c := Challenge(g, tag, i, base, X, V, p.T_1, p.T_2)
+	p.Z_1 = g.G1().Scalar().Add(a, g.G1().Scalar().Mul(c, x))
+	p.Z_2 = g.G1().Scalar().Add(b, g.G1().Scalar().Mul(c, r))
+	return p
+}
+
+/* This function checks a proof made by ProveShare with the same tag and sender i */
+func VerifyShare(g pairing.Suite, h, base kyber.Point, tag string, i int, X, V kyber.Point, p ShareProof) bool {
+	if X == nil || V == nil || p.T_1 == nil || p.T_2 == nil || p.Z_1 == nil || p.Z_2 == nil {
+		return false
+	}
+	c := Challenge(g, tag, i, base, X, V, p.T_1, p.T_2)
+
+	// Z_1·g + Z_2·h = T_1 + c·V
+	left := g.G1().Point().Add(g.G1().Point().Mul(p.Z_1, nil), g.G1().Point().Mul(p.Z_2, h))
+	right := g.G1().Point().Add(p.T_1, g.G1().Point().Mul(c, V))
+	if !left.Equal(right) {
+		return false
+	}
+
+	// Z_1·B = T_2 + c·X
+	return base.Clone().Mul(p.Z_1, base).Equal(base.Clone().Add(p.T_2, base.Clone().Mul(c, X)))
+}
+
+// Challenge hashes a tag, an index and points into a scalar, with 512 bits of hash output so
+// the reduction is close to uniform.
+func Challenge(g pairing.Suite, tag string, i int, points ...kyber.Point) kyber.Scalar {
+	h := sha256.New()
+	h.Write([]byte(tag))
+	_ = binary.Write(h, binary.BigEndian, uint64(i))
+	for _, p := range points {
+		b, _ := p.MarshalBinary()
+		h.Write(b)
+	}
+	seed := h.Sum(nil)
+
+	lo := sha256.Sum256(append(seed, 0))
+	hi := sha256.Sum256(append(seed, 1))
+	v := new(big.Int).SetBytes(append(hi[:], lo[:]...))
+
+	f, err := polynomial.FieldOf(g)
+	if err != nil {
+		panic(err)
+	}
+	return f.SetInt(v)
+}
@@ -0,0 +1,60 @@
+package testnet
+
+import (
+	vss "BingoVSS/Bingo"
+	"math/rand"
+)
+
+// Party is a party of a protocol that runs over vss.Envelopes, like an ADKG or a refresh party.
+type Party interface {
+	Start() ([]vss.Envelope, error)
+	Handle(env vss.Envelope) ([]vss.Envelope, error)
+}
+
+// Handler is a party that only reacts to messages, like a new party of a resharing.
+type Handler interface {
+	Handle(env vss.Envelope) ([]vss.Envelope, error)
+}
+
+/*
+This function starts the parties 1..len(parties)-1 that did not crash and delivers their messages
+with Deliver. It returns the first error of a Start.
+*/
+func Run[P Party](parties []P, crashed map[int]bool, tamper func(env vss.Envelope) vss.Envelope, seed int64) error {
+	var queue []vss.Envelope
+	for i := 1; i < len(parties); i++ {
+		if crashed[i] {
+			continue
+		}
+		out, err := parties[i].Start()
+		if err != nil {
+			return err
+		}
+		queue = append(queue, out...)
+	}
+	Deliver(parties, queue, crashed, tamper, seed)
+	return nil
+}
+
+/*
+This function delivers the messages of the queue, and the messages the parties send in response,
+in a random order given by seed until none are left. Messages from or to crashed parties are
+dropped, and tamper, if not nil, may change the others. The errors of Handle are ignored: a
+party drops what it rejects and carries on.
+*/
+func Deliver[P Handler](parties []P, queue []vss.Envelope, crashed map[int]bool, tamper func(env vss.Envelope) vss.Envelope, seed int64) {
+	rnd := rand.New(rand.NewSource(seed))
+	for len(queue) > 0 {
+		k := rnd.Intn(len(queue))
+		env := queue[k]
+		queue = append(queue[:k], queue[k+1:]...)
+		if crashed[env.To] || crashed[env.From] {
+			continue
+		}
+		if tamper != nil {
+			env = tamper(env)
+		}
+		out, _ := parties[env.To].Handle(env)
+		queue = append(queue, out...)
+	}
+}
@@ -0,0 +1,74 @@
+package testnet
+
+import (
+	vss "BingoVSS/Bingo"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// relay sends one message to every other party on start and answers the first message of every sender.
+type relay struct {
+	id, n    int
+	received map[int]int
+	fail     bool
+}
+
+func (r *relay) Start() ([]vss.Envelope, error) {
+	if r.fail {
+		return nil, errors.New("relay: cannot start")
+	}
+	var out []vss.Envelope
+	for j := 1; j <= r.n; j++ {
+		if j != r.id {
+			out = append(out, vss.Envelope{From: r.id, To: j, Msg: &vss.DoneMessage{}})
+		}
+	}
+	return out, nil
+}
+
+func (r *relay) Handle(env vss.Envelope) ([]vss.Envelope, error) {
+	r.received[env.From]++
+	if r.received[env.From] > 1 {
+		return nil, nil
+	}
+	return []vss.Envelope{{From: r.id, To: env.From, Msg: &vss.DoneMessage{}}}, nil
+}
+
+func relays(n int) []*relay {
+	parties := make([]*relay, n+1)
+	for i := 1; i <= n; i++ {
+		parties[i] = &relay{id: i, n: n, received: make(map[int]int)}
+	}
+	return parties
+}
+
+func TestRun(t *testing.T) {
+	parties := relays(4)
+	require.NoError(t, Run(parties, nil, nil, 1))
+	for i := 1; i <= 4; i++ {
+		for j := 1; j <= 4; j++ {
+			if j != i {
+				require.Equal(t, 2, parties[i].received[j])
+			}
+		}
+	}
+
+	// Crashed parties neither start nor send or receive, and tamper sees every other message
+	parties = relays(4)
+	tampered := 0
+	require.NoError(t, Run(parties, map[int]bool{3: true}, func(env vss.Envelope) vss.Envelope {
+		require.NotEqual(t, 3, env.From)
+		require.NotEqual(t, 3, env.To)
+		tampered++
+		return env
+	}, 2))
+	require.Equal(t, 12, tampered)
+	require.Empty(t, parties[3].received)
+	require.Zero(t, parties[1].received[3])
+
+	parties = relays(4)
+	parties[2].fail = true
+	require.Error(t, Run(parties, nil, nil, 3))
+}
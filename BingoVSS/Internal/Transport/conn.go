@@ -0,0 +1,355 @@
+package transport
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	"golang.org/x/crypto/chacha20poly1305"
+	"golang.org/x/crypto/hkdf"
+)
+
+/*
+A Conn is an authenticated and encrypted channel between two parties that know each other's
+PublicIdentity from the Directory. It runs over any reliable stream: an in-memory pipe, a TCP or
+TLS connection or a websocket.
+
+The handshake binds the channel to both parties and to fresh nonces of both sides:
+
+	dialer   -> acceptor   hello(from, to, nonce_d), signed by the dialer
+	acceptor -> dialer     hello(from, to, nonce_a), signed by the acceptor over nonce_d as well
+	dialer   -> acceptor   confirm, the signature of the dialer over nonce_a and both indices
+
+and the session id is SHA-256(nonce_d || nonce_a || dialer || acceptor). Each side signs the
+fresh nonce of the other, so a recorded handshake cannot be replayed by someone who only
+knows the public key of the party it claims to be. Every data frame
+
+	length uint32 | seq uint64 | ephemeral X25519 key | ciphertext | Ed25519 signature
+
+is encrypted to the X25519 key of the recipient with a fresh ephemeral key and signed by the
+sender over the session id, both indices, the sequence number, the ephemeral key and the
+ciphertext. A party therefore cannot claim another index, read frames meant for someone
+else, or replay frames inside the session or from an older one.
+*/
+type Conn struct {
+	rw      io.ReadWriter
+	self    *Identity
+	peer    PublicIdentity
+	session []byte
+
+	wmu     sync.Mutex
+	sendSeq uint64
+	rmu     sync.Mutex
+	recvSeq uint64
+}
+
+const (
+	nonceSize     = 32
+	helloSize     = 4 + 4 + nonceSize + ed25519.SignatureSize
+	maxFrameSize  = 1 << 24
+	frameOverhead = 8 + 32 + chacha20poly1305.Overhead + ed25519.SignatureSize
+)
+
+var (
+	helloContext   = []byte("BingoVSS transport hello")
+	confirmContext = []byte("BingoVSS transport confirm")
+	sessionContext = []byte("BingoVSS transport session")
+	frameContext   = []byte("BingoVSS transport frame")
+)
+
+// Dial runs the handshake as the party that opened the stream and expects peer on the other end.
+func Dial(rw io.ReadWriter, self *Identity, peer PublicIdentity) (*Conn, error) {
+	nonce_d, err := freshNonce()
+	if err != nil {
+		return nil, err
+	}
+
+	hello := newHello(self, peer.ID, nonce_d, nil)
+	if _, err := rw.Write(hello); err != nil {
+		return nil, err
+	}
+
+	from, to, nonce_a, err := readHello(rw, func(from int) (PublicIdentity, error) {
+		if from != peer.ID {
+			return PublicIdentity{}, fmt.Errorf("transport: expected party %d, got %d", peer.ID, from)
+		}
+		return peer, nil
+	}, nonce_d)
+	if err != nil {
+		return nil, err
+	}
+	if to != self.id {
+		return nil, fmt.Errorf("transport: handshake addressed to %d instead of %d", to, self.id)
+	}
+
+	// Answer the challenge of the acceptor
+	if _, err := rw.Write(ed25519.Sign(self.sign, signedConfirm(nonce_a, self.id, from))); err != nil {
+		return nil, err
+	}
+
+	return newConn(rw, self, peer, sessionID(nonce_d, nonce_a, self.id, from)), nil
+}
+
+// Accept runs the handshake as the party that received the stream. The peer is authenticated
+// against the directory, so its index is the one it proved, not one it picked.
+func Accept(rw io.ReadWriter, self *Identity, dir Directory) (*Conn, error) {
+	from, to, nonce_d, err := readHello(rw, func(from int) (PublicIdentity, error) {
+		peer, ok := dir[from]
+		if !ok || from == self.id {
+			return PublicIdentity{}, fmt.Errorf("transport: unknown party %d", from)
+		}
+		return peer, nil
+	}, nil)
+	if err != nil {
+		return nil, err
+	}
+	if to != self.id {
+		return nil, fmt.Errorf("transport: handshake addressed to %d instead of %d", to, self.id)
+	}
+
+	nonce_a, err := freshNonce()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := rw.Write(newHello(self, from, nonce_a, nonce_d)); err != nil {
+		return nil, err
+	}
+
+	// The hello of the dialer may be a recording, only a signature over our fresh nonce proves it is live
+	confirm := make([]byte, ed25519.SignatureSize)
+	if _, err := io.ReadFull(rw, confirm); err != nil {
+		return nil, err
+	}
+	if !ed25519.Verify(dir[from].SigningKey, signedConfirm(nonce_a, from, self.id), confirm) {
+		return nil, fmt.Errorf("transport: invalid handshake confirmation from %d", from)
+	}
+
+	return newConn(rw, self, dir[from], sessionID(nonce_d, nonce_a, from, self.id)), nil
+}
+
+func newConn(rw io.ReadWriter, self *Identity, peer PublicIdentity, session []byte) *Conn {
+	return &Conn{rw: rw, self: self, peer: peer, session: session}
+}
+
+// Peer returns the authenticated index of the other party.
+func (c *Conn) Peer() int {
+	return c.peer.ID
+}
+
+// Send encrypts the payload to the peer, signs it and writes it as a single frame.
+func (c *Conn) Send(payload []byte) error {
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+
+	if len(payload)+frameOverhead > maxFrameSize {
+		return fmt.Errorf("transport: payload of %d bytes is too large", len(payload))
+	}
+
+	c.sendSeq++
+	frame, err := sealFrame(c.self, c.peer, c.session, c.sendSeq, payload)
+	if err != nil {
+		return err
+	}
+
+	_, err = c.rw.Write(frame)
+	return err
+}
+
+// Receive reads the next frame from the peer and returns its decrypted payload. Any frame that
+// is not signed by the peer, not encrypted to us, or out of sequence is an error.
+func (c *Conn) Receive() ([]byte, error) {
+	c.rmu.Lock()
+	defer c.rmu.Unlock()
+
+	var l uint32
+	if err := binary.Read(c.rw, binary.BigEndian, &l); err != nil {
+		return nil, err
+	}
+	if l < frameOverhead || l > maxFrameSize {
+		return nil, fmt.Errorf("transport: invalid frame length %d", l)
+	}
+
+	body := make([]byte, l)
+	if _, err := io.ReadFull(c.rw, body); err != nil {
+		return nil, err
+	}
+
+	payload, seq, err := openFrame(c.self, c.peer, c.session, body)
+	if err != nil {
+		return nil, err
+	}
+	if seq != c.recvSeq+1 {
+		return nil, fmt.Errorf("transport: frame %d out of sequence, expected %d", seq, c.recvSeq+1)
+	}
+	c.recvSeq = seq
+
+	return payload, nil
+}
+
+// sealFrame builds the frame carrying payload from self to peer, including its length prefix.
+func sealFrame(self *Identity, peer PublicIdentity, session []byte, seq uint64, payload []byte) ([]byte, error) {
+	eph, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	shared, err := eph.ECDH(peer.BoxKey)
+	if err != nil {
+		return nil, err
+	}
+
+	epk := eph.PublicKey().Bytes()
+	aead, err := frameCipher(shared, session, epk, peer.BoxKey.Bytes())
+	if err != nil {
+		return nil, err
+	}
+
+	header := frameHeader(session, self.id, peer.ID, seq)
+	nonce := make([]byte, aead.NonceSize()) // the key is used for this frame only
+	ciphertext := aead.Seal(nil, nonce, payload, header)
+
+	body := make([]byte, 0, 8+len(epk)+len(ciphertext)+ed25519.SignatureSize)
+	body = binary.BigEndian.AppendUint64(body, seq)
+	body = append(body, epk...)
+	body = append(body, ciphertext...)
+	body = append(body, ed25519.Sign(self.sign, signedFrame(header, body))...)
+
+	frame := make([]byte, 4, 4+len(body))
+	binary.BigEndian.PutUint32(frame, uint32(len(body)))
+	return append(frame, body...), nil
+}
+
+// openFrame checks the signature of the peer on a frame body and decrypts it.
+func openFrame(self *Identity, peer PublicIdentity, session []byte, body []byte) ([]byte, uint64, error) {
+	if len(body) < frameOverhead {
+		return nil, 0, errors.New("transport: frame too short")
+	}
+
+	seq := binary.BigEndian.Uint64(body)
+	header := frameHeader(session, peer.ID, self.id, seq)
+
+	signed := body[:len(body)-ed25519.SignatureSize]
+	sig := body[len(body)-ed25519.SignatureSize:]
+	if !ed25519.Verify(peer.SigningKey, signedFrame(header, signed), sig) {
+		return nil, 0, fmt.Errorf("transport: invalid signature on frame from %d", peer.ID)
+	}
+
+	epk := signed[8 : 8+32]
+	ephemeral, err := ecdh.X25519().NewPublicKey(epk)
+	if err != nil {
+		return nil, 0, err
+	}
+	shared, err := self.box.ECDH(ephemeral)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	aead, err := frameCipher(shared, session, epk, self.box.PublicKey().Bytes())
+	if err != nil {
+		return nil, 0, err
+	}
+	payload, err := aead.Open(nil, make([]byte, aead.NonceSize()), signed[8+32:], header)
+	if err != nil {
+		return nil, 0, fmt.Errorf("transport: cannot decrypt frame from %d", peer.ID)
+	}
+
+	return payload, seq, nil
+}
+
+// frameCipher derives the key of a single frame from the X25519 shared secret.
+func frameCipher(shared, session, epk, recipient []byte) (cipher.AEAD, error) {
+	info := append(append([]byte{}, epk...), recipient...)
+	key := make([]byte, chacha20poly1305.KeySize)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, shared, session, info), key); err != nil {
+		return nil, err
+	}
+	return chacha20poly1305.New(key)
+}
+
+func frameHeader(session []byte, from, to int, seq uint64) []byte {
+	h := append([]byte{}, session...)
+	h = binary.BigEndian.AppendUint32(h, uint32(from))
+	h = binary.BigEndian.AppendUint32(h, uint32(to))
+	return binary.BigEndian.AppendUint64(h, seq)
+}
+
+func signedFrame(header, body []byte) []byte {
+	var buf bytes.Buffer
+	buf.Write(frameContext)
+	buf.Write(header)
+	buf.Write(body)
+	return buf.Bytes()
+}
+
+// newHello builds a signed hello from self to the party to. The acceptor also signs the nonce of the dialer.
+func newHello(self *Identity, to int, nonce, peerNonce []byte) []byte {
+	msg := make([]byte, 0, helloSize)
+	msg = binary.BigEndian.AppendUint32(msg, uint32(self.id))
+	msg = binary.BigEndian.AppendUint32(msg, uint32(to))
+	msg = append(msg, nonce...)
+
+	sig := ed25519.Sign(self.sign, signedHello(msg, peerNonce))
+	return append(msg, sig...)
+}
+
+// readHello reads a hello and checks its signature with the key returned by lookup.
+func readHello(r io.Reader, lookup func(from int) (PublicIdentity, error), peerNonce []byte) (int, int, []byte, error) {
+	msg := make([]byte, helloSize)
+	if _, err := io.ReadFull(r, msg); err != nil {
+		return 0, 0, nil, err
+	}
+
+	from := int(binary.BigEndian.Uint32(msg))
+	to := int(binary.BigEndian.Uint32(msg[4:]))
+	nonce := msg[8 : 8+nonceSize]
+
+	peer, err := lookup(from)
+	if err != nil {
+		return 0, 0, nil, err
+	}
+	if !ed25519.Verify(peer.SigningKey, signedHello(msg[:8+nonceSize], peerNonce), msg[8+nonceSize:]) {
+		return 0, 0, nil, fmt.Errorf("transport: invalid handshake signature from %d", from)
+	}
+
+	return from, to, nonce, nil
+}
+
+func signedHello(msg, peerNonce []byte) []byte {
+	var buf bytes.Buffer
+	buf.Write(helloContext)
+	buf.Write(msg)
+	buf.Write(peerNonce)
+	return buf.Bytes()
+}
+
+func signedConfirm(nonce_a []byte, dialer, acceptor int) []byte {
+	var buf bytes.Buffer
+	buf.Write(confirmContext)
+	buf.Write(nonce_a)
+	_ = binary.Write(&buf, binary.BigEndian, uint32(dialer))
+	_ = binary.Write(&buf, binary.BigEndian, uint32(acceptor))
+	return buf.Bytes()
+}
+
+func sessionID(nonce_d, nonce_a []byte, dialer, acceptor int) []byte {
+	h := sha256.New()
+	h.Write(sessionContext)
+	h.Write(nonce_d)
+	h.Write(nonce_a)
+	_ = binary.Write(h, binary.BigEndian, uint32(dialer))
+	_ = binary.Write(h, binary.BigEndian, uint32(acceptor))
+	return h.Sum(nil)
+}
+
+func freshNonce() ([]byte, error) {
+	nonce := make([]byte, nonceSize)
+	_, err := io.ReadFull(rand.Reader, nonce)
+	return nonce, err
+}
@@ -0,0 +1,205 @@
+package transport
+
+import (
+	"crypto/ecdh"
+	"crypto/ed25519"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+)
+
+// Identity is the long-term key pair of a party: an Ed25519 key that signs every frame the
+// party sends and an X25519 key that frames addressed to the party are encrypted to.
+type Identity struct {
+	id   int
+	sign ed25519.PrivateKey
+	box  *ecdh.PrivateKey
+}
+
+// PublicIdentity is the public part of an Identity, as known to the other parties.
+type PublicIdentity struct {
+	ID         int
+	SigningKey ed25519.PublicKey
+	BoxKey     *ecdh.PublicKey
+}
+
+// Directory maps the index of every party to its public identity. It plays the role of the
+// PKI assumed by the protocol and must be distributed out of band.
+type Directory map[int]PublicIdentity
+
+/* This function generates a new identity for the party with index id */
+func NewIdentity(id int, rand io.Reader) (*Identity, error) {
+	if id < 0 {
+		return nil, fmt.Errorf("transport: negative party index %d", id)
+	}
+
+	_, sign, err := ed25519.GenerateKey(rand)
+	if err != nil {
+		return nil, err
+	}
+	box, err := ecdh.X25519().GenerateKey(rand)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Identity{id: id, sign: sign, box: box}, nil
+}
+
+func (id *Identity) ReturnID() int {
+	return id.id
+}
+
+// Public returns the public identity to publish in the directory.
+func (id *Identity) Public() PublicIdentity {
+	return PublicIdentity{ID: id.id, SigningKey: id.sign.Public().(ed25519.PublicKey), BoxKey: id.box.PublicKey()}
+}
+
+/* This function builds the directory of the given parties */
+func NewDirectory(peers ...PublicIdentity) Directory {
+	dir := make(Directory, len(peers))
+	for _, p := range peers {
+		dir[p.ID] = p
+	}
+	return dir
+}
+
+// identityFile is the on-disk form of an identity and of a directory entry, with keys in hex.
+type identityFile struct {
+	ID         int    `json:"id"`
+	SigningKey string `json:"signing_key"`
+	BoxKey     string `json:"box_key"`
+}
+
+// MarshalJSON encodes the private identity. The result is secret and must be stored accordingly.
+func (id *Identity) MarshalJSON() ([]byte, error) {
+	return json.Marshal(identityFile{
+		ID:         id.id,
+		SigningKey: hex.EncodeToString(id.sign.Seed()),
+		BoxKey:     hex.EncodeToString(id.box.Bytes()),
+	})
+}
+
+// UnmarshalJSON decodes a private identity written by MarshalJSON.
+func (id *Identity) UnmarshalJSON(data []byte) error {
+	var f identityFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return err
+	}
+
+	seed, err := hex.DecodeString(f.SigningKey)
+	if err != nil || len(seed) != ed25519.SeedSize {
+		return errors.New("transport: invalid signing key")
+	}
+	b, err := hex.DecodeString(f.BoxKey)
+	if err != nil {
+		return errors.New("transport: invalid encryption key")
+	}
+	box, err := ecdh.X25519().NewPrivateKey(b)
+	if err != nil {
+		return fmt.Errorf("transport: invalid encryption key: %w", err)
+	}
+	if f.ID < 0 {
+		return fmt.Errorf("transport: negative party index %d", f.ID)
+	}
+
+	*id = Identity{id: f.ID, sign: ed25519.NewKeyFromSeed(seed), box: box}
+	return nil
+}
+
+// MarshalJSON encodes the directory as a list of public identities ordered by index.
+func (d Directory) MarshalJSON() ([]byte, error) {
+	ids := make([]int, 0, len(d))
+	for i := range d {
+		ids = append(ids, i)
+	}
+	sort.Ints(ids)
+
+	entries := make([]identityFile, len(ids))
+	for k, i := range ids {
+		entries[k] = identityFile{
+			ID:         i,
+			SigningKey: hex.EncodeToString(d[i].SigningKey),
+			BoxKey:     hex.EncodeToString(d[i].BoxKey.Bytes()),
+		}
+	}
+	return json.Marshal(entries)
+}
+
+// UnmarshalJSON decodes a directory written by MarshalJSON.
+func (d *Directory) UnmarshalJSON(data []byte) error {
+	var entries []identityFile
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return err
+	}
+
+	dir := make(Directory, len(entries))
+	for _, e := range entries {
+		sign, err := hex.DecodeString(e.SigningKey)
+		if err != nil || len(sign) != ed25519.PublicKeySize {
+			return fmt.Errorf("transport: invalid signing key for party %d", e.ID)
+		}
+		b, err := hex.DecodeString(e.BoxKey)
+		if err != nil {
+			return fmt.Errorf("transport: invalid encryption key for party %d", e.ID)
+		}
+		box, err := ecdh.X25519().NewPublicKey(b)
+		if err != nil {
+			return fmt.Errorf("transport: invalid encryption key for party %d: %w", e.ID, err)
+		}
+		if _, ok := dir[e.ID]; ok || e.ID < 0 {
+			return fmt.Errorf("transport: invalid or duplicate party index %d", e.ID)
+		}
+		dir[e.ID] = PublicIdentity{ID: e.ID, SigningKey: sign, BoxKey: box}
+	}
+
+	*d = dir
+	return nil
+}
+
+// SaveIdentity writes the private identity to path, readable only by the owner.
+func SaveIdentity(path string, id *Identity) error {
+	data, err := json.Marshal(id)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0600)
+}
+
+// LoadIdentity reads a private identity written by SaveIdentity.
+func LoadIdentity(path string) (*Identity, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	id := &Identity{}
+	if err := json.Unmarshal(data, id); err != nil {
+		return nil, err
+	}
+	return id, nil
+}
+
+// SaveDirectory writes the public directory to path.
+func SaveDirectory(path string, d Directory) error {
+	data, err := json.MarshalIndent(d, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// LoadDirectory reads a directory written by SaveDirectory.
+func LoadDirectory(path string) (Directory, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var d Directory
+	if err := json.Unmarshal(data, &d); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
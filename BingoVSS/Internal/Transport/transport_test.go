@@ -0,0 +1,331 @@
+package transport
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/require"
+)
+
+func newIdentities(t *testing.T, n int) ([]*Identity, Directory) {
+	ids := make([]*Identity, n)
+	dir := NewDirectory()
+	for i := range ids {
+		id, err := NewIdentity(i, rand.Reader)
+		require.NoError(t, err)
+		ids[i] = id
+		dir[i] = id.Public()
+	}
+	return ids, dir
+}
+
+// connect runs the handshake between dialer and acceptor over the two ends of a stream.
+func connect(t *testing.T, a, b io.ReadWriter, dialer *Identity, acceptor *Identity, dir Directory) (*Conn, *Conn) {
+	type result struct {
+		c   *Conn
+		err error
+	}
+	done := make(chan result)
+	go func() {
+		c, err := Accept(b, acceptor, dir)
+		done <- result{c, err}
+	}()
+
+	dc, err := Dial(a, dialer, dir[acceptor.id])
+	require.NoError(t, err)
+	r := <-done
+	require.NoError(t, r.err)
+
+	return dc, r.c
+}
+
+func exchange(t *testing.T, dc, ac *Conn) {
+	go func() {
+		for _, m := range []string{"row", "column", ""} {
+			require.NoError(t, dc.Send([]byte(m)))
+		}
+	}()
+	for _, m := range []string{"row", "column", ""} {
+		got, err := ac.Receive()
+		require.NoError(t, err)
+		require.Equal(t, m, string(got))
+	}
+
+	go func() {
+		require.NoError(t, ac.Send([]byte("reply")))
+	}()
+	got, err := dc.Receive()
+	require.NoError(t, err)
+	require.Equal(t, "reply", string(got))
+}
+
+func TestConnOverPipe(t *testing.T) {
+	ids, dir := newIdentities(t, 3)
+
+	a, b := net.Pipe()
+	defer a.Close()
+	defer b.Close()
+
+	dc, ac := connect(t, a, b, ids[1], ids[0], dir)
+	require.Equal(t, 0, dc.Peer())
+	require.Equal(t, 1, ac.Peer())
+
+	exchange(t, dc, ac)
+}
+
+func TestConnOverTLS(t *testing.T) {
+	ids, dir := newIdentities(t, 2)
+	cert, pool := selfSignedCertificate(t)
+
+	l, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{Certificates: []tls.Certificate{cert}})
+	require.NoError(t, err)
+	defer l.Close()
+
+	accepted := make(chan net.Conn)
+	go func() {
+		c, err := l.Accept()
+		require.NoError(t, err)
+		require.NoError(t, c.(*tls.Conn).Handshake())
+		accepted <- c
+	}()
+
+	client, err := tls.Dial("tcp", l.Addr().String(), &tls.Config{RootCAs: pool, ServerName: "127.0.0.1"})
+	require.NoError(t, err)
+	defer client.Close()
+	server := <-accepted
+	defer server.Close()
+
+	dc, ac := connect(t, client, server, ids[1], ids[0], dir)
+	exchange(t, dc, ac)
+}
+
+func TestConnOverWebSocket(t *testing.T) {
+	ids, dir := newIdentities(t, 2)
+
+	accepted := make(chan *Conn)
+	upgrader := websocket.Upgrader{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ws, err := upgrader.Upgrade(w, r, nil)
+		require.NoError(t, err)
+		c, err := Accept(NewWebSocketStream(ws), ids[0], dir)
+		require.NoError(t, err)
+		accepted <- c
+	}))
+	defer srv.Close()
+
+	ws, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http"), nil)
+	require.NoError(t, err)
+	defer ws.Close()
+
+	dc, err := Dial(NewWebSocketStream(ws), ids[1], dir[0])
+	require.NoError(t, err)
+	ac := <-accepted
+
+	exchange(t, dc, ac)
+}
+
+func TestConnRejectsImpersonation(t *testing.T) {
+	ids, dir := newIdentities(t, 3)
+
+	// Party 2 generates its own keys but claims to be party 1
+	mallory, err := NewIdentity(1, rand.Reader)
+	require.NoError(t, err)
+
+	a, b := net.Pipe()
+	defer a.Close()
+	defer b.Close()
+
+	go func() {
+		_, _ = Dial(a, mallory, dir[0])
+	}()
+	_, err = Accept(b, ids[0], dir)
+	require.Error(t, err)
+
+	// A party that is not in the directory
+	stranger, _ := NewIdentity(7, rand.Reader)
+	c, d := net.Pipe()
+	defer c.Close()
+	defer d.Close()
+
+	go func() {
+		_, _ = Dial(c, stranger, dir[0])
+	}()
+	_, err = Accept(d, ids[0], dir)
+	require.Error(t, err)
+}
+
+func TestFramesAreBoundToSenderAndRecipient(t *testing.T) {
+	ids, dir := newIdentities(t, 3)
+	session := sessionID(make([]byte, nonceSize), make([]byte, nonceSize), 1, 0)
+
+	frame, err := sealFrame(ids[1], dir[0], session, 1, []byte("row of party 0"))
+	require.NoError(t, err)
+	body := frame[4:]
+
+	payload, seq, err := openFrame(ids[0], dir[1], session, body)
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), seq)
+	require.Equal(t, "row of party 0", string(payload))
+
+	// Party 2 cannot read it, nor pass it off as coming from itself
+	_, _, err = openFrame(ids[2], dir[1], session, body)
+	require.Error(t, err)
+	_, _, err = openFrame(ids[0], dir[2], session, body)
+	require.Error(t, err)
+
+	// It does not verify in another session
+	other := sessionID(make([]byte, nonceSize), bytes.Repeat([]byte{1}, nonceSize), 1, 0)
+	_, _, err = openFrame(ids[0], dir[1], other, body)
+	require.Error(t, err)
+
+	// Every flipped bit is detected
+	for i := range body {
+		tampered := append([]byte{}, body...)
+		tampered[i] ^= 0x01
+		_, _, err := openFrame(ids[0], dir[1], session, tampered)
+		require.Error(t, err, "byte %d", i)
+	}
+}
+
+func TestConnRejectsReplay(t *testing.T) {
+	ids, dir := newIdentities(t, 2)
+
+	// Record what the dialer writes so it can be replayed
+	a, b := net.Pipe()
+	defer a.Close()
+	defer b.Close()
+	var recorded bytes.Buffer
+	rec := &recorder{ReadWriter: a, w: &recorded}
+
+	dc, ac := connect(t, rec, b, ids[1], ids[0], dir)
+	recorded.Reset()
+
+	go func() {
+		require.NoError(t, dc.Send([]byte("once")))
+	}()
+	_, err := ac.Receive()
+	require.NoError(t, err)
+
+	replay := append([]byte{}, recorded.Bytes()...)
+	go func() {
+		_, _ = a.Write(replay)
+	}()
+	_, err = ac.Receive()
+	require.Error(t, err)
+}
+
+func TestConnRejectsReplayedHandshake(t *testing.T) {
+	ids, dir := newIdentities(t, 2)
+
+	// Record everything the dialer writes during an honest handshake
+	a, b := net.Pipe()
+	defer a.Close()
+	defer b.Close()
+	var recorded bytes.Buffer
+	rec := &recorder{ReadWriter: a, w: &recorded}
+	connect(t, rec, b, ids[1], ids[0], dir)
+
+	// Someone without the key of party 1 plays the recording back to the acceptor
+	replay := &replayer{r: bytes.NewReader(recorded.Bytes())}
+	_, err := Accept(replay, ids[0], dir)
+	require.Error(t, err)
+}
+
+func TestIdentityFiles(t *testing.T) {
+	ids, dir := newIdentities(t, 3)
+	tmp := t.TempDir()
+
+	path := filepath.Join(tmp, "party1.json")
+	require.NoError(t, SaveIdentity(path, ids[1]))
+	loaded, err := LoadIdentity(path)
+	require.NoError(t, err)
+	require.Equal(t, 1, loaded.ReturnID())
+	require.True(t, loaded.sign.Equal(ids[1].sign))
+	require.True(t, loaded.box.Equal(ids[1].box))
+
+	dirPath := filepath.Join(tmp, "directory.json")
+	require.NoError(t, SaveDirectory(dirPath, dir))
+	loadedDir, err := LoadDirectory(dirPath)
+	require.NoError(t, err)
+	require.Len(t, loadedDir, 3)
+	for i := range dir {
+		require.True(t, dir[i].SigningKey.Equal(loadedDir[i].SigningKey))
+		require.True(t, dir[i].BoxKey.Equal(loadedDir[i].BoxKey))
+	}
+
+	// The loaded keys still talk to each other
+	a, b := net.Pipe()
+	defer a.Close()
+	defer b.Close()
+	dc, ac := connect(t, a, b, loaded, ids[0], loadedDir)
+	exchange(t, dc, ac)
+
+	var broken Directory
+	require.Error(t, broken.UnmarshalJSON([]byte(`[{"id":1,"signing_key":"00","box_key":"00"}]`)))
+}
+
+// recorder copies everything written to the stream into w.
+type recorder struct {
+	io.ReadWriter
+	w io.Writer
+}
+
+func (r *recorder) Write(p []byte) (int, error) {
+	r.w.Write(p)
+	return r.ReadWriter.Write(p)
+}
+
+// replayer reads a recorded stream and discards whatever is written to it.
+type replayer struct {
+	r io.Reader
+}
+
+func (r *replayer) Read(p []byte) (int, error) {
+	return r.r.Read(p)
+}
+
+func (r *replayer) Write(p []byte) (int, error) {
+	return len(p), nil
+}
+
+func selfSignedCertificate(t *testing.T) (tls.Certificate, *x509.CertPool) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "BingoVSS test"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		IsCA:         true,
+
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	leaf, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	pool := x509.NewCertPool()
+	pool.AddCert(leaf)
+
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: leaf}, pool
+}
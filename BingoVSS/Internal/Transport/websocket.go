@@ -0,0 +1,48 @@
+package transport
+
+import (
+	"io"
+
+	"github.com/gorilla/websocket"
+)
+
+// wsStream presents a websocket connection as a stream: every Write is sent as one binary
+// message and Read continues with the next message once the current one is consumed.
+type wsStream struct {
+	conn *websocket.Conn
+	r    io.Reader
+}
+
+/* This function adapts a websocket connection, so it can carry a Conn */
+func NewWebSocketStream(conn *websocket.Conn) io.ReadWriter {
+	return &wsStream{conn: conn}
+}
+
+func (s *wsStream) Read(p []byte) (int, error) {
+	for {
+		if s.r == nil {
+			_, r, err := s.conn.NextReader()
+			if err != nil {
+				return 0, err
+			}
+			s.r = r
+		}
+
+		n, err := s.r.Read(p)
+		if err == io.EOF {
+			s.r = nil
+			if n == 0 {
+				continue
+			}
+			err = nil
+		}
+		return n, err
+	}
+}
+
+func (s *wsStream) Write(p []byte) (int, error) {
+	if err := s.conn.WriteMessage(websocket.BinaryMessage, p); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
@@ -0,0 +1,29 @@
+package refresh
+
+import (
+	vss "BingoVSS/Bingo"
+	"BingoVSS/Internal/Agreement"
+)
+
+// The refresh messages travel in vss.Envelopes, with types after those of the ADKG.
+const (
+	MsgDealing   vss.MessageType = iota + 80 // a BingoShare message of the sharing of zero of Dealer
+	MsgAgreement                             // a step of the agreement on the sharing of Dealer
+)
+
+// DealingMessage carries a message of the BingoShare instance in which Dealer deals its sharing of zero for Epoch.
+type DealingMessage struct {
+	Epoch  int
+	Dealer int
+	Msg    vss.Message
+}
+
+// AgreementMessage carries a message of the agreement on whether the sharing of Dealer is used in Epoch.
+type AgreementMessage struct {
+	Epoch  int
+	Dealer int
+	Msg    agreement.Message
+}
+
+func (m *DealingMessage) Type() vss.MessageType   { return MsgDealing }
+func (m *AgreementMessage) Type() vss.MessageType { return MsgAgreement }
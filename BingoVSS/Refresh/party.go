@@ -0,0 +1,365 @@
+package refresh
+
+import (
+	vss "BingoVSS/Bingo"
+	"BingoVSS/Internal/Agreement"
+	kzg "BingoVSS/Internal/Biv_KZG"
+	"fmt"
+	"reflect"
+
+	"github.com/drand/kyber"
+)
+
+// State is the progress of a party in a refresh.
+type State int
+
+const (
+	StateDealing   State = iota // the sharings of zero and the agreements on them are running
+	StateAgreed                 // the set of sharings is agreed on, waiting for their rows
+	StateRefreshed              // holds the row and the commitments of the next epoch
+	StateErased                 // the refresh is over and its state is gone
+)
+
+func (s State) String() string {
+	switch s {
+	case StateDealing:
+		return "dealing"
+	case StateAgreed:
+		return "agreed"
+	case StateRefreshed:
+		return "refreshed"
+	case StateErased:
+		return "erased"
+	}
+	return "unknown"
+}
+
+/*
+Party is one participant in the refresh of a Bingo dealing from one epoch to the next. Every
+party i holds its row φ(X, i) of the current epoch and the row commitments cm[0..n]. For party i:
+
+ 1. deal a random sharing of zero ψ_i with BingoShare, ψ_i(X, 0) = ψ'_i(X, 0) = 0, so that the
+    commitment of its row 0 is the identity
+ 2. agree on the sharings to use with a subset agreement. A party votes for a sharing once its
+    BingoShare terminated and its row 0 commitment is the identity
+ 3. once it holds its row of every agreed sharing S, the row of the next epoch is
+    φ(X, i) + Σ_{d∈S} ψ_d(X, i), and the commitments are cm[j] + Σ_{d∈S} cm_d[j]
+
+The new rows keep every packed secret φ(-k, 0), since ψ_d(X, 0) = 0, but the rows of the two
+epochs lie on different polynomials: old rows do not match the new commitments, and f old rows
+together with new ones do not interpolate to the secrets. The state of the refresh holds the rows
+ψ_d(X, i), which would turn old rows into new ones, so it must be dropped with Erase once the
+other parties no longer need the messages of the party.
+*/
+type Party struct {
+	id     int
+	epoch  int
+	params vss.Params
+	suite  *vss.Suite
+	setup  *kzg.KzgSetup
+	sh     *kzg.KzgShareSetup
+
+	row    []kyber.Scalar // φ(X, i) of the current epoch, of the next once refreshed
+	hiding []kyber.Scalar
+	cm     []kyber.Point
+
+	started  bool
+	dealings []*vss.Node // dealings[d] is our part in the sharing of zero of d
+	subset   *agreement.Subset
+	accepted []bool // accepted[d] is set once the sharing of d was checked
+	invalid  []int  // dealers whose sharing does not have a zero row
+	set      []int
+	state    State
+}
+
+/* This function constructs the party with index id in 1..n for the refresh from epoch to epoch+1; coin is its key for the coins of the agreements */
+func NewParty(id, epoch int, params vss.Params, suite *vss.Suite, setup *kzg.KzgSetup, row, hiding []kyber.Scalar, cm []kyber.Point, coin *agreement.CoinKey) (*Party, error) {
+	if err := params.Validate(); err != nil {
+		return nil, err
+	}
+	if id < 1 || id > params.N {
+		return nil, fmt.Errorf("refresh: party index %d outside 1..%d", id, params.N)
+	}
+	if coin == nil || coin.ReturnSelf() != id {
+		return nil, fmt.Errorf("refresh: no coin key for party %d", id)
+	}
+	if len(cm) != params.N+1 {
+		return nil, fmt.Errorf("refresh: %d row commitments, %d are needed", len(cm), params.N+1)
+	}
+	if len(row) != params.D_1+1 || len(hiding) != params.D_1+1 {
+		return nil, fmt.Errorf("refresh: the row of %d does not have degree %d", id, params.D_1)
+	}
+
+	sh := kzg.NewShareSetup(setup.ReturnT_1(), setup.ReturnT_2(), setup.ReturnT_u(), suite.ReturnSuite(), setup.ReturnG_u(), setup.ReturnG_1())
+	if !kzg.KZGCommits(sh, row, hiding).Equal(cm[id]) {
+		return nil, fmt.Errorf("refresh: the row of %d does not match its commitment", id)
+	}
+
+	parties := make([]int, params.N)
+	for j := range parties {
+		parties[j] = j + 1
+	}
+	subset, err := agreement.NewSubset(id, parties, params.F, func(d int) agreement.Coin {
+		return coin.Coin(fmt.Sprintf("refresh/%d/%d", epoch, d))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	p := &Party{
+		id:       id,
+		epoch:    epoch,
+		params:   params,
+		suite:    suite,
+		setup:    setup,
+		sh:       sh,
+		row:      row,
+		hiding:   hiding,
+		cm:       cm,
+		dealings: make([]*vss.Node, params.N+1),
+		subset:   subset,
+		accepted: make([]bool, params.N+1),
+	}
+	for d := 1; d <= params.N; d++ {
+		nd, err := vss.NewNode(id, params, suite, setup)
+		if err != nil {
+			return nil, err
+		}
+		p.dealings[d] = nd
+	}
+	return p, nil
+}
+
+func (p *Party) ReturnID() int {
+	return p.id
+}
+
+// ReturnEpoch returns the epoch the refresh starts from.
+func (p *Party) ReturnEpoch() int {
+	return p.epoch
+}
+
+func (p *Party) ReturnState() State {
+	return p.state
+}
+
+// ReturnSet returns the dealers whose sharings of zero are used, or nil before they are agreed on.
+func (p *Party) ReturnSet() []int {
+	return p.set
+}
+
+// ReturnRow returns the row φ(X, i) and the hiding row φ'(X, i) of the party: those of the next
+// epoch once refreshed, of the current one before.
+func (p *Party) ReturnRow() ([]kyber.Scalar, []kyber.Scalar) {
+	return p.row, p.hiding
+}
+
+// ReturnCommitments returns the row commitments that match ReturnRow.
+func (p *Party) ReturnCommitments() []kyber.Point {
+	return p.cm
+}
+
+// ReturnInvalid returns the dealers whose sharing terminated without a zero row.
+func (p *Party) ReturnInvalid() []int {
+	return p.invalid
+}
+
+// Start deals the sharing of zero of the party and returns the messages to send.
+func (p *Party) Start() ([]vss.Envelope, error) {
+	if p.started {
+		return nil, fmt.Errorf("refresh: party %d already started", p.id)
+	}
+	if p.state == StateErased {
+		return nil, fmt.Errorf("refresh: party %d erased its refresh", p.id)
+	}
+	p.started = true
+
+	msgs, err := vss.NewDealerWithSuite(p.suite).DealZeroMessages(p.params, p.setup)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]vss.Envelope, 0, len(msgs))
+	for _, env := range msgs {
+		out = append(out, vss.Envelope{From: p.id, To: env.To, Msg: &DealingMessage{Epoch: p.epoch, Dealer: p.id, Msg: env.Msg}})
+	}
+	return p.route(out, nil)
+}
+
+// Handle processes one inbound message and returns the messages the party sends in response. As
+// with vss.Node, an error means that something in the message was invalid and has been dropped,
+// and it may come together with messages to send. Once erased, the party ignores all messages.
+func (p *Party) Handle(env vss.Envelope) ([]vss.Envelope, error) {
+	if env.To != p.id {
+		return nil, fmt.Errorf("refresh: message for %d delivered to %d", env.To, p.id)
+	}
+	if env.Msg == nil || reflect.ValueOf(env.Msg).IsNil() {
+		return nil, fmt.Errorf("refresh: empty message from %d", env.From)
+	}
+	if env.From < 1 || env.From > p.params.N {
+		return nil, fmt.Errorf("refresh: unknown party %d", env.From)
+	}
+	if p.state == StateErased {
+		return nil, nil
+	}
+
+	out, err := p.handle(env)
+	return p.route(out, err)
+}
+
+// Erase drops the rows of the sharings of zero and the agreements. Call it once the refresh is
+// over for every party, since the party stops answering messages.
+func (p *Party) Erase() error {
+	if p.state != StateRefreshed {
+		return fmt.Errorf("refresh: party %d is not refreshed", p.id)
+	}
+	p.dealings, p.subset = nil, nil
+	p.state = StateErased
+	return nil
+}
+
+// route handles the messages of the party to itself right away and returns the others.
+func (p *Party) route(msgs []vss.Envelope, err error) ([]vss.Envelope, error) {
+	var out []vss.Envelope
+	for len(msgs) > 0 {
+		env := msgs[0]
+		msgs = msgs[1:]
+		if env.To != p.id {
+			out = append(out, env)
+			continue
+		}
+		next, _ := p.handle(env)
+		msgs = append(msgs, next...)
+	}
+	return out, err
+}
+
+func (p *Party) handle(env vss.Envelope) ([]vss.Envelope, error) {
+	var out []vss.Envelope
+	var err error
+
+	switch m := env.Msg.(type) {
+	case *DealingMessage:
+		if m.Epoch != p.epoch {
+			return nil, fmt.Errorf("refresh: message of epoch %d from %d in epoch %d", m.Epoch, env.From, p.epoch)
+		}
+		if m.Dealer < 1 || m.Dealer > p.params.N || m.Msg == nil || reflect.ValueOf(m.Msg).IsNil() {
+			return nil, fmt.Errorf("refresh: invalid dealing message from %d", env.From)
+		}
+		// Only the dealer sends the commitment and the rows of its own sharing
+		from := env.From
+		if from == m.Dealer && (m.Msg.Type() == vss.MsgCommitment || m.Msg.Type() == vss.MsgPolynomial) {
+			from = vss.DealerID
+		}
+		var next []vss.Envelope
+		next, err = p.dealings[m.Dealer].Handle(vss.Envelope{From: from, To: p.id, Msg: m.Msg})
+		for _, n := range next {
+			out = append(out, vss.Envelope{From: p.id, To: n.To, Msg: &DealingMessage{Epoch: p.epoch, Dealer: m.Dealer, Msg: n.Msg}})
+		}
+
+	case *AgreementMessage:
+		if m.Epoch != p.epoch {
+			return nil, fmt.Errorf("refresh: message of epoch %d from %d in epoch %d", m.Epoch, env.From, p.epoch)
+		}
+		var next []agreement.SubsetEnvelope
+		next, err = p.subset.Handle(env.From, m.Dealer, m.Msg)
+		out = p.agreementMessages(next)
+
+	default:
+		return nil, fmt.Errorf("refresh: unexpected %s message from %d", env.Msg.Type(), env.From)
+	}
+
+	next, progressErr := p.progress()
+	if err == nil {
+		err = progressErr
+	}
+	return append(out, next...), err
+}
+
+// progress moves the party on as far as the state of the sharings and agreements allows.
+func (p *Party) progress() ([]vss.Envelope, error) {
+	var out []vss.Envelope
+
+	// Step 2: vote for the sharings of zero that terminated
+	for d := 1; d <= p.params.N; d++ {
+		if p.accepted[d] || p.dealings[d].ReturnState() != vss.StateTerminated {
+			continue
+		}
+		p.accepted[d] = true
+		if !p.dealings[d].ReturnCommitments()[0].Equal(p.suite.ReturnSuite().G1().Point().Null()) {
+			p.invalid = append(p.invalid, d)
+			continue
+		}
+		next, err := p.subset.Accept(d)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, p.agreementMessages(next)...)
+	}
+	if p.set = p.subset.Set(); p.set == nil {
+		return out, nil
+	}
+	if p.state == StateDealing {
+		p.state = StateAgreed
+	}
+
+	// Step 3: add the rows of the agreed sharings
+	if p.state == StateAgreed {
+		for _, d := range p.set {
+			if p.dealings[d].ReturnRow() == nil {
+				return out, nil
+			}
+		}
+		p.apply()
+	}
+	return out, nil
+}
+
+// apply adds the rows and the commitments of the agreed sharings to those of the party.
+func (p *Party) apply() {
+	row := make([]kyber.Scalar, len(p.row))
+	hiding := make([]kyber.Scalar, len(p.hiding))
+	for c := range row {
+		row[c], hiding[c] = p.row[c].Clone(), p.hiding[c].Clone()
+	}
+	cm := make([]kyber.Point, len(p.cm))
+	for j := range cm {
+		cm[j] = p.cm[j].Clone()
+	}
+
+	for _, d := range p.set {
+		r := p.dealings[d].ReturnRow()
+		for c := range row {
+			row[c].Add(row[c], r.Coefficients()[c])
+			hiding[c].Add(hiding[c], r.Coefficients_2()[c])
+		}
+		for j, c := range p.dealings[d].ReturnCommitments() {
+			cm[j].Add(cm[j], c)
+		}
+	}
+
+	p.row, p.hiding, p.cm = row, hiding, cm
+	p.state = StateRefreshed
+}
+
+func (p *Party) agreementMessages(msgs []agreement.SubsetEnvelope) []vss.Envelope {
+	out := make([]vss.Envelope, 0, len(msgs))
+	for _, env := range msgs {
+		out = append(out, vss.Envelope{From: p.id, To: env.To, Msg: &AgreementMessage{Epoch: p.epoch, Dealer: env.Proposer, Msg: env.Msg}})
+	}
+	return out
+}
+
+// Incomplete returns nil once the party is refreshed, and otherwise what it still waits for.
+func (p *Party) Incomplete() error {
+	switch p.state {
+	case StateRefreshed, StateErased:
+		return nil
+	case StateAgreed:
+		for _, d := range p.set {
+			if err := p.dealings[d].Incomplete(); p.dealings[d].ReturnRow() == nil {
+				return fmt.Errorf("refresh: waiting for the row of the sharing of %d: %v", d, err)
+			}
+		}
+	}
+	return fmt.Errorf("refresh: waiting for the agreements: %d of %d decided", p.subset.Decisions(), p.params.N)
+}
@@ -0,0 +1,244 @@
+package refresh
+
+import (
+	vss "BingoVSS/Bingo"
+	"BingoVSS/Internal/Agreement"
+	kzg "BingoVSS/Internal/Biv_KZG"
+	"BingoVSS/Internal/Testnet"
+	"testing"
+
+	"github.com/drand/kyber"
+	"github.com/stretchr/testify/require"
+)
+
+// epoch is a Bingo dealing of f+1 secrets, as held by the parties after its BingoShare terminated.
+type epoch struct {
+	g       *vss.Suite
+	params  vss.Params
+	setup   *kzg.KzgSetup
+	sh      *kzg.KzgShareSetup
+	secrets []kyber.Scalar
+	rows    [][]kyber.Scalar // rows[i] is φ(X, i)
+	hiding  [][]kyber.Scalar
+	cm      []kyber.Point
+	coins   map[int]*agreement.CoinKey
+}
+
+func deal(t *testing.T, g *vss.Suite, params vss.Params) *epoch {
+	setup, err := kzg.NewKzgSetup(params.D_1+1, g.ReturnSuite())
+	require.NoError(t, err)
+	e := &epoch{
+		g:      g,
+		params: params,
+		setup:  setup,
+		sh:     kzg.NewShareSetup(setup.ReturnT_1(), setup.ReturnT_2(), setup.ReturnT_u(), g.ReturnSuite(), setup.ReturnG_u(), setup.ReturnG_1()),
+		rows:   make([][]kyber.Scalar, params.N+1),
+		hiding: make([][]kyber.Scalar, params.N+1),
+	}
+
+	secrets := make([]vss.Secret, params.F+1)
+	for k := range secrets {
+		secrets[k] = *vss.NewSecret(k, *g)
+		e.secrets = append(e.secrets, secrets[k].SendSecret())
+	}
+	queue, err := vss.NewDealerWithSuite(g).DealMessages(secrets, params, setup)
+	require.NoError(t, err)
+
+	nodes := make([]*vss.Node, params.N+1)
+	for i := 1; i <= params.N; i++ {
+		nodes[i], err = vss.NewNode(i, params, g, setup)
+		require.NoError(t, err)
+	}
+	for len(queue) > 0 {
+		env := queue[0]
+		queue = queue[1:]
+		out, err := nodes[env.To].Handle(env)
+		require.NoError(t, err)
+		queue = append(queue, out...)
+	}
+	for i := 1; i <= params.N; i++ {
+		require.Equal(t, vss.StateTerminated, nodes[i].ReturnState())
+		e.rows[i], e.hiding[i] = nodes[i].ReturnRow().Coefficients(), nodes[i].ReturnRow().Coefficients_2()
+	}
+	e.cm = nodes[1].ReturnCommitments()
+
+	ids := make([]int, params.N)
+	for i := range ids {
+		ids[i] = i + 1
+	}
+	e.coins, err = agreement.DealCoinKeys(g.ReturnSuite(), ids, params.F+1, g.ReturnSuite().RandomStream())
+	require.NoError(t, err)
+	return e
+}
+
+func (e *epoch) newParties(t *testing.T, number int) []*Party {
+	parties := make([]*Party, e.params.N+1)
+	for i := 1; i <= e.params.N; i++ {
+		p, err := NewParty(i, number, e.params, e.g, e.setup, e.rows[i], e.hiding[i], e.cm, e.coins[i])
+		require.NoError(t, err)
+		parties[i] = p
+	}
+	return parties
+}
+
+// checkRefresh checks that the honest parties agree on the set and the new commitments, that
+// their new rows match them, and that the new rows still hold the secrets of the dealing.
+func checkRefresh(t *testing.T, e *epoch, parties []*Party, honest []int) {
+	s := e.g.ReturnSuite()
+	first := parties[honest[0]]
+	require.GreaterOrEqual(t, len(first.ReturnSet()), e.params.N-e.params.F)
+
+	var rows [][]kyber.Scalar
+	for _, i := range honest {
+		p := parties[i]
+		require.NoError(t, p.Incomplete())
+		require.Equal(t, StateRefreshed, p.ReturnState())
+		require.Equal(t, first.ReturnSet(), p.ReturnSet())
+		for j, c := range p.ReturnCommitments() {
+			require.True(t, first.ReturnCommitments()[j].Equal(c))
+		}
+		require.True(t, first.ReturnCommitments()[0].Equal(e.cm[0]))
+
+		row, hiding := p.ReturnRow()
+		require.True(t, kzg.KZGCommits(e.sh, row, hiding).Equal(p.ReturnCommitments()[i]))
+		require.False(t, kzg.KZGCommits(e.sh, e.rows[i], e.hiding[i]).Equal(p.ReturnCommitments()[i]))
+		rows = append(rows, row)
+	}
+
+	got, err := vss.RecoverSecrets(s, honest[:e.params.F+1], rows[:e.params.F+1], len(e.secrets))
+	require.NoError(t, err)
+	for k := range e.secrets {
+		require.True(t, e.secrets[k].Equal(got[k]), "secret %d", k)
+	}
+}
+
+func TestRefreshHonest(t *testing.T) {
+	g := vss.NewSuite()
+	params := vss.NewParams(4, 1)
+	e := deal(t, g, params)
+	for seed := int64(0); seed < 3; seed++ {
+		parties := e.newParties(t, 0)
+		require.NoError(t, testnet.Run(parties, nil, nil, seed))
+		checkRefresh(t, e, parties, []int{1, 2, 3, 4})
+		require.Empty(t, parties[1].ReturnInvalid())
+	}
+}
+
+func TestRefreshOldRowsAreUseless(t *testing.T) {
+	g := vss.NewSuite()
+	params := vss.NewParams(7, 2)
+	e := deal(t, g, params)
+	parties := e.newParties(t, 3)
+	require.NoError(t, testnet.Run(parties, nil, nil, 4))
+	checkRefresh(t, e, parties, []int{1, 2, 3, 4, 5, 6, 7})
+
+	// An old row does not start the next refresh, and f old rows with one new row do not give
+	// the secrets
+	cm := parties[1].ReturnCommitments()
+	_, err := NewParty(1, 4, params, g, e.setup, e.rows[1], e.hiding[1], cm, e.coins[1])
+	require.Error(t, err)
+
+	row, _ := parties[3].ReturnRow()
+	got, err := vss.RecoverSecrets(g.ReturnSuite(), []int{1, 2, 3}, [][]kyber.Scalar{e.rows[1], e.rows[2], row}, len(e.secrets))
+	require.NoError(t, err)
+	for k := range e.secrets {
+		require.False(t, e.secrets[k].Equal(got[k]), "secret %d", k)
+	}
+
+	// The next epoch refreshes the new rows
+	next := make([]*Party, params.N+1)
+	for i := 1; i <= params.N; i++ {
+		row, hiding := parties[i].ReturnRow()
+		next[i], err = NewParty(i, 4, params, g, e.setup, row, hiding, cm, e.coins[i])
+		require.NoError(t, err)
+	}
+	require.NoError(t, testnet.Run(next, nil, nil, 5))
+	checkRefresh(t, e, next, []int{1, 2, 3, 4, 5, 6, 7})
+}
+
+func TestRefreshCrashedParties(t *testing.T) {
+	g := vss.NewSuite()
+	params := vss.NewParams(7, 2)
+	e := deal(t, g, params)
+	parties := e.newParties(t, 0)
+	require.NoError(t, testnet.Run(parties, map[int]bool{2: true, 6: true}, nil, 1))
+	checkRefresh(t, e, parties, []int{1, 3, 4, 5, 7})
+
+	for _, d := range parties[1].ReturnSet() {
+		require.NotContains(t, []int{2, 6}, d)
+	}
+}
+
+func TestRefreshNonZeroDealing(t *testing.T) {
+	g := vss.NewSuite()
+	params := vss.NewParams(4, 1)
+	e := deal(t, g, params)
+	parties := e.newParties(t, 0)
+
+	// Party 3 deals a sharing of random secrets instead of zero to the others
+	secrets := []vss.Secret{*vss.NewSecret(0, *g), *vss.NewSecret(1, *g)}
+	bad, err := vss.NewDealerWithSuite(g).DealMessages(secrets, params, e.setup)
+	require.NoError(t, err)
+	tamper := func(env vss.Envelope) vss.Envelope {
+		m, ok := env.Msg.(*DealingMessage)
+		if !ok || env.From != 3 || m.Dealer != 3 {
+			return env
+		}
+		for _, b := range bad {
+			if b.To == env.To && b.Msg.Type() == m.Msg.Type() {
+				env.Msg = &DealingMessage{Epoch: m.Epoch, Dealer: 3, Msg: b.Msg}
+			}
+		}
+		return env
+	}
+	require.NoError(t, testnet.Run(parties, nil, tamper, 2))
+	checkRefresh(t, e, parties, []int{1, 2, 4})
+
+	for _, i := range []int{1, 2, 4} {
+		require.Equal(t, []int{3}, parties[i].ReturnInvalid())
+		require.NotContains(t, parties[i].ReturnSet(), 3)
+	}
+}
+
+func TestRefreshRejectsInvalidMessages(t *testing.T) {
+	g := vss.NewSuite()
+	params := vss.NewParams(4, 1)
+	e := deal(t, g, params)
+	parties := e.newParties(t, 1)
+	p := parties[1]
+
+	_, err := NewParty(5, 1, params, g, e.setup, e.rows[1], e.hiding[1], e.cm, e.coins[5])
+	require.Error(t, err)
+	_, err = NewParty(1, 1, params, g, e.setup, e.rows[2], e.hiding[2], e.cm, e.coins[1])
+	require.Error(t, err)
+	_, err = NewParty(1, 1, params, g, e.setup, e.rows[1], e.hiding[1], e.cm[1:], e.coins[1])
+	require.Error(t, err)
+	_, err = NewParty(1, 1, params, g, e.setup, e.rows[1], e.hiding[1], e.cm, e.coins[2])
+	require.Error(t, err)
+
+	_, err = p.Handle(vss.Envelope{From: 2, To: 1, Msg: &AgreementMessage{Epoch: 0, Dealer: 2}})
+	require.ErrorContains(t, err, "epoch")
+	_, err = p.Handle(vss.Envelope{From: 2, To: 1, Msg: &DealingMessage{Epoch: 1, Dealer: 5}})
+	require.Error(t, err)
+	_, err = p.Handle(vss.Envelope{From: 2, To: 3, Msg: &DealingMessage{}})
+	require.Error(t, err)
+	_, err = p.Handle(vss.Envelope{From: 9, To: 1, Msg: &DealingMessage{}})
+	require.Error(t, err)
+	_, err = p.Handle(vss.Envelope{From: 2, To: 1, Msg: (*DealingMessage)(nil)})
+	require.Error(t, err)
+	require.Error(t, p.Erase())
+
+	// Once erased, the party keeps its new row and ignores the refresh
+	require.NoError(t, testnet.Run(parties, nil, nil, 3))
+	checkRefresh(t, e, parties, []int{1, 2, 3, 4})
+	row, _ := p.ReturnRow()
+	require.NoError(t, p.Erase())
+	require.Equal(t, StateErased, p.ReturnState())
+	after, _ := p.ReturnRow()
+	require.Equal(t, row, after)
+	out, err := p.Handle(vss.Envelope{From: 2, To: 1, Msg: &AgreementMessage{Epoch: 1, Dealer: 2}})
+	require.NoError(t, err)
+	require.Nil(t, out)
+	_, err = p.Start()
+	require.Error(t, err)
+}
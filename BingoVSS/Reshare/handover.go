@@ -0,0 +1,158 @@
+package reshare
+
+import (
+	vss "BingoVSS/Bingo"
+	kzg "BingoVSS/Internal/Biv_KZG"
+	"BingoVSS/Internal/Polynomial"
+	"fmt"
+
+	"github.com/drand/kyber"
+)
+
+/*
+Handover describes the resharing of a Bingo dealing from an old committee to a new one. The old
+parties 1..Old.N hold the rows φ(X, i) of the dealing with the row commitments cm[0..Old.N], and
+the new parties 1..New.N end up with the rows of a new dealing of the same secrets φ(-k, 0),
+k = 0..Secrets-1.
+*/
+type Handover struct {
+	Epoch       int
+	Old         vss.Params
+	New         vss.Params
+	Secrets     int
+	Commitments []kyber.Point // cm[0..Old.N] of the old dealing
+}
+
+// Validate checks that the secrets of the old dealing fit into a dealing of the new committee.
+func (h Handover) Validate() error {
+	if err := h.Old.Validate(); err != nil {
+		return err
+	}
+	if err := h.New.Validate(); err != nil {
+		return err
+	}
+	if len(h.Commitments) != h.Old.N+1 {
+		return fmt.Errorf("reshare: %d row commitments, %d are needed", len(h.Commitments), h.Old.N+1)
+	}
+	if h.Secrets < 1 || h.Secrets > h.Old.D_1+1 {
+		return fmt.Errorf("reshare: %d secrets in a dealing of degree %d", h.Secrets, h.Old.D_1)
+	}
+	// f new parties see f points of every row at Y = 0, which leaves D_1+1-f of them free
+	if h.Secrets > h.New.D_1-h.New.F+1 {
+		return fmt.Errorf("reshare: %d secrets do not stay hidden from %d new parties, at most %d do", h.Secrets, h.New.F, h.New.D_1-h.New.F+1)
+	}
+	return nil
+}
+
+// checkSetup checks that the setup commits to the rows of both committees and proves the secrets.
+func (h Handover) checkSetup(setup *kzg.KzgSetup) error {
+	d := h.Old.D_1
+	if h.New.D_1 > d {
+		d = h.New.D_1
+	}
+	if len(setup.ReturnT_1()) < d+1 || len(setup.ReturnT_2()) < h.Secrets+1 {
+		return fmt.Errorf("reshare: the setup supports degree %d, %d is needed", len(setup.ReturnT_1())-1, d)
+	}
+	return nil
+}
+
+// points returns the points -k of the secrets, k = 0..Secrets-1.
+func (h Handover) points(g *vss.Suite) []kyber.Scalar {
+	z := make([]kyber.Scalar, h.Secrets)
+	for k := range z {
+		z[k] = g.ReturnSuite().G1().Scalar().SetInt64(int64(-k))
+	}
+	return z
+}
+
+/*
+This function deals the sub-sharing of the old party id, which holds the row φ(X, id) and the
+hiding row φ'(X, id) of the old dealing, to the new committee. The sub-sharing ψ_id is a Bingo
+dealing over the new parameters whose row at Y = 0 agrees with the old row on the secrets:
+ψ_id(-k, 0) = φ(-k, id) and ψ'_id(-k, 0) = φ'(-k, id), and is random everywhere else. Along
+with its messages, every new party gets a vanishing proof that ψ_id(X, 0) - φ(X, id) is zero on
+every -k, which it checks against cm[id] and the commitment of row 0 of the sub-sharing.
+
+The messages come from vss.DealerID with Old set to id. The transport must authenticate the old
+party on the channel and set Old itself, as it sets From; a new party only takes the messages of
+old party id for the sub-sharing of id.
+*/
+func Deal(h Handover, id int, suite *vss.Suite, setup *kzg.KzgSetup, row, hiding []kyber.Scalar) ([]vss.Envelope, error) {
+	if err := h.Validate(); err != nil {
+		return nil, err
+	}
+	if err := h.checkSetup(setup); err != nil {
+		return nil, err
+	}
+	if id < 1 || id > h.Old.N {
+		return nil, fmt.Errorf("reshare: old party index %d outside 1..%d", id, h.Old.N)
+	}
+	if len(row) != h.Old.D_1+1 || len(hiding) != h.Old.D_1+1 {
+		return nil, fmt.Errorf("reshare: the row of %d does not have degree %d", id, h.Old.D_1)
+	}
+	g := suite.ReturnSuite()
+	sh := kzg.NewShareSetup(setup.ReturnT_1(), setup.ReturnT_2(), setup.ReturnT_u(), g, setup.ReturnG_u(), setup.ReturnG_1())
+	if !kzg.KZGCommits(sh, row, hiding).Equal(h.Commitments[id]) {
+		return nil, fmt.Errorf("reshare: the row of %d does not match its commitment", id)
+	}
+
+	z := h.points(suite)
+	psi, err := extend(suite, z, row, h.New.D_1)
+	if err != nil {
+		return nil, err
+	}
+	psiHiding, err := extend(suite, z, hiding, h.New.D_1)
+	if err != nil {
+		return nil, err
+	}
+	proof, err := kzg.KZGVanishingProof(sh, sub(suite, psi, row), sub(suite, psiHiding, hiding), z)
+	if err != nil {
+		return nil, err
+	}
+
+	msgs, err := vss.NewDealerWithSuite(suite).DealRowMessages(psi, psiHiding, h.New, setup)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]vss.Envelope, 0, len(msgs)+h.New.N)
+	for _, env := range msgs {
+		out = append(out, vss.Envelope{From: vss.DealerID, To: env.To, Old: id, Msg: &DealingMessage{Epoch: h.Epoch, Dealer: id, Msg: env.Msg}})
+	}
+	for j := 1; j <= h.New.N; j++ {
+		out = append(out, vss.Envelope{From: vss.DealerID, To: j, Old: id, Msg: &ProofMessage{Epoch: h.Epoch, Dealer: id, Proof: proof}})
+	}
+	return out, nil
+}
+
+// extend returns a random polynomial of degree d that agrees with f on every point of z. It
+// interpolates the values of f on z together with random values at 1..d+1-len(z).
+func extend(g *vss.Suite, z, f []kyber.Scalar, d int) ([]kyber.Scalar, error) {
+	s := g.ReturnSuite()
+	x := append([]kyber.Scalar{}, z...)
+	y := polynomial.EvaluateMany(s, f, z)
+	for k := 1; len(x) < d+1; k++ {
+		x = append(x, s.G1().Scalar().SetInt64(int64(k)))
+		y = append(y, s.G1().Scalar().Pick(s.RandomStream()))
+	}
+	return polynomial.Interpolate(s, x, y)
+}
+
+// sub returns the coefficients of a - b.
+func sub(g *vss.Suite, a, b []kyber.Scalar) []kyber.Scalar {
+	s := g.ReturnSuite()
+	n := len(a)
+	if len(b) > n {
+		n = len(b)
+	}
+	out := make([]kyber.Scalar, n)
+	for c := range out {
+		out[c] = s.G1().Scalar().Zero()
+		if c < len(a) {
+			out[c].Add(out[c], a[c])
+		}
+		if c < len(b) {
+			out[c].Sub(out[c], b[c])
+		}
+	}
+	return out
+}
@@ -0,0 +1,40 @@
+package reshare
+
+import (
+	vss "BingoVSS/Bingo"
+	"BingoVSS/Internal/Agreement"
+
+	"github.com/drand/kyber"
+)
+
+// The resharing messages travel in vss.Envelopes, with types after those of the refresh.
+const (
+	MsgDealing   vss.MessageType = iota + 90 // a BingoShare message of the sub-sharing of old party Dealer
+	MsgProof                                 // the proof that the sub-sharing of Dealer holds its old row
+	MsgAgreement                             // a step of the agreement on the sub-sharing of Dealer
+)
+
+// DealingMessage carries a message of the BingoShare instance in which old party Dealer deals its sub-sharing for Epoch.
+type DealingMessage struct {
+	Epoch  int
+	Dealer int
+	Msg    vss.Message
+}
+
+// ProofMessage carries the vanishing proof of the sub-sharing of old party Dealer, see Deal.
+type ProofMessage struct {
+	Epoch  int
+	Dealer int
+	Proof  kyber.Point
+}
+
+// AgreementMessage carries a message of the agreement on whether the sub-sharing of Dealer is used in Epoch.
+type AgreementMessage struct {
+	Epoch  int
+	Dealer int
+	Msg    agreement.Message
+}
+
+func (m *DealingMessage) Type() vss.MessageType   { return MsgDealing }
+func (m *ProofMessage) Type() vss.MessageType     { return MsgProof }
+func (m *AgreementMessage) Type() vss.MessageType { return MsgAgreement }
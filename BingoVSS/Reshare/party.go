@@ -0,0 +1,378 @@
+package reshare
+
+import (
+	vss "BingoVSS/Bingo"
+	"BingoVSS/Internal/Agreement"
+	kzg "BingoVSS/Internal/Biv_KZG"
+	"BingoVSS/Internal/Polynomial"
+	"fmt"
+	"reflect"
+
+	"github.com/drand/kyber"
+)
+
+// State is the progress of a new party in a resharing.
+type State int
+
+const (
+	StateDealing State = iota // the sub-sharings and the agreements on them are running
+	StateAgreed               // the set of sub-sharings is agreed on, waiting for their rows
+	StateDone                 // holds its row of the new dealing and the new commitments
+)
+
+func (s State) String() string {
+	switch s {
+	case StateDealing:
+		return "dealing"
+	case StateAgreed:
+		return "agreed"
+	case StateDone:
+		return "done"
+	}
+	return "unknown"
+}
+
+/*
+Party is one member j of the new committee in a resharing. The old parties deal their sub-sharings
+with Deal; the new parties do not deal anything themselves. For party j:
+
+ 1. take part in the BingoShare instance of every sub-sharing ψ_i. Once it terminated and the
+    vanishing proof of i verifies against cm[i] and the commitment of row 0 of ψ_i, accept i
+ 2. agree on the sub-sharings to use with a subset agreement among the new parties. It decides at
+    least Old.N-Old.F of them, and only some that an honest new party accepted
+ 3. take the first Old.D_2+1 of them, T, with the Lagrange coefficients λ_i at Y = 0 of the old
+    indices in T. The new row is ρ(X, j) = Σ_{i∈T} λ_i ψ_i(X, j), and the new commitments are
+    Σ_{i∈T} λ_i cm_i[j] for j = 0..New.N
+
+Since ψ_i(-k, 0) = φ(-k, i), the new dealing ρ holds the secrets ρ(-k, 0) = φ(-k, 0). It has the
+degrees of the new committee, and every sub-sharing hides its secrets from New.F new parties.
+*/
+type Party struct {
+	id    int
+	h     Handover
+	suite *vss.Suite
+	sh    *kzg.KzgShareSetup
+	z     []kyber.Scalar
+
+	dealings []*vss.Node   // dealings[i] is our part in the sub-sharing of old party i
+	proofs   []kyber.Point // proofs[i] is the vanishing proof of i, the first one i sent
+	subset   *agreement.Subset
+	checked  []bool // checked[i] is set once the proof of i was checked
+	accepted []bool // accepted[i] is set if the proof of i verified
+	invalid  []int  // old parties whose vanishing proof did not verify
+	set      []int
+	state    State
+
+	row    []kyber.Scalar
+	hiding []kyber.Scalar
+	cm     []kyber.Point
+}
+
+/* This function constructs the new party with index id in 1..New.N of the handover h; coin is its key for the coins of the agreements among the new committee */
+func NewParty(h Handover, id int, suite *vss.Suite, setup *kzg.KzgSetup, coin *agreement.CoinKey) (*Party, error) {
+	if err := h.Validate(); err != nil {
+		return nil, err
+	}
+	if err := h.checkSetup(setup); err != nil {
+		return nil, err
+	}
+	if id < 1 || id > h.New.N {
+		return nil, fmt.Errorf("reshare: new party index %d outside 1..%d", id, h.New.N)
+	}
+	if coin == nil || coin.ReturnSelf() != id {
+		return nil, fmt.Errorf("reshare: no coin key for new party %d", id)
+	}
+
+	parties := make([]int, h.New.N)
+	for j := range parties {
+		parties[j] = j + 1
+	}
+	dealers := make([]int, h.Old.N)
+	for i := range dealers {
+		dealers[i] = i + 1
+	}
+	subset, err := agreement.NewSubsetFor(id, parties, h.New.F, dealers, h.Old.N-h.Old.F, func(d int) agreement.Coin {
+		return coin.Coin(fmt.Sprintf("reshare/%d/%d", h.Epoch, d))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	p := &Party{
+		id:       id,
+		h:        h,
+		suite:    suite,
+		sh:       kzg.NewShareSetup(setup.ReturnT_1(), setup.ReturnT_2(), setup.ReturnT_u(), suite.ReturnSuite(), setup.ReturnG_u(), setup.ReturnG_1()),
+		z:        h.points(suite),
+		dealings: make([]*vss.Node, h.Old.N+1),
+		proofs:   make([]kyber.Point, h.Old.N+1),
+		subset:   subset,
+		checked:  make([]bool, h.Old.N+1),
+		accepted: make([]bool, h.Old.N+1),
+	}
+	for i := 1; i <= h.Old.N; i++ {
+		nd, err := vss.NewNode(id, h.New, suite, setup)
+		if err != nil {
+			return nil, err
+		}
+		p.dealings[i] = nd
+	}
+	return p, nil
+}
+
+func (p *Party) ReturnID() int {
+	return p.id
+}
+
+func (p *Party) ReturnState() State {
+	return p.state
+}
+
+// ReturnSet returns the old parties whose sub-sharings are agreed on, or nil before. The first
+// Old.D_2+1 of them make up the new row.
+func (p *Party) ReturnSet() []int {
+	return p.set
+}
+
+// ReturnInvalid returns the old parties that sent a vanishing proof that did not verify.
+func (p *Party) ReturnInvalid() []int {
+	return p.invalid
+}
+
+// ReturnRow returns the row ρ(X, j) and the hiding row ρ'(X, j) of the new dealing, or nil before the party is done.
+func (p *Party) ReturnRow() ([]kyber.Scalar, []kyber.Scalar) {
+	return p.row, p.hiding
+}
+
+// ReturnCommitments returns the row commitments of the new dealing, or nil before the party is done.
+func (p *Party) ReturnCommitments() []kyber.Point {
+	return p.cm
+}
+
+// Handle processes one inbound message and returns the messages the party sends in response. As
+// with vss.Node, an error means that something in the message was invalid and has been dropped,
+// and it may come together with messages to send. The messages of old parties come from
+// vss.DealerID, with env.Old set by the channel to the old party that sent them, and are only
+// taken for the sub-sharing of that party.
+func (p *Party) Handle(env vss.Envelope) ([]vss.Envelope, error) {
+	if env.To != p.id {
+		return nil, fmt.Errorf("reshare: message for %d delivered to %d", env.To, p.id)
+	}
+	if env.Msg == nil || reflect.ValueOf(env.Msg).IsNil() {
+		return nil, fmt.Errorf("reshare: empty message from %d", env.From)
+	}
+	if env.From < vss.DealerID || env.From > p.h.New.N {
+		return nil, fmt.Errorf("reshare: unknown party %d", env.From)
+	}
+	if env.From == vss.DealerID && (env.Old < 1 || env.Old > p.h.Old.N) {
+		return nil, fmt.Errorf("reshare: message from unknown old party %d", env.Old)
+	}
+	if env.From != vss.DealerID && env.Old != 0 {
+		return nil, fmt.Errorf("reshare: message from %d claims to come from old party %d", env.From, env.Old)
+	}
+
+	out, err := p.handle(env)
+	return p.route(out, err)
+}
+
+// route handles the messages of the party to itself right away and returns the others.
+func (p *Party) route(msgs []vss.Envelope, err error) ([]vss.Envelope, error) {
+	var out []vss.Envelope
+	for len(msgs) > 0 {
+		env := msgs[0]
+		msgs = msgs[1:]
+		if env.To != p.id {
+			out = append(out, env)
+			continue
+		}
+		next, _ := p.handle(env)
+		msgs = append(msgs, next...)
+	}
+	return out, err
+}
+
+func (p *Party) handle(env vss.Envelope) ([]vss.Envelope, error) {
+	var out []vss.Envelope
+	var err error
+
+	switch m := env.Msg.(type) {
+	case *DealingMessage:
+		if err := p.checkDealer(env, m.Epoch, m.Dealer); err != nil {
+			return nil, err
+		}
+		if m.Msg == nil || reflect.ValueOf(m.Msg).IsNil() {
+			return nil, fmt.Errorf("reshare: invalid dealing message from %d", env.From)
+		}
+		// Only the old party sends the commitment and the rows of its own sub-sharing
+		fromDealer := m.Msg.Type() == vss.MsgCommitment || m.Msg.Type() == vss.MsgPolynomial
+		if fromDealer != (env.From == vss.DealerID) {
+			return nil, fmt.Errorf("reshare: unexpected %s message of the sub-sharing of %d from %d", m.Msg.Type(), m.Dealer, env.From)
+		}
+		if fromDealer && env.Old != m.Dealer {
+			return nil, fmt.Errorf("reshare: old party %d sent a %s message of the sub-sharing of %d", env.Old, m.Msg.Type(), m.Dealer)
+		}
+		var next []vss.Envelope
+		next, err = p.dealings[m.Dealer].Handle(vss.Envelope{From: env.From, To: p.id, Msg: m.Msg})
+		for _, n := range next {
+			out = append(out, vss.Envelope{From: p.id, To: n.To, Msg: &DealingMessage{Epoch: p.h.Epoch, Dealer: m.Dealer, Msg: n.Msg}})
+		}
+
+	case *ProofMessage:
+		if err := p.checkDealer(env, m.Epoch, m.Dealer); err != nil {
+			return nil, err
+		}
+		if env.From != vss.DealerID || m.Proof == nil {
+			return nil, fmt.Errorf("reshare: invalid proof message from %d", env.From)
+		}
+		if env.Old != m.Dealer {
+			return nil, fmt.Errorf("reshare: old party %d sent a proof for %d", env.Old, m.Dealer)
+		}
+		// The first proof counts, an old party cannot replace it with another one
+		if p.proofs[m.Dealer] != nil {
+			return nil, fmt.Errorf("reshare: second proof from old party %d", m.Dealer)
+		}
+		p.proofs[m.Dealer] = m.Proof
+
+	case *AgreementMessage:
+		if err := p.checkDealer(env, m.Epoch, m.Dealer); err != nil {
+			return nil, err
+		}
+		if env.From == vss.DealerID {
+			return nil, fmt.Errorf("reshare: agreement message from an old party")
+		}
+		var next []agreement.SubsetEnvelope
+		next, err = p.subset.Handle(env.From, m.Dealer, m.Msg)
+		out = p.agreementMessages(next)
+
+	default:
+		return nil, fmt.Errorf("reshare: unexpected %s message from %d", env.Msg.Type(), env.From)
+	}
+
+	next, progressErr := p.progress()
+	if err == nil {
+		err = progressErr
+	}
+	return append(out, next...), err
+}
+
+func (p *Party) checkDealer(env vss.Envelope, epoch, dealer int) error {
+	if epoch != p.h.Epoch {
+		return fmt.Errorf("reshare: message of epoch %d from %d in epoch %d", epoch, env.From, p.h.Epoch)
+	}
+	if dealer < 1 || dealer > p.h.Old.N {
+		return fmt.Errorf("reshare: message about unknown old party %d from %d", dealer, env.From)
+	}
+	return nil
+}
+
+// progress moves the party on as far as the state of the sub-sharings and agreements allows.
+func (p *Party) progress() ([]vss.Envelope, error) {
+	var out []vss.Envelope
+
+	// Step 1: accept the sub-sharings that terminated with a valid proof
+	for i := 1; i <= p.h.Old.N; i++ {
+		if p.checked[i] || p.proofs[i] == nil || p.dealings[i].ReturnState() != vss.StateTerminated {
+			continue
+		}
+		p.checked[i] = true
+		c := p.suite.ReturnSuite().G1().Point().Sub(p.dealings[i].ReturnCommitments()[0], p.h.Commitments[i])
+		if !kzg.KZGVerifyVanishing(p.sh, c, p.proofs[i], p.z) {
+			p.invalid = append(p.invalid, i)
+			continue
+		}
+		p.accepted[i] = true
+		next, err := p.subset.Accept(i)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, p.agreementMessages(next)...)
+	}
+	if p.set = p.subset.Set(); p.set == nil {
+		return out, nil
+	}
+	if p.state == StateDealing {
+		p.state = StateAgreed
+	}
+
+	// Step 3: combine the rows of the first Old.D_2+1 agreed sub-sharings
+	if p.state == StateAgreed {
+		if len(p.set) < p.h.Old.D_2+1 {
+			return out, fmt.Errorf("reshare: %d sub-sharings agreed on, %d are needed", len(p.set), p.h.Old.D_2+1)
+		}
+		for _, i := range p.set[:p.h.Old.D_2+1] {
+			if p.dealings[i].ReturnRow() == nil {
+				return out, nil
+			}
+		}
+		if err := p.combine(p.set[:p.h.Old.D_2+1]); err != nil {
+			return out, err
+		}
+	}
+	return out, nil
+}
+
+// combine interpolates the rows and the commitments of the sub-sharings of T at Y = 0.
+func (p *Party) combine(T []int) error {
+	g := p.suite.ReturnSuite()
+	x := make([]kyber.Scalar, len(T))
+	for k, i := range T {
+		x[k] = g.G1().Scalar().SetInt64(int64(i))
+	}
+	l, err := polynomial.NewLagrange(g, x)
+	if err != nil {
+		return err
+	}
+	lambda := l.At(g.G1().Scalar().Zero())
+
+	row := make([]kyber.Scalar, p.h.New.D_1+1)
+	hiding := make([]kyber.Scalar, p.h.New.D_1+1)
+	for c := range row {
+		row[c], hiding[c] = g.G1().Scalar().Zero(), g.G1().Scalar().Zero()
+	}
+	cm := make([]kyber.Point, p.h.New.N+1)
+	for j := range cm {
+		cm[j] = g.G1().Point().Null()
+	}
+
+	t := g.G1().Scalar()
+	for k, i := range T {
+		r := p.dealings[i].ReturnRow()
+		for c := range row {
+			row[c].Add(row[c], t.Mul(lambda[k], r.Coefficients()[c]))
+			hiding[c].Add(hiding[c], t.Mul(lambda[k], r.Coefficients_2()[c]))
+		}
+		for j, c := range p.dealings[i].ReturnCommitments() {
+			cm[j].Add(cm[j], g.G1().Point().Mul(lambda[k], c))
+		}
+	}
+
+	p.row, p.hiding, p.cm = row, hiding, cm
+	p.state = StateDone
+	return nil
+}
+
+func (p *Party) agreementMessages(msgs []agreement.SubsetEnvelope) []vss.Envelope {
+	out := make([]vss.Envelope, 0, len(msgs))
+	for _, env := range msgs {
+		out = append(out, vss.Envelope{From: p.id, To: env.To, Msg: &AgreementMessage{Epoch: p.h.Epoch, Dealer: env.Proposer, Msg: env.Msg}})
+	}
+	return out
+}
+
+// Incomplete returns nil once the party is done, and otherwise what it still waits for.
+func (p *Party) Incomplete() error {
+	switch p.state {
+	case StateDone:
+		return nil
+	case StateAgreed:
+		if len(p.set) < p.h.Old.D_2+1 {
+			return fmt.Errorf("reshare: %d sub-sharings agreed on, %d are needed", len(p.set), p.h.Old.D_2+1)
+		}
+		for _, i := range p.set[:p.h.Old.D_2+1] {
+			if err := p.dealings[i].Incomplete(); p.dealings[i].ReturnRow() == nil {
+				return fmt.Errorf("reshare: waiting for the row of the sub-sharing of %d: %v", i, err)
+			}
+		}
+	}
+	return fmt.Errorf("reshare: waiting for the agreements: %d of %d decided", p.subset.Decisions(), p.h.Old.N)
+}
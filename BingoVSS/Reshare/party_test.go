@@ -0,0 +1,352 @@
+package reshare
+
+import (
+	vss "BingoVSS/Bingo"
+	"BingoVSS/Internal/Agreement"
+	kzg "BingoVSS/Internal/Biv_KZG"
+	"BingoVSS/Internal/Testnet"
+	"testing"
+
+	"github.com/drand/kyber"
+	"github.com/stretchr/testify/require"
+)
+
+// dealing is a Bingo dealing of f+1 secrets, as held by the parties after its BingoShare terminated.
+type dealing struct {
+	params  vss.Params
+	secrets []kyber.Scalar
+	rows    [][]kyber.Scalar // rows[i] is φ(X, i)
+	hiding  [][]kyber.Scalar
+	cm      []kyber.Point
+}
+
+func deal(t *testing.T, g *vss.Suite, params vss.Params, setup *kzg.KzgSetup) *dealing {
+	d := &dealing{params: params, rows: make([][]kyber.Scalar, params.N+1), hiding: make([][]kyber.Scalar, params.N+1)}
+	secrets := make([]vss.Secret, params.F+1)
+	for k := range secrets {
+		secrets[k] = *vss.NewSecret(k, *g)
+		d.secrets = append(d.secrets, secrets[k].SendSecret())
+	}
+	queue, err := vss.NewDealerWithSuite(g).DealMessages(secrets, params, setup)
+	require.NoError(t, err)
+
+	nodes := make([]*vss.Node, params.N+1)
+	for i := 1; i <= params.N; i++ {
+		nodes[i], err = vss.NewNode(i, params, g, setup)
+		require.NoError(t, err)
+	}
+	for len(queue) > 0 {
+		env := queue[0]
+		queue = queue[1:]
+		out, err := nodes[env.To].Handle(env)
+		require.NoError(t, err)
+		queue = append(queue, out...)
+	}
+	for i := 1; i <= params.N; i++ {
+		require.Equal(t, vss.StateTerminated, nodes[i].ReturnState())
+		d.rows[i], d.hiding[i] = nodes[i].ReturnRow().Coefficients(), nodes[i].ReturnRow().Coefficients_2()
+	}
+	d.cm = nodes[1].ReturnCommitments()
+	return d
+}
+
+func newSetup(t *testing.T, g *vss.Suite, old, next vss.Params) *kzg.KzgSetup {
+	d := old.D_1
+	if next.D_1 > d {
+		d = next.D_1
+	}
+	setup, err := kzg.NewKzgSetup(d+1, g.ReturnSuite())
+	require.NoError(t, err)
+	return setup
+}
+
+// newCoins deals the coin keys of the parties 1..n with threshold f+1.
+func newCoins(t *testing.T, g *vss.Suite, params vss.Params) map[int]*agreement.CoinKey {
+	ids := make([]int, params.N)
+	for i := range ids {
+		ids[i] = i + 1
+	}
+	coins, err := agreement.DealCoinKeys(g.ReturnSuite(), ids, params.F+1, g.ReturnSuite().RandomStream())
+	require.NoError(t, err)
+	return coins
+}
+
+func newParties(t *testing.T, h Handover, g *vss.Suite, setup *kzg.KzgSetup) []*Party {
+	coins := newCoins(t, g, h.New)
+	parties := make([]*Party, h.New.N+1)
+	for j := 1; j <= h.New.N; j++ {
+		p, err := NewParty(h, j, g, setup, coins[j])
+		require.NoError(t, err)
+		parties[j] = p
+	}
+	return parties
+}
+
+// run has the old parties that are not silent deal their sub-sharings, and delivers the messages
+// to the new parties in a random order until none are left. Messages from or to crashed new
+// parties are dropped.
+func run(t *testing.T, h Handover, g *vss.Suite, setup *kzg.KzgSetup, d *dealing, parties []*Party, silent, crashed map[int]bool, seed int64) {
+	var queue []vss.Envelope
+	for i := 1; i <= h.Old.N; i++ {
+		if silent[i] {
+			continue
+		}
+		out, err := Deal(h, i, g, setup, d.rows[i], d.hiding[i])
+		require.NoError(t, err)
+		queue = append(queue, out...)
+	}
+	testnet.Deliver(parties, queue, crashed, nil, seed)
+}
+
+// checkHandover checks that the honest new parties agree on the set and the new commitments,
+// that their rows match them, and that any New.F+1 of the rows give the secrets of the old dealing.
+func checkHandover(t *testing.T, h Handover, g *vss.Suite, setup *kzg.KzgSetup, d *dealing, parties []*Party, honest []int) {
+	s := g.ReturnSuite()
+	sh := kzg.NewShareSetup(setup.ReturnT_1(), setup.ReturnT_2(), setup.ReturnT_u(), s, setup.ReturnG_u(), setup.ReturnG_1())
+	first := parties[honest[0]]
+	require.GreaterOrEqual(t, len(first.ReturnSet()), h.Old.N-h.Old.F)
+
+	rows := make(map[int][]kyber.Scalar)
+	for _, j := range honest {
+		p := parties[j]
+		require.NoError(t, p.Incomplete())
+		require.Equal(t, StateDone, p.ReturnState())
+		require.Equal(t, first.ReturnSet(), p.ReturnSet())
+		require.Len(t, p.ReturnCommitments(), h.New.N+1)
+		for i, c := range p.ReturnCommitments() {
+			require.True(t, first.ReturnCommitments()[i].Equal(c))
+		}
+
+		row, hiding := p.ReturnRow()
+		require.Len(t, row, h.New.D_1+1)
+		require.True(t, kzg.KZGCommits(sh, row, hiding).Equal(p.ReturnCommitments()[j]))
+		rows[j] = row
+	}
+
+	for _, ids := range [][]int{honest[:h.New.F+1], honest[len(honest)-h.New.F-1:]} {
+		var sel [][]kyber.Scalar
+		for _, j := range ids {
+			sel = append(sel, rows[j])
+		}
+		got, err := vss.RecoverSecrets(s, ids, sel, h.Secrets)
+		require.NoError(t, err)
+		for k := 0; k < h.Secrets; k++ {
+			require.True(t, d.secrets[k].Equal(got[k]), "secret %d", k)
+		}
+	}
+}
+
+func TestHandoverToLargerCommittee(t *testing.T) {
+	g := vss.NewSuite()
+	old, next := vss.NewParams(4, 1), vss.NewParams(7, 2)
+	setup := newSetup(t, g, old, next)
+	d := deal(t, g, old, setup)
+	h := Handover{Epoch: 1, Old: old, New: next, Secrets: old.F + 1, Commitments: d.cm}
+
+	for seed := int64(0); seed < 2; seed++ {
+		parties := newParties(t, h, g, setup)
+		run(t, h, g, setup, d, parties, nil, nil, seed)
+		checkHandover(t, h, g, setup, d, parties, []int{1, 2, 3, 4, 5, 6, 7})
+		require.Empty(t, parties[1].ReturnInvalid())
+	}
+}
+
+func TestHandoverToSmallerCommittee(t *testing.T) {
+	g := vss.NewSuite()
+	old, next := vss.NewParams(7, 2), vss.NewParams(4, 1)
+	setup := newSetup(t, g, old, next)
+	d := deal(t, g, old, setup)
+
+	// The three secrets of the old dealing do not all stay hidden in a dealing of degree 2
+	h := Handover{Epoch: 1, Old: old, New: next, Secrets: 3, Commitments: d.cm}
+	require.Error(t, h.Validate())
+	_, err := NewParty(h, 1, g, setup, newCoins(t, g, next)[1])
+	require.Error(t, err)
+
+	// Two old parties are silent and one new party crashed
+	h.Secrets = 2
+	parties := newParties(t, h, g, setup)
+	run(t, h, g, setup, d, parties, map[int]bool{3: true, 5: true}, map[int]bool{2: true}, 3)
+	checkHandover(t, h, g, setup, d, parties, []int{1, 3, 4})
+	require.NotContains(t, parties[1].ReturnSet(), 3)
+	require.NotContains(t, parties[1].ReturnSet(), 5)
+
+	// The new committee hands the secrets on again, without the crashed party
+	again := &dealing{params: next, secrets: d.secrets, rows: make([][]kyber.Scalar, next.N+1), hiding: make([][]kyber.Scalar, next.N+1), cm: parties[1].ReturnCommitments()}
+	for _, j := range []int{1, 3, 4} {
+		again.rows[j], again.hiding[j] = parties[j].ReturnRow()
+	}
+	h = Handover{Epoch: 2, Old: next, New: old, Secrets: 2, Commitments: again.cm}
+	parties = newParties(t, h, g, setup)
+	run(t, h, g, setup, again, parties, map[int]bool{2: true}, nil, 4)
+	checkHandover(t, h, g, setup, d, parties, []int{1, 2, 3, 4, 5, 6, 7})
+}
+
+func TestHandoverRejectsWrongRows(t *testing.T) {
+	g := vss.NewSuite()
+	old, next := vss.NewParams(4, 1), vss.NewParams(4, 1)
+	setup := newSetup(t, g, old, next)
+	d := deal(t, g, old, setup)
+	h := Handover{Epoch: 2, Old: old, New: next, Secrets: 2, Commitments: d.cm}
+
+	// Old party 2 does not deal from the row of another party
+	_, err := Deal(h, 2, g, setup, d.rows[3], d.hiding[3])
+	require.Error(t, err)
+
+	// Old party 2 deals the row of 3 with its own proof, so the proof does not verify
+	honest, err := Deal(h, 2, g, setup, d.rows[2], d.hiding[2])
+	require.NoError(t, err)
+	forged, err := Deal(h, 3, g, setup, d.rows[3], d.hiding[3])
+	require.NoError(t, err)
+	var queue []vss.Envelope
+	for _, env := range forged {
+		if m, ok := env.Msg.(*DealingMessage); ok {
+			queue = append(queue, vss.Envelope{From: env.From, To: env.To, Old: 2, Msg: &DealingMessage{Epoch: 2, Dealer: 2, Msg: m.Msg}})
+		}
+	}
+	for _, env := range honest {
+		if env.Msg.Type() == MsgProof {
+			queue = append(queue, env)
+		}
+	}
+	for _, i := range []int{1, 3, 4} {
+		out, err := Deal(h, i, g, setup, d.rows[i], d.hiding[i])
+		require.NoError(t, err)
+		queue = append(queue, out...)
+	}
+
+	parties := newParties(t, h, g, setup)
+	testnet.Deliver(parties, queue, nil, nil, 1)
+	checkHandover(t, h, g, setup, d, parties, []int{1, 2, 3, 4})
+	for j := 1; j <= next.N; j++ {
+		require.Equal(t, []int{2}, parties[j].ReturnInvalid())
+		require.NotContains(t, parties[j].ReturnSet(), 2)
+	}
+}
+
+func TestHandoverRejectsProofsOfOtherOldParties(t *testing.T) {
+	g := vss.NewSuite()
+	old, next := vss.NewParams(4, 1), vss.NewParams(4, 1)
+	setup := newSetup(t, g, old, next)
+	d := deal(t, g, old, setup)
+	h := Handover{Epoch: 1, Old: old, New: next, Secrets: 2, Commitments: d.cm}
+
+	// Old party 3 sends its own proof, and its commitment, under the index of party 2 ahead of
+	// every message of 2
+	forged, err := Deal(h, 3, g, setup, d.rows[3], d.hiding[3])
+	require.NoError(t, err)
+	parties := newParties(t, h, g, setup)
+	for _, env := range forged {
+		switch m := env.Msg.(type) {
+		case *ProofMessage:
+			_, err := parties[env.To].Handle(vss.Envelope{From: env.From, To: env.To, Old: 3, Msg: &ProofMessage{Epoch: 1, Dealer: 2, Proof: m.Proof}})
+			require.Error(t, err)
+		case *DealingMessage:
+			if m.Msg.Type() == vss.MsgCommitment {
+				_, err := parties[env.To].Handle(vss.Envelope{From: env.From, To: env.To, Old: 3, Msg: &DealingMessage{Epoch: 1, Dealer: 2, Msg: m.Msg}})
+				require.Error(t, err)
+			}
+		}
+	}
+
+	run(t, h, g, setup, d, parties, nil, nil, 3)
+	checkHandover(t, h, g, setup, d, parties, []int{1, 2, 3, 4})
+	for j := 1; j <= next.N; j++ {
+		require.Empty(t, parties[j].ReturnInvalid())
+	}
+
+	// Once a party holds the proof of 2, 2 cannot replace it either
+	for _, env := range forged {
+		if m, ok := env.Msg.(*ProofMessage); ok && env.To == 1 {
+			_, err := parties[1].Handle(vss.Envelope{From: env.From, To: 1, Old: 2, Msg: &ProofMessage{Epoch: 1, Dealer: 2, Proof: m.Proof}})
+			require.ErrorContains(t, err, "second proof")
+		}
+	}
+}
+
+func TestHandoverRejectsInvalidMessages(t *testing.T) {
+	g := vss.NewSuite()
+	old, next := vss.NewParams(4, 1), vss.NewParams(7, 2)
+	setup := newSetup(t, g, old, next)
+	d := deal(t, g, old, setup)
+	h := Handover{Epoch: 1, Old: old, New: next, Secrets: 2, Commitments: d.cm}
+	parties := newParties(t, h, g, setup)
+	p := parties[1]
+
+	bad := h
+	bad.Commitments = d.cm[1:]
+	require.Error(t, bad.Validate())
+	bad = h
+	bad.Secrets = 0
+	require.Error(t, bad.Validate())
+	coins := newCoins(t, g, h.New)
+	_, err := NewParty(h, 8, g, setup, coins[1])
+	require.Error(t, err)
+	_, err = NewParty(h, 1, g, setup, coins[2])
+	require.Error(t, err)
+	_, err = NewParty(h, 1, g, setup, nil)
+	require.Error(t, err)
+	small, err := kzg.NewKzgSetup(old.D_1+1, g.ReturnSuite())
+	require.NoError(t, err)
+	_, err = NewParty(h, 1, g, small, coins[1])
+	require.Error(t, err)
+	_, err = Deal(h, 5, g, setup, d.rows[1], d.hiding[1])
+	require.Error(t, err)
+
+	msgs, err := Deal(h, 1, g, setup, d.rows[1], d.hiding[1])
+	require.NoError(t, err)
+	var commitment *DealingMessage
+	var proof *ProofMessage
+	for _, env := range msgs {
+		if env.To != 1 {
+			continue
+		}
+		switch m := env.Msg.(type) {
+		case *DealingMessage:
+			if m.Msg.Type() == vss.MsgCommitment {
+				commitment = m
+			}
+		case *ProofMessage:
+			proof = m
+		}
+	}
+	require.NotNil(t, commitment)
+	require.NotNil(t, proof)
+
+	// The commitment and the proof only come from old parties, agreements only from new ones
+	_, err = p.Handle(vss.Envelope{From: 2, To: 1, Msg: commitment})
+	require.Error(t, err)
+	_, err = p.Handle(vss.Envelope{From: 2, To: 1, Msg: proof})
+	require.Error(t, err)
+	_, err = p.Handle(vss.Envelope{From: vss.DealerID, To: 1, Old: 1, Msg: &AgreementMessage{Epoch: 1, Dealer: 1}})
+	require.Error(t, err)
+
+	// Old parties only send for their own sub-sharing, and new parties cannot pose as old ones
+	_, err = p.Handle(vss.Envelope{From: vss.DealerID, To: 1, Msg: proof})
+	require.Error(t, err)
+	_, err = p.Handle(vss.Envelope{From: vss.DealerID, To: 1, Old: 5, Msg: proof})
+	require.Error(t, err)
+	_, err = p.Handle(vss.Envelope{From: vss.DealerID, To: 1, Old: 2, Msg: proof})
+	require.Error(t, err)
+	_, err = p.Handle(vss.Envelope{From: vss.DealerID, To: 1, Old: 2, Msg: commitment})
+	require.Error(t, err)
+	_, err = p.Handle(vss.Envelope{From: 2, To: 1, Old: 1, Msg: &AgreementMessage{Epoch: 1, Dealer: 1}})
+	require.Error(t, err)
+
+	// Other epochs, unknown old parties and unknown senders
+	_, err = p.Handle(vss.Envelope{From: vss.DealerID, To: 1, Old: 1, Msg: &ProofMessage{Epoch: 2, Dealer: 1, Proof: proof.Proof}})
+	require.ErrorContains(t, err, "epoch")
+	_, err = p.Handle(vss.Envelope{From: vss.DealerID, To: 1, Old: 5, Msg: &ProofMessage{Epoch: 1, Dealer: 5, Proof: proof.Proof}})
+	require.Error(t, err)
+	_, err = p.Handle(vss.Envelope{From: 8, To: 1, Msg: proof})
+	require.Error(t, err)
+	_, err = p.Handle(vss.Envelope{From: 2, To: 3, Msg: proof})
+	require.Error(t, err)
+	_, err = p.Handle(vss.Envelope{From: vss.DealerID, To: 1, Old: 1, Msg: (*ProofMessage)(nil)})
+	require.Error(t, err)
+
+	require.Error(t, p.Incomplete())
+	row, hiding := p.ReturnRow()
+	require.Nil(t, row)
+	require.Nil(t, hiding)
+}
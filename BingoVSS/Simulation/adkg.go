@@ -0,0 +1,150 @@
+package simulation
+
+import (
+	"BingoVSS/ADKG"
+	vss "BingoVSS/Bingo"
+	"BingoVSS/Internal/Agreement"
+	"BingoVSS/Internal/Polynomial"
+	"fmt"
+
+	"github.com/drand/kyber"
+)
+
+/*
+This function constructs a simulator of the asynchronous DKG for the given configuration. Every
+party 1..n runs an adkg.Party and is a dealer, so Config.Corrupt may only name parties 1..n, and
+the strategy of a party applies both to its own dealing and to everything else it sends.
+Config.Secrets is not used.
+*/
+func NewADKG(cfg Config) (*Simulator, error) {
+	if _, ok := cfg.Corrupt[vss.DealerID]; ok {
+		return nil, fmt.Errorf("simulation: there is no single dealer in an ADKG")
+	}
+	s, err := New(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]int, s.params.N)
+	for i := range ids {
+		ids[i] = i + 1
+	}
+	g := s.suite.ReturnSuite()
+	coins, err := agreement.DealCoinKeys(g, ids, s.params.F+1, g.RandomStream())
+	if err != nil {
+		return nil, err
+	}
+
+	s.parties = make([]*adkg.Party, s.params.N+1)
+	for i := 1; i <= s.params.N; i++ {
+		p, err := adkg.NewParty(i, s.params, s.suite, s.setup, coins[i])
+		if err != nil {
+			return nil, err
+		}
+		s.parties[i] = p
+	}
+	return s, nil
+}
+
+// RunADKG starts every party and delivers messages until none are left.
+func (s *Simulator) RunADKG() (*ADKGResult, error) {
+	if s.parties == nil {
+		return nil, fmt.Errorf("simulation: not an ADKG simulator, use NewADKG")
+	}
+	for i := 1; i <= s.params.N; i++ {
+		out, err := s.parties[i].Start()
+		if err != nil {
+			return nil, err
+		}
+		s.send(out)
+	}
+
+	if err := s.loop(func(env vss.Envelope) ([]vss.Envelope, error) { return s.parties[env.To].Handle(env) }); err != nil {
+		return nil, err
+	}
+
+	r := &ADKGResult{
+		Seed:     s.cfg.Seed,
+		Events:   s.events,
+		Rejected: s.rejected,
+		Time:     s.now,
+		Parties:  s.parties,
+		params:   s.params,
+		suite:    s.suite,
+	}
+	for i := 1; i <= s.params.N; i++ {
+		if s.cfg.Corrupt[i] == nil {
+			r.Honest = append(r.Honest, i)
+		}
+	}
+	return r, nil
+}
+
+// ADKGResult is the state of all parties after an ADKG run.
+type ADKGResult struct {
+	Seed     int64
+	Events   int   // number of delivered messages
+	Rejected int   // number of messages a party rejected as invalid
+	Time     int64 // time of the last delivery
+	Parties  []*adkg.Party
+	Honest   []int
+
+	params vss.Params
+	suite  *vss.Suite
+}
+
+/*
+This function checks the properties of the ADKG on the honest parties:
+
+  - termination: they all hold a key share and the public key
+  - agreement: they use the same n-f or more dealings and output the same public key and
+    public key shares
+  - correctness: their key shares match their public key shares, and any f+1 of them
+    interpolate to the secret key of the public key
+*/
+func (r *ADKGResult) Check() error {
+	g := r.suite.ReturnSuite()
+	first := r.Parties[r.Honest[0]]
+	for _, i := range r.Honest {
+		if err := r.Parties[i].Incomplete(); err != nil {
+			return fmt.Errorf("termination: party %d: %v", i, err)
+		}
+	}
+
+	if len(first.ReturnSet()) < r.params.N-r.params.F {
+		return fmt.Errorf("agreement: only %d dealings were used", len(first.ReturnSet()))
+	}
+	for _, i := range r.Honest {
+		p := r.Parties[i]
+		if fmt.Sprint(p.ReturnSet()) != fmt.Sprint(first.ReturnSet()) {
+			return fmt.Errorf("agreement: party %d used the dealings %v, not %v", i, p.ReturnSet(), first.ReturnSet())
+		}
+		if !equalPoints(p.ReturnPublicShares(), first.ReturnPublicShares()) {
+			return fmt.Errorf("agreement: party %d output other public key shares", i)
+		}
+		if !g.G1().Point().Mul(p.ReturnShare(), nil).Equal(first.ReturnPublicShares()[i]) {
+			return fmt.Errorf("correctness: the key share of party %d does not match its public key share", i)
+		}
+	}
+
+	k := r.params.F + 1
+	if len(r.Honest) < k {
+		return nil
+	}
+	for _, ids := range [][]int{r.Honest[:k], r.Honest[len(r.Honest)-k:]} {
+		x := make([]kyber.Scalar, k)
+		y := make([]kyber.Scalar, k)
+		for j, i := range ids {
+			x[j] = g.G1().Scalar().SetInt64(int64(i))
+			y[j] = r.Parties[i].ReturnShare()
+		}
+		coeffs, err := polynomial.Interpolate(g, x, y)
+		if err != nil {
+			return err
+		}
+		if !g.G1().Point().Mul(coeffs[0], nil).Equal(first.ReturnPublicKey()) {
+			return fmt.Errorf("correctness: the key shares of %v do not interpolate to the secret key", ids)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,182 @@
+package simulation
+
+import (
+	"BingoVSS/ADKG"
+	vss "BingoVSS/Bingo"
+
+	"github.com/drand/kyber"
+)
+
+// Strategy decides what a corrupt party actually sends. Tamper is called for every message the
+// honest code of the party would send and returns the messages that go out instead.
+type Strategy interface {
+	Tamper(s *Simulator, env vss.Envelope) []Delivery
+}
+
+// StrategyFunc lets an ordinary function act as a Strategy.
+type StrategyFunc func(s *Simulator, env vss.Envelope) []Delivery
+
+func (f StrategyFunc) Tamper(s *Simulator, env vss.Envelope) []Delivery {
+	return f(s, env)
+}
+
+// Drop loses every message of the party with probability Rate. A rate of 1 is a crashed party.
+type Drop struct {
+	Rate float64
+}
+
+func (d Drop) Tamper(s *Simulator, env vss.Envelope) []Delivery {
+	if s.Rand().Float64() < d.Rate {
+		return nil
+	}
+	return []Delivery{{Env: env}}
+}
+
+// Delay holds back every message of the party for up to Max extra time units.
+type Delay struct {
+	Max int64
+}
+
+func (d Delay) Tamper(s *Simulator, env vss.Envelope) []Delivery {
+	return []Delivery{{Env: env, Delay: s.Rand().Int63n(d.Max + 1)}}
+}
+
+// BadProofs sends row and column points, rows for a dealer and public key shares in an ADKG,
+// with values that do not match their proofs or commitments. Everything else is sent unchanged.
+type BadProofs struct{}
+
+func (BadProofs) Tamper(s *Simulator, env vss.Envelope) []Delivery {
+	g := s.ReturnSuite().ReturnSuite()
+	switch m := env.Msg.(type) {
+	case *vss.RowPointMessage:
+		env.Msg = &vss.RowPointMessage{Y_1: shift(g, m.Y_1), Y_2: m.Y_2, Proof: m.Proof}
+	case *vss.ColumnPointMessage:
+		env.Msg = &vss.ColumnPointMessage{Y_1: shift(g, m.Y_1), Y_2: m.Y_2, Proof: m.Proof}
+	case *vss.PolynomialMessage:
+		row := append([]kyber.Scalar{}, m.Row...)
+		row[0] = shift(g, row[0])
+		env.Msg = &vss.PolynomialMessage{Row: row, RowHiding: m.RowHiding}
+	case *adkg.PublicShareMessage:
+		bad := *m
+		bad.X = g.G1().Point().Add(m.X, g.G1().Point().Base())
+		env.Msg = &bad
+	}
+	return []Delivery{{Env: env}}
+}
+
+// Equivocate echoes and readies a forged commitment to every other participant, and claims to
+// be done with the sharing right away. Everything else is sent unchanged.
+type Equivocate struct {
+	forged []kyber.Point
+}
+
+func (e *Equivocate) Tamper(s *Simulator, env vss.Envelope) []Delivery {
+	if e.forged == nil {
+		e.forged = randomPoints(s, s.ReturnParams().D_2+1)
+	}
+
+	switch env.Msg.(type) {
+	case *vss.EchoMessage:
+		if env.To%2 == 0 {
+			env.Msg = &vss.EchoMessage{Commitments: e.forged}
+		}
+	case *vss.ReadyMessage:
+		if env.To%2 == 0 {
+			env.Msg = &vss.ReadyMessage{Commitments: e.forged}
+		}
+		// The done message goes out as early as possible, long before the party is complete
+		return []Delivery{{Env: env}, {Env: vss.Envelope{From: env.From, To: env.To, Msg: &vss.DoneMessage{}}}}
+	case *vss.DoneMessage:
+		return nil
+	}
+	return []Delivery{{Env: env}}
+}
+
+// WithholdRows is a dealer that never sends the rows of Count participants, picked at random.
+type WithholdRows struct {
+	Count    int
+	withheld map[int]bool
+}
+
+func (w *WithholdRows) Tamper(s *Simulator, env vss.Envelope) []Delivery {
+	if w.withheld == nil {
+		w.withheld = make(map[int]bool)
+		for _, p := range s.Rand().Perm(s.ReturnParams().N)[:w.Count] {
+			w.withheld[p+1] = true
+		}
+	}
+	if env.Msg.Type() == vss.MsgPolynomial && w.withheld[env.To] {
+		return nil
+	}
+	return []Delivery{{Env: env}}
+}
+
+// EquivocatingDealer deals twice and sends the second dealing to the participants in Second.
+type EquivocatingDealer struct {
+	Second map[int]bool
+	msgs   []vss.Envelope
+}
+
+func (e *EquivocatingDealer) Tamper(s *Simulator, env vss.Envelope) []Delivery {
+	if e.msgs == nil {
+		secrets := []vss.Secret{*vss.NewSecret(0, *s.ReturnSuite())}
+		msgs, err := vss.NewDealerWithSuite(s.ReturnSuite()).DealMessages(secrets, s.ReturnParams(), s.ReturnSetup())
+		if err != nil {
+			return []Delivery{{Env: env}}
+		}
+		e.msgs = msgs
+	}
+
+	if !e.Second[env.To] {
+		return []Delivery{{Env: env}}
+	}
+	for _, other := range e.msgs {
+		if other.To == env.To && other.Msg.Type() == env.Msg.Type() {
+			return []Delivery{{Env: other}}
+		}
+	}
+	return nil
+}
+
+// Combine applies the strategies one after the other.
+func Combine(strategies ...Strategy) Strategy {
+	return StrategyFunc(func(s *Simulator, env vss.Envelope) []Delivery {
+		out := []Delivery{{Env: env}}
+		for _, st := range strategies {
+			var next []Delivery
+			for _, d := range out {
+				for _, t := range st.Tamper(s, d.Env) {
+					t.Delay += d.Delay
+					next = append(next, t)
+				}
+			}
+			out = next
+		}
+		return out
+	})
+}
+
+func shift(g interface{ G1() kyber.Group }, v kyber.Scalar) kyber.Scalar {
+	return g.G1().Scalar().Add(v, g.G1().Scalar().One())
+}
+
+func randomPoints(s *Simulator, n int) []kyber.Point {
+	g := s.ReturnSuite().ReturnSuite()
+	points := make([]kyber.Point, n)
+	for i := range points {
+		points[i] = g.G1().Point().Mul(g.G1().Scalar().SetInt64(s.Rand().Int63()), nil)
+	}
+	return points
+}
+
+// FlipVotes sends the opposite of every value the party votes for in the agreements of an ADKG.
+type FlipVotes struct{}
+
+func (FlipVotes) Tamper(s *Simulator, env vss.Envelope) []Delivery {
+	if m, ok := env.Msg.(*adkg.AgreementMessage); ok {
+		flipped := *m
+		flipped.Msg.Value = !m.Msg.Value
+		env.Msg = &flipped
+	}
+	return []Delivery{{Env: env}}
+}
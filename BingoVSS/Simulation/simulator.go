@@ -0,0 +1,371 @@
+package simulation
+
+import (
+	"BingoVSS/ADKG"
+	vss "BingoVSS/Bingo"
+	kzg "BingoVSS/Internal/Biv_KZG"
+	"container/heap"
+	"errors"
+	"fmt"
+	"math/rand"
+
+	"github.com/drand/kyber"
+)
+
+// Config describes one simulated run of BingoShare.
+type Config struct {
+	Params  vss.Params
+	Suite   *vss.Suite    // NewSuite() if nil
+	Setup   *kzg.KzgSetup // a fresh setup if nil; reuse one across runs to save time
+	Seed    int64
+	Secrets int // number of secrets to share, f+1 if 0
+
+	// Every message takes between 1 and MaxDelay time units to arrive, 10 if 0.
+	MaxDelay int64
+	// The run is aborted after MaxEvents deliveries, 1<<20 if 0.
+	MaxEvents int
+
+	// Corrupt maps the parties controlled by the adversary to their strategy. The dealer is
+	// DealerID; at most f of the participants 1..n may be corrupt.
+	Corrupt map[int]Strategy
+}
+
+// Delivery is a message and the extra time it spends in the network.
+type Delivery struct {
+	Env   vss.Envelope
+	Delay int64
+}
+
+/*
+Simulator is a deterministic discrete-event simulation of BingoShare. Every participant runs a
+vss.Node, and every message is an event that arrives after a random delay. The delays, the order
+of simultaneous events and the choices of the adversary all come from a single source seeded with
+Config.Seed, so a run can be replayed from its seed. Only the schedule is replayed: the dealing
+itself uses fresh cryptographic randomness in every run.
+
+The corrupt parties run the honest code as well, but every message they send goes through their
+Strategy, which may drop, change, duplicate or delay it.
+*/
+type Simulator struct {
+	cfg    Config
+	params vss.Params
+	suite  *vss.Suite
+	setup  *kzg.KzgSetup
+	rnd    *rand.Rand
+
+	nodes   []*vss.Node
+	parties []*adkg.Party // nil unless the simulator was built by NewADKG
+	secrets []vss.Secret
+
+	now      int64
+	seq      uint64
+	queue    eventQueue
+	events   int
+	rejected int
+}
+
+/* This function constructs a simulator for the given configuration */
+func New(cfg Config) (*Simulator, error) {
+	if err := cfg.Params.Validate(); err != nil {
+		return nil, err
+	}
+	corrupt := 0
+	for p := range cfg.Corrupt {
+		if p < vss.DealerID || p > cfg.Params.N {
+			return nil, fmt.Errorf("simulation: unknown party %d", p)
+		}
+		if p != vss.DealerID {
+			corrupt++
+		}
+	}
+	if corrupt > cfg.Params.F {
+		return nil, fmt.Errorf("simulation: %d corrupt participants, at most f = %d", corrupt, cfg.Params.F)
+	}
+
+	if cfg.Suite == nil {
+		cfg.Suite = vss.NewSuite()
+	}
+	if cfg.Setup == nil {
+		setup, err := kzg.NewKzgSetup(cfg.Params.D_1+1, cfg.Suite.ReturnSuite())
+		if err != nil {
+			return nil, err
+		}
+		cfg.Setup = setup
+	}
+	if cfg.Secrets == 0 {
+		cfg.Secrets = cfg.Params.F + 1
+	}
+	if cfg.MaxDelay == 0 {
+		cfg.MaxDelay = 10
+	}
+	if cfg.MaxEvents == 0 {
+		cfg.MaxEvents = 1 << 20
+	}
+
+	s := &Simulator{
+		cfg:    cfg,
+		params: cfg.Params,
+		suite:  cfg.Suite,
+		setup:  cfg.Setup,
+		rnd:    rand.New(rand.NewSource(cfg.Seed)),
+		nodes:  make([]*vss.Node, cfg.Params.N+1),
+	}
+	for i := 1; i <= cfg.Params.N; i++ {
+		nd, err := vss.NewNode(i, cfg.Params, cfg.Suite, cfg.Setup)
+		if err != nil {
+			return nil, err
+		}
+		s.nodes[i] = nd
+	}
+
+	return s, nil
+}
+
+func (s *Simulator) ReturnParams() vss.Params {
+	return s.params
+}
+
+func (s *Simulator) ReturnSuite() *vss.Suite {
+	return s.suite
+}
+
+func (s *Simulator) ReturnSetup() *kzg.KzgSetup {
+	return s.setup
+}
+
+// Rand returns the seeded source of the run. Strategies must draw all their choices from it.
+func (s *Simulator) Rand() *rand.Rand {
+	return s.rnd
+}
+
+// Now returns the current simulated time.
+func (s *Simulator) Now() int64 {
+	return s.now
+}
+
+// Run deals the secrets and delivers messages until none are left.
+func (s *Simulator) Run() (*Result, error) {
+	s.secrets = make([]vss.Secret, s.cfg.Secrets)
+	for i := range s.secrets {
+		s.secrets[i] = *vss.NewSecret(i, *s.suite)
+	}
+
+	dealer := vss.NewDealerWithSuite(s.suite)
+	msgs, err := dealer.DealMessages(s.secrets, s.params, s.setup)
+	if err != nil {
+		return nil, err
+	}
+	s.send(msgs)
+
+	if err := s.loop(func(env vss.Envelope) ([]vss.Envelope, error) { return s.nodes[env.To].Handle(env) }); err != nil {
+		return nil, err
+	}
+	return s.result(), nil
+}
+
+// loop delivers the messages with handle until none are left.
+func (s *Simulator) loop(handle func(env vss.Envelope) ([]vss.Envelope, error)) error {
+	for s.queue.Len() > 0 {
+		if s.events >= s.cfg.MaxEvents {
+			return fmt.Errorf("simulation: aborted after %d events", s.events)
+		}
+		ev := heap.Pop(&s.queue).(*event)
+		s.now = ev.at
+		s.events++
+
+		out, err := handle(ev.env)
+		if err != nil {
+			s.rejected++
+		}
+		s.send(out)
+	}
+	return nil
+}
+
+// send schedules the messages of one party, passing them through its strategy if it is corrupt.
+func (s *Simulator) send(msgs []vss.Envelope) {
+	for _, env := range msgs {
+		deliveries := []Delivery{{Env: env}}
+		if strategy, ok := s.cfg.Corrupt[env.From]; ok {
+			deliveries = s.tamper(strategy, env)
+		}
+		for _, d := range deliveries {
+			if d.Env.To < 1 || d.Env.To > s.params.N {
+				continue
+			}
+			s.seq++
+			delay := 1 + s.rnd.Int63n(s.cfg.MaxDelay) + d.Delay
+			heap.Push(&s.queue, &event{at: s.now + delay, seq: s.seq, env: d.Env})
+		}
+	}
+}
+
+// tamper passes a message through a strategy. The BingoShare messages of an ADKG reach the
+// strategy unwrapped, so that every strategy for BingoShare applies to all dealings.
+func (s *Simulator) tamper(strategy Strategy, env vss.Envelope) []Delivery {
+	m, ok := env.Msg.(*adkg.DealingMessage)
+	if !ok {
+		return strategy.Tamper(s, env)
+	}
+	out := strategy.Tamper(s, vss.Envelope{From: env.From, To: env.To, Msg: m.Msg})
+	for k := range out {
+		// Strategies for a dealer send as DealerID, the channel still says who sent it
+		out[k].Env.From = env.From
+		out[k].Env.Msg = &adkg.DealingMessage{Dealer: m.Dealer, Msg: out[k].Env.Msg}
+	}
+	return out
+}
+
+func (s *Simulator) result() *Result {
+	r := &Result{
+		Seed:         s.cfg.Seed,
+		Events:       s.events,
+		Rejected:     s.rejected,
+		Time:         s.now,
+		Nodes:        s.nodes,
+		Secrets:      s.secrets,
+		DealerHonest: s.cfg.Corrupt[vss.DealerID] == nil,
+		params:       s.params,
+		suite:        s.suite,
+		setup:        s.setup,
+	}
+	for i := 1; i <= s.params.N; i++ {
+		if s.cfg.Corrupt[i] == nil {
+			r.Honest = append(r.Honest, i)
+		}
+	}
+	return r
+}
+
+// Result is the state of all parties after a run.
+type Result struct {
+	Seed         int64
+	Events       int   // number of delivered messages
+	Rejected     int   // number of messages a node rejected as invalid
+	Time         int64 // time of the last delivery
+	Nodes        []*vss.Node
+	Honest       []int
+	Secrets      []vss.Secret
+	DealerHonest bool
+
+	params vss.Params
+	suite  *vss.Suite
+	setup  *kzg.KzgSetup
+}
+
+/*
+This function checks the properties of BingoShare on the honest participants:
+
+  - agreement: all honest participants that accepted a commitment accepted the same one, and
+    their rows match it, so any f+1 of them define the same secrets
+  - validity: with an honest dealer those secrets are the ones that were dealt
+  - completeness: with an honest dealer every honest participant terminates, and with any
+    dealer either all honest participants terminate or none does
+*/
+func (r *Result) Check() error {
+	var cm []kyber.Point
+	var rows []int
+	terminated := 0
+	for _, i := range r.Honest {
+		nd := r.Nodes[i]
+		if nd.ReturnState() == vss.StateTerminated {
+			terminated++
+		}
+		if c := nd.ReturnCommitments(); c != nil {
+			if cm == nil {
+				cm = c
+			} else if !equalPoints(cm, c) {
+				return fmt.Errorf("agreement: participant %d accepted another commitment", i)
+			}
+		}
+		if row := nd.ReturnRow(); row != nil {
+			sh := kzg.NewShareSetup(r.setup.ReturnT_1(), r.setup.ReturnT_2(), r.setup.ReturnT_u(), r.suite.ReturnSuite(), r.setup.ReturnG_u(), r.setup.ReturnG_1())
+			if !kzg.KZGCommits(sh, row.Coefficients(), row.Coefficients_2()).Equal(nd.ReturnCommitments()[i]) {
+				return fmt.Errorf("agreement: the row of participant %d does not match the commitment", i)
+			}
+			rows = append(rows, i)
+		}
+	}
+
+	if terminated != 0 && terminated != len(r.Honest) {
+		return fmt.Errorf("completeness: %d of %d honest participants terminated", terminated, len(r.Honest))
+	}
+	if r.DealerHonest && terminated != len(r.Honest) {
+		return fmt.Errorf("completeness: the dealer is honest but only %d of %d honest participants terminated", terminated, len(r.Honest))
+	}
+
+	k := r.params.D_2 + 1
+	if len(rows) < k {
+		return nil
+	}
+	first, err := r.reconstruct(rows[:k])
+	if err != nil {
+		return err
+	}
+	last, err := r.reconstruct(rows[len(rows)-k:])
+	if err != nil {
+		return err
+	}
+	for j := range first {
+		if !first[j].Equal(last[j]) {
+			return fmt.Errorf("agreement: the honest rows define different values for secret %d", j)
+		}
+	}
+
+	if r.DealerHonest {
+		for j, secret := range r.Secrets {
+			if !first[j].Equal(secret.SendSecret()) {
+				return fmt.Errorf("validity: secret %d differs from the one dealt", j)
+			}
+		}
+	}
+	return nil
+}
+
+// reconstruct recovers φ(-k, 0) for every secret k from the rows of d_2+1 participants.
+func (r *Result) reconstruct(ids []int) ([]kyber.Scalar, error) {
+	if len(ids) != r.params.D_2+1 {
+		return nil, errors.New("simulation: wrong number of rows")
+	}
+	rows := make([][]kyber.Scalar, len(ids))
+	for j, i := range ids {
+		rows[j] = r.Nodes[i].ReturnRow().Coefficients()
+	}
+	return vss.RecoverSecrets(r.suite.ReturnSuite(), ids, rows, len(r.Secrets))
+}
+
+func equalPoints(a, b []kyber.Point) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !a[i].Equal(b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+type event struct {
+	at  int64
+	seq uint64 // breaks ties in the order the events were scheduled
+	env vss.Envelope
+}
+
+type eventQueue []*event
+
+func (q eventQueue) Len() int { return len(q) }
+func (q eventQueue) Less(i, j int) bool {
+	if q[i].at != q[j].at {
+		return q[i].at < q[j].at
+	}
+	return q[i].seq < q[j].seq
+}
+func (q eventQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+func (q *eventQueue) Push(x any)   { *q = append(*q, x.(*event)) }
+func (q *eventQueue) Pop() any {
+	old := *q
+	ev := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return ev
+}
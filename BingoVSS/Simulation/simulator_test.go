@@ -0,0 +1,211 @@
+package simulation
+
+import (
+	vss "BingoVSS/Bingo"
+	kzg "BingoVSS/Internal/Biv_KZG"
+	"flag"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// Run with -sim.runs=5000 to check the properties over thousands of schedules.
+var runs = flag.Int("sim.runs", 5, "number of seeds every scenario is simulated with")
+
+type scenario struct {
+	name    string
+	params  vss.Params
+	corrupt func() map[int]Strategy
+	// terminates tells whether the honest participants must terminate; agreement and
+	// validity are checked either way
+	terminates bool
+}
+
+func scenarios() []scenario {
+	p4, p7 := vss.NewParams(4, 1), vss.NewParams(7, 2)
+	return []scenario{
+		{"honest", p4, func() map[int]Strategy { return nil }, true},
+		{"crashed participants", p7, func() map[int]Strategy {
+			return map[int]Strategy{3: Drop{Rate: 1}, 6: Drop{Rate: 1}}
+		}, true},
+		{"lossy participants", p7, func() map[int]Strategy {
+			return map[int]Strategy{1: Drop{Rate: 0.5}, 7: Drop{Rate: 0.3}}
+		}, true},
+		{"bad proofs", p7, func() map[int]Strategy {
+			return map[int]Strategy{2: BadProofs{}, 5: BadProofs{}}
+		}, true},
+		{"equivocating participants", p7, func() map[int]Strategy {
+			return map[int]Strategy{4: &Equivocate{}, 6: &Equivocate{}}
+		}, true},
+		{"slow participants", p4, func() map[int]Strategy {
+			return map[int]Strategy{2: Delay{Max: 1000}}
+		}, true},
+		{"dealer withholds f rows", p7, func() map[int]Strategy {
+			return map[int]Strategy{vss.DealerID: &WithholdRows{Count: 2}}
+		}, true},
+		{"dealer withholds rows and participants crash", p7, func() map[int]Strategy {
+			return map[int]Strategy{vss.DealerID: &WithholdRows{Count: 2}, 1: Drop{Rate: 1}, 2: Combine(BadProofs{}, Delay{Max: 50})}
+		}, false},
+		{"dealer sends bad rows", p4, func() map[int]Strategy {
+			return map[int]Strategy{vss.DealerID: BadProofs{}}
+		}, false},
+		{"equivocating dealer", p4, func() map[int]Strategy {
+			return map[int]Strategy{vss.DealerID: &EquivocatingDealer{Second: map[int]bool{3: true, 4: true}}}
+		}, false},
+		{"equivocating dealer and participant", p4, func() map[int]Strategy {
+			return map[int]Strategy{vss.DealerID: &EquivocatingDealer{Second: map[int]bool{4: true}}, 2: &Equivocate{}}
+		}, false},
+	}
+}
+
+func TestScenarios(t *testing.T) {
+	setups := make(map[vss.Params]*kzg.KzgSetup)
+	g := vss.NewSuite()
+
+	for _, sc := range scenarios() {
+		sc := sc
+		t.Run(sc.name, func(t *testing.T) {
+			if setups[sc.params] == nil {
+				setup, err := kzg.NewKzgSetup(sc.params.D_1+1, g.ReturnSuite())
+				require.NoError(t, err)
+				setups[sc.params] = setup
+			}
+
+			for seed := int64(0); seed < int64(*runs); seed++ {
+				sim, err := New(Config{Params: sc.params, Suite: g, Setup: setups[sc.params], Seed: seed, Corrupt: sc.corrupt()})
+				require.NoError(t, err)
+				res, err := sim.Run()
+				require.NoError(t, err)
+
+				require.NoError(t, res.Check(), "seed %d", seed)
+				if sc.terminates {
+					for _, i := range res.Honest {
+						require.Equal(t, vss.StateTerminated, res.Nodes[i].ReturnState(), "seed %d, participant %d", seed, i)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestSameSeedSameSchedule(t *testing.T) {
+	params := vss.NewParams(4, 1)
+	trace := func(seed int64) string {
+		sim, err := New(Config{Params: params, Seed: seed, Corrupt: map[int]Strategy{2: Drop{Rate: 0.5}}})
+		require.NoError(t, err)
+		res, err := sim.Run()
+		require.NoError(t, err)
+		return fmt.Sprintf("%d %d %d", res.Events, res.Rejected, res.Time)
+	}
+
+	require.Equal(t, trace(42), trace(42))
+	require.Equal(t, trace(7), trace(7))
+}
+
+func TestCheckDetectsViolations(t *testing.T) {
+	params := vss.NewParams(4, 1)
+
+	// The dealer sends rows that do not match the commitment to everyone, so nobody terminates:
+	// allowed for a corrupt dealer, a violation for an honest one
+	sim, err := New(Config{Params: params, Corrupt: map[int]Strategy{vss.DealerID: BadProofs{}}})
+	require.NoError(t, err)
+	res, err := sim.Run()
+	require.NoError(t, err)
+	require.NoError(t, res.Check())
+
+	res.DealerHonest = true
+	require.ErrorContains(t, res.Check(), "completeness")
+}
+
+func TestConfigValidation(t *testing.T) {
+	params := vss.NewParams(4, 1)
+
+	_, err := New(Config{Params: params, Corrupt: map[int]Strategy{1: Drop{Rate: 1}, 2: Drop{Rate: 1}}})
+	require.Error(t, err)
+	_, err = New(Config{Params: params, Corrupt: map[int]Strategy{9: Drop{Rate: 1}}})
+	require.Error(t, err)
+	_, err = New(Config{Params: vss.NewParams(3, 1)})
+	require.Error(t, err)
+
+	sim, err := New(Config{Params: params, MaxEvents: 5})
+	require.NoError(t, err)
+	_, err = sim.Run()
+	require.Error(t, err)
+}
+
+func TestADKGScenarios(t *testing.T) {
+	p4, p7 := vss.NewParams(4, 1), vss.NewParams(7, 2)
+	scenarios := []struct {
+		name    string
+		params  vss.Params
+		corrupt func() map[int]Strategy
+	}{
+		{"honest", p4, func() map[int]Strategy { return nil }},
+		{"crashed party", p4, func() map[int]Strategy { return map[int]Strategy{2: Drop{Rate: 1}} }},
+		{"crashed parties", p7, func() map[int]Strategy {
+			return map[int]Strategy{3: Drop{Rate: 1}, 6: Drop{Rate: 1}}
+		}},
+		{"bad proofs and flipped votes", p7, func() map[int]Strategy {
+			return map[int]Strategy{2: Combine(BadProofs{}, FlipVotes{}), 5: BadProofs{}}
+		}},
+		{"equivocating dealer and participant", p7, func() map[int]Strategy {
+			return map[int]Strategy{1: &EquivocatingDealer{Second: map[int]bool{3: true, 4: true}}, 7: &Equivocate{}}
+		}},
+		{"withheld rows and slow party", p4, func() map[int]Strategy {
+			return map[int]Strategy{4: Combine(&WithholdRows{Count: 1}, Delay{Max: 200})}
+		}},
+	}
+
+	g := vss.NewSuite()
+	setups := make(map[vss.Params]*kzg.KzgSetup)
+	for _, sc := range scenarios {
+		sc := sc
+		t.Run(sc.name, func(t *testing.T) {
+			if setups[sc.params] == nil {
+				setup, err := kzg.NewKzgSetup(sc.params.D_1+1, g.ReturnSuite())
+				require.NoError(t, err)
+				setups[sc.params] = setup
+			}
+
+			for seed := int64(0); seed < int64(*runs); seed++ {
+				sim, err := NewADKG(Config{Params: sc.params, Suite: g, Setup: setups[sc.params], Seed: seed, Corrupt: sc.corrupt()})
+				require.NoError(t, err)
+				res, err := sim.RunADKG()
+				require.NoError(t, err)
+				require.NoError(t, res.Check(), "seed %d", seed)
+			}
+		})
+	}
+}
+
+func TestADKGCheckDetectsViolations(t *testing.T) {
+	params := vss.NewParams(4, 1)
+	_, err := NewADKG(Config{Params: params, Corrupt: map[int]Strategy{vss.DealerID: BadProofs{}}})
+	require.Error(t, err)
+
+	sim, err := New(Config{Params: params})
+	require.NoError(t, err)
+	_, err = sim.RunADKG()
+	require.Error(t, err)
+
+	runADKG := func() *ADKGResult {
+		sim, err := NewADKG(Config{Params: params})
+		require.NoError(t, err)
+		res, err := sim.RunADKG()
+		require.NoError(t, err)
+		require.NoError(t, res.Check())
+		return res
+	}
+
+	// A party that took part in another key generation
+	res, other := runADKG(), runADKG()
+	res.Parties[2] = other.Parties[2]
+	require.ErrorContains(t, res.Check(), "agreement")
+
+	// A party that never started
+	idle, err := NewADKG(Config{Params: params})
+	require.NoError(t, err)
+	res.Parties[2] = idle.parties[2]
+	require.ErrorContains(t, res.Check(), "termination")
+}
@@ -0,0 +1,446 @@
+package store
+
+import (
+	vss "BingoVSS/Bingo"
+	kzg "BingoVSS/Internal/Biv_KZG"
+	"bytes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/drand/kyber"
+	"golang.org/x/crypto/chacha20poly1305"
+	"golang.org/x/crypto/scrypt"
+)
+
+/*
+KDF holds the scrypt parameters that turn the passphrase into the key of a record. N is the
+cost and has to be a power of two.
+*/
+type KDF struct {
+	N int
+	R int
+	P int
+}
+
+// DefaultKDF is the cost scrypt recommends for interactive use.
+var DefaultKDF = KDF{N: 1 << 15, R: 8, P: 1}
+
+// Bounds on the parameters of a stored record, so that a crafted file cannot make Load run for hours.
+const (
+	maxLogN = 22
+	maxR    = 32
+	maxP    = 16
+)
+
+func (k KDF) validate() error {
+	if k.N < 2 || k.N&(k.N-1) != 0 || k.N > 1<<maxLogN {
+		return fmt.Errorf("store: scrypt cost %d is not a power of two in 2..2^%d", k.N, maxLogN)
+	}
+	if k.R < 1 || k.R > maxR || k.P < 1 || k.P > maxP {
+		return fmt.Errorf("store: scrypt parameters r = %d, p = %d outside 1..%d, 1..%d", k.R, k.P, maxR, maxP)
+	}
+	return nil
+}
+
+/*
+A Record is what a participant needs to carry on after a restart: its verifier state, the row
+commitments cm[0..n] of the dealing and the curve both are on.
+*/
+type Record struct {
+	Curve       string
+	Verifier    vss.VerifierState
+	Commitments []kyber.Point
+}
+
+/*
+A Store keeps records and node checkpoints in a directory, one file per name. Every file is encrypted with
+XChaCha20-Poly1305 under a key derived from the passphrase with scrypt and a fresh salt:
+
+	magic       "BINGOSTR" for a record, "BINGOCKP" for a checkpoint
+	version     uint8    fileVersion
+	kdf         uint8 log2(N), uint8 r, uint8 p
+	salt        saltSize bytes
+	nonce       chacha20poly1305.NonceSizeX bytes
+	ciphertext  the sealed record
+
+The header and the name of the record are authenticated along with the ciphertext, so a file
+cannot be edited or moved to another name without Load noticing.
+*/
+type Store struct {
+	dir        string
+	passphrase []byte
+	kdf        KDF
+}
+
+// kind tells the files of records and checkpoints apart, both by their magic and their extension.
+type kind struct {
+	noun      string
+	magic     string
+	extension string
+}
+
+var (
+	recordKind     = kind{"record", "BINGOSTR", ".bingo"}
+	checkpointKind = kind{"checkpoint", "BINGOCKP", ".checkpoint"}
+)
+
+const (
+	fileVersion uint8 = 1
+	saltSize          = 16
+	keySize           = chacha20poly1305.KeySize
+	headerSize        = 8 + 1 + 3 + saltSize + chacha20poly1305.NonceSizeX // both magics have 8 bytes
+)
+
+/* This function opens the store in dir, creating the directory if needed */
+func Open(dir string, passphrase []byte, kdf KDF) (*Store, error) {
+	if len(passphrase) == 0 {
+		return nil, errors.New("store: empty passphrase")
+	}
+	if err := kdf.validate(); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	return &Store{dir: dir, passphrase: append([]byte{}, passphrase...), kdf: kdf}, nil
+}
+
+// ReturnDir returns the directory of the store.
+func (s *Store) ReturnDir() string {
+	return s.dir
+}
+
+/*
+This function encrypts the record and stores it under name. The file is written next to its
+destination, synced and renamed over it, so a crash leaves either the old record or the new one
+and never a mix of both.
+*/
+func (s *Store) Save(name string, rec *Record) error {
+	plain, err := encodeRecord(rec)
+	if err != nil {
+		return err
+	}
+	return s.seal(name, recordKind, plain)
+}
+
+/*
+This function decrypts the record stored under name and checks it before returning it: the
+curve has to be the one of the setup, and the row and the verified proofs of the verifier have
+to match the stored commitments (VerifierState.Verify). A record that was tampered with or
+encrypted under another passphrase is rejected.
+*/
+func (s *Store) Load(name string, setup *kzg.KzgShareSetup) (*Record, error) {
+	plain, err := s.open(name, recordKind)
+	if err != nil {
+		return nil, err
+	}
+	rec, g, err := decodeRecord(plain)
+	if err != nil {
+		return nil, err
+	}
+	if setup.ReturnSuite().G1().String() != g.ReturnSuite().G1().String() {
+		return nil, fmt.Errorf("store: %s is on %s, the setup is not", name, rec.Curve)
+	}
+	if err := rec.Verifier.Verify(setup, rec.Commitments); err != nil {
+		return nil, fmt.Errorf("store: %s: %w", name, err)
+	}
+	return rec, nil
+}
+
+/*
+This function stores the checkpoint of a Node under name, the same way as Save. A node saves its
+checkpoint after every message it handled and before it sends the answers, so that it can resume
+with vss.ResumeNode after a crash.
+*/
+func (s *Store) SaveCheckpoint(name string, cp vss.Checkpoint) error {
+	plain, err := vss.MarshalCheckpoint(cp)
+	if err != nil {
+		return err
+	}
+	return s.seal(name, checkpointKind, plain)
+}
+
+// LoadCheckpoint decrypts the checkpoint stored under name and checks it against the setup (Checkpoint.Verify).
+func (s *Store) LoadCheckpoint(name string, setup *kzg.KzgSetup) (vss.Checkpoint, error) {
+	plain, err := s.open(name, checkpointKind)
+	if err != nil {
+		return vss.Checkpoint{}, err
+	}
+	cp, err := vss.UnmarshalCheckpoint(plain)
+	if err != nil {
+		return vss.Checkpoint{}, err
+	}
+	if err := cp.Verify(setup); err != nil {
+		return vss.Checkpoint{}, fmt.Errorf("store: %s: %w", name, err)
+	}
+	return cp, nil
+}
+
+// seal encrypts plain and writes it atomically to the file of name.
+func (s *Store) seal(name string, k kind, plain []byte) error {
+	path, err := s.path(name, k)
+	if err != nil {
+		return err
+	}
+
+	header := make([]byte, 0, headerSize)
+	header = append(header, k.magic...)
+	header = append(header, fileVersion, uint8(log2(s.kdf.N)), uint8(s.kdf.R), uint8(s.kdf.P))
+	salt := make([]byte, saltSize)
+	nonce := make([]byte, chacha20poly1305.NonceSizeX)
+	for _, b := range [][]byte{salt, nonce} {
+		if _, err := io.ReadFull(rand.Reader, b); err != nil {
+			return err
+		}
+	}
+	header = append(append(header, salt...), nonce...)
+
+	aead, err := s.cipher(s.kdf, salt)
+	if err != nil {
+		return err
+	}
+	data := aead.Seal(header, nonce, plain, additionalData(header, name))
+
+	return writeAtomic(s.dir, path, data)
+}
+
+// open reads the file of name and decrypts it.
+func (s *Store) open(name string, k kind) ([]byte, error) {
+	path, err := s.path(name, k)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < headerSize+chacha20poly1305.Overhead || string(data[:len(k.magic)]) != k.magic {
+		return nil, fmt.Errorf("store: %s is not a %s", name, k.noun)
+	}
+	header := data[:headerSize]
+	if v := header[len(k.magic)]; v != fileVersion {
+		return nil, fmt.Errorf("store: unsupported file version %d", v)
+	}
+	p := header[len(k.magic)+1:]
+	if p[0] > maxLogN {
+		return nil, fmt.Errorf("store: scrypt cost 2^%d of %s exceeds 2^%d", p[0], name, maxLogN)
+	}
+	kdf := KDF{N: 1 << p[0], R: int(p[1]), P: int(p[2])}
+	if err := kdf.validate(); err != nil {
+		return nil, err
+	}
+
+	salt := header[len(k.magic)+4 : len(k.magic)+4+saltSize]
+	nonce := header[len(k.magic)+4+saltSize:]
+	aead, err := s.cipher(kdf, salt)
+	if err != nil {
+		return nil, err
+	}
+	plain, err := aead.Open(nil, nonce, data[headerSize:], additionalData(header, name))
+	if err != nil {
+		return nil, fmt.Errorf("store: cannot decrypt %s: wrong passphrase or corrupted file", name)
+	}
+	return plain, nil
+}
+
+// Delete removes the record stored under name.
+func (s *Store) Delete(name string) error {
+	return s.remove(name, recordKind)
+}
+
+// DeleteCheckpoint removes the checkpoint stored under name, for example once the node terminated.
+func (s *Store) DeleteCheckpoint(name string) error {
+	return s.remove(name, checkpointKind)
+}
+
+func (s *Store) remove(name string, k kind) error {
+	path, err := s.path(name, k)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil {
+		return err
+	}
+	return syncDir(s.dir)
+}
+
+// List returns the names of all the records in the store, sorted.
+func (s *Store) List() ([]string, error) {
+	return s.list(recordKind)
+}
+
+// ListCheckpoints returns the names of all the checkpoints in the store, sorted.
+func (s *Store) ListCheckpoints() ([]string, error) {
+	return s.list(checkpointKind)
+}
+
+func (s *Store) list(k kind) ([]string, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, e := range entries {
+		name := e.Name()
+		if e.Type().IsRegular() && !strings.HasPrefix(name, ".") && strings.HasSuffix(name, k.extension) {
+			names = append(names, strings.TrimSuffix(name, k.extension))
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// path returns the file of name. Names are plain file names; temporary files start with a dot.
+func (s *Store) path(name string, k kind) (string, error) {
+	if name == "" || strings.HasPrefix(name, ".") || strings.ContainsAny(name, `/\`) || strings.ContainsRune(name, 0) {
+		return "", fmt.Errorf("store: invalid record name %q", name)
+	}
+	return filepath.Join(s.dir, name+k.extension), nil
+}
+
+// cipher returns the AEAD keyed with the passphrase of the store, stretched under kdf and salt.
+func (s *Store) cipher(kdf KDF, salt []byte) (cipher.AEAD, error) {
+	key, err := scrypt.Key(s.passphrase, salt, kdf.N, kdf.R, kdf.P, keySize)
+	if err != nil {
+		return nil, err
+	}
+	return chacha20poly1305.NewX(key)
+}
+
+func additionalData(header []byte, name string) []byte {
+	return append(append([]byte{}, header...), name...)
+}
+
+func log2(n int) int {
+	l := 0
+	for ; n > 1; n >>= 1 {
+		l++
+	}
+	return l
+}
+
+// writeAtomic writes data to a temporary file in dir and renames it to path once it is on disk.
+func writeAtomic(dir, path string, data []byte) error {
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	name := tmp.Name()
+	if _, err = tmp.Write(data); err == nil {
+		err = tmp.Sync()
+	}
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(name, path)
+	}
+	if err != nil {
+		os.Remove(name)
+		return err
+	}
+	return syncDir(dir)
+}
+
+// syncDir flushes a rename or a removal in dir to disk.
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	if err := d.Sync(); err != nil && !errors.Is(err, fs.ErrInvalid) {
+		return err
+	}
+	return nil
+}
+
+/*
+The plaintext of a record is the curve (uint16 length and the bytes), the verifier state
+(uint32 length and vss.MarshalVerifierState) and the commitments (uint16 count and the points).
+*/
+func encodeRecord(rec *Record) ([]byte, error) {
+	if _, err := vss.NewSuiteForCurve(rec.Curve); err != nil {
+		return nil, err
+	}
+	state, err := vss.MarshalVerifierState(rec.Verifier)
+	if err != nil {
+		return nil, err
+	}
+	if len(rec.Commitments) > 0xffff {
+		return nil, fmt.Errorf("store: %d commitments do not fit in a record", len(rec.Commitments))
+	}
+
+	var buf bytes.Buffer
+	_ = binary.Write(&buf, binary.BigEndian, uint16(len(rec.Curve)))
+	buf.WriteString(rec.Curve)
+	_ = binary.Write(&buf, binary.BigEndian, uint32(len(state)))
+	buf.Write(state)
+	_ = binary.Write(&buf, binary.BigEndian, uint16(len(rec.Commitments)))
+	for _, c := range rec.Commitments {
+		if c == nil {
+			return nil, errors.New("store: cannot encode a missing commitment")
+		}
+		if _, err := c.MarshalTo(&buf); err != nil {
+			return nil, err
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// decodeRecord returns the record along with the suite of its curve.
+func decodeRecord(data []byte) (*Record, *vss.Suite, error) {
+	r := bytes.NewReader(data)
+	rec := &Record{}
+
+	var l uint16
+	if err := binary.Read(r, binary.BigEndian, &l); err != nil {
+		return nil, nil, fmt.Errorf("store: malformed record: %w", err)
+	}
+	curve := make([]byte, l)
+	if _, err := io.ReadFull(r, curve); err != nil {
+		return nil, nil, fmt.Errorf("store: malformed record: %w", err)
+	}
+	rec.Curve = string(curve)
+	g, err := vss.NewSuiteForCurve(rec.Curve)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var sl uint32
+	if err := binary.Read(r, binary.BigEndian, &sl); err != nil {
+		return nil, nil, fmt.Errorf("store: malformed record: %w", err)
+	}
+	if int64(sl) > int64(r.Len()) {
+		return nil, nil, fmt.Errorf("store: malformed record: state of %d bytes, %d left", sl, r.Len())
+	}
+	state := make([]byte, sl)
+	_, _ = io.ReadFull(r, state)
+	if rec.Verifier, err = vss.UnmarshalVerifierState(state, g); err != nil {
+		return nil, nil, err
+	}
+
+	if err := binary.Read(r, binary.BigEndian, &l); err != nil {
+		return nil, nil, fmt.Errorf("store: malformed record: %w", err)
+	}
+	if int(l)*g.ReturnSuite().G1().PointLen() != r.Len() {
+		return nil, nil, fmt.Errorf("store: malformed record: %d commitments in %d bytes", l, r.Len())
+	}
+	rec.Commitments = make([]kyber.Point, l)
+	for i := range rec.Commitments {
+		rec.Commitments[i] = g.ReturnSuite().G1().Point()
+		if _, err := rec.Commitments[i].UnmarshalFrom(r); err != nil {
+			return nil, nil, fmt.Errorf("store: malformed record: %w", err)
+		}
+	}
+	return rec, g, nil
+}
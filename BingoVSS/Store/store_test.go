@@ -0,0 +1,258 @@
+package store
+
+import (
+	vss "BingoVSS/Bingo"
+	kzg "BingoVSS/Internal/Biv_KZG"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/drand/kyber"
+	"github.com/stretchr/testify/require"
+)
+
+// testKDF keeps the tests fast; the parameters are stored in every file, so Load uses them too.
+var testKDF = KDF{N: 1 << 4, R: 1, P: 1}
+
+// deal runs an honest BingoShare with f = 1 and returns the verifiers and the row commitments.
+func deal(t *testing.T, g *vss.Suite) ([]vss.Verifier, []kyber.Point, *kzg.KzgShareSetup, []vss.Secret) {
+	f := 1
+	d_1, d_2, n := 2*f+1, f, 3*f+1
+	secrets := []vss.Secret{*vss.NewSecret(0, *g), *vss.NewSecret(1, *g)}
+
+	setup, err := kzg.NewKzgSetup(d_1+1, g.ReturnSuite())
+	require.NoError(t, err)
+	sh_setup := kzg.NewShareSetup(setup.ReturnT_1(), setup.ReturnT_2(), setup.ReturnT_u(), g.ReturnSuite(), setup.ReturnG_u(), setup.ReturnG_1())
+	vn := make([]kyber.Scalar, n+1)
+	for i := range vn {
+		vn[i] = g.ReturnSuite().G1().Scalar().SetInt64(int64(i))
+	}
+	CM, verifiers, err := vss.BingoShareDealer(secrets, d_1, d_2, n, 0, *g, setup)
+	require.NoError(t, err)
+	cm := kzg.PartialEval(setup, CM, vn)
+	for _, status := range []string{"has sent rows", "Done"} {
+		for i := 0; i <= n; i++ {
+			require.NoError(t, vss.BingoShare(verifiers, d_1, d_2, n, i, cm, *g, sh_setup, setup))
+			verifiers[i].UpdateStatus(status)
+		}
+	}
+	return verifiers, cm, sh_setup, secrets
+}
+
+func TestSaveLoad(t *testing.T) {
+	for _, curve := range vss.Curves() {
+		t.Run(curve, func(t *testing.T) {
+			g, err := vss.NewSuiteForCurve(curve)
+			require.NoError(t, err)
+			verifiers, cm, setup, secrets := deal(t, g)
+
+			s, err := Open(filepath.Join(t.TempDir(), "shares"), []byte("correct horse"), testKDF)
+			require.NoError(t, err)
+			for i := range verifiers {
+				require.NoError(t, s.Save(name(i), &Record{Curve: curve, Verifier: verifiers[i].State(), Commitments: cm}))
+			}
+
+			// The restored verifiers reconstruct the secrets
+			restored := make([]vss.Verifier, len(verifiers))
+			for i := range verifiers {
+				rec, err := s.Load(name(i), setup)
+				require.NoError(t, err)
+				require.Equal(t, curve, rec.Curve)
+				require.Equal(t, len(cm), len(rec.Commitments))
+				for c := range cm {
+					require.True(t, cm[c].Equal(rec.Commitments[c]))
+				}
+				v, err := vss.RestoreVerifier(g, rec.Verifier)
+				require.NoError(t, err)
+				require.Equal(t, "Done", v.SendStatus())
+				restored[i] = *v
+			}
+			secret, err := vss.BingoReconstruct(restored, 0, setup, 1, 1, cm)
+			require.NoError(t, err)
+			require.True(t, secrets[1].SendSecret().Equal(secret))
+		})
+	}
+}
+
+func TestLoadRejects(t *testing.T) {
+	g := vss.NewSuite()
+	verifiers, cm, setup, _ := deal(t, g)
+	dir := t.TempDir()
+	s, err := Open(dir, []byte("correct horse"), testKDF)
+	require.NoError(t, err)
+	rec := &Record{Curve: g.ReturnCurve(), Verifier: verifiers[1].State(), Commitments: cm}
+	require.NoError(t, s.Save("party-1", rec))
+
+	// Another passphrase
+	other, err := Open(dir, []byte("battery staple"), testKDF)
+	require.NoError(t, err)
+	_, err = other.Load("party-1", setup)
+	require.Error(t, err)
+
+	// Every flipped byte of the file is noticed
+	path := filepath.Join(dir, "party-1"+recordKind.extension)
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	for _, at := range []int{0, len(recordKind.magic), len(recordKind.magic) + 2, len(recordKind.magic) + 4, headerSize - 1, headerSize, len(data) - 1} {
+		bad := append([]byte{}, data...)
+		bad[at] ^= 1
+		require.NoError(t, os.WriteFile(path, bad, 0600))
+		_, err = s.Load("party-1", setup)
+		require.Error(t, err, "byte %d", at)
+	}
+	require.NoError(t, os.WriteFile(path, data[:headerSize], 0600))
+	_, err = s.Load("party-1", setup)
+	require.Error(t, err)
+
+	// A record moved to another name
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "party-2"+recordKind.extension), data, 0600))
+	_, err = s.Load("party-2", setup)
+	require.Error(t, err)
+
+	// A row that does not match the stored commitments is not loaded
+	shifted := append([]kyber.Point{cm[1]}, cm[1:]...)
+	require.NoError(t, s.Save("party-1", &Record{Curve: rec.Curve, Verifier: rec.Verifier, Commitments: shifted}))
+	_, err = s.Load("party-1", setup)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "does not match")
+
+	// A setup on another curve
+	bls, err := vss.NewSuiteForCurve(vss.CurveBLS12381)
+	require.NoError(t, err)
+	blsSetup, err := kzg.NewKzgSetup(4, bls.ReturnSuite())
+	require.NoError(t, err)
+	require.NoError(t, s.Save("party-1", rec))
+	_, err = s.Load("party-1", kzg.NewShareSetup(blsSetup.ReturnT_1(), blsSetup.ReturnT_2(), blsSetup.ReturnT_u(), bls.ReturnSuite(), blsSetup.ReturnG_u(), blsSetup.ReturnG_1()))
+	require.Error(t, err)
+	_, err = s.Load("party-1", setup)
+	require.NoError(t, err)
+}
+
+func TestSaveReplacesAtomically(t *testing.T) {
+	g := vss.NewSuite()
+	verifiers, cm, setup, _ := deal(t, g)
+	dir := t.TempDir()
+	s, err := Open(dir, []byte("correct horse"), testKDF)
+	require.NoError(t, err)
+
+	for i := range verifiers {
+		require.NoError(t, s.Save("party", &Record{Curve: g.ReturnCurve(), Verifier: verifiers[i].State(), Commitments: cm}))
+		rec, err := s.Load("party", setup)
+		require.NoError(t, err)
+		require.Equal(t, i+1, rec.Verifier.ID)
+	}
+
+	// Only the record is left, readable by its owner alone
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	info, err := entries[0].Info()
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	// A temporary file left by a crash is not a record
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".party"+recordKind.extension+".tmp-1"), []byte("partial"), 0600))
+	names, err := s.List()
+	require.NoError(t, err)
+	require.Equal(t, []string{"party"}, names)
+}
+
+func TestListDelete(t *testing.T) {
+	g := vss.NewSuite()
+	verifiers, cm, _, _ := deal(t, g)
+	s, err := Open(t.TempDir(), []byte("correct horse"), testKDF)
+	require.NoError(t, err)
+
+	names, err := s.List()
+	require.NoError(t, err)
+	require.Empty(t, names)
+	for _, i := range []int{2, 0, 1} {
+		require.NoError(t, s.Save(name(i), &Record{Curve: g.ReturnCurve(), Verifier: verifiers[i].State(), Commitments: cm}))
+	}
+	names, err = s.List()
+	require.NoError(t, err)
+	require.Equal(t, []string{name(0), name(1), name(2)}, names)
+
+	require.NoError(t, s.Delete(name(1)))
+	names, err = s.List()
+	require.NoError(t, err)
+	require.Equal(t, []string{name(0), name(2)}, names)
+	require.Error(t, s.Delete(name(1)))
+
+	for _, bad := range []string{"", ".hidden", "../escape", `a\b`, "a/b"} {
+		require.Error(t, s.Save(bad, &Record{Curve: g.ReturnCurve(), Verifier: verifiers[0].State(), Commitments: cm}), bad)
+	}
+	require.Error(t, s.Save("unknown", &Record{Curve: "p256", Verifier: verifiers[0].State(), Commitments: cm}))
+	_, err = Open(t.TempDir(), nil, testKDF)
+	require.Error(t, err)
+	_, err = Open(t.TempDir(), []byte("x"), KDF{N: 3, R: 1, P: 1})
+	require.Error(t, err)
+}
+
+func name(i int) string {
+	return fmt.Sprintf("party-%d", i)
+}
+
+func TestCheckpoints(t *testing.T) {
+	g := vss.NewSuite()
+	params := vss.NewParams(4, 1)
+	setup, err := kzg.NewKzgSetup(params.D_1+1, g.ReturnSuite())
+	require.NoError(t, err)
+	secrets := []vss.Secret{*vss.NewSecret(0, *g), *vss.NewSecret(1, *g)}
+	queue, err := vss.NewDealerWithSuite(g).DealMessages(secrets, params, setup)
+	require.NoError(t, err)
+
+	nodes := make([]*vss.Node, params.N+1)
+	for i := 1; i <= params.N; i++ {
+		nodes[i], err = vss.NewNode(i, params, g, setup)
+		require.NoError(t, err)
+	}
+	dir := t.TempDir()
+	s, err := Open(dir, []byte("correct horse"), testKDF)
+	require.NoError(t, err)
+
+	// Node 2 saves its checkpoint after every message, as it would before sending its answers
+	for len(queue) > 0 {
+		env := queue[0]
+		queue = queue[1:]
+		out, err := nodes[env.To].Handle(env)
+		require.NoError(t, err)
+		if env.To == 2 {
+			require.NoError(t, s.SaveCheckpoint("node-2", nodes[2].Checkpoint()))
+		}
+		queue = append(queue, out...)
+	}
+
+	cp, err := s.LoadCheckpoint("node-2", setup)
+	require.NoError(t, err)
+	nd, _, err := vss.ResumeNode(cp, g, setup)
+	require.NoError(t, err)
+	require.Equal(t, vss.StateTerminated, nd.ReturnState())
+	require.True(t, nodes[2].ReturnRow().Coefficients()[0].Equal(nd.ReturnRow().Coefficients()[0]))
+
+	// Checkpoints and records do not mix
+	names, err := s.ListCheckpoints()
+	require.NoError(t, err)
+	require.Equal(t, []string{"node-2"}, names)
+	names, err = s.List()
+	require.NoError(t, err)
+	require.Empty(t, names)
+	data, err := os.ReadFile(filepath.Join(dir, "node-2"+checkpointKind.extension))
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "node-2"+recordKind.extension), data, 0600))
+	_, err = s.Load("node-2", kzg.NewShareSetup(setup.ReturnT_1(), setup.ReturnT_2(), setup.ReturnT_u(), g.ReturnSuite(), setup.ReturnG_u(), setup.ReturnG_1()))
+	require.Error(t, err)
+
+	// A checkpoint that does not match its commitment is not loaded
+	cp.Row = append([]kyber.Scalar{}, cp.Row...)
+	cp.Row[0] = g.ReturnSuite().G1().Scalar().Zero()
+	require.NoError(t, s.SaveCheckpoint("node-2", cp))
+	_, err = s.LoadCheckpoint("node-2", setup)
+	require.Error(t, err)
+
+	require.NoError(t, s.DeleteCheckpoint("node-2"))
+	names, err = s.ListCheckpoints()
+	require.NoError(t, err)
+	require.Empty(t, names)
+}
@@ -0,0 +1,109 @@
+package threshold
+
+import (
+	kzg "BingoVSS/Internal/Biv_KZG"
+	"fmt"
+
+	"github.com/drand/kyber"
+	"github.com/drand/kyber/pairing"
+	"github.com/drand/kyber/sign/bls"
+)
+
+// PartialSignature is the BLS signature V·H(m) in G1 of one key share, with the public key share
+// it verifies under.
+type PartialSignature struct {
+	Share *PublicShare
+	Sig   []byte
+}
+
+// Signature is a BLS signature recovered from partial signatures.
+type Signature struct {
+	Sig       []byte      // s_K·H(m) in G1
+	PublicKey kyber.Point // s_K·g_2, the key the signature verifies under
+	Used      []int       // the participants whose partial signatures were combined
+	Invalid   []int       // the participants whose partial signatures did not verify
+}
+
+/* This function signs msg with the key share s */
+func (s *KeyShare) Sign(ts *kzg.KzgShareSetup, msg []byte) (*PartialSignature, error) {
+	sig, err := bls.NewSchemeOnG1(ts.ReturnSuite()).Sign(s.V, msg)
+	if err != nil {
+		return nil, err
+	}
+	return &PartialSignature{Share: s.Public(ts), Sig: sig}, nil
+}
+
+/*
+This function checks a partial signature on msg. The public key share must match the commitment
+cm[I] of the signer, and the signature must verify under it: e(σ_I, g_2) = e(H(m), X_I).
+*/
+func VerifyPartial(ts *kzg.KzgShareSetup, cm []kyber.Point, msg []byte, p *PartialSignature) error {
+	if p == nil {
+		return fmt.Errorf("threshold: no partial signature")
+	}
+	if err := VerifyPublicShare(ts, cm, p.Share); err != nil {
+		return err
+	}
+	if err := bls.NewSchemeOnG1(ts.ReturnSuite()).Verify(p.Share.X, msg, p.Sig); err != nil {
+		return fmt.Errorf("threshold: invalid partial signature of participant %d: %v", p.Share.I, err)
+	}
+	return nil
+}
+
+/*
+This function combines the partial signatures on msg into the BLS signature of the secret k under
+the dealing with the row commitments cm. It verifies the partial signatures, skips those that do
+not verify or repeat a participant, and interpolates the signature and the public key from the
+first d_2+1 valid ones. The secret is never reconstructed. It returns an error when fewer than
+d_2+1 partial signatures are valid.
+*/
+func Combine(ts *kzg.KzgShareSetup, cm []kyber.Point, msg []byte, k int, partials []*PartialSignature, d_2 int) (*Signature, error) {
+	g := ts.ReturnSuite()
+	res := &Signature{}
+	seen := make(map[int]bool)
+	var sigs, keys []kyber.Point
+
+	for _, p := range partials {
+		if len(res.Used) == d_2+1 {
+			break
+		}
+		if p != nil && p.Share != nil && seen[p.Share.I] {
+			continue
+		}
+		if err := VerifyPartial(ts, cm, msg, p); err != nil || p.Share.K != k {
+			if p != nil && p.Share != nil {
+				res.Invalid = append(res.Invalid, p.Share.I)
+			}
+			continue
+		}
+		sig := g.G1().Point()
+		if err := sig.UnmarshalBinary(p.Sig); err != nil {
+			res.Invalid = append(res.Invalid, p.Share.I)
+			continue
+		}
+		seen[p.Share.I] = true
+		res.Used = append(res.Used, p.Share.I)
+		sigs = append(sigs, sig)
+		keys = append(keys, p.Share.X)
+	}
+	if len(res.Used) < d_2+1 {
+		return nil, fmt.Errorf("threshold: %d valid partial signatures, %d are needed", len(res.Used), d_2+1)
+	}
+
+	sig, err := interpolate(g, g.G1(), res.Used, sigs)
+	if err != nil {
+		return nil, err
+	}
+	if res.Sig, err = sig.MarshalBinary(); err != nil {
+		return nil, err
+	}
+	if res.PublicKey, err = interpolate(g, g.G2(), res.Used, keys); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// Verify checks a BLS signature on G1 under a public key on G2, as recovered by Combine.
+func Verify(g pairing.Suite, publicKey kyber.Point, msg, sig []byte) error {
+	return bls.NewSchemeOnG1(g).Verify(publicKey, msg, sig)
+}
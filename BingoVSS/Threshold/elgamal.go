@@ -0,0 +1,217 @@
+package threshold
+
+import (
+	kzg "BingoVSS/Internal/Biv_KZG"
+	"BingoVSS/Internal/Proofs"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/drand/kyber"
+)
+
+/*
+Ciphertext is a TDH2 encryption after Shoup and Gennaro under a public key s_k·g_2: U = r·g_2
+for a random r, and the message sealed with AES-256-GCM under the key SHA-256(U || r·s_k·g_2).
+Ubar = r·ḡ for the second base ḡ = τ·g_2 of the setup, whose logarithm nobody knows, and Proof
+is a Chaum–Pedersen proof that U and Ubar have the same r, bound to Label and Data. Only the
+encryptor, who knows r, can make a valid ciphertext, so a ciphertext cannot be mauled into
+another one, or moved to another label, and still be decrypted. Every key is used for a single
+message, so the nonce is zero.
+*/
+type Ciphertext struct {
+	U     kyber.Point
+	Ubar  kyber.Point
+	Label []byte
+	Data  []byte
+	Proof proofs.DLEQProof
+}
+
+// DecryptionShare is the share D = x_i·U of one key share, with the public key share it must
+// match and a Chaum–Pedersen proof that D and X_i have the same exponent.
+type DecryptionShare struct {
+	Share *PublicShare
+	D     kyber.Point
+	Proof proofs.DLEQProof
+}
+
+// Decryption is a message recovered from decryption shares.
+type Decryption struct {
+	Plaintext []byte
+	Used      []int // the participants whose decryption shares were combined
+	Invalid   []int // the participants whose decryption shares did not verify
+}
+
+/*
+This function encrypts msg with the label under the public key of a secret, as returned by
+PublicKey or Combine. The label is public, for example the purpose of the message, and is bound
+to the ciphertext.
+*/
+func Encrypt(ts *kzg.KzgShareSetup, publicKey kyber.Point, msg, label []byte) (*Ciphertext, error) {
+	if publicKey == nil {
+		return nil, errors.New("threshold: no public key")
+	}
+	g := ts.ReturnSuite()
+	r := g.G2().Scalar().Pick(g.RandomStream())
+	defer r.Zero()
+
+	ct := &Ciphertext{
+		U:     g.G2().Point().Mul(r, nil),
+		Ubar:  g.G2().Point().Mul(r, secondBase(ts)),
+		Label: append([]byte{}, label...),
+	}
+	aead, err := newAEAD(ct.U, g.G2().Point().Mul(r, publicKey))
+	if err != nil {
+		return nil, err
+	}
+	ct.Data = aead.Seal(nil, make([]byte, aead.NonceSize()), msg, ct.Label)
+	ct.Proof = proofs.ProveDLEQ(g, ciphertextTag(ct), 0, r, g.G2().Point().Base(), secondBase(ts), ct.U, ct.Ubar)
+	return ct, nil
+}
+
+// VerifyCiphertext checks that ct is well formed, that is the proof that U and Ubar have the same
+// exponent under its label and data.
+func VerifyCiphertext(ts *kzg.KzgShareSetup, ct *Ciphertext) error {
+	if ct == nil || ct.U == nil || ct.Ubar == nil {
+		return errors.New("threshold: empty ciphertext")
+	}
+	g := ts.ReturnSuite()
+	if !proofs.VerifyDLEQ(g, ciphertextTag(ct), 0, g.G2().Point().Base(), secondBase(ts), ct.U, ct.Ubar, ct.Proof) {
+		return errors.New("threshold: invalid ciphertext")
+	}
+	return nil
+}
+
+/* This function computes the decryption share of ct with the key share s, after checking that ct is valid */
+func (s *KeyShare) Decrypt(ts *kzg.KzgShareSetup, ct *Ciphertext) (*DecryptionShare, error) {
+	if err := VerifyCiphertext(ts, ct); err != nil {
+		return nil, err
+	}
+	g := ts.ReturnSuite()
+	share := s.Public(ts)
+	D := g.G2().Point().Mul(s.V, ct.U)
+	return &DecryptionShare{
+		Share: share,
+		D:     D,
+		Proof: proofs.ProveDLEQ(g, decryptTag(s.K), s.I, s.V, g.G2().Point().Base(), ct.U, share.X, D),
+	}, nil
+}
+
+/*
+This function checks a decryption share of ct. The ciphertext must be valid, the public key share
+must match the commitment cm[I] of the participant, and the proof must show that D = x_I·U for
+the same x_I as X_I.
+*/
+func VerifyDecryptionShare(ts *kzg.KzgShareSetup, cm []kyber.Point, ct *Ciphertext, d *DecryptionShare) error {
+	if err := VerifyCiphertext(ts, ct); err != nil {
+		return err
+	}
+	return verifyDecryptionShare(ts, cm, ct, d)
+}
+
+// verifyDecryptionShare checks a decryption share of a ciphertext that is known to be valid.
+func verifyDecryptionShare(ts *kzg.KzgShareSetup, cm []kyber.Point, ct *Ciphertext, d *DecryptionShare) error {
+	if d == nil {
+		return errors.New("threshold: no decryption share")
+	}
+	if err := VerifyPublicShare(ts, cm, d.Share); err != nil {
+		return err
+	}
+	g := ts.ReturnSuite()
+	if !proofs.VerifyDLEQ(g, decryptTag(d.Share.K), d.Share.I, g.G2().Point().Base(), ct.U, d.Share.X, d.D, d.Proof) {
+		return fmt.Errorf("threshold: invalid decryption share of participant %d", d.Share.I)
+	}
+	return nil
+}
+
+/*
+This function decrypts ct, encrypted under the public key of the secret k of the dealing with
+the row commitments cm. It checks the ciphertext, verifies the decryption shares, skips those
+that do not verify or repeat a participant, and interpolates r·s_k·g_2 from the first d_2+1
+valid ones. The secret is never reconstructed. It returns an error when the ciphertext is
+invalid or fewer than d_2+1 shares are valid.
+*/
+func Decrypt(ts *kzg.KzgShareSetup, cm []kyber.Point, ct *Ciphertext, k int, shares []*DecryptionShare, d_2 int) (*Decryption, error) {
+	if err := VerifyCiphertext(ts, ct); err != nil {
+		return nil, err
+	}
+	g := ts.ReturnSuite()
+	res := &Decryption{}
+	seen := make(map[int]bool)
+	var points []kyber.Point
+
+	for _, d := range shares {
+		if len(res.Used) == d_2+1 {
+			break
+		}
+		if d != nil && d.Share != nil && seen[d.Share.I] {
+			continue
+		}
+		if err := verifyDecryptionShare(ts, cm, ct, d); err != nil || d.Share.K != k {
+			if d != nil && d.Share != nil {
+				res.Invalid = append(res.Invalid, d.Share.I)
+			}
+			continue
+		}
+		seen[d.Share.I] = true
+		res.Used = append(res.Used, d.Share.I)
+		points = append(points, d.D)
+	}
+	if len(res.Used) < d_2+1 {
+		return nil, fmt.Errorf("threshold: %d valid decryption shares, %d are needed", len(res.Used), d_2+1)
+	}
+
+	shared, err := interpolate(g, g.G2(), res.Used, points)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := newAEAD(ct.U, shared)
+	if err != nil {
+		return nil, err
+	}
+	if res.Plaintext, err = aead.Open(nil, make([]byte, aead.NonceSize()), ct.Data, ct.Label); err != nil {
+		return nil, errors.New("threshold: the ciphertext was altered")
+	}
+	return res, nil
+}
+
+// secondBase returns ḡ = τ·g_2 from the setup. Nobody knows τ, so nobody knows the logarithm of ḡ
+// to the base g_2.
+func secondBase(ts *kzg.KzgShareSetup) kyber.Point {
+	return ts.ReturnT_2()[1]
+}
+
+// ciphertextTag binds the validity proof of a ciphertext to its label and data.
+func ciphertextTag(ct *Ciphertext) string {
+	h := sha256.New()
+	_ = binary.Write(h, binary.BigEndian, uint64(len(ct.Label)))
+	h.Write(ct.Label)
+	h.Write(ct.Data)
+	return fmt.Sprintf("threshold/tdh2/%x", h.Sum(nil))
+}
+
+// newAEAD derives the AES-256-GCM key of a ciphertext from U and the shared point r·s_k·g_2.
+func newAEAD(U, shared kyber.Point) (cipher.AEAD, error) {
+	h := sha256.New()
+	h.Write([]byte("threshold/elgamal"))
+	for _, p := range []kyber.Point{U, shared} {
+		b, err := p.MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		h.Write(b)
+	}
+	block, err := aes.NewCipher(h.Sum(nil))
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// decryptTag separates the proofs of decryption shares of different secrets.
+func decryptTag(k int) string {
+	return fmt.Sprintf("threshold/decrypt/%d", k)
+}
@@ -0,0 +1,148 @@
+package threshold
+
+import (
+	vss "BingoVSS/Bingo"
+	kzg "BingoVSS/Internal/Biv_KZG"
+	"BingoVSS/Internal/MSM"
+	"BingoVSS/Internal/Polynomial"
+	"BingoVSS/Internal/Proofs"
+	"errors"
+	"fmt"
+
+	"github.com/drand/kyber"
+	"github.com/drand/kyber/pairing"
+)
+
+/*
+KeyShare is the share of participant I in the packed secret K of a Bingo dealing. The secret is
+s_K = φ(-K, 0), and the share is V = φ(-K, I) from the row φ(X, I) of the participant. The shares
+of all participants lie on φ(-K, Y), of degree d_2 in Y, so any d_2+1 of them determine s_K. R and
+Opening are the hiding value φ'(-K, I) and the KZG opening of the row at -K, which tie V to the
+commitment cm[I] of the row without revealing it.
+*/
+type KeyShare struct {
+	I       int
+	K       int
+	V       kyber.Scalar
+	R       kyber.Scalar
+	Opening kyber.Point
+}
+
+/*
+PublicShare is the public part of a key share: X = V·g_2 in G2, the value commitment
+C = V·g + R·gUp that the opening proves against cm[I], and a proof that X and C hold the same V.
+*/
+type PublicShare struct {
+	I       int
+	K       int
+	X       kyber.Point
+	C       kyber.Point
+	Opening kyber.Point
+	Proof   proofs.ShareProof
+}
+
+/* This function derives the key share of participant i in the secret k from the row it verified in BingoShare */
+func NewKeyShare(ts *kzg.KzgShareSetup, v *vss.Verifier, i, k int) (*KeyShare, error) {
+	row := v.SendPolynomials()
+	return NewKeyShareFromRow(ts, i, k, row.Coefficients(), row.Coefficients_2())
+}
+
+/* This function derives the key share of participant i in the secret k from its row φ(X, i) and the hiding row φ'(X, i) */
+func NewKeyShareFromRow(ts *kzg.KzgShareSetup, i, k int, row, hiding []kyber.Scalar) (*KeyShare, error) {
+	if i < 1 {
+		// φ(X, 0) is the polynomial of the secrets themselves
+		return nil, fmt.Errorf("threshold: %d is not a participant", i)
+	}
+	if k < 0 {
+		return nil, fmt.Errorf("threshold: no secret %d", k)
+	}
+	if len(row) == 0 || len(hiding) == 0 {
+		return nil, fmt.Errorf("threshold: participant %d holds no row", i)
+	}
+
+	opening, v, r, err := kzg.KZGEval(ts, row, hiding, point(ts.ReturnSuite(), k))
+	if err != nil {
+		return nil, err
+	}
+	return &KeyShare{I: i, K: k, V: v, R: r, Opening: opening}, nil
+}
+
+// Public returns the public key share of s with its proof.
+func (s *KeyShare) Public(ts *kzg.KzgShareSetup) *PublicShare {
+	g := ts.ReturnSuite()
+	X := g.G2().Point().Mul(s.V, nil)
+	C := kzg.ValueCommitment(ts, s.V, s.R)
+	return &PublicShare{
+		I:       s.I,
+		K:       s.K,
+		X:       X,
+		C:       C,
+		Opening: s.Opening,
+		Proof:   proofs.ProveShare(g, ts.ReturnG_u(), g.G2().Point().Base(), tag(s.K), s.I, s.V, s.R, X, C),
+	}
+}
+
+// VerifyPublicShare checks that X is the key share of participant I in the secret K of the dealing with the row commitments cm.
+func VerifyPublicShare(ts *kzg.KzgShareSetup, cm []kyber.Point, p *PublicShare) error {
+	if p == nil || p.X == nil || p.C == nil || p.Opening == nil {
+		return errors.New("threshold: incomplete public key share")
+	}
+	if p.I < 1 || p.I >= len(cm) {
+		return fmt.Errorf("threshold: no commitment for participant %d", p.I)
+	}
+	if p.K < 0 {
+		return fmt.Errorf("threshold: no secret %d", p.K)
+	}
+
+	g := ts.ReturnSuite()
+	if !kzg.KZGVerifyCommitted(ts, cm[p.I], p.Opening, point(g, p.K), p.C) {
+		return fmt.Errorf("threshold: the opening of participant %d does not match its commitment", p.I)
+	}
+	if !proofs.VerifyShare(g, ts.ReturnG_u(), g.G2().Point().Base(), tag(p.K), p.I, p.X, p.C, p.Proof) {
+		return fmt.Errorf("threshold: the public key share of participant %d does not match its opening", p.I)
+	}
+	return nil
+}
+
+/*
+This function interpolates the public key s_K·g_2 of a secret from d_2+1 verified public key
+shares of distinct participants. Only the first d_2+1 shares are used.
+*/
+func PublicKey(g pairing.Suite, shares []*PublicShare, d_2 int) (kyber.Point, error) {
+	if len(shares) < d_2+1 {
+		return nil, fmt.Errorf("threshold: %d public key shares, %d are needed", len(shares), d_2+1)
+	}
+	ids := make([]int, d_2+1)
+	points := make([]kyber.Point, d_2+1)
+	for j, s := range shares[:d_2+1] {
+		if s.K != shares[0].K {
+			return nil, fmt.Errorf("threshold: public key shares of the secrets %d and %d", shares[0].K, s.K)
+		}
+		ids[j], points[j] = s.I, s.X
+	}
+	return interpolate(g, g.G2(), ids, points)
+}
+
+// interpolate returns Σ λ_j(0)·points[j], the value at Y = 0 of the polynomial in the exponent
+// that is points[j] at Y = ids[j].
+func interpolate(g pairing.Suite, group kyber.Group, ids []int, points []kyber.Point) (kyber.Point, error) {
+	y := make([]kyber.Scalar, len(ids))
+	for j, i := range ids {
+		y[j] = g.G1().Scalar().SetInt64(int64(i))
+	}
+	l, err := polynomial.NewLagrange(g, y)
+	if err != nil {
+		return nil, fmt.Errorf("threshold: the participants are not distinct")
+	}
+	return msm.MultiExp(group, points, l.At(g.G1().Scalar().Zero())), nil
+}
+
+// point returns -k, where the rows hold the secret k.
+func point(g pairing.Suite, k int) kyber.Scalar {
+	return g.G1().Scalar().Neg(g.G1().Scalar().SetInt64(int64(k)))
+}
+
+// tag separates the proofs of public key shares of different secrets.
+func tag(k int) string {
+	return fmt.Sprintf("threshold/key/%d", k)
+}
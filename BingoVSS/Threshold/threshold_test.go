@@ -0,0 +1,281 @@
+package threshold
+
+import (
+	vss "BingoVSS/Bingo"
+	kzg "BingoVSS/Internal/Biv_KZG"
+	"testing"
+
+	"github.com/drand/kyber"
+	"github.com/stretchr/testify/require"
+)
+
+// dealing is a Bingo dealing of f+1 secrets among 3f+1 participants.
+type dealing struct {
+	g         *vss.Suite
+	f         int
+	secrets   []vss.Secret
+	verifiers []vss.Verifier
+	cm        []kyber.Point
+	ts        *kzg.KzgShareSetup
+}
+
+func deal(t *testing.T, g *vss.Suite, f int) *dealing {
+	d_1, d_2, n := 2*f+1, f, 3*f+1
+	setup, err := kzg.NewKzgSetup(2*f+2, g.ReturnSuite())
+	require.NoError(t, err)
+	ts := kzg.NewShareSetup(setup.ReturnT_1(), setup.ReturnT_2(), setup.ReturnT_u(), g.ReturnSuite(), setup.ReturnG_u(), setup.ReturnG_1())
+
+	secrets := make([]vss.Secret, f+1)
+	for i := range secrets {
+		secrets[i] = *vss.NewSecret(i, *g)
+	}
+	vn := make([]kyber.Scalar, n+1)
+	for i := range vn {
+		vn[i] = g.ReturnSuite().G1().Scalar().SetInt64(int64(i))
+	}
+	CM, verifiers, err := vss.BingoShareDealer(secrets, d_1, d_2, n, 0, *g, setup)
+	require.NoError(t, err)
+	return &dealing{g: g, f: f, secrets: secrets, verifiers: verifiers, cm: kzg.PartialEval(setup, CM, vn), ts: ts}
+}
+
+func (d *dealing) keyShare(t *testing.T, i, k int) *KeyShare {
+	s, err := NewKeyShare(d.ts, &d.verifiers[i], i, k)
+	require.NoError(t, err)
+	return s
+}
+
+func forEachCurve(t *testing.T, test func(t *testing.T, g *vss.Suite)) {
+	for _, curve := range vss.Curves() {
+		g, err := vss.NewSuiteForCurve(curve)
+		require.NoError(t, err)
+		t.Run(curve, func(t *testing.T) {
+			test(t, g)
+		})
+	}
+}
+
+func TestKeyShares(t *testing.T) {
+	forEachCurve(t, func(t *testing.T, g *vss.Suite) {
+		d := deal(t, g, 1)
+		s := g.ReturnSuite()
+
+		for k, secret := range d.secrets {
+			var shares []*PublicShare
+			for i := 1; i <= 3*d.f+1; i++ {
+				p := d.keyShare(t, i, k).Public(d.ts)
+				require.NoError(t, VerifyPublicShare(d.ts, d.cm, p))
+				shares = append(shares, p)
+			}
+			pk, err := PublicKey(s, shares, d.f)
+			require.NoError(t, err)
+			require.True(t, s.G2().Point().Mul(secret.SendSecret(), nil).Equal(pk))
+
+			// Any d_2+1 shares give the same key
+			pk, err = PublicKey(s, shares[len(shares)-d.f-1:], d.f)
+			require.NoError(t, err)
+			require.True(t, s.G2().Point().Mul(secret.SendSecret(), nil).Equal(pk))
+		}
+
+		// A public key share of another value, of another secret or of another row
+		p := d.keyShare(t, 2, 0).Public(d.ts)
+		bad := *p
+		bad.X = s.G2().Point().Add(p.X, s.G2().Point().Base())
+		require.Error(t, VerifyPublicShare(d.ts, d.cm, &bad))
+		bad = *p
+		bad.K = 1
+		require.Error(t, VerifyPublicShare(d.ts, d.cm, &bad))
+		bad = *p
+		bad.I = 3
+		require.Error(t, VerifyPublicShare(d.ts, d.cm, &bad))
+		bad = *p
+		bad.I = 9
+		require.Error(t, VerifyPublicShare(d.ts, d.cm, &bad))
+		require.Error(t, VerifyPublicShare(d.ts, d.cm, &PublicShare{I: 1}))
+
+		_, err := NewKeyShare(d.ts, &d.verifiers[0], 0, 0)
+		require.Error(t, err)
+		_, err = PublicKey(s, []*PublicShare{p}, d.f)
+		require.Error(t, err)
+		_, err = PublicKey(s, []*PublicShare{p, p}, d.f)
+		require.Error(t, err)
+	})
+}
+
+func TestThresholdBLS(t *testing.T) {
+	forEachCurve(t, func(t *testing.T, g *vss.Suite) {
+		d := deal(t, g, 2)
+		s := g.ReturnSuite()
+		msg := []byte("round 42")
+
+		for k, secret := range d.secrets {
+			partials := make([]*PartialSignature, 3*d.f+2)
+			for i := 1; i <= 3*d.f+1; i++ {
+				p, err := d.keyShare(t, i, k).Sign(d.ts, msg)
+				require.NoError(t, err)
+				require.NoError(t, VerifyPartial(d.ts, d.cm, msg, p))
+				partials[i] = p
+			}
+
+			first, err := Combine(d.ts, d.cm, msg, k, partials[1:], d.f)
+			require.NoError(t, err)
+			require.Equal(t, []int{1, 2, 3}, first.Used)
+			require.NoError(t, Verify(s, first.PublicKey, msg, first.Sig))
+			require.True(t, s.G2().Point().Mul(secret.SendSecret(), nil).Equal(first.PublicKey))
+
+			// BLS signatures are unique, so every set of signers recovers the same one
+			last, err := Combine(d.ts, d.cm, msg, k, partials[len(partials)-d.f-1:], d.f)
+			require.NoError(t, err)
+			require.Equal(t, first.Sig, last.Sig)
+			require.Error(t, Verify(s, first.PublicKey, []byte("round 43"), first.Sig))
+		}
+	})
+}
+
+func TestCombineSkipsInvalidPartials(t *testing.T) {
+	d := deal(t, vss.NewSuite(), 2)
+	msg := []byte("message")
+
+	var partials []*PartialSignature
+	for i := 1; i <= 7; i++ {
+		p, err := d.keyShare(t, i, 0).Sign(d.ts, msg)
+		require.NoError(t, err)
+		partials = append(partials, p)
+	}
+	other, err := d.keyShare(t, 2, 0).Sign(d.ts, []byte("other message"))
+	require.NoError(t, err)
+	wrongSecret, err := d.keyShare(t, 3, 1).Sign(d.ts, msg)
+	require.NoError(t, err)
+
+	// Participant 2 signs another message first and then sends its valid partial twice, 3 signs
+	// with the key of another secret and 4 sends garbage
+	garbage := *partials[3]
+	garbage.Sig = []byte{1, 2, 3}
+	mixed := []*PartialSignature{other, partials[1], partials[1], wrongSecret, &garbage, nil, partials[4], partials[5], partials[6]}
+
+	sig, err := Combine(d.ts, d.cm, msg, 0, mixed, d.f)
+	require.NoError(t, err)
+	require.Equal(t, []int{2, 5, 6}, sig.Used)
+	require.Equal(t, []int{2, 3, 4}, sig.Invalid)
+	require.NoError(t, Verify(d.g.ReturnSuite(), sig.PublicKey, msg, sig.Sig))
+
+	_, err = Combine(d.ts, d.cm, msg, 0, mixed[:7], d.f)
+	require.Error(t, err)
+}
+
+func TestThresholdElGamal(t *testing.T) {
+	forEachCurve(t, func(t *testing.T, g *vss.Suite) {
+		d := deal(t, g, 2)
+		s := g.ReturnSuite()
+		msg := []byte("sealed bid: 1200")
+
+		for k, secret := range d.secrets {
+			ct, err := Encrypt(d.ts, s.G2().Point().Mul(secret.SendSecret(), nil), msg, []byte("auction 7"))
+			require.NoError(t, err)
+
+			var shares []*DecryptionShare
+			for i := 1; i <= 3*d.f+1; i++ {
+				ds, err := d.keyShare(t, i, k).Decrypt(d.ts, ct)
+				require.NoError(t, err)
+				require.NoError(t, VerifyDecryptionShare(d.ts, d.cm, ct, ds))
+				shares = append(shares, ds)
+			}
+
+			for _, set := range [][]*DecryptionShare{shares[:d.f+1], shares[len(shares)-d.f-1:]} {
+				res, err := Decrypt(d.ts, d.cm, ct, k, set, d.f)
+				require.NoError(t, err)
+				require.Equal(t, msg, res.Plaintext)
+			}
+		}
+	})
+}
+
+func TestDecryptSkipsInvalidShares(t *testing.T) {
+	d := deal(t, vss.NewSuite(), 2)
+	s := d.g.ReturnSuite()
+	var shares []*PublicShare
+	for i := 1; i <= 3; i++ {
+		shares = append(shares, d.keyShare(t, i, 0).Public(d.ts))
+	}
+	pk, err := PublicKey(s, shares, d.f)
+	require.NoError(t, err)
+
+	msg := []byte("payload")
+	ct, err := Encrypt(d.ts, pk, msg, nil)
+	require.NoError(t, err)
+	other, err := Encrypt(d.ts, pk, msg, nil)
+	require.NoError(t, err)
+
+	valid := make([]*DecryptionShare, 8)
+	for i := 1; i <= 7; i++ {
+		valid[i], err = d.keyShare(t, i, 0).Decrypt(d.ts, ct)
+		require.NoError(t, err)
+	}
+	// Participant 1 decrypts another ciphertext, 2 shifts its share, 3 uses the key of another
+	// secret and 4 sends its valid share twice
+	wrongCt, err := d.keyShare(t, 1, 0).Decrypt(d.ts, other)
+	require.NoError(t, err)
+	shifted := *valid[2]
+	shifted.D = s.G2().Point().Add(valid[2].D, s.G2().Point().Base())
+	wrongSecret, err := d.keyShare(t, 3, 1).Decrypt(d.ts, ct)
+	require.NoError(t, err)
+
+	mixed := []*DecryptionShare{wrongCt, &shifted, wrongSecret, valid[4], valid[4], nil, valid[6], valid[7]}
+	res, err := Decrypt(d.ts, d.cm, ct, 0, mixed, d.f)
+	require.NoError(t, err)
+	require.Equal(t, msg, res.Plaintext)
+	require.Equal(t, []int{4, 6, 7}, res.Used)
+	require.Equal(t, []int{1, 2, 3}, res.Invalid)
+
+	_, err = Decrypt(d.ts, d.cm, ct, 0, mixed[:7], d.f)
+	require.Error(t, err)
+
+	// The shares of one ciphertext do not verify for another
+	require.Error(t, VerifyDecryptionShare(d.ts, d.cm, other, valid[1]))
+
+	_, err = Encrypt(d.ts, nil, msg, nil)
+	require.Error(t, err)
+	_, err = d.keyShare(t, 1, 0).Decrypt(d.ts, &Ciphertext{})
+	require.Error(t, err)
+}
+
+func TestDecryptRefusesMauledCiphertexts(t *testing.T) {
+	d := deal(t, vss.NewSuite(), 2)
+	s := d.g.ReturnSuite()
+	var shares []*PublicShare
+	for i := 1; i <= 3; i++ {
+		shares = append(shares, d.keyShare(t, i, 0).Public(d.ts))
+	}
+	pk, err := PublicKey(s, shares, d.f)
+	require.NoError(t, err)
+
+	ct, err := Encrypt(d.ts, pk, []byte("payload"), []byte("vote"))
+	require.NoError(t, err)
+	require.NoError(t, VerifyCiphertext(d.ts, ct))
+	valid := make([]*DecryptionShare, 4)
+	for i := 1; i <= 3; i++ {
+		valid[i], err = d.keyShare(t, i, 0).Decrypt(d.ts, ct)
+		require.NoError(t, err)
+	}
+
+	// Flipped data, another label, and U shifted to U + g_2 with Ubar unchanged: every change
+	// breaks the validity proof, so no participant issues a share and the old shares are useless
+	data := *ct
+	data.Data = append([]byte{}, ct.Data...)
+	data.Data[0] ^= 1
+	label := *ct
+	label.Label = []byte("veto")
+	shifted := *ct
+	shifted.U = s.G2().Point().Add(ct.U, s.G2().Point().Base())
+	for _, mauled := range []*Ciphertext{&data, &label, &shifted, {U: ct.U, Data: ct.Data}} {
+		require.Error(t, VerifyCiphertext(d.ts, mauled))
+		_, err := d.keyShare(t, 1, 0).Decrypt(d.ts, mauled)
+		require.ErrorContains(t, err, "ciphertext")
+		require.Error(t, VerifyDecryptionShare(d.ts, d.cm, mauled, valid[1]))
+		_, err = Decrypt(d.ts, d.cm, mauled, 0, valid[1:], d.f)
+		require.ErrorContains(t, err, "ciphertext")
+	}
+
+	res, err := Decrypt(d.ts, d.cm, ct, 0, valid[1:], d.f)
+	require.NoError(t, err)
+	require.Equal(t, []byte("payload"), res.Plaintext)
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	vss "BingoVSS/Bingo"
+	kzg "BingoVSS/Internal/Biv_KZG"
+	ceremony "BingoVSS/Internal/Ceremony"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+)
+
+var newTranscript = flag.Bool("new", false, "create a new transcript with no contributions")
+var powers = flag.Int("powers", 0, "number of powers of tau (degree in X + 1) when creating a transcript")
+var verifyOnly = flag.Bool("verify", false, "only verify the transcript given with -in")
+var in = flag.String("in", "", "transcript to read")
+var out = flag.String("out", "", "file to write the transcript to")
+var curve = flag.String("curve", vss.DefaultCurve, "pairing curve of the transcript (bn256 or bls12-381)")
+var srs = flag.String("srs", "", "file to export the verified SRS to, in the format read by biv_kzg.LoadSetup")
+
+/*
+The ceremony tool runs one step of the powers-of-tau ceremony on a transcript file:
+
+	ceremony -new -powers 6 -out srs.bin     creates an empty transcript
+	ceremony -in srs.bin -out srs.bin        verifies the transcript and adds a contribution
+	ceremony -verify -in srs.bin             verifies the whole chain of contributions
+
+With -srs the verified powers are also exported so that nodes can load them with biv_kzg.LoadSetup.
+*/
+func main() {
+	flag.Parse()
+	log.SetFlags(0)
+
+	suite, err := vss.NewSuiteForCurve(*curve)
+	if err != nil {
+		log.Fatal(err)
+	}
+	pairing := suite.ReturnSuite()
+
+	if *newTranscript {
+		if *powers < 2 || *out == "" {
+			log.Fatal("-new requires -powers (at least 2) and -out")
+		}
+		tr, err := ceremony.NewTranscript(*powers, pairing)
+		if err != nil {
+			log.Fatal(err)
+		}
+		write(tr, *out)
+		fmt.Printf("Created a transcript with %d powers in %s\n", *powers, *out)
+		return
+	}
+
+	if *in == "" {
+		log.Fatal("-in is required")
+	}
+
+	data, err := os.ReadFile(*in)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	tr, err := ceremony.UnmarshalTranscript(data, pairing)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// An empty transcript is the only one that is not verified before contributing
+	if tr.ReturnContributions() > 0 || *verifyOnly {
+		if err := tr.Verify(); err != nil {
+			log.Fatal("Verification failed: ", err)
+		}
+		fmt.Printf("Transcript with %d contributions is valid\n", tr.ReturnContributions())
+	}
+
+	if *verifyOnly {
+		export(tr)
+		return
+	}
+
+	if *out == "" {
+		log.Fatal("-out is required to contribute")
+	}
+
+	if err := tr.Contribute(pairing.RandomStream()); err != nil {
+		log.Fatal(err)
+	}
+	if err := tr.Verify(); err != nil {
+		log.Fatal("Verification of the new contribution failed: ", err)
+	}
+
+	write(tr, *out)
+	fmt.Printf("Added contribution %d, written to %s\n", tr.ReturnContributions(), *out)
+	export(tr)
+}
+
+func export(tr *ceremony.Transcript) {
+	if *srs == "" {
+		return
+	}
+
+	setup, err := tr.Setup()
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := kzg.SaveSetup(*srs, setup); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("Exported the SRS to %s\n", *srs)
+}
+
+func write(tr *ceremony.Transcript, path string) {
+	data, err := tr.MarshalBinary()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Write next to the destination first, so an interrupted run never leaves a broken transcript
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		log.Fatal(err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		log.Fatal(err)
+	}
+}
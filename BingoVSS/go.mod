@@ -4,19 +4,20 @@ go 1.20
 
 require (
 	github.com/drand/kyber v1.2.0
+	github.com/drand/kyber-bls12381 v0.3.1
+	github.com/gorilla/websocket v1.5.0
 	github.com/stretchr/testify v1.8.4
+	golang.org/x/crypto v0.7.0
 	gonum.org/v1/gonum v0.14.0
 )
 
 require (
 	github.com/davecgh/go-spew v1.1.1 // indirect
-	github.com/drand/bls12-381 v0.3.2 // indirect
-	github.com/drand/kyber-bls12381 v0.3.1 // indirect
-	github.com/gorilla/websocket v1.5.0 // indirect
 	github.com/kilic/bls12-381 v0.1.0 // indirect
+	github.com/kr/pretty v0.1.0 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
 	go.dedis.ch/fixbuf v1.0.3 // indirect
-	golang.org/x/crypto v0.7.0 // indirect
 	golang.org/x/sys v0.6.0 // indirect
+	gopkg.in/check.v1 v1.0.0-20190902080502-41f04d3bba15 // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
 )